@@ -0,0 +1,127 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/paolorechia/issue-flow/internal/worktree"
+	"github.com/spf13/cobra"
+)
+
+var (
+	backportProject     string
+	backportTo          []string
+	backportNoFetch     bool
+	backportNoBootstrap bool
+)
+
+var backportCmd = &cobra.Command{
+	Use:   "backport <issue|id> --to <branch>[,<branch>...]",
+	Short: "Cherry-pick an issue's commits onto other branches",
+	Long: `Create a worktree for the issue on each target branch and cherry-pick the
+commits of its worktree into it with -x. Each backport is an attempt named
+after its target, e.g. #123:backport-release-1.4 for release/1.4.
+
+Targets are independent. When a cherry-pick conflicts it is left in progress
+in that target's worktree, to be resolved there and finished with
+'git cherry-pick --continue'; the other targets are still backported. A
+target whose backport worktree already exists is left alone.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(backportTo) == 0 {
+			fmt.Fprintln(os.Stderr, "Error: --to is required")
+			os.Exit(1)
+		}
+
+		db, err := getDB()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error opening database: %v\n", err)
+			os.Exit(1)
+		}
+		if shouldCloseDB(db) {
+			defer db.Close()
+		}
+
+		p, source, err := resolveWorktree(db, args[0], backportProject)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error finding worktree: %v\n", err)
+			os.Exit(1)
+		}
+		opts := worktree.BackportOptions{Fetch: !backportNoFetch}
+		if opts.WorktreeBase, err = worktreeBase(p); err != nil {
+			fmt.Fprintf(os.Stderr, "Error loading config: %v\n", err)
+			os.Exit(1)
+		}
+
+		manager := worktree.NewManager(db, getGit())
+		outcomes, warnings, err := manager.Backport(context.Background(), p, source, backportTo, opts)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error backporting %s: %v\n", worktreeLabel(source), err)
+			os.Exit(1)
+		}
+		for _, warning := range warnings {
+			fmt.Fprintf(os.Stderr, "Warning: %s\n", warning)
+		}
+
+		out := cmd.OutOrStdout()
+		failed := 0
+		w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "TARGET\tISSUE\tBRANCH\tRESULT\tDETAIL")
+		for _, o := range outcomes {
+			for _, warning := range o.Warnings {
+				fmt.Fprintf(os.Stderr, "Warning: %s: %s\n", o.Target, warning)
+			}
+			issue, branch := "-", "-"
+			if o.Worktree != nil {
+				issue, branch = worktreeLabel(o.Worktree), o.Worktree.Branch
+			}
+			detail := "-"
+			switch {
+			case o.Err != nil:
+				detail = o.Err.Error()
+			case len(o.Conflicts) > 0:
+				detail = fmt.Sprintf("picked %d, conflicts in %s", o.Picked, strings.Join(o.Conflicts, ", "))
+			case o.Result == worktree.BackportPicked:
+				detail = fmt.Sprintf("picked %d commits", o.Picked)
+			}
+			if len(o.Skipped) > 0 && o.Err == nil {
+				detail += fmt.Sprintf(", skipped %d already on %s", len(o.Skipped), o.Target)
+			}
+			if o.Result == worktree.BackportConflict || o.Result == worktree.BackportFailed {
+				failed++
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", o.Target, issue, branch, o.Result, detail)
+		}
+		w.Flush()
+
+		for _, o := range outcomes {
+			if o.Worktree == nil || (o.Result != worktree.BackportPicked && o.Result != worktree.BackportConflict) {
+				continue
+			}
+			if len(p.Config.EnvTemplates) > 0 && !renderEnvFiles(cmd, manager, p, o.Worktree) {
+				failed++
+				continue
+			}
+			if !backportNoBootstrap && !p.Config.Bootstrap.Empty() && !runBootstrap(cmd, manager, p, o.Worktree) {
+				failed++
+			}
+		}
+
+		if failed > 0 {
+			fmt.Fprintf(os.Stderr, "%d backports need attention.\n", failed)
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(backportCmd)
+
+	backportCmd.Flags().StringVarP(&backportProject, "project", "p", "", "Project ID when selecting by issue number")
+	backportCmd.Flags().StringSliceVar(&backportTo, "to", nil, "Branches to backport to, comma-separated")
+	backportCmd.Flags().BoolVar(&backportNoFetch, "no-fetch", false, "Do not fetch the upstream remote first")
+	backportCmd.Flags().BoolVar(&backportNoBootstrap, "no-bootstrap", false, "Skip the project's bootstrap steps")
+}
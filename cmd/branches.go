@@ -0,0 +1,127 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/paolorechia/issue-flow/internal/worktree"
+	"github.com/spf13/cobra"
+)
+
+var (
+	branchesProject string
+	branchesRemote  bool
+	branchesDryRun  bool
+	branchesYes     bool
+)
+
+var branchesCmd = &cobra.Command{
+	Use:   "branches",
+	Short: "Manage issue branches",
+}
+
+var branchesGCCmd = &cobra.Command{
+	Use:   "gc",
+	Short: "Delete stale issue branches across projects",
+	Long: `Find local branches matching each project's branch pattern that are merged
+into their base branch, have no worktree any more, or belong to an archived
+worktree, show them grouped by project and delete them.
+
+Branches checked out somewhere or used by an active, parked or review
+worktree are never selected, and branches with commits that are on no
+remote and not merged are always kept.
+
+With --remote, the remote-tracking branches of each project's push remote
+that are merged or belong to an archived worktree are deleted too; the
+branches on the remote itself are left alone.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		db, err := getDB()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error opening database: %v\n", err)
+			os.Exit(1)
+		}
+		if shouldCloseDB(db) {
+			defer db.Close()
+		}
+
+		ctx := context.Background()
+		manager := worktree.NewManager(db, getGit())
+		candidates, err := manager.PlanBranchGC(ctx, worktree.BranchGCOptions{ProjectID: branchesProject, Remote: branchesRemote})
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error finding stale branches: %v\n", err)
+			os.Exit(1)
+		}
+
+		out := cmd.OutOrStdout()
+		if len(candidates) == 0 {
+			fmt.Fprintln(out, "No stale branches.")
+			return
+		}
+
+		safe := 0
+		now := time.Now()
+		w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+		for i, c := range candidates {
+			if i == 0 || c.ProjectID != candidates[i-1].ProjectID {
+				w.Flush()
+				fmt.Fprintf(out, "%s:\n", c.ProjectID)
+				fmt.Fprintln(w, "  BRANCH\tREASONS\tLAST COMMIT\tACTION")
+			}
+			action := "delete"
+			if c.Safe() {
+				safe++
+			} else {
+				action = fmt.Sprintf("keep, %d unpushed commits", len(c.Unpushed))
+			}
+			fmt.Fprintf(w, "  %s\t%s\t%s\t%s\n", c.Branch, strings.Join(c.Reasons, ","), timeAgo(c.LastCommit, now), action)
+		}
+		w.Flush()
+
+		if branchesDryRun {
+			fmt.Fprintln(out, "Dry run: nothing was deleted.")
+			return
+		}
+		if safe == 0 {
+			fmt.Fprintln(out, "Nothing to delete: every stale branch has unpushed commits.")
+			return
+		}
+		if !branchesYes && !confirm(cmd, fmt.Sprintf("Delete %d branches?", safe)) {
+			fmt.Fprintln(out, "Aborted.")
+			return
+		}
+
+		deleted, kept, failed := 0, 0, 0
+		for _, o := range manager.ApplyBranchGC(ctx, candidates) {
+			c := o.Candidate
+			switch {
+			case o.Kept:
+				kept++
+			case o.Err != nil:
+				failed++
+				fmt.Fprintf(out, "✗ Failed to delete %s %s: %v\n", c.ProjectID, c.Branch, o.Err)
+			default:
+				deleted++
+				fmt.Fprintf(out, "✓ Deleted %s %s\n", c.ProjectID, c.Branch)
+			}
+		}
+		fmt.Fprintf(out, "Deleted %d, kept %d.\n", deleted, kept)
+		if failed > 0 {
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(branchesCmd)
+	branchesCmd.AddCommand(branchesGCCmd)
+
+	branchesGCCmd.Flags().StringVarP(&branchesProject, "project", "p", "", "Only collect branches of this project")
+	branchesGCCmd.Flags().BoolVar(&branchesRemote, "remote", false, "Also delete stale remote-tracking branches of the push remote")
+	branchesGCCmd.Flags().BoolVarP(&branchesDryRun, "dry-run", "n", false, "Show the stale branches without deleting anything")
+	branchesGCCmd.Flags().BoolVarP(&branchesYes, "yes", "y", false, "Do not ask for confirmation")
+}
@@ -0,0 +1,42 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/paolorechia/issue-flow/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBranchesGCCommand(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	sp := testutil.CreateGitProject(t, db, "app")
+	repo := sp.LocalPath
+	testutil.RunGit(t, repo, "branch", "feature/1-done")
+	testutil.RunGit(t, repo, "checkout", "-q", "-b", "feature/2-wip")
+	testutil.GitCommitFile(t, repo, "wip.txt", "wip\n", "Unpushed work")
+	testutil.RunGit(t, repo, "checkout", "-q", "main")
+
+	testDB = db
+	t.Cleanup(func() { testDB = nil })
+	t.Cleanup(func() { branchesProject, branchesRemote, branchesDryRun, branchesYes = "", false, false, false })
+
+	runStart(t, "3", "--project", "app", "--title", "Active")
+
+	out := runRoot(t, "branches", "gc", "--dry-run")
+	assert.Contains(t, out, "app:\n")
+	assert.Regexp(t, `feature/1-done\s+no-worktree\s+\S.*\s+delete`, out)
+	assert.Regexp(t, `feature/2-wip\s+no-worktree\s+.*keep, 1 unpushed commits`, out)
+	assert.NotContains(t, out, "feature/3-active")
+	assert.Contains(t, out, "Dry run: nothing was deleted.")
+
+	branchesDryRun = false
+	rootCmd.SetIn(strings.NewReader("y\n"))
+	t.Cleanup(func() { rootCmd.SetIn(nil) })
+	out = runRoot(t, "branches", "gc", "--project", "app")
+	assert.Contains(t, out, "Delete 1 branches? [y/N]")
+	assert.Contains(t, out, "✓ Deleted app feature/1-done")
+	assert.Contains(t, out, "Deleted 1, kept 1.")
+	assert.Equal(t, "feature/2-wip\nfeature/3-active\nmain",
+		testutil.RunGit(t, repo, "for-each-ref", "--format=%(refname:short)", "refs/heads/"))
+}
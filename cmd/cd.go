@@ -0,0 +1,137 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/paolorechia/issue-flow/internal/storage"
+	"github.com/paolorechia/issue-flow/internal/worktree"
+	"github.com/spf13/cobra"
+)
+
+var cdProject string
+
+var cdCmd = &cobra.Command{
+	Use:   "cd <issue|branch|query>",
+	Short: "Print the path of an issue worktree",
+	Long: `Resolve a worktree from the database and print its path.
+
+The argument may be an issue number (123 or #123), an attempt (123:a1), a
+"<project>#<issue>" name, a worktree ID, a branch name, or part of a branch
+name. When several worktrees match, they are listed and nothing is printed on
+stdout.
+
+A process cannot change its parent shell's directory, so use it through the
+wrapper installed by 'issue-flow shell-init', or as:
+
+  cd "$(issue-flow cd 123)"`,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeWorktrees,
+	Run: func(cmd *cobra.Command, args []string) {
+		db, err := getDB()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error opening database: %v\n", err)
+			os.Exit(1)
+		}
+		if shouldCloseDB(db) {
+			defer db.Close()
+		}
+
+		worktrees, err := cdCandidates(db, cdProject)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error listing worktrees: %v\n", err)
+			os.Exit(1)
+		}
+
+		matches := worktree.Find(worktrees, args[0])
+		switch len(matches) {
+		case 0:
+			fmt.Fprintf(os.Stderr, "Error: no worktree matches %q\n", args[0])
+			os.Exit(1)
+		case 1:
+		default:
+			fmt.Fprintf(os.Stderr, "Error: %q matches %d worktrees:\n", args[0], len(matches))
+			for _, w := range matches {
+				fmt.Fprintf(os.Stderr, "  %-16s %s\n", worktree.QualifiedName(w), w.Branch)
+			}
+			os.Exit(1)
+		}
+
+		w := matches[0]
+		if _, err := os.Stat(w.Path); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: worktree %s is missing at %s; see 'issue-flow worktree reconcile'\n", worktree.QualifiedName(w), w.Path)
+			os.Exit(1)
+		}
+		fmt.Fprintln(cmd.OutOrStdout(), w.Path)
+	},
+}
+
+// cdCandidates lists the worktrees cd can jump to: every non-stale row, or
+// only those of projectID when given.
+func cdCandidates(db *storage.Database, projectID string) ([]storage.Worktree, error) {
+	var worktrees []storage.Worktree
+	var err error
+	if projectID != "" {
+		worktrees, err = db.ListWorktreesByProject(projectID)
+	} else {
+		worktrees, err = db.ListWorktrees()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var result []storage.Worktree
+	for _, w := range worktrees {
+		if w.Status != storage.WorktreeStatusStale {
+			result = append(result, w)
+		}
+	}
+	return result, nil
+}
+
+// completeWorktrees completes issue numbers, qualified with the project when
+// the same number exists in several projects. Once the user types a letter
+// it completes branch names and "<project>#<issue>" names instead.
+func completeWorktrees(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	db, err := getDB()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveError
+	}
+	if shouldCloseDB(db) {
+		defer db.Close()
+	}
+
+	projectID, _ := cmd.Flags().GetString("project")
+	worktrees, err := cdCandidates(db, projectID)
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveError
+	}
+
+	var completions []string
+	if toComplete == "" || strings.ContainsAny(toComplete[:1], "#0123456789") {
+		for i, name := range worktree.ShortNames(worktrees) {
+			if strings.HasPrefix(toComplete, "#") && !strings.Contains(name, "#") {
+				name = "#" + name
+			}
+			completions = append(completions, name+"\t"+worktrees[i].Branch)
+		}
+	} else {
+		for _, w := range worktrees {
+			completions = append(completions,
+				w.Branch+"\t"+worktree.QualifiedName(w),
+				worktree.QualifiedName(w)+"\t"+w.Branch)
+		}
+	}
+	return completions, cobra.ShellCompDirectiveNoFileComp
+}
+
+func init() {
+	rootCmd.AddCommand(cdCmd)
+
+	cdCmd.Flags().StringVarP(&cdProject, "project", "p", "", "Only consider worktrees of this project")
+}
@@ -0,0 +1,91 @@
+package cmd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/paolorechia/issue-flow/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func runRoot(t *testing.T, args ...string) string {
+	t.Helper()
+
+	buf := new(bytes.Buffer)
+	rootCmd.SetOut(buf)
+	rootCmd.SetErr(buf)
+	rootCmd.SetArgs(args)
+
+	err := rootCmd.Execute()
+	require.NoError(t, err)
+	return buf.String()
+}
+
+func setupCdProjects(t *testing.T) {
+	t.Helper()
+
+	db := testutil.NewTestDB(t)
+	testutil.CreateGitProject(t, db, "app")
+	testutil.CreateGitProject(t, db, "web")
+
+	testDB = db
+	t.Cleanup(func() { testDB = nil })
+	t.Cleanup(func() { cdProject = "" })
+
+	runStart(t, "12", "--project", "app", "--title", "Add oauth")
+	runStart(t, "12", "--project", "web", "--title", "Crash fix")
+	runStart(t, "7", "--project", "web", "--title", "Dark mode")
+}
+
+func TestCdCommand(t *testing.T) {
+	setupCdProjects(t)
+
+	web7 := testutil.AssertWorktreeExists(t, testDB, "wt-web-7")
+	app12 := testutil.AssertWorktreeExists(t, testDB, "wt-app-12")
+
+	assert.Equal(t, web7.Path+"\n", runRoot(t, "cd", "7"))
+	assert.Equal(t, web7.Path+"\n", runRoot(t, "cd", "dark"))
+	assert.Equal(t, app12.Path+"\n", runRoot(t, "cd", "app#12"))
+	assert.Equal(t, app12.Path+"\n", runRoot(t, "cd", "12", "--project", "app"))
+}
+
+func TestCdCommand_Ambiguous(t *testing.T) {
+	t.Skip("Skipped: command calls os.Exit(1) which terminates test process")
+}
+
+func TestCdCommand_Completion(t *testing.T) {
+	setupCdProjects(t)
+
+	out := runRoot(t, "__complete", "cd", "")
+	assert.Contains(t, out, "app#12\tfeature/12-add-oauth")
+	assert.Contains(t, out, "web#12\tfeature/12-crash-fix")
+	assert.Contains(t, out, "7\tfeature/7-dark-mode")
+	assert.NotContains(t, out, "\n12\t")
+
+	out = runRoot(t, "__complete", "cd", "#")
+	assert.Contains(t, out, "#7\t")
+
+	out = runRoot(t, "__complete", "cd", "fe")
+	assert.Contains(t, out, "feature/7-dark-mode\tweb#7")
+	assert.Contains(t, out, "web#7\tfeature/7-dark-mode")
+}
+
+func TestShellInitCommand(t *testing.T) {
+	t.Cleanup(func() { shellInitName = "iflow" })
+
+	for _, shell := range []string{"bash", "zsh", "fish"} {
+		out := runRoot(t, "shell-init", shell)
+		assert.Contains(t, out, "command issue-flow cd", shell)
+		assert.Contains(t, out, "builtin cd", shell)
+		assert.Contains(t, out, "__complete", shell)
+	}
+
+	out := runRoot(t, "shell-init", "bash", "--name", "jump")
+	assert.True(t, strings.Contains(out, "jump() {"))
+	assert.Contains(t, out, "complete -o default -F __start_issue-flow jump")
+
+	out = runRoot(t, "shell-init", "fish", "--name", "jump")
+	assert.Contains(t, out, "function jump --wraps issue-flow")
+}
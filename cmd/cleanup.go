@@ -0,0 +1,148 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/paolorechia/issue-flow/internal/worktree"
+	"github.com/spf13/cobra"
+)
+
+var (
+	cleanupProject      string
+	cleanupMerged       bool
+	cleanupClosed       bool
+	cleanupCompleted    bool
+	cleanupInactiveDays int
+	cleanupDryRun       bool
+	cleanupYes          bool
+	cleanupForce        bool
+	cleanupKeepBranches bool
+	cleanupJobs         int
+)
+
+var cleanupCmd = &cobra.Command{
+	Use:   "cleanup",
+	Short: "Remove finished worktrees in bulk",
+	Long: `Find worktrees whose branch is merged, whose cached issue is closed, or
+which have been inactive for a number of days, show them as a plan and remove
+them together with their branches and database records.
+
+Without selection flags, cleanup behaves as if --completed was given.
+Worktrees that would lose work are skipped unless --force is given. Review
+worktrees are left to 'review done'.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		db, err := getDB()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error opening database: %v\n", err)
+			os.Exit(1)
+		}
+		if shouldCloseDB(db) {
+			defer db.Close()
+		}
+
+		criteria := worktree.CleanupCriteria{
+			ProjectID:    cleanupProject,
+			Merged:       cleanupMerged || cleanupCompleted,
+			Closed:       cleanupClosed || cleanupCompleted,
+			InactiveDays: cleanupInactiveDays,
+		}
+		if !criteria.Merged && !criteria.Closed && criteria.InactiveDays == 0 {
+			criteria.Merged, criteria.Closed = true, true
+		}
+
+		ctx := context.Background()
+		manager := worktree.NewManager(db, getGit())
+		candidates, err := manager.PlanCleanup(ctx, criteria, cleanupJobs)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error planning cleanup: %v\n", err)
+			os.Exit(1)
+		}
+
+		out := cmd.OutOrStdout()
+		if len(candidates) == 0 {
+			fmt.Fprintln(out, "Nothing to clean up.")
+			return
+		}
+
+		fmt.Fprintf(out, "Cleanup plan (%d worktrees):\n", len(candidates))
+		w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "PROJECT\tISSUE\tBRANCH\tREASONS\tLAST COMMIT\tPATH")
+		for _, c := range candidates {
+			fmt.Fprintf(w, "%s\t#%d\t%s\t%s\t%s\t%s\n",
+				c.ProjectID, c.IssueNumber, c.Branch, strings.Join(c.Reasons, ","),
+				timeAgo(c.LastCommit, time.Now()), c.Path)
+		}
+		w.Flush()
+
+		if cleanupDryRun {
+			fmt.Fprintln(out, "Dry run: nothing was removed.")
+			return
+		}
+
+		if !cleanupYes && !confirm(cmd, fmt.Sprintf("Remove %d worktrees?", len(candidates))) {
+			fmt.Fprintln(out, "Aborted.")
+			return
+		}
+
+		outcomes, err := manager.ApplyCleanup(ctx, candidates, worktree.RemoveOptions{
+			Force:        cleanupForce,
+			DeleteBranch: !cleanupKeepBranches,
+		}, cleanupJobs)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error applying cleanup: %v\n", err)
+			os.Exit(1)
+		}
+
+		removed, failed := 0, 0
+		for _, o := range outcomes {
+			c := o.Candidate
+			switch {
+			case errors.Is(o.Err, worktree.ErrWouldLoseWork):
+				failed++
+				fmt.Fprintf(out, "✗ Skipped %s #%d: would lose work\n", c.ProjectID, c.IssueNumber)
+				printLossReport(out, o.Result.Report)
+			case o.Err != nil:
+				failed++
+				fmt.Fprintf(out, "✗ Failed %s #%d: %v\n", c.ProjectID, c.IssueNumber, o.Err)
+			default:
+				removed++
+				fmt.Fprintf(out, "✓ Removed %s #%d (%s)\n", c.ProjectID, c.IssueNumber, c.Path)
+				for _, warning := range o.Result.Warnings {
+					fmt.Fprintf(os.Stderr, "Warning: %s\n", warning)
+				}
+			}
+		}
+		fmt.Fprintf(out, "Removed %d, skipped %d.\n", removed, failed)
+	},
+}
+
+// confirm asks a yes/no question on the command's input and defaults to no.
+func confirm(cmd *cobra.Command, question string) bool {
+	fmt.Fprintf(cmd.OutOrStdout(), "%s [y/N] ", question)
+	answer, _ := bufio.NewReader(cmd.InOrStdin()).ReadString('\n')
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
+
+func init() {
+	rootCmd.AddCommand(cleanupCmd)
+
+	cleanupCmd.Flags().StringVarP(&cleanupProject, "project", "p", "", "Only clean up worktrees of this project")
+	cleanupCmd.Flags().BoolVar(&cleanupMerged, "merged", false, "Select worktrees whose branch is merged into the default branch")
+	cleanupCmd.Flags().BoolVar(&cleanupClosed, "closed", false, "Select worktrees whose cached issue is closed")
+	cleanupCmd.Flags().BoolVar(&cleanupCompleted, "completed", false, "Shorthand for --merged --closed")
+	cleanupCmd.Flags().IntVar(&cleanupInactiveDays, "inactive", 0, "Select worktrees inactive for at least this many days")
+	cleanupCmd.Flags().BoolVarP(&cleanupDryRun, "dry-run", "n", false, "Show the plan without removing anything")
+	cleanupCmd.Flags().BoolVarP(&cleanupYes, "yes", "y", false, "Do not ask for confirmation")
+	cleanupCmd.Flags().BoolVarP(&cleanupForce, "force", "f", false, "Remove worktrees even if work would be lost")
+	cleanupCmd.Flags().BoolVar(&cleanupKeepBranches, "keep-branches", false, "Do not delete the local branches")
+	cleanupCmd.Flags().IntVarP(&cleanupJobs, "jobs", "j", worktree.DefaultWorkers, "Number of worktrees to process concurrently")
+}
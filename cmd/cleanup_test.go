@@ -0,0 +1,105 @@
+package cmd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/paolorechia/issue-flow/internal/storage"
+	"github.com/paolorechia/issue-flow/internal/worktree"
+	"github.com/paolorechia/issue-flow/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func resetCleanupFlags() {
+	cleanupProject = ""
+	cleanupMerged, cleanupClosed, cleanupCompleted = false, false, false
+	cleanupInactiveDays = 0
+	cleanupDryRun, cleanupYes, cleanupForce, cleanupKeepBranches = false, false, false, false
+	cleanupJobs = worktree.DefaultWorkers
+}
+
+func runCleanup(t *testing.T, stdin string, args ...string) string {
+	t.Helper()
+
+	resetCleanupFlags()
+	t.Cleanup(resetCleanupFlags)
+
+	buf := new(bytes.Buffer)
+	rootCmd.SetOut(buf)
+	rootCmd.SetErr(buf)
+	rootCmd.SetIn(strings.NewReader(stdin))
+	rootCmd.SetArgs(append([]string{"cleanup"}, args...))
+
+	err := rootCmd.Execute()
+	require.NoError(t, err)
+	return buf.String()
+}
+
+// setupCleanupProject creates three worktrees: #1 merged, #2 with a closed
+// issue, #3 still in progress.
+func setupCleanupProject(t *testing.T) (*storage.Database, *storage.Project) {
+	db := testutil.NewTestDB(t)
+	p := testutil.CreateGitProject(t, db, "app")
+
+	testDB = db
+	t.Cleanup(func() { testDB = nil })
+
+	runStart(t, "1", "--project", "app", "--title", "Merged")
+	runStart(t, "2", "--project", "app", "--title", "Closed")
+	runStart(t, "3", "--project", "app", "--title", "In progress")
+
+	merged := testutil.AssertWorktreeExists(t, db, "wt-app-1")
+	testutil.GitCommitFile(t, merged.Path, "one.txt", "1\n", "Work for 1")
+	testutil.RunGit(t, p.LocalPath, "merge", "--ff-only", "--quiet", merged.Branch)
+
+	require.NoError(t, db.CacheIssue(&storage.IssueCache{
+		ProjectID: "app", IssueNumber: 2, Title: "Closed", Status: "closed", CachedAt: time.Now(),
+	}))
+
+	return db, p
+}
+
+func TestCleanupCommand_DryRun(t *testing.T) {
+	db, _ := setupCleanupProject(t)
+
+	out := runCleanup(t, "", "--completed", "--dry-run")
+	assert.Contains(t, out, "Cleanup plan (2 worktrees)")
+	assert.Contains(t, out, "merged")
+	assert.Contains(t, out, "closed")
+	assert.Contains(t, out, "Dry run")
+	testutil.AssertWorktreeCount(t, db, 3)
+}
+
+func TestCleanupCommand_Apply(t *testing.T) {
+	db, p := setupCleanupProject(t)
+	merged := testutil.AssertWorktreeExists(t, db, "wt-app-1")
+
+	out := runCleanup(t, "", "--yes", "--project", "app")
+	assert.Contains(t, out, "Removed 2, skipped 0.")
+
+	testutil.AssertWorktreeCount(t, db, 1)
+	testutil.AssertWorktreeExists(t, db, "wt-app-3")
+	assert.NoDirExists(t, merged.Path)
+	assert.Empty(t, testutil.RunGit(t, p.LocalPath, "branch", "--list", merged.Branch))
+}
+
+func TestCleanupCommand_ConfirmDeclined(t *testing.T) {
+	db, _ := setupCleanupProject(t)
+
+	out := runCleanup(t, "n\n", "--merged")
+	assert.Contains(t, out, "Cleanup plan (1 worktrees)")
+	assert.Contains(t, out, "Aborted.")
+	testutil.AssertWorktreeCount(t, db, 3)
+}
+
+func TestCleanupCommand_Nothing(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	testDB = db
+	t.Cleanup(func() { testDB = nil })
+
+	out := runCleanup(t, "", "--completed")
+	assert.Contains(t, out, "Nothing to clean up.")
+}
@@ -0,0 +1,120 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/paolorechia/issue-flow/internal/project"
+	"github.com/paolorechia/issue-flow/internal/storage"
+	"github.com/paolorechia/issue-flow/internal/worktree"
+	"github.com/spf13/cobra"
+)
+
+var (
+	doctorProject string
+	doctorFix     bool
+)
+
+var doctorCmd = &cobra.Command{
+	Use:   "doctor",
+	Short: "Check worktrees for problems",
+	Long: `Check every worktree (of --project, if given) and report problems.
+
+Currently this checks the git identity: when a project config sets an
+identity (name, email, signing_key, signing_format), each of its worktrees
+must use it. Worktrees created before the identity was configured, or whose
+config was changed by hand, are flagged; --fix writes the identity into
+their per-worktree config.
+
+Exits with status 1 when problems remain.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		db, err := getDB()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error opening database: %v\n", err)
+			os.Exit(1)
+		}
+		if shouldCloseDB(db) {
+			defer db.Close()
+		}
+
+		var worktrees []storage.Worktree
+		if doctorProject != "" {
+			worktrees, err = db.ListWorktreesByProject(doctorProject)
+		} else {
+			worktrees, err = db.ListWorktrees()
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error listing worktrees: %v\n", err)
+			os.Exit(1)
+		}
+
+		ctx := context.Background()
+		pm := project.NewManager(db)
+		manager := worktree.NewManager(db, getGit())
+		out := cmd.OutOrStdout()
+		checked, problems, fixed := 0, 0, 0
+		for i := range worktrees {
+			w := &worktrees[i]
+			if w.Status == storage.WorktreeStatusStale {
+				continue
+			}
+			p, err := pm.Get(w.ProjectID)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error loading project %s: %v\n", w.ProjectID, err)
+				os.Exit(1)
+			}
+			checked++
+			mismatches, err := manager.CheckIdentity(ctx, p, w)
+			if err != nil {
+				fmt.Fprintf(out, "✗ %s %s: %v\n", p.ID, worktreeLabel(w), err)
+				problems++
+				continue
+			}
+			if len(mismatches) == 0 {
+				continue
+			}
+			fmt.Fprintf(out, "✗ %s %s does not use the project's git identity:\n", p.ID, worktreeLabel(w))
+			for _, mm := range mismatches {
+				got := mm.Got
+				if got == "" {
+					got = "unset"
+				}
+				fmt.Fprintf(out, "    %s is %s, want %s\n", mm.Key, got, mm.Want)
+			}
+			if !doctorFix {
+				problems++
+				continue
+			}
+			if err := manager.ApplyIdentity(ctx, p, w); err != nil {
+				fmt.Fprintf(os.Stderr, "Error applying identity to %s: %v\n", worktreeLabel(w), err)
+				problems++
+				continue
+			}
+			fmt.Fprintf(out, "  ✓ Fixed\n")
+			fixed++
+		}
+
+		if problems > 0 {
+			fmt.Fprintf(out, "%d problems found", problems)
+			if !doctorFix {
+				fmt.Fprint(out, "; re-run with --fix to apply the identities")
+			}
+			fmt.Fprintln(out)
+			os.Exit(1)
+		}
+		if fixed > 0 {
+			fmt.Fprintf(out, "✓ Checked %d worktrees, fixed %d\n", checked, fixed)
+			return
+		}
+		fmt.Fprintf(out, "✓ Checked %d worktrees, no problems found\n", checked)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(doctorCmd)
+
+	doctorCmd.Flags().StringVarP(&doctorProject, "project", "p", "", "Only check this project's worktrees")
+	doctorCmd.Flags().BoolVar(&doctorFix, "fix", false, "Apply the project's git identity to worktrees that differ")
+}
@@ -1,22 +1,27 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"text/tabwriter"
 
 	"github.com/paolorechia/issue-flow/internal/project"
+	"github.com/paolorechia/issue-flow/internal/worktree"
 	"github.com/spf13/cobra"
 )
 
 var (
-	projectID     string
-	projectName   string
-	githubOwner   string
-	githubRepo    string
-	localPath     string
-	worktreeDir   string
-	verboseOutput bool
+	projectID      string
+	projectName    string
+	githubOwner    string
+	githubRepo     string
+	localPath      string
+	worktreeDir    string
+	verboseOutput  bool
+	upstreamRemote string
+	forkRemote     string
+	forkOwner      string
 )
 
 var projectCmd = &cobra.Command{
@@ -94,6 +99,11 @@ var projectAddCmd = &cobra.Command{
 					AutoLaunch:  false,
 					ContextFile: ".opencode-context",
 				},
+				Remotes: project.RemotesConfig{
+					Upstream:  upstreamRemote,
+					Fork:      forkRemote,
+					ForkOwner: forkOwner,
+				},
 			},
 		}
 
@@ -135,15 +145,124 @@ var projectShowCmd = &cobra.Command{
 		fmt.Printf("  Repository: %s\n", p.GitHubFullName())
 		fmt.Printf("  Local Path: %s\n", p.LocalPath)
 		fmt.Printf("  Worktree Dir: %s\n", p.WorktreeDir)
+		if p.IsFork() {
+			fmt.Printf("  Upstream: %s\n", p.UpstreamRemote())
+			fmt.Printf("  Fork: %s (%s/%s)\n", p.PushRemote(), p.Config.Remotes.ForkOwner, p.GitHubRepo)
+		}
 		fmt.Printf("  Created: %s\n", p.CreatedAt.Format("2006-01-02"))
 	},
 }
 
+var projectForkSyncCmd = &cobra.Command{
+	Use:   "fork-sync [id]",
+	Short: "Fast-forward the fork's default branch to upstream",
+	Long: `Fetch the project's upstream and fork remotes and push upstream's default
+branch to the fork, so the fork is not behind the repository pull requests
+target. It never force-pushes: a fork whose default branch has commits
+upstream lacks is reported and left alone.
+
+The project must declare its remotes, e.g. with 'project add --upstream
+upstream --fork origin --fork-owner <you>' or remotes: in its config.`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		db, err := getDB()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error opening database: %v\n", err)
+			os.Exit(1)
+		}
+		if shouldCloseDB(db) {
+			defer db.Close()
+		}
+
+		var id string
+		if len(args) == 1 {
+			id = args[0]
+		}
+		p, err := resolveProject(db, id)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error resolving project: %v\n", err)
+			os.Exit(1)
+		}
+
+		result, err := worktree.NewManager(db, getGit()).ForkSync(context.Background(), p)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error syncing fork: %v\n", err)
+			os.Exit(1)
+		}
+
+		out := cmd.OutOrStdout()
+		fork := p.PushRemote() + "/" + result.Branch
+		switch {
+		case !result.Updated:
+			fmt.Fprintf(out, "✓ %s is up to date with %s/%s\n", fork, p.UpstreamRemote(), result.Branch)
+		case result.From == "":
+			fmt.Fprintf(out, "✓ Created %s at %s\n", fork, result.To[:7])
+		default:
+			fmt.Fprintf(out, "✓ Fast-forwarded %s from %s to %s\n", fork, result.From[:7], result.To[:7])
+		}
+	},
+}
+
+var projectRelocateCmd = &cobra.Command{
+	Use:   "relocate <id>",
+	Short: "Change a project's worktree directory and move its worktrees",
+	Long: `Set the project's worktree directory and re-home all its worktrees: move
+each one with 'git worktree move' to where 'start' would put it and rename
+its branch to what the branch pattern gives today (see 'worktree move').
+
+Without --worktree-dir the worktrees are re-homed under the current
+directory, e.g. after the branch pattern changed. Either every worktree is
+moved and the project updated or, if one move fails, nothing changes.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		db, err := getDB()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error opening database: %v\n", err)
+			os.Exit(1)
+		}
+		if shouldCloseDB(db) {
+			defer db.Close()
+		}
+
+		p, err := project.NewManager(db).Get(args[0])
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error getting project: %v\n", err)
+			os.Exit(1)
+		}
+		if cmd.Flags().Changed("worktree-dir") {
+			p.WorktreeDir = worktreeDir
+		}
+		worktrees, err := db.ListWorktreesByProject(p.ID)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error listing worktrees: %v\n", err)
+			os.Exit(1)
+		}
+
+		manager := worktree.NewManager(db, getGit())
+		moves := planRehome(manager, p, worktrees)
+
+		out := cmd.OutOrStdout()
+		if moveDryRun {
+			printMoves(out, moves, true)
+			return
+		}
+		if err := manager.ApplyMoves(context.Background(), p, moves, true); err != nil {
+			fmt.Fprintf(os.Stderr, "Error relocating project: %v\n", err)
+			os.Exit(1)
+		}
+		printMoves(out, moves, false)
+		fmt.Fprintf(out, "✓ Relocated %s (%d worktrees moved)\n", p.ID, len(moves))
+		rerenderMoved(cmd, manager, p, moves)
+	},
+}
+
 func init() {
 	rootCmd.AddCommand(projectCmd)
 	projectCmd.AddCommand(projectListCmd)
 	projectCmd.AddCommand(projectAddCmd)
 	projectCmd.AddCommand(projectShowCmd)
+	projectCmd.AddCommand(projectRelocateCmd)
+	projectCmd.AddCommand(projectForkSyncCmd)
 
 	projectAddCmd.Flags().StringVarP(&projectID, "id", "i", "", "Project ID (required)")
 	projectAddCmd.Flags().StringVarP(&projectName, "name", "n", "", "Project name (required)")
@@ -151,4 +270,11 @@ func init() {
 	projectAddCmd.Flags().StringVarP(&githubRepo, "repo", "r", "", "GitHub repo (required)")
 	projectAddCmd.Flags().StringVarP(&localPath, "path", "p", "", "Local path (optional)")
 	projectAddCmd.Flags().StringVar(&worktreeDir, "worktree-dir", "", "Worktree directory (optional)")
+	projectAddCmd.Flags().StringVar(&upstreamRemote, "upstream", "", "Remote branches start from (default origin)")
+	projectAddCmd.Flags().StringVar(&forkRemote, "fork", "", "Remote branches are pushed to, when working from a fork")
+	projectAddCmd.Flags().StringVar(&forkOwner, "fork-owner", "", "GitHub owner of the fork (required with --fork)")
+
+	projectRelocateCmd.Flags().StringVar(&worktreeDir, "worktree-dir", "", "New worktree directory")
+	projectRelocateCmd.Flags().BoolVar(&moveKeepBranches, "keep-branches", false, "Do not rename branches")
+	projectRelocateCmd.Flags().BoolVar(&moveDryRun, "dry-run", false, "Show what would change without changing anything")
 }
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"testing"
 
+	"github.com/paolorechia/issue-flow/internal/project"
 	"github.com/paolorechia/issue-flow/testutil"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -44,6 +45,61 @@ func TestProjectAddCommand(t *testing.T) {
 	assert.Equal(t, githubRepo, project.GitHubRepo)
 }
 
+func TestProjectAddCommand_Fork(t *testing.T) {
+	db := testutil.NewTestDB(t)
+
+	testDB = db
+	t.Cleanup(func() { testDB = nil })
+	t.Cleanup(func() { upstreamRemote, forkRemote, forkOwner = "", "", "" })
+
+	runRoot(t, "project", "add", "--id", "forked", "--name", "Forked", "--owner", "acme", "--repo", "app",
+		"--upstream", "upstream", "--fork", "origin", "--fork-owner", "ada")
+
+	row := testutil.AssertProjectExists(t, db, "forked")
+	assert.Contains(t, row.Config, `"remotes":{"upstream":"upstream","fork":"origin","fork_owner":"ada"}`)
+}
+
+func TestProjectAddCommand_ForkWithoutOwner(t *testing.T) {
+	db := testutil.NewTestDB(t)
+
+	// project add reports the manager's error and exits, so check it directly.
+	err := project.NewManager(db).Add(&project.Project{
+		ID: "forked", Name: "Forked", GitHubOwner: "acme", GitHubRepo: "app",
+		Config: project.ProjectConfig{Remotes: project.RemotesConfig{Upstream: "upstream", Fork: "origin"}},
+	})
+	assert.ErrorContains(t, err, "fork owner is required when pushing to remote origin")
+	testutil.AssertProjectCount(t, db, 0)
+}
+
+func TestProjectForkSyncCommand(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	sp := testutil.CreateGitProjectWithConfig(t, db, "app", `{"remotes": {"upstream": "upstream", "fork": "origin", "fork_owner": "ada"}}`)
+	upstream := t.TempDir()
+	testutil.RunGit(t, upstream, "clone", "--quiet", sp.LocalPath, ".")
+	testutil.RunGit(t, upstream, "config", "user.name", "Upstream")
+	testutil.RunGit(t, upstream, "config", "user.email", "upstream@issue-flow.local")
+	testutil.GitCommitFile(t, upstream, "upstream.txt", "new\n", "Upstream change")
+	fork := t.TempDir()
+	testutil.RunGit(t, fork, "init", "--quiet", "--bare")
+	testutil.RunGit(t, sp.LocalPath, "remote", "add", "upstream", upstream)
+	testutil.RunGit(t, sp.LocalPath, "remote", "add", "origin", fork)
+	testutil.RunGit(t, sp.LocalPath, "push", "--quiet", "origin", "main")
+
+	testDB = db
+	t.Cleanup(func() { testDB = nil })
+
+	out := runRoot(t, "project", "fork-sync", "app")
+	assert.Contains(t, out, "✓ Fast-forwarded origin/main from ")
+	assert.Equal(t, testutil.RunGit(t, upstream, "rev-parse", "HEAD"), testutil.RunGit(t, fork, "rev-parse", "main"))
+
+	out = runRoot(t, "project", "fork-sync", "app")
+	assert.Contains(t, out, "✓ origin/main is up to date with upstream/main")
+}
+
+func TestProjectForkSyncCommand_NotAFork(t *testing.T) {
+	t.Skip("Skipped: command calls os.Exit(1) which terminates test process")
+}
+
 func TestProjectAddCommandMissingRequiredFlags(t *testing.T) {
 	t.Skip("Skipped: command calls os.Exit(1) which terminates test process")
 }
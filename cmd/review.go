@@ -0,0 +1,179 @@
+package cmd
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/paolorechia/issue-flow/internal/worktree"
+	"github.com/spf13/cobra"
+)
+
+var (
+	reviewProject     string
+	reviewRemote      string
+	reviewBranch      string
+	reviewNoBootstrap bool
+	reviewForce       bool
+)
+
+var reviewCmd = &cobra.Command{
+	Use:   "review <pr>",
+	Short: "Check out a pull request in a review worktree",
+	Long: `Fetch a pull request's head (refs/pull/<pr>/head) from the project's
+upstream remote and check it out in its own worktree on branch review/<pr>.
+Review worktrees are recorded with status review; sync, restack and
+re-homing leave them alone.
+
+For a pull request from a fork, add the fork as a remote and pass --remote
+and --branch to fetch its branch instead.
+
+Running review again fetches the pull request again and fast-forwards the
+worktree if it is clean. Remove it with 'issue-flow review done <pr>'.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		prNumber, err := parseIssueNumber(args[0])
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: invalid pull request number: %s\n", args[0])
+			os.Exit(1)
+		}
+
+		db, err := getDB()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error opening database: %v\n", err)
+			os.Exit(1)
+		}
+		if shouldCloseDB(db) {
+			defer db.Close()
+		}
+
+		p, err := resolveProject(db, reviewProject)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error resolving project: %v\n", err)
+			os.Exit(1)
+		}
+		opts := worktree.ReviewOptions{PRNumber: prNumber, Remote: reviewRemote, Branch: reviewBranch}
+		if opts.WorktreeBase, err = worktreeBase(p); err != nil {
+			fmt.Fprintf(os.Stderr, "Error loading config: %v\n", err)
+			os.Exit(1)
+		}
+
+		manager := worktree.NewManager(db, getGit())
+		result, err := manager.Review(context.Background(), p, opts)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reviewing PR #%d: %v\n", prNumber, err)
+			os.Exit(1)
+		}
+		for _, warning := range result.Warnings {
+			fmt.Fprintf(os.Stderr, "Warning: %s\n", warning)
+		}
+
+		w := result.Worktree
+		out := cmd.OutOrStdout()
+		switch {
+		case result.Created:
+			fmt.Fprintf(out, "✓ Created review worktree for %s\n", worktreeLabel(w))
+		case result.Updated:
+			fmt.Fprintf(out, "✓ Updated review worktree for %s\n", worktreeLabel(w))
+		default:
+			fmt.Fprintf(out, "✓ Reusing review worktree for %s\n", worktreeLabel(w))
+		}
+		fmt.Fprintf(out, "  Branch: %s\n", w.Branch)
+		fmt.Fprintf(out, "  Head: %s\n", result.Head[:7])
+		fmt.Fprintf(out, "  Path: %s\n", w.Path)
+		if len(result.Ports) > 0 {
+			ports := make([]string, len(result.Ports))
+			for i, port := range result.Ports {
+				ports[i] = fmt.Sprintf("%s=%d", port.Env, port.Port)
+			}
+			fmt.Fprintf(out, "  Ports: %s\n", strings.Join(ports, " "))
+		}
+
+		if result.Created && len(p.Config.EnvTemplates) > 0 && !renderEnvFiles(cmd, manager, p, w) {
+			os.Exit(1)
+		}
+		if result.Created && !reviewNoBootstrap && !p.Config.Bootstrap.Empty() && !runBootstrap(cmd, manager, p, w) {
+			os.Exit(1)
+		}
+	},
+}
+
+var reviewDoneCmd = &cobra.Command{
+	Use:   "done <pr>",
+	Short: "Remove a pull request's review worktree",
+	Long: `Remove the review worktree of a pull request, its review/<pr> branch and
+the fetched pull request head. Like 'worktree remove', it refuses when the
+worktree has uncommitted changes, untracked files, stashes or commits that
+are on no remote, unless --force is given.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		prNumber, err := parseIssueNumber(args[0])
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: invalid pull request number: %s\n", args[0])
+			os.Exit(1)
+		}
+
+		db, err := getDB()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error opening database: %v\n", err)
+			os.Exit(1)
+		}
+		if shouldCloseDB(db) {
+			defer db.Close()
+		}
+
+		p, err := resolveProject(db, reviewProject)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error resolving project: %v\n", err)
+			os.Exit(1)
+		}
+		w, err := db.GetReviewWorktree(p.ID, prNumber)
+		if errors.Is(err, sql.ErrNoRows) {
+			fmt.Fprintf(os.Stderr, "Error: no review worktree for PR #%d in project %s\n", prNumber, p.ID)
+			os.Exit(1)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error finding review worktree: %v\n", err)
+			os.Exit(1)
+		}
+
+		manager := worktree.NewManager(db, getGit())
+		result, err := manager.ReviewDone(context.Background(), p, w, reviewForce)
+		if errors.Is(err, worktree.ErrWouldLoseWork) {
+			fmt.Fprintf(os.Stderr, "Refusing to remove %s (%s); it would lose:\n", w.ID, worktreeLabel(w))
+			printLossReport(os.Stderr, result.Report)
+			fmt.Fprintln(os.Stderr, "Re-run with --force to remove it anyway.")
+			os.Exit(1)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error removing review worktree: %v\n", err)
+			os.Exit(1)
+		}
+		for _, warning := range result.Warnings {
+			fmt.Fprintf(os.Stderr, "Warning: %s\n", warning)
+		}
+
+		out := cmd.OutOrStdout()
+		if !result.Report.Empty() {
+			fmt.Fprintln(out, "Discarded:")
+			printLossReport(out, result.Report)
+		}
+		fmt.Fprintf(out, "✓ Removed review worktree for %s (%s)\n", worktreeLabel(w), w.Path)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(reviewCmd)
+	reviewCmd.AddCommand(reviewDoneCmd)
+
+	reviewCmd.Flags().StringVarP(&reviewProject, "project", "p", "", "Project ID (defaults to the project containing the current directory)")
+	reviewCmd.Flags().StringVar(&reviewRemote, "remote", "", "Remote to fetch the pull request from (default: the project's upstream remote)")
+	reviewCmd.Flags().StringVar(&reviewBranch, "branch", "", "Fetch this branch of the remote instead of refs/pull/<pr>/head")
+	reviewCmd.Flags().BoolVar(&reviewNoBootstrap, "no-bootstrap", false, "Skip the project's bootstrap steps")
+
+	reviewDoneCmd.Flags().StringVarP(&reviewProject, "project", "p", "", "Project ID (defaults to the project containing the current directory)")
+	reviewDoneCmd.Flags().BoolVarP(&reviewForce, "force", "f", false, "Remove the worktree even if work would be lost")
+}
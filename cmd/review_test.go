@@ -0,0 +1,50 @@
+package cmd
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/paolorechia/issue-flow/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReviewCommands(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	sp := testutil.CreateGitProject(t, db, "app")
+	repo := sp.LocalPath
+	testutil.RunGit(t, repo, "remote", "add", "origin", repo)
+	testutil.RunGit(t, repo, "checkout", "-q", "-b", "contrib")
+	testutil.GitCommitFile(t, repo, "pr.txt", "pr\n", "Add pr.txt")
+	testutil.RunGit(t, repo, "update-ref", "refs/pull/45/head", "contrib")
+	testutil.RunGit(t, repo, "checkout", "-q", "main")
+
+	testDB = db
+	t.Cleanup(func() { testDB = nil })
+	t.Cleanup(func() {
+		reviewProject, reviewRemote, reviewBranch, reviewNoBootstrap, reviewForce = "", "", "", false, false
+	})
+
+	out := runRoot(t, "review", "45", "--project", "app")
+	assert.Contains(t, out, "✓ Created review worktree for PR#45")
+	assert.Contains(t, out, "Branch: review/45")
+	w := testutil.AssertWorktreeExists(t, db, "wt-app-pr-45")
+	assert.Equal(t, "review", w.Status)
+	assert.FileExists(t, filepath.Join(w.Path, "pr.txt"))
+
+	out = runWorktree(t, "list", "--project", "app")
+	rows := testutil.ParseTableOutput(t, out)
+	require.Len(t, rows, 2)
+	assert.Equal(t, []string{"app", "PR#45", "review/45", "review"}, rows[1][:4])
+
+	out = runRoot(t, "review", "45", "--project", "app")
+	assert.Contains(t, out, "✓ Reusing review worktree for PR#45")
+
+	out = runRoot(t, "review", "done", "45", "--project", "app")
+	assert.Contains(t, out, "✓ Removed review worktree for PR#45")
+	testutil.AssertWorktreeCount(t, db, 0)
+}
+
+func TestReviewDoneCommand_RefusesToLoseWork(t *testing.T) {
+	t.Skip("Skipped: command calls os.Exit(1) which terminates test process")
+}
@@ -1,14 +1,20 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
 	"os"
 
+	"github.com/paolorechia/issue-flow/internal/config"
+	"github.com/paolorechia/issue-flow/internal/git"
+	"github.com/paolorechia/issue-flow/internal/github"
 	"github.com/paolorechia/issue-flow/internal/storage"
 	"github.com/spf13/cobra"
 )
 
 var testDB *storage.Database
+var testGit git.Git
+var testGitHub github.Client
 
 var rootCmd = &cobra.Command{
 	Use:   "issue-flow",
@@ -30,6 +36,24 @@ func getDB() (*storage.Database, error) {
 	return storage.New()
 }
 
+func getGit() git.Git {
+	if testGit != nil {
+		return testGit
+	}
+	return git.NewClient(nil)
+}
+
+func getGitHub(ctx context.Context) (github.Client, error) {
+	if testGitHub != nil {
+		return testGitHub, nil
+	}
+	cfg, err := config.Load()
+	if err != nil {
+		return nil, err
+	}
+	return github.NewClient(ctx, cfg.GitHub)
+}
+
 func shouldCloseDB(db *storage.Database) bool {
 	return db != testDB
 }
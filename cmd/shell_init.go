@@ -0,0 +1,100 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+
+	"github.com/spf13/cobra"
+)
+
+var shellInitName string
+
+var shellFunctionName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_-]*$`)
+
+var shellInitCmd = &cobra.Command{
+	Use:   "shell-init <bash|zsh|fish>",
+	Short: "Print shell integration for jumping into worktrees",
+	Long: `Print a shell function that wraps issue-flow so that "iflow cd 123"
+changes the current shell's directory, together with completion for
+issue-flow and the wrapper. Every other subcommand is passed through.
+
+Add one of these to your shell's startup file:
+
+  bash:  eval "$(issue-flow shell-init bash)"
+  zsh:   eval "$(issue-flow shell-init zsh)"
+  fish:  issue-flow shell-init fish | source`,
+	Args:      cobra.ExactArgs(1),
+	ValidArgs: []string{"bash", "zsh", "fish"},
+	Run: func(cmd *cobra.Command, args []string) {
+		if !shellFunctionName.MatchString(shellInitName) {
+			fmt.Fprintf(os.Stderr, "Error: invalid function name %q\n", shellInitName)
+			os.Exit(1)
+		}
+
+		if err := writeShellInit(cmd.OutOrStdout(), args[0], shellInitName); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+const posixWrapper = `
+%[1]s() {
+    if [ "$1" = "cd" ]; then
+        shift
+        local dir
+        dir="$(command %[2]s cd "$@")" || return
+        builtin cd -- "$dir"
+    else
+        command %[2]s "$@"
+    fi
+}
+`
+
+const fishWrapper = `
+function %[1]s --wraps %[2]s --description 'issue-flow with cd into worktrees'
+    if test (count $argv) -gt 0; and test "$argv[1]" = cd
+        set -l dir (command %[2]s cd $argv[2..-1]); or return
+        builtin cd $dir
+    else
+        command %[2]s $argv
+    end
+end
+`
+
+// writeShellInit writes the completion script for shell followed by the cd
+// wrapper function and the wrapper's completion registration.
+func writeShellInit(out io.Writer, shell, name string) error {
+	bin := rootCmd.Name()
+
+	switch shell {
+	case "bash":
+		if err := rootCmd.GenBashCompletionV2(out, true); err != nil {
+			return err
+		}
+		fmt.Fprintf(out, posixWrapper, name, bin)
+		fmt.Fprintf(out, "complete -o default -F __start_%s %s\n", bin, name)
+	case "zsh":
+		if err := rootCmd.GenZshCompletion(out); err != nil {
+			return err
+		}
+		fmt.Fprintf(out, posixWrapper, name, bin)
+		fmt.Fprintf(out, "compdef _%s %s\n", bin, name)
+	case "fish":
+		if err := rootCmd.GenFishCompletion(out, true); err != nil {
+			return err
+		}
+		fmt.Fprintf(out, fishWrapper, name, bin)
+	default:
+		return fmt.Errorf("unsupported shell %q (expected bash, zsh or fish)", shell)
+	}
+	return nil
+}
+
+func init() {
+	rootCmd.AddCommand(shellInitCmd)
+
+	shellInitCmd.Flags().StringVar(&shellInitName, "name", "iflow", "Name of the wrapper function")
+}
@@ -0,0 +1,221 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/paolorechia/issue-flow/internal/project"
+	"github.com/paolorechia/issue-flow/internal/storage"
+	"github.com/paolorechia/issue-flow/internal/worktree"
+	"github.com/spf13/cobra"
+)
+
+var (
+	startProject     string
+	startTitle       string
+	startType        string
+	startNoFetch     bool
+	startNoBootstrap bool
+	startAttempt     string
+	startOn          string
+	startBase        string
+)
+
+var startCmd = &cobra.Command{
+	Use:   "start <issue>",
+	Short: "Start working on an issue",
+	Long: `Create a branch and git worktree for an issue and record it in the database.
+
+The branch name is computed from the project's branch pattern. An issue that
+is not cached is looked up on GitHub unless --title is given. Running start
+again for the same issue reuses the existing worktree.
+
+New worktrees are bootstrapped with the project's bootstrap steps unless
+--no-bootstrap is given.
+
+--attempt=<name> starts another attempt at the same issue in its own branch
+and worktree, suffixed with the attempt name; --attempt alone picks a1, a2, ...
+Compare attempts with 'worktree compare' and keep one with 'worktree pick'.
+
+--on <issue> stacks the new worktree on another issue's worktree: its branch
+is created from that worktree's branch, and 'worktree restack' keeps it on
+top when the parent changes.
+
+The branch is created from the default branch unless --base names another,
+or the issue type's base_branch rule picks one: a fixed branch, the latest
+branch matching a glob such as release/*, or a branch chosen when starting.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		issueNumber, err := parseIssueNumber(args[0])
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		db, err := getDB()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error opening database: %v\n", err)
+			os.Exit(1)
+		}
+		if shouldCloseDB(db) {
+			defer db.Close()
+		}
+
+		p, err := resolveProject(db, startProject)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error resolving project: %v\n", err)
+			os.Exit(1)
+		}
+
+		opts := worktree.StartOptions{
+			IssueNumber: issueNumber,
+			Title:       startTitle,
+			Type:        startType,
+			Fetch:       !startNoFetch,
+			Attempt:     startAttempt,
+			BaseBranch:  startBase,
+		}
+		if startOn != "" {
+			if _, opts.Parent, err = resolveWorktree(db, startOn, p.ID); err != nil {
+				fmt.Fprintf(os.Stderr, "Error finding worktree to stack on: %v\n", err)
+				os.Exit(1)
+			}
+		}
+		if opts.WorktreeBase, err = worktreeBase(p); err != nil {
+			fmt.Fprintf(os.Stderr, "Error loading config: %v\n", err)
+			os.Exit(1)
+		}
+		if startTitle == "" {
+			if _, err := db.GetIssueCache(p.ID, issueNumber); errors.Is(err, sql.ErrNoRows) {
+				if opts.GitHub, err = getGitHub(context.Background()); err != nil {
+					fmt.Fprintf(os.Stderr, "Warning: cannot look up issue #%d on GitHub, pass --title: %v\n", issueNumber, err)
+				}
+			}
+		}
+
+		manager := worktree.NewManager(db, getGit())
+		result, err := manager.Start(context.Background(), p, opts)
+		var prompt *worktree.BaseBranchPrompt
+		if errors.As(err, &prompt) {
+			if opts.BaseBranch, err = chooseBaseBranch(cmd, prompt); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			result, err = manager.Start(context.Background(), p, opts)
+		}
+		if errors.Is(err, worktree.ErrParked) {
+			fmt.Fprintf(os.Stderr, "Issue #%d is parked; run 'issue-flow worktree resume %d' first.\n", issueNumber, issueNumber)
+			os.Exit(1)
+		}
+		if errors.Is(err, worktree.ErrArchived) {
+			fmt.Fprintf(os.Stderr, "Issue #%d is archived; run 'issue-flow worktree unarchive %d' first.\n", issueNumber, issueNumber)
+			os.Exit(1)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error starting issue #%d: %v\n", issueNumber, err)
+			os.Exit(1)
+		}
+
+		for _, warning := range result.Warnings {
+			fmt.Fprintf(os.Stderr, "Warning: %s\n", warning)
+		}
+
+		out := cmd.OutOrStdout()
+		if result.Created {
+			fmt.Fprintf(out, "✓ Created worktree for %s\n", worktreeLabel(result.Worktree))
+		} else {
+			fmt.Fprintf(out, "✓ Reusing worktree for %s\n", worktreeLabel(result.Worktree))
+		}
+		fmt.Fprintf(out, "  Branch: %s\n", result.Worktree.Branch)
+		fmt.Fprintf(out, "  Path: %s\n", result.Worktree.Path)
+		if result.Worktree.BaseBranch != "" {
+			fmt.Fprintf(out, "  Base: %s\n", result.Worktree.BaseBranch)
+		}
+		if opts.Parent != nil {
+			fmt.Fprintf(out, "  Stacked on: %s (%s)\n", worktreeLabel(opts.Parent), opts.Parent.Branch)
+		}
+		if len(result.Ports) > 0 {
+			ports := make([]string, len(result.Ports))
+			for i, port := range result.Ports {
+				ports[i] = fmt.Sprintf("%s=%d", port.Env, port.Port)
+			}
+			fmt.Fprintf(out, "  Ports: %s\n", strings.Join(ports, " "))
+		}
+
+		w := result.Worktree
+		if result.Created && len(p.Config.EnvTemplates) > 0 {
+			if !renderEnvFiles(cmd, manager, p, w) {
+				os.Exit(1)
+			}
+		}
+		switch {
+		case result.Created && !startNoBootstrap && !p.Config.Bootstrap.Empty():
+			if !runBootstrap(cmd, manager, p, w) {
+				os.Exit(1)
+			}
+		case w.BootstrapStatus == storage.BootstrapFailed:
+			fmt.Fprintf(os.Stderr, "Warning: the last bootstrap of #%d failed; run 'issue-flow worktree bootstrap %s --retry'\n", issueNumber, w.ID)
+		}
+
+		warnQuota(os.Stderr, manager, p, w.ID)
+	},
+}
+
+// chooseBaseBranch asks which branch to start from, accepting a candidate's
+// number or any branch name.
+func chooseBaseBranch(cmd *cobra.Command, prompt *worktree.BaseBranchPrompt) (string, error) {
+	out := cmd.OutOrStdout()
+	fmt.Fprintf(out, "Base branch for a %s issue:\n", prompt.IssueType)
+	for i, candidate := range prompt.Candidates {
+		fmt.Fprintf(out, "  %d) %s\n", i+1, candidate)
+	}
+	fmt.Fprint(out, "Branch (number or name): ")
+	answer, _ := bufio.NewReader(cmd.InOrStdin()).ReadString('\n')
+	answer = strings.TrimSpace(answer)
+	if answer == "" {
+		return "", fmt.Errorf("no base branch chosen; pass one with --base")
+	}
+	if n, err := strconv.Atoi(answer); err == nil {
+		if n < 1 || n > len(prompt.Candidates) {
+			return "", fmt.Errorf("no base branch numbered %d", n)
+		}
+		return prompt.Candidates[n-1], nil
+	}
+	return answer, nil
+}
+
+// parseIssueNumber accepts "123" or "#123".
+func parseIssueNumber(s string) (int, error) {
+	n, err := strconv.Atoi(strings.TrimPrefix(s, "#"))
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("invalid issue number: %s", s)
+	}
+	return n, nil
+}
+
+// resolveProject picks the project from --project, the current directory, or
+// the only configured project.
+func resolveProject(db *storage.Database, id string) (*project.Project, error) {
+	cwd, _ := os.Getwd()
+	return project.NewManager(db).Resolve(id, cwd)
+}
+
+func init() {
+	rootCmd.AddCommand(startCmd)
+
+	startCmd.Flags().StringVarP(&startProject, "project", "p", "", "Project ID (defaults to the project containing the current directory)")
+	startCmd.Flags().StringVarP(&startTitle, "title", "t", "", "Issue title, used for the branch name instead of looking the issue up on GitHub")
+	startCmd.Flags().StringVar(&startType, "type", "", "Issue type, used for the branch prefix when the issue is not cached")
+	startCmd.Flags().BoolVar(&startNoFetch, "no-fetch", false, "Do not fetch the upstream remote before creating the branch")
+	startCmd.Flags().BoolVar(&startNoBootstrap, "no-bootstrap", false, "Do not run the project's bootstrap steps")
+	startCmd.Flags().StringVar(&startAttempt, "attempt", "", "Start a separate attempt at the issue (--attempt=<name>, or a1, a2, ... when no name is given)")
+	startCmd.Flags().Lookup("attempt").NoOptDefVal = worktree.AutoAttempt
+	startCmd.Flags().StringVar(&startBase, "base", "", "Branch to start from instead of the issue type's base branch")
+	startCmd.Flags().StringVar(&startOn, "on", "", "Stack the worktree on another issue's worktree (<issue>[:<attempt>] or worktree ID)")
+}
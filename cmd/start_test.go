@@ -0,0 +1,193 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/paolorechia/issue-flow/internal/github"
+	"github.com/paolorechia/issue-flow/internal/storage"
+	"github.com/paolorechia/issue-flow/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func runStart(t *testing.T, args ...string) string {
+	t.Helper()
+
+	startProject, startTitle, startType, startNoFetch, startNoBootstrap, startAttempt, startOn, startBase = "", "", "", false, false, "", "", ""
+	t.Cleanup(func() {
+		startProject, startTitle, startType, startNoFetch, startNoBootstrap, startAttempt, startOn, startBase = "", "", "", false, false, "", "", ""
+	})
+
+	buf := new(bytes.Buffer)
+	rootCmd.SetOut(buf)
+	rootCmd.SetErr(buf)
+	rootCmd.SetArgs(append([]string{"start"}, args...))
+
+	err := rootCmd.Execute()
+	require.NoError(t, err)
+	return buf.String()
+}
+
+func TestStartCommand_CreatesWorktree(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	p := testutil.CreateGitProject(t, db, "app")
+
+	testDB = db
+	t.Cleanup(func() { testDB = nil })
+
+	out := runStart(t, "123", "--project", "app", "--title", "Add login page")
+
+	expectedPath := filepath.Join(p.WorktreeDir, "issue-123")
+	assert.Contains(t, out, "Created worktree for #123")
+	assert.Contains(t, out, expectedPath)
+
+	testutil.AssertWorktreeCount(t, db, 1)
+	w := testutil.AssertWorktreeExists(t, db, "wt-app-123")
+	assert.Equal(t, "feature/123-add-login-page", w.Branch)
+	assert.Equal(t, expectedPath, w.Path)
+	assert.Equal(t, storage.WorktreeStatusActive, w.Status)
+
+	assert.Equal(t, "feature/123-add-login-page", testutil.RunGit(t, w.Path, "symbolic-ref", "--short", "HEAD"))
+	testutil.AssertIssueCacheCount(t, db, "app", 1)
+}
+
+func TestStartCommand_IsIdempotent(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	testutil.CreateGitProject(t, db, "app")
+
+	testDB = db
+	t.Cleanup(func() { testDB = nil })
+
+	runStart(t, "#7", "--project", "app", "--title", "Fix crash")
+	out := runStart(t, "7", "--project", "app")
+
+	assert.Contains(t, out, "Reusing worktree for #7")
+	testutil.AssertWorktreeCount(t, db, 1)
+	w := testutil.AssertWorktreeExists(t, db, "wt-app-7")
+	assert.Equal(t, "feature/7-fix-crash", w.Branch)
+}
+
+func TestStartCommand_RecreatesMissingWorktree(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	testutil.CreateGitProject(t, db, "app")
+
+	testDB = db
+	t.Cleanup(func() { testDB = nil })
+
+	runStart(t, "9", "--project", "app", "--title", "Something")
+	w := testutil.AssertWorktreeExists(t, db, "wt-app-9")
+	require.NoError(t, os.RemoveAll(w.Path))
+
+	out := runStart(t, "9", "--project", "app")
+	assert.Contains(t, out, "Created worktree for #9")
+	assert.DirExists(t, w.Path)
+	testutil.AssertWorktreeCount(t, db, 1)
+}
+
+func TestStartCommand_UsesCachedIssueType(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	testutil.CreateGitProject(t, db, "app")
+	require.NoError(t, db.CacheIssue(&storage.IssueCache{
+		ProjectID:   "app",
+		IssueNumber: 42,
+		Title:       "Null pointer when saving very long documents",
+		Type:        "bug",
+		Status:      "open",
+		CachedAt:    time.Now(),
+	}))
+
+	testDB = db
+	t.Cleanup(func() { testDB = nil })
+
+	runStart(t, "42", "--project", "app")
+
+	w := testutil.AssertWorktreeExists(t, db, "wt-app-42")
+	assert.Equal(t, "fix/42-null-pointer-when-sa", w.Branch)
+}
+
+func TestStartCommand_LooksUpIssueOnGitHub(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	sp := testutil.CreateGitProjectWithConfig(t, db, "widgets",
+		`{"issue_types":[{"name":"bug","label":"bug","branch_prefix":"fix"}],"branch_config":{"pattern":"{prefix}/{issue-number}-{slug}","max_slug_length":20}}`)
+	sp.GitHubOwner = "acme"
+	require.NoError(t, db.UpdateProject(sp))
+
+	fake := testutil.NewFakeGitHub(t).LoadFixtures("issues")
+	client := github.NewRESTClient("test-token")
+	client.BaseURL = fake.URL
+
+	testDB = db
+	testGitHub = client
+	t.Cleanup(func() { testDB, testGitHub = nil, nil })
+
+	runStart(t, "12", "--project", "widgets")
+
+	assert.Equal(t, "/repos/acme/widgets/issues/12", fake.LastRequest().Path)
+	w := testutil.AssertWorktreeExists(t, db, "wt-widgets-12")
+	assert.Equal(t, "fix/12-login-fails-with-sso", w.Branch)
+	issue, err := db.GetIssueCache("widgets", 12)
+	require.NoError(t, err)
+	assert.Equal(t, "Login fails with SSO", issue.Title)
+	assert.Equal(t, "bug", issue.Type)
+
+	runStart(t, "12", "--project", "widgets")
+	assert.Len(t, fake.Requests(), 1, "the cached issue is not fetched again")
+}
+
+func TestStartCommand_ReusesExistingBranch(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	p := testutil.CreateGitProject(t, db, "app")
+	testutil.RunGit(t, p.LocalPath, "branch", "feature/5-existing")
+
+	testDB = db
+	t.Cleanup(func() { testDB = nil })
+
+	runStart(t, "5", "--project", "app", "--title", "Existing")
+
+	w := testutil.AssertWorktreeExists(t, db, "wt-app-5")
+	assert.Equal(t, "feature/5-existing", testutil.RunGit(t, w.Path, "symbolic-ref", "--short", "HEAD"))
+}
+
+func TestStartCommand_InvalidIssue(t *testing.T) {
+	t.Skip("Skipped: command calls os.Exit(1) which terminates test process")
+}
+
+func TestResolveProject_FromWorktreeSubdirectory(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	testutil.CreateGitProject(t, db, "app")
+	testutil.CreateGitProject(t, db, "lib")
+
+	testDB = db
+	t.Cleanup(func() { testDB = nil })
+
+	runStart(t, "5", "--project", "lib", "--title", "Fix parser")
+	w := testutil.AssertWorktreeExists(t, db, "wt-lib-5")
+	sub := filepath.Join(w.Path, "pkg", "parser")
+	require.NoError(t, os.MkdirAll(sub, 0755))
+	t.Chdir(sub)
+
+	p, err := resolveProject(db, "")
+	require.NoError(t, err)
+	assert.Equal(t, "lib", p.ID)
+}
+
+func TestResolveProject_ExpandsHomeInLocalPath(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	require.NoError(t, os.MkdirAll(filepath.Join(home, "dev", "app", "src"), 0755))
+	require.NoError(t, db.CreateProject(&storage.Project{
+		ID: "app", Name: "App", GitHubOwner: "o", GitHubRepo: "app",
+		LocalPath: "~/dev/app", WorktreeDir: "~/worktrees/app", Config: `{}`,
+	}))
+	testutil.CreateGitProject(t, db, "lib")
+
+	t.Chdir(filepath.Join(home, "dev", "app", "src"))
+	p, err := resolveProject(db, "")
+	require.NoError(t, err)
+	assert.Equal(t, "app", p.ID)
+}
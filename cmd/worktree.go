@@ -0,0 +1,470 @@
+package cmd
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/paolorechia/issue-flow/internal/project"
+	"github.com/paolorechia/issue-flow/internal/storage"
+	"github.com/paolorechia/issue-flow/internal/worktree"
+	"github.com/spf13/cobra"
+)
+
+var (
+	worktreeProject      string
+	worktreeJobs         int
+	worktreeForce        bool
+	worktreeDeleteBranch bool
+	worktreeJSON         bool
+	reconcileActions     worktree.ReconcileActions
+	syncStrategy         string
+	syncAutostash        bool
+	syncNoFetch          bool
+)
+
+var worktreeCmd = &cobra.Command{
+	Use:   "worktree",
+	Short: "Manage worktrees",
+	Long:  "List and manage the git worktrees issue-flow created for issues.",
+}
+
+var worktreeListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List worktrees with their live git state",
+	Run: func(cmd *cobra.Command, args []string) {
+		db, err := getDB()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error opening database: %v\n", err)
+			os.Exit(1)
+		}
+		if shouldCloseDB(db) {
+			defer db.Close()
+		}
+
+		var worktrees []storage.Worktree
+		if worktreeProject != "" {
+			worktrees, err = db.ListWorktreesByProject(worktreeProject)
+		} else {
+			worktrees, err = db.ListWorktrees()
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error listing worktrees: %v\n", err)
+			os.Exit(1)
+		}
+
+		out := cmd.OutOrStdout()
+		if len(worktrees) == 0 {
+			fmt.Fprintln(out, "No worktrees found. Use 'issue-flow start <issue>' to create one.")
+			return
+		}
+
+		manager := worktree.NewManager(db, getGit())
+		states, err := manager.Inspect(context.Background(), worktrees, worktreeJobs)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error inspecting worktrees: %v\n", err)
+			os.Exit(1)
+		}
+
+		w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "PROJECT\tISSUE\tBRANCH\tSTATUS\tCHANGES\tSYNC\tLAST COMMIT\tMERGED\tPATH")
+		for _, s := range states {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+				s.ProjectID, worktreeLabel(&s.Worktree), s.Branch, s.Status,
+				changesLabel(s), syncLabel(s), timeAgo(s.LastCommit, time.Now()), yesNo(s.Merged), s.Path)
+		}
+		w.Flush()
+
+		for _, s := range states {
+			if s.Err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: %s: %v\n", s.ID, s.Err)
+			}
+		}
+	},
+}
+
+var worktreeRemoveCmd = &cobra.Command{
+	Use:   "remove <issue|id>",
+	Short: "Remove a worktree without losing work",
+	Long: `Remove an issue worktree and its database record.
+
+Before removing, issue-flow checks for uncommitted changes, untracked files,
+stashes and commits that are on no remote. If any are found they are listed
+and nothing is removed unless --force is given.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		db, err := getDB()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error opening database: %v\n", err)
+			os.Exit(1)
+		}
+		if shouldCloseDB(db) {
+			defer db.Close()
+		}
+
+		p, w, err := resolveWorktree(db, args[0], worktreeProject)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error finding worktree: %v\n", err)
+			os.Exit(1)
+		}
+
+		manager := worktree.NewManager(db, getGit())
+		result, err := manager.Remove(context.Background(), p, w, worktree.RemoveOptions{
+			Force:        worktreeForce,
+			DeleteBranch: worktreeDeleteBranch,
+		})
+		if errors.Is(err, worktree.ErrWouldLoseWork) {
+			fmt.Fprintf(os.Stderr, "Refusing to remove %s (#%d); it would lose:\n", w.ID, w.IssueNumber)
+			printLossReport(os.Stderr, result.Report)
+			fmt.Fprintln(os.Stderr, "Re-run with --force to remove it anyway.")
+			os.Exit(1)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error removing worktree: %v\n", err)
+			os.Exit(1)
+		}
+
+		for _, warning := range result.Warnings {
+			fmt.Fprintf(os.Stderr, "Warning: %s\n", warning)
+		}
+
+		out := cmd.OutOrStdout()
+		if !result.Report.Empty() {
+			fmt.Fprintln(out, "Discarded:")
+			printLossReport(out, result.Report)
+		}
+		fmt.Fprintf(out, "✓ Removed worktree for #%d (%s)\n", w.IssueNumber, w.Path)
+		if result.BranchDeleted {
+			fmt.Fprintf(out, "✓ Deleted branch %s\n", w.Branch)
+		}
+	},
+}
+
+var worktreeReconcileCmd = &cobra.Command{
+	Use:   "reconcile",
+	Short: "Reconcile the worktrees table with git",
+	Long: `Compare the worktrees table with 'git worktree list' for every project and
+report rows whose directory is missing, directories git no longer tracks,
+git worktrees issue-flow does not know about, and branch mismatches.
+
+Without action flags only the report is printed. Use --json for a
+machine-readable report.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		db, err := getDB()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error opening database: %v\n", err)
+			os.Exit(1)
+		}
+		if shouldCloseDB(db) {
+			defer db.Close()
+		}
+
+		ctx := context.Background()
+		manager := worktree.NewManager(db, getGit())
+		drifts, err := manager.Reconcile(ctx, worktreeProject)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reconciling worktrees: %v\n", err)
+			os.Exit(1)
+		}
+		drifts = manager.ApplyReconcile(ctx, drifts, reconcileActions)
+
+		out := cmd.OutOrStdout()
+		if worktreeJSON {
+			if drifts == nil {
+				drifts = []worktree.Drift{}
+			}
+			enc := json.NewEncoder(out)
+			enc.SetIndent("", "  ")
+			if err := enc.Encode(map[string][]worktree.Drift{"drifts": drifts}); err != nil {
+				fmt.Fprintf(os.Stderr, "Error encoding report: %v\n", err)
+				os.Exit(1)
+			}
+			return
+		}
+
+		if len(drifts) == 0 {
+			fmt.Fprintln(out, "✓ Worktrees table matches git.")
+			return
+		}
+
+		w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "KIND\tPROJECT\tISSUE\tPATH\tDETAIL\tACTION")
+		for _, d := range drifts {
+			action := d.Action
+			if action == "" {
+				action = "-"
+			} else if d.Error != "" {
+				action += " failed: " + d.Error
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n",
+				d.Kind, d.ProjectID, issueLabel(d.IssueNumber), d.Path, driftDetail(d), action)
+		}
+		w.Flush()
+	},
+}
+
+var worktreeSyncCmd = &cobra.Command{
+	Use:   "sync",
+	Short: "Rebase or merge all active worktrees onto their base branch",
+	Long: `Fetch each project once, then rebase (or merge, per the project's sync
+strategy) every active worktree onto its base branch in parallel: the
+project's default branch, or the branch its issue type's base branch rule
+picked.
+
+Worktrees with uncommitted changes are skipped unless --autostash is given.
+A sync that hits conflicts is aborted, leaving the worktree untouched.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		db, err := getDB()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error opening database: %v\n", err)
+			os.Exit(1)
+		}
+		if shouldCloseDB(db) {
+			defer db.Close()
+		}
+
+		manager := worktree.NewManager(db, getGit())
+		outcomes, warnings, err := manager.Sync(context.Background(), worktree.SyncOptions{
+			ProjectID: worktreeProject,
+			Strategy:  syncStrategy,
+			Autostash: syncAutostash,
+			NoFetch:   syncNoFetch,
+		}, worktreeJobs)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error syncing worktrees: %v\n", err)
+			os.Exit(1)
+		}
+		for _, warning := range warnings {
+			fmt.Fprintf(os.Stderr, "Warning: %s\n", warning)
+		}
+
+		out := cmd.OutOrStdout()
+		if len(outcomes) == 0 {
+			fmt.Fprintln(out, "No active worktrees to sync.")
+			return
+		}
+
+		failed := 0
+		w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "PROJECT\tISSUE\tBRANCH\tBASE\tRESULT\tDETAIL")
+		for _, o := range outcomes {
+			detail := "-"
+			switch {
+			case o.Err != nil:
+				detail = o.Err.Error()
+			case len(o.Conflicts) > 0:
+				detail = "conflicts in " + strings.Join(o.Conflicts, ", ")
+			case o.Result == worktree.SyncStacked:
+				detail = "see 'worktree restack'"
+			case o.Behind > 0:
+				detail = fmt.Sprintf("%d new commits", o.Behind)
+			}
+			if o.Result == worktree.SyncConflict || o.Result == worktree.SyncFailed {
+				failed++
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n",
+				o.Worktree.ProjectID, worktreeLabel(&o.Worktree), o.Worktree.Branch, o.Base, o.Result, detail)
+		}
+		w.Flush()
+
+		if failed > 0 {
+			fmt.Fprintf(os.Stderr, "%d worktrees could not be synced.\n", failed)
+			os.Exit(1)
+		}
+	},
+}
+
+func issueLabel(n int) string {
+	if n <= 0 {
+		return "-"
+	}
+	return fmt.Sprintf("#%d", n)
+}
+
+// worktreeLabel names a worktree's issue and, for attempts, the attempt:
+// "#123" or "#123:a1". Review worktrees are named after their pull request:
+// "PR#45".
+func worktreeLabel(w *storage.Worktree) string {
+	if w.PRNumber > 0 {
+		return fmt.Sprintf("PR#%d", w.PRNumber)
+	}
+	if w.Attempt == "" {
+		return fmt.Sprintf("#%d", w.IssueNumber)
+	}
+	return fmt.Sprintf("#%d:%s", w.IssueNumber, w.Attempt)
+}
+
+func driftDetail(d worktree.Drift) string {
+	switch d.Kind {
+	case worktree.DriftBranchMismatch:
+		return fmt.Sprintf("db=%s git=%s", d.DBBranch, d.GitBranch)
+	case worktree.DriftUntracked:
+		if d.Attempt != "" {
+			return fmt.Sprintf("branch=%s attempt=%s", d.GitBranch, d.Attempt)
+		}
+		return "branch=" + d.GitBranch
+	default:
+		return "branch=" + d.DBBranch
+	}
+}
+
+// resolveWorktree finds a worktree by issue number ("123" or "#123"), or an
+// attempt at it ("123:a1"), within the resolved project, or by its worktree
+// ID. A bare issue number selects the primary worktree, or the only attempt
+// if the issue has no primary.
+func resolveWorktree(db *storage.Database, arg, projectID string) (*project.Project, *storage.Worktree, error) {
+	if issueNumber, attempt, ok := worktree.ParseIssueRef(arg); ok {
+		p, err := resolveProject(db, projectID)
+		if err != nil {
+			return nil, nil, err
+		}
+		w, err := db.GetWorktreeByAttempt(p.ID, issueNumber, attempt)
+		if errors.Is(err, sql.ErrNoRows) && attempt == "" {
+			w, err = onlyAttempt(db, p.ID, issueNumber)
+		}
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil, fmt.Errorf("no worktree for issue %s in project %s", worktreeLabel(&storage.Worktree{IssueNumber: issueNumber, Attempt: attempt}), p.ID)
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		return p, w, nil
+	}
+
+	w, err := db.GetWorktree(arg)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil, fmt.Errorf("no worktree with ID %s", arg)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	p, err := project.NewManager(db).Get(w.ProjectID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load project %s: %w", w.ProjectID, err)
+	}
+	return p, w, nil
+}
+
+// onlyAttempt returns the issue's worktree when it has exactly one.
+func onlyAttempt(db *storage.Database, projectID string, issueNumber int) (*storage.Worktree, error) {
+	worktrees, err := db.ListWorktreesByIssue(projectID, issueNumber)
+	if err != nil {
+		return nil, err
+	}
+	switch len(worktrees) {
+	case 0:
+		return nil, sql.ErrNoRows
+	case 1:
+		return &worktrees[0], nil
+	}
+	refs := make([]string, len(worktrees))
+	for i := range worktrees {
+		refs[i] = strings.TrimPrefix(worktreeLabel(&worktrees[i]), "#")
+	}
+	return nil, fmt.Errorf("issue #%d has several attempts (%s); select one as <issue>:<attempt>", issueNumber, strings.Join(refs, ", "))
+}
+
+func printLossReport(out io.Writer, r *worktree.LossReport) {
+	for _, f := range r.Uncommitted {
+		fmt.Fprintf(out, "  uncommitted: %s\n", f)
+	}
+	for _, f := range r.Untracked {
+		fmt.Fprintf(out, "  untracked:   %s\n", f)
+	}
+	for _, st := range r.Stashes {
+		fmt.Fprintf(out, "  stash:       %s %s\n", st.Ref, st.Message)
+	}
+	for _, c := range r.Unpushed {
+		fmt.Fprintf(out, "  unpushed:    %s %s\n", c.Short(), c.Subject)
+	}
+	if r.Archive != "" {
+		fmt.Fprintf(out, "  archive:     %s\n", r.Archive)
+	}
+	if r.Snapshot != "" {
+		fmt.Fprintf(out, "  parked:      %s\n", r.Snapshot)
+	}
+}
+
+func changesLabel(s worktree.State) string {
+	switch {
+	case !s.Exists:
+		return "missing"
+	case s.Dirty:
+		return "dirty"
+	default:
+		return "clean"
+	}
+}
+
+func syncLabel(s worktree.State) string {
+	if s.Upstream == "" {
+		return "-"
+	}
+	if s.Ahead == 0 && s.Behind == 0 {
+		return "up-to-date"
+	}
+	return fmt.Sprintf("+%d/-%d", s.Ahead, s.Behind)
+}
+
+func yesNo(b bool) string {
+	if b {
+		return "yes"
+	}
+	return "no"
+}
+
+// timeAgo renders t relative to now in a compact form such as "3d ago".
+func timeAgo(t, now time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	d := now.Sub(t)
+	switch {
+	case d < time.Minute:
+		return "just now"
+	case d < time.Hour:
+		return fmt.Sprintf("%dm ago", int(d.Minutes()))
+	case d < 24*time.Hour:
+		return fmt.Sprintf("%dh ago", int(d.Hours()))
+	default:
+		return fmt.Sprintf("%dd ago", int(d.Hours()/24))
+	}
+}
+
+func init() {
+	rootCmd.AddCommand(worktreeCmd)
+	worktreeCmd.AddCommand(worktreeListCmd)
+	worktreeCmd.AddCommand(worktreeRemoveCmd)
+	worktreeCmd.AddCommand(worktreeReconcileCmd)
+	worktreeCmd.AddCommand(worktreeSyncCmd)
+
+	worktreeListCmd.Flags().StringVarP(&worktreeProject, "project", "p", "", "Only list worktrees of this project")
+	worktreeListCmd.Flags().IntVarP(&worktreeJobs, "jobs", "j", worktree.DefaultWorkers, "Number of worktrees to inspect concurrently")
+
+	worktreeRemoveCmd.Flags().StringVarP(&worktreeProject, "project", "p", "", "Project ID when selecting by issue number")
+	worktreeRemoveCmd.Flags().BoolVarP(&worktreeForce, "force", "f", false, "Remove even if work would be lost")
+	worktreeRemoveCmd.Flags().BoolVar(&worktreeDeleteBranch, "delete-branch", false, "Also delete the local branch")
+
+	worktreeReconcileCmd.Flags().StringVarP(&worktreeProject, "project", "p", "", "Only reconcile this project")
+	worktreeReconcileCmd.Flags().BoolVar(&worktreeJSON, "json", false, "Print the report as JSON")
+	worktreeReconcileCmd.Flags().BoolVar(&reconcileActions.Adopt, "adopt", false, "Record untracked git worktrees in the database")
+	worktreeReconcileCmd.Flags().BoolVar(&reconcileActions.Repair, "repair", false, "Run 'git worktree repair' on unregistered worktrees")
+	worktreeReconcileCmd.Flags().BoolVar(&reconcileActions.MarkStale, "mark-stale", false, "Mark rows with missing directories as stale")
+	worktreeReconcileCmd.Flags().BoolVar(&reconcileActions.Drop, "drop", false, "Delete rows with missing directories")
+	worktreeReconcileCmd.Flags().BoolVar(&reconcileActions.FixBranches, "fix-branches", false, "Update rows to the branch checked out in git")
+
+	worktreeSyncCmd.Flags().StringVarP(&worktreeProject, "project", "p", "", "Only sync worktrees of this project")
+	worktreeSyncCmd.Flags().StringVar(&syncStrategy, "strategy", "", "Override the project's sync strategy (rebase or merge)")
+	worktreeSyncCmd.Flags().BoolVar(&syncAutostash, "autostash", false, "Stash uncommitted changes around the sync instead of skipping")
+	worktreeSyncCmd.Flags().BoolVar(&syncNoFetch, "no-fetch", false, "Do not fetch the upstream remote first")
+	worktreeSyncCmd.Flags().IntVarP(&worktreeJobs, "jobs", "j", worktree.DefaultWorkers, "Number of worktrees to sync concurrently")
+}
@@ -0,0 +1,189 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/paolorechia/issue-flow/internal/worktree"
+	"github.com/spf13/cobra"
+)
+
+var (
+	archiveKeepBranch    bool
+	unarchiveNoBootstrap bool
+)
+
+var worktreeArchiveCmd = &cobra.Command{
+	Use:   "archive <issue|id>",
+	Short: "Save a worktree's work to a bundle and remove it",
+	Long: `Write the branch's commits that are not on the default branch, plus a
+snapshot of uncommitted and untracked changes, to a git bundle under
+~/.issue-flow/archive/<project>/<issue>/, then remove the worktree and its
+branch. The worktree is kept in the database with status archived until
+'worktree unarchive' recreates it.
+
+Ignored files and stashes are not archived.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		db, err := getDB()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error opening database: %v\n", err)
+			os.Exit(1)
+		}
+		if shouldCloseDB(db) {
+			defer db.Close()
+		}
+
+		p, w, err := resolveWorktree(db, args[0], worktreeProject)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error finding worktree: %v\n", err)
+			os.Exit(1)
+		}
+
+		manager := worktree.NewManager(db, getGit())
+		result, err := manager.Archive(context.Background(), p, w, worktree.ArchiveOptions{KeepBranch: archiveKeepBranch})
+		if result != nil {
+			for _, warning := range result.Warnings {
+				fmt.Fprintf(os.Stderr, "Warning: %s\n", warning)
+			}
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error archiving worktree: %v\n", err)
+			os.Exit(1)
+		}
+
+		out := cmd.OutOrStdout()
+		fmt.Fprintf(out, "✓ Archived %s to %s (%s)\n", worktreeLabel(w), result.Dir, archiveSummary(result.Manifest))
+		if result.BranchDeleted {
+			fmt.Fprintf(out, "✓ Deleted branch %s\n", w.Branch)
+		}
+	},
+}
+
+var worktreeArchiveListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List archived worktrees",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		db, err := getDB()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error opening database: %v\n", err)
+			os.Exit(1)
+		}
+		if shouldCloseDB(db) {
+			defer db.Close()
+		}
+
+		worktrees, err := db.ListArchivedWorktrees(worktreeProject)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error listing archived worktrees: %v\n", err)
+			os.Exit(1)
+		}
+
+		out := cmd.OutOrStdout()
+		if len(worktrees) == 0 {
+			fmt.Fprintln(out, "No archived worktrees.")
+			return
+		}
+
+		tw := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "PROJECT\tISSUE\tBRANCH\tCOMMITS\tCHANGES\tARCHIVE")
+		for _, w := range worktrees {
+			commits, changes := "?", "?"
+			if manifest, err := worktree.ReadArchive(w.ArchivePath); err == nil {
+				commits, changes = strconv.Itoa(manifest.Commits), "no"
+				if manifest.Snapshot != "" {
+					changes = "yes"
+				}
+			}
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\n", w.ProjectID, worktreeLabel(&w), w.Branch, commits, changes, w.ArchivePath)
+		}
+		tw.Flush()
+	},
+}
+
+var worktreeUnarchiveCmd = &cobra.Command{
+	Use:   "unarchive <issue|id>",
+	Short: "Recreate an archived worktree",
+	Long: `Restore the branch from the archive's bundle, check the worktree out again
+at its old path, restore the uncommitted changes and mark it active. Env
+files are rendered and the bootstrap runs as for a new worktree. The archive
+is deleted afterwards.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		db, err := getDB()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error opening database: %v\n", err)
+			os.Exit(1)
+		}
+		if shouldCloseDB(db) {
+			defer db.Close()
+		}
+
+		p, w, err := resolveWorktree(db, args[0], worktreeProject)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error finding worktree: %v\n", err)
+			os.Exit(1)
+		}
+
+		manager := worktree.NewManager(db, getGit())
+		result, err := manager.Unarchive(context.Background(), p, w)
+		if result != nil {
+			for _, warning := range result.Warnings {
+				fmt.Fprintf(os.Stderr, "Warning: %s\n", warning)
+			}
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error unarchiving worktree: %v\n", err)
+			os.Exit(1)
+		}
+
+		out := cmd.OutOrStdout()
+		fmt.Fprintf(out, "✓ Restored %s at %s (%s)\n", worktreeLabel(w), w.Path, archiveSummary(result.Manifest))
+		if len(result.Ports) > 0 {
+			ports := make([]string, len(result.Ports))
+			for i, port := range result.Ports {
+				ports[i] = fmt.Sprintf("%s=%d", port.Env, port.Port)
+			}
+			fmt.Fprintf(out, "  Ports: %s\n", strings.Join(ports, " "))
+		}
+
+		if len(p.Config.EnvTemplates) > 0 && !renderEnvFiles(cmd, manager, p, w) {
+			os.Exit(1)
+		}
+		if !unarchiveNoBootstrap && !p.Config.Bootstrap.Empty() && !runBootstrap(cmd, manager, p, w) {
+			os.Exit(1)
+		}
+	},
+}
+
+// archiveSummary describes what an archive holds, e.g. "3 commits,
+// uncommitted changes".
+func archiveSummary(m *worktree.ArchiveManifest) string {
+	summary := fmt.Sprintf("%d commits", m.Commits)
+	if m.Commits == 1 {
+		summary = "1 commit"
+	}
+	if m.Snapshot != "" {
+		summary += ", uncommitted changes"
+	}
+	return summary
+}
+
+func init() {
+	worktreeCmd.AddCommand(worktreeArchiveCmd)
+	worktreeCmd.AddCommand(worktreeUnarchiveCmd)
+	worktreeArchiveCmd.AddCommand(worktreeArchiveListCmd)
+
+	worktreeArchiveCmd.Flags().StringVarP(&worktreeProject, "project", "p", "", "Project ID when selecting by issue number")
+	worktreeArchiveCmd.Flags().BoolVar(&archiveKeepBranch, "keep-branch", false, "Keep the local branch")
+
+	worktreeArchiveListCmd.Flags().StringVarP(&worktreeProject, "project", "p", "", "Only list archives of this project")
+
+	worktreeUnarchiveCmd.Flags().StringVarP(&worktreeProject, "project", "p", "", "Project ID when selecting by issue number")
+	worktreeUnarchiveCmd.Flags().BoolVar(&unarchiveNoBootstrap, "no-bootstrap", false, "Skip the project's bootstrap steps")
+}
@@ -0,0 +1,55 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/paolorechia/issue-flow/internal/storage"
+	"github.com/paolorechia/issue-flow/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWorktreeArchiveAndUnarchiveCommands(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	db := testutil.NewTestDB(t)
+	testutil.CreateGitProject(t, db, "app")
+
+	testDB = db
+	t.Cleanup(func() { testDB = nil })
+	t.Cleanup(func() { worktreeProject, archiveKeepBranch, unarchiveNoBootstrap = "", false, false })
+
+	runStart(t, "5", "--project", "app", "--title", "Shelved")
+	w := testutil.AssertWorktreeExists(t, db, "wt-app-5")
+	testutil.GitCommitFile(t, w.Path, "feature.txt", "feature\n", "Add feature")
+	require.NoError(t, os.WriteFile(filepath.Join(w.Path, "draft.txt"), []byte("draft\n"), 0644))
+
+	out := runWorktree(t, "archive", "5", "--project", "app")
+	assert.Contains(t, out, "✓ Archived #5 to ")
+	assert.Contains(t, out, "(1 commit, uncommitted changes)")
+	assert.Contains(t, out, "✓ Deleted branch "+w.Branch)
+	assert.NoDirExists(t, w.Path)
+	assert.Equal(t, storage.WorktreeStatusArchived, testutil.AssertWorktreeExists(t, db, w.ID).Status)
+
+	out = runWorktree(t, "list", "--project", "app")
+	assert.NotContains(t, out, w.Branch)
+
+	out = runWorktree(t, "archive", "list")
+	rows := testutil.ParseTableOutput(t, out)
+	require.Len(t, rows, 2)
+	assert.Equal(t, []string{"app", "#5", w.Branch, "1", "yes"}, rows[1][:5])
+
+	out = runWorktree(t, "unarchive", "wt-app-5")
+	assert.Contains(t, out, "✓ Restored #5 at "+w.Path)
+	assert.FileExists(t, filepath.Join(w.Path, "feature.txt"))
+	assert.FileExists(t, filepath.Join(w.Path, "draft.txt"))
+	assert.Equal(t, storage.WorktreeStatusActive, testutil.AssertWorktreeExists(t, db, w.ID).Status)
+
+	out = runWorktree(t, "archive", "list")
+	assert.Contains(t, out, "No archived worktrees.")
+}
+
+func TestStartCommand_RefusesArchived(t *testing.T) {
+	t.Skip("Skipped: command calls os.Exit(1) which terminates test process")
+}
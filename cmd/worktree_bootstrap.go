@@ -0,0 +1,122 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/paolorechia/issue-flow/internal/project"
+	"github.com/paolorechia/issue-flow/internal/storage"
+	"github.com/paolorechia/issue-flow/internal/worktree"
+	"github.com/spf13/cobra"
+)
+
+var bootstrapRetry bool
+
+var worktreeBootstrapCmd = &cobra.Command{
+	Use:   "bootstrap [issue|id]",
+	Short: "Run the project's bootstrap steps in a worktree",
+	Long: `Copy or symlink the configured files from the project's local path and run
+the bootstrap commands inside a worktree. start runs these steps for new
+worktrees automatically.
+
+With --retry only worktrees whose last bootstrap failed are run; without an
+argument, --retry re-runs every failed bootstrap.`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) == 0 && !bootstrapRetry {
+			fmt.Fprintln(os.Stderr, "Error: specify a worktree or use --retry")
+			os.Exit(1)
+		}
+
+		db, err := getDB()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error opening database: %v\n", err)
+			os.Exit(1)
+		}
+		if shouldCloseDB(db) {
+			defer db.Close()
+		}
+
+		var targets []*storage.Worktree
+		if len(args) == 1 {
+			_, w, err := resolveWorktree(db, args[0], worktreeProject)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error finding worktree: %v\n", err)
+				os.Exit(1)
+			}
+			targets = append(targets, w)
+		} else {
+			var worktrees []storage.Worktree
+			if worktreeProject != "" {
+				worktrees, err = db.ListWorktreesByProject(worktreeProject)
+			} else {
+				worktrees, err = db.ListWorktrees()
+			}
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error listing worktrees: %v\n", err)
+				os.Exit(1)
+			}
+			for i := range worktrees {
+				targets = append(targets, &worktrees[i])
+			}
+		}
+
+		out := cmd.OutOrStdout()
+		pm := project.NewManager(db)
+		manager := worktree.NewManager(db, getGit())
+		ran, failed := 0, 0
+		for _, w := range targets {
+			if bootstrapRetry && w.BootstrapStatus != storage.BootstrapFailed {
+				if len(args) == 1 {
+					fmt.Fprintf(out, "Bootstrap of #%d has not failed; nothing to retry\n", w.IssueNumber)
+				}
+				continue
+			}
+
+			p, err := pm.Get(w.ProjectID)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error loading project %s: %v\n", w.ProjectID, err)
+				os.Exit(1)
+			}
+
+			ran++
+			if !runBootstrap(cmd, manager, p, w) {
+				failed++
+			}
+		}
+
+		if ran == 0 && len(args) == 0 {
+			fmt.Fprintln(out, "No failed bootstraps to retry")
+		}
+		if failed > 0 {
+			os.Exit(1)
+		}
+	},
+}
+
+// runBootstrap runs the bootstrap for w, streaming its output, and reports
+// whether it succeeded. Projects without bootstrap steps always succeed.
+func runBootstrap(cmd *cobra.Command, manager *worktree.Manager, p *project.Project, w *storage.Worktree) bool {
+	out := cmd.OutOrStdout()
+	if p.Config.Bootstrap.Empty() {
+		fmt.Fprintf(out, "Project %s has no bootstrap steps\n", p.ID)
+		return true
+	}
+
+	fmt.Fprintf(out, "Bootstrapping #%d in %s\n", w.IssueNumber, w.Path)
+	if err := manager.Bootstrap(context.Background(), p, w, out, cmd.ErrOrStderr()); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		fmt.Fprintf(os.Stderr, "Fix the problem and run 'issue-flow worktree bootstrap %s --retry'.\n", w.ID)
+		return false
+	}
+	fmt.Fprintf(out, "✓ Bootstrapped %s\n", worktreeLabel(w))
+	return true
+}
+
+func init() {
+	worktreeCmd.AddCommand(worktreeBootstrapCmd)
+
+	worktreeBootstrapCmd.Flags().StringVarP(&worktreeProject, "project", "p", "", "Project ID when selecting by issue number, or the project to retry")
+	worktreeBootstrapCmd.Flags().BoolVar(&bootstrapRetry, "retry", false, "Only run bootstraps that previously failed")
+}
@@ -0,0 +1,84 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/paolorechia/issue-flow/internal/storage"
+	"github.com/paolorechia/issue-flow/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const bootstrapProjectConfig = `{"branch_config":{"pattern":"{prefix}/{issue-number}-{slug}"},"bootstrap":{"files":[{"path":".env"}],"commands":[{"run":"echo installing && touch installed.txt","timeout":"1m"}]}}`
+
+func setupBootstrapProject(t *testing.T) (*storage.Database, *storage.Project) {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("bootstrap commands in this test use sh")
+	}
+
+	db := testutil.NewTestDB(t)
+	sp := testutil.CreateGitProjectWithConfig(t, db, "app", bootstrapProjectConfig)
+	require.NoError(t, os.WriteFile(filepath.Join(sp.LocalPath, ".env"), []byte("TOKEN=x\n"), 0600))
+
+	testDB = db
+	t.Cleanup(func() { testDB = nil })
+	t.Cleanup(func() { worktreeProject, bootstrapRetry = "", false })
+	return db, sp
+}
+
+func TestStartCommand_Bootstraps(t *testing.T) {
+	db, _ := setupBootstrapProject(t)
+
+	out := runStart(t, "7", "--project", "app", "--title", "Needs deps")
+	assert.Contains(t, out, "→ [2/2] echo installing")
+	assert.Contains(t, out, "installing\n")
+	assert.Contains(t, out, "✓ Bootstrapped #7")
+
+	w := testutil.AssertWorktreeExists(t, db, "wt-app-7")
+	assert.Equal(t, storage.BootstrapSucceeded, w.BootstrapStatus)
+	assert.FileExists(t, filepath.Join(w.Path, ".env"))
+	assert.FileExists(t, filepath.Join(w.Path, "installed.txt"))
+
+	out = runStart(t, "7", "--project", "app")
+	assert.NotContains(t, out, "Bootstrapping", "reused worktrees are not bootstrapped again")
+}
+
+func TestStartCommand_NoBootstrap(t *testing.T) {
+	db, _ := setupBootstrapProject(t)
+
+	out := runStart(t, "7", "--project", "app", "--title", "Skip deps", "--no-bootstrap")
+	assert.NotContains(t, out, "Bootstrapping")
+
+	w := testutil.AssertWorktreeExists(t, db, "wt-app-7")
+	assert.Empty(t, w.BootstrapStatus)
+	assert.NoFileExists(t, filepath.Join(w.Path, "installed.txt"))
+}
+
+func TestWorktreeBootstrapCommand_Retry(t *testing.T) {
+	db, _ := setupBootstrapProject(t)
+	runStart(t, "7", "--project", "app", "--title", "Needs deps", "--no-bootstrap")
+
+	out := runWorktree(t, "bootstrap", "7", "--project", "app", "--retry")
+	assert.Contains(t, out, "nothing to retry")
+
+	w := testutil.AssertWorktreeExists(t, db, "wt-app-7")
+	w.BootstrapStatus, w.BootstrapError = storage.BootstrapFailed, "npm exploded"
+	require.NoError(t, db.UpdateWorktree(w))
+
+	out = runWorktree(t, "bootstrap", "--retry")
+	assert.Contains(t, out, "✓ Bootstrapped #7")
+	assert.Equal(t, storage.BootstrapSucceeded, testutil.AssertWorktreeExists(t, db, w.ID).BootstrapStatus)
+	assert.FileExists(t, filepath.Join(w.Path, "installed.txt"))
+
+	bootstrapRetry = false
+	out = runWorktree(t, "bootstrap", "--retry")
+	assert.Contains(t, out, "No failed bootstraps to retry")
+}
+
+func TestWorktreeBootstrapCommand_Failure(t *testing.T) {
+	t.Skip("Skipped: command calls os.Exit(1) which terminates test process")
+}
@@ -0,0 +1,228 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/paolorechia/issue-flow/internal/worktree"
+	"github.com/spf13/cobra"
+)
+
+// failedTestLines is how much of a failed test run's output compare prints.
+const failedTestLines = 20
+
+var (
+	compareTest      string
+	compareNoTest    bool
+	compareTimeout   time.Duration
+	compareJobs      int
+	pickKeepBranches bool
+)
+
+var worktreeCompareCmd = &cobra.Command{
+	Use:   "compare <issue>",
+	Short: "Compare the attempts at an issue side by side",
+	Long: `Show, for every worktree of an issue, the commits and diffstat relative to
+the base branch, whether it has uncommitted changes, and the result of the
+project's test command run in it.
+
+The test command comes from the project's test config and can be overridden
+with --test or skipped with --no-test. Each attempt runs its tests with its
+own ports and ISSUE_FLOW_* variables; --jobs runs several at once.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		issueNumber, err := parseIssueNumber(args[0])
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		db, err := getDB()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error opening database: %v\n", err)
+			os.Exit(1)
+		}
+		if shouldCloseDB(db) {
+			defer db.Close()
+		}
+
+		p, err := resolveProject(db, worktreeProject)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error resolving project: %v\n", err)
+			os.Exit(1)
+		}
+		worktrees, err := db.ListWorktreesByIssue(p.ID, issueNumber)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error listing worktrees: %v\n", err)
+			os.Exit(1)
+		}
+		if len(worktrees) == 0 {
+			fmt.Fprintf(os.Stderr, "Error: no worktrees for issue #%d in project %s\n", issueNumber, p.ID)
+			os.Exit(1)
+		}
+
+		testCommand := p.Config.Test.Command
+		if compareTest != "" {
+			testCommand = compareTest
+		}
+		if compareNoTest {
+			testCommand = ""
+		}
+
+		manager := worktree.NewManager(db, getGit())
+		comparisons, err := manager.Compare(context.Background(), p, worktrees, worktree.CompareOptions{
+			TestCommand: testCommand,
+			Timeout:     compareTimeout,
+			Workers:     compareJobs,
+		})
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error comparing attempts: %v\n", err)
+			os.Exit(1)
+		}
+
+		out := cmd.OutOrStdout()
+		w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "ATTEMPT\tBRANCH\tCOMMITS\tFILES\t+/-\tDIRTY\tTESTS")
+		for _, c := range comparisons {
+			if c.Err != nil {
+				fmt.Fprintf(w, "%s\t%s\t-\t-\t-\t-\t-\n", worktreeLabel(&c.Worktree), c.Worktree.Branch)
+				continue
+			}
+			fmt.Fprintf(w, "%s\t%s\t%d\t%d\t+%d/-%d\t%s\t%s\n",
+				worktreeLabel(&c.Worktree), c.Worktree.Branch, c.Commits,
+				c.Diff.Files, c.Diff.Insertions, c.Diff.Deletions, yesNo(c.Dirty), testLabel(c.Test))
+		}
+		w.Flush()
+
+		for _, c := range comparisons {
+			if c.Err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: %s: %v\n", c.Worktree.ID, c.Err)
+				continue
+			}
+			if c.Test != nil && !c.Test.Passed {
+				printFailedTest(out, &c)
+			}
+		}
+	},
+}
+
+var worktreePickCmd = &cobra.Command{
+	Use:   "pick <issue:attempt|id>",
+	Short: "Keep one attempt at an issue and remove the others",
+	Long: `Keep the given worktree and remove every other worktree of its issue,
+deleting their branches unless --keep-branches is given. A winning attempt
+becomes the issue's primary worktree, keeping its branch and directory.
+
+Commits on the other attempts' branches are discarded. Uncommitted changes,
+untracked files, stashes and parked snapshots are listed and nothing is
+removed unless --force is given.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		db, err := getDB()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error opening database: %v\n", err)
+			os.Exit(1)
+		}
+		if shouldCloseDB(db) {
+			defer db.Close()
+		}
+
+		p, w, err := resolveWorktree(db, args[0], worktreeProject)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error finding worktree: %v\n", err)
+			os.Exit(1)
+		}
+		label := worktreeLabel(w)
+
+		manager := worktree.NewManager(db, getGit())
+		result, err := manager.Pick(context.Background(), p, w, worktree.PickOptions{
+			Force:        worktreeForce,
+			KeepBranches: pickKeepBranches,
+		})
+		if errors.Is(err, worktree.ErrWouldLoseWork) {
+			fmt.Fprintf(os.Stderr, "Refusing to pick %s; the other attempts would lose:\n", label)
+			for _, b := range result.Blocked {
+				fmt.Fprintf(os.Stderr, "%s (%s):\n", worktreeLabel(&b.Worktree), b.Worktree.ID)
+				if b.Parked {
+					fmt.Fprintf(os.Stderr, "  parked:      %s\n", worktree.WIPRef(&b.Worktree))
+				}
+				printLossReport(os.Stderr, b.Report)
+			}
+			fmt.Fprintln(os.Stderr, "Re-run with --force to remove them anyway.")
+			os.Exit(1)
+		}
+		if result != nil {
+			for _, warning := range result.Warnings {
+				fmt.Fprintf(os.Stderr, "Warning: %s\n", warning)
+			}
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error picking %s: %v\n", label, err)
+			os.Exit(1)
+		}
+
+		out := cmd.OutOrStdout()
+		for _, r := range result.Removed {
+			fmt.Fprintf(out, "✓ Removed %s (%s)\n", worktreeLabel(&r), r.Path)
+		}
+		if result.Promoted {
+			fmt.Fprintf(out, "✓ %s is now the primary worktree for #%d (%s)\n", label, w.IssueNumber, w.Branch)
+		} else {
+			fmt.Fprintf(out, "✓ Kept %s (%s)\n", label, w.Branch)
+		}
+	},
+}
+
+func testLabel(r *worktree.TestResult) string {
+	switch {
+	case r == nil:
+		return "-"
+	case r.Err != nil:
+		return "error"
+	case r.Passed:
+		return fmt.Sprintf("pass (%s)", r.Duration.Round(100*time.Millisecond))
+	default:
+		return fmt.Sprintf("FAIL exit %d (%s)", r.ExitCode, r.Duration.Round(100*time.Millisecond))
+	}
+}
+
+// printFailedTest prints why a test run failed and the tail of its output.
+func printFailedTest(out io.Writer, c *worktree.Comparison) {
+	fmt.Fprintln(out)
+	if c.Test.Err != nil {
+		fmt.Fprintf(out, "%s: tests failed: %v\n", worktreeLabel(&c.Worktree), c.Test.Err)
+	} else {
+		fmt.Fprintf(out, "%s: tests failed with exit code %d\n", worktreeLabel(&c.Worktree), c.Test.ExitCode)
+	}
+	lines := strings.Split(strings.TrimRight(c.Test.Output, "\n"), "\n")
+	if len(lines) > failedTestLines {
+		fmt.Fprintf(out, "  ... (%d lines omitted)\n", len(lines)-failedTestLines)
+		lines = lines[len(lines)-failedTestLines:]
+	}
+	for _, line := range lines {
+		if line != "" {
+			fmt.Fprintf(out, "  %s\n", line)
+		}
+	}
+}
+
+func init() {
+	worktreeCmd.AddCommand(worktreeCompareCmd)
+	worktreeCmd.AddCommand(worktreePickCmd)
+
+	worktreeCompareCmd.Flags().StringVarP(&worktreeProject, "project", "p", "", "Project ID (defaults to the project containing the current directory)")
+	worktreeCompareCmd.Flags().StringVar(&compareTest, "test", "", "Test command to run instead of the project's")
+	worktreeCompareCmd.Flags().BoolVar(&compareNoTest, "no-test", false, "Do not run tests")
+	worktreeCompareCmd.Flags().DurationVar(&compareTimeout, "timeout", 0, "Timeout for each test run (defaults to the project's test timeout)")
+	worktreeCompareCmd.Flags().IntVarP(&compareJobs, "jobs", "j", 1, "Number of attempts to test concurrently")
+
+	worktreePickCmd.Flags().StringVarP(&worktreeProject, "project", "p", "", "Project ID when selecting by issue number")
+	worktreePickCmd.Flags().BoolVarP(&worktreeForce, "force", "f", false, "Remove the other attempts even if work would be lost")
+	worktreePickCmd.Flags().BoolVar(&pickKeepBranches, "keep-branches", false, "Keep the other attempts' branches")
+}
@@ -0,0 +1,76 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/paolorechia/issue-flow/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func resetCompareFlags(t *testing.T) {
+	t.Cleanup(func() {
+		worktreeProject, worktreeForce = "", false
+		compareTest, compareNoTest, compareTimeout, compareJobs, pickKeepBranches = "", false, 0, 1, false
+	})
+}
+
+func TestWorktreeCompareCommand(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	testutil.CreateGitProjectWithConfig(t, db, "app", `{"test": {"command": "test -f ok.txt || { echo missing ok.txt; exit 2; }"}}`)
+
+	testDB = db
+	t.Cleanup(func() { testDB = nil })
+	resetCompareFlags(t)
+
+	runStart(t, "8", "--project", "app", "--title", "Cache")
+	primary := testutil.AssertWorktreeExists(t, db, "wt-app-8")
+	out := runStart(t, "8", "--project", "app", "--attempt")
+	assert.Contains(t, out, "✓ Created worktree for #8:a1")
+	a1 := testutil.AssertWorktreeExists(t, db, "wt-app-8-a1")
+	testutil.GitCommitFile(t, a1.Path, "ok.txt", "ok\n", "Add ok")
+
+	out = runWorktree(t, "compare", "8", "--project", "app")
+	table := testutil.ParseTableOutput(t, out)
+	assert.Equal(t, []string{"ATTEMPT", "BRANCH", "COMMITS", "FILES", "+/-", "DIRTY", "TESTS"}, table[0])
+	assert.Equal(t, []string{"#8", primary.Branch, "0", "0", "+0/-0", "no", "FAIL"}, table[1][:7])
+	assert.Equal(t, []string{"#8:a1", a1.Branch, "1", "1", "+1/-0", "no", "pass"}, table[2][:7])
+	assert.Contains(t, out, "#8: tests failed with exit code 2\n  missing ok.txt\n")
+
+	out = runWorktree(t, "compare", "8", "--project", "app", "--no-test")
+	assert.NotContains(t, out, "tests failed")
+}
+
+func TestWorktreePickCommand(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	testutil.CreateGitProject(t, db, "app")
+
+	testDB = db
+	t.Cleanup(func() { testDB = nil })
+	resetCompareFlags(t)
+
+	runStart(t, "8", "--project", "app", "--attempt=fast")
+	runStart(t, "8", "--project", "app", "--attempt=slow")
+	fast := testutil.AssertWorktreeExists(t, db, "wt-app-8-fast")
+	slow := testutil.AssertWorktreeExists(t, db, "wt-app-8-slow")
+
+	_, _, err := resolveWorktree(db, "8", "app")
+	assert.ErrorContains(t, err, "several attempts (8:fast, 8:slow)")
+
+	out := runWorktree(t, "pick", "8:fast", "--project", "app")
+	assert.Contains(t, out, "✓ Removed #8:slow ("+slow.Path+")")
+	assert.Contains(t, out, "✓ #8:fast is now the primary worktree for #8 ("+fast.Branch+")")
+
+	testutil.AssertWorktreeCount(t, db, 1)
+	w := testutil.AssertWorktreeExists(t, db, "wt-app-8")
+	assert.Equal(t, fast.Path, w.Path)
+	assert.NoDirExists(t, slow.Path)
+
+	_, resolved, err := resolveWorktree(db, "8", "app")
+	require.NoError(t, err)
+	assert.Equal(t, "wt-app-8", resolved.ID)
+}
+
+func TestWorktreePickCommand_WouldLoseWork(t *testing.T) {
+	t.Skip("Skipped: command calls os.Exit(1) which terminates test process")
+}
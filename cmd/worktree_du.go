@@ -0,0 +1,159 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	"github.com/paolorechia/issue-flow/internal/project"
+	"github.com/paolorechia/issue-flow/internal/storage"
+	"github.com/paolorechia/issue-flow/internal/worktree"
+	"github.com/spf13/cobra"
+)
+
+// maxQuotaCandidates bounds the cleanup suggestions printed by start.
+const maxQuotaCandidates = 5
+
+var worktreeDuCmd = &cobra.Command{
+	Use:   "du",
+	Short: "Show disk usage of worktrees",
+	Long: `Measure the disk usage of every worktree and total it per project.
+
+Usage is split into tracked sources, dependency directories (node_modules,
+vendor, .venv, ...), other ignored files such as build artifacts, and the
+rest: untracked files and git metadata. Projects with a quota.max_size in
+their config show how much of it is used.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		db, err := getDB()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error opening database: %v\n", err)
+			os.Exit(1)
+		}
+		if shouldCloseDB(db) {
+			defer db.Close()
+		}
+
+		var worktrees []storage.Worktree
+		if worktreeProject != "" {
+			worktrees, err = db.ListWorktreesByProject(worktreeProject)
+		} else {
+			worktrees, err = db.ListWorktrees()
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error listing worktrees: %v\n", err)
+			os.Exit(1)
+		}
+
+		out := cmd.OutOrStdout()
+		if len(worktrees) == 0 {
+			fmt.Fprintln(out, "No worktrees found. Use 'issue-flow start <issue>' to create one.")
+			return
+		}
+
+		manager := worktree.NewManager(db, getGit())
+		usages := manager.DiskUsage(context.Background(), worktrees, worktreeJobs, true)
+		projects := worktree.SummarizeUsage(usages)
+
+		measured := make([]worktree.Usage, 0, len(usages))
+		for _, u := range usages {
+			if u.Err == nil {
+				measured = append(measured, u)
+			}
+		}
+		sort.SliceStable(measured, func(i, j int) bool {
+			return measured[i].Total > measured[j].Total
+		})
+
+		w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "PROJECT\tISSUE\tBRANCH\tTOTAL\tTRACKED\tDEPS\tARTIFACTS\tOTHER")
+		for _, u := range measured {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", u.Worktree.ProjectID, worktreeLabel(&u.Worktree), u.Worktree.Branch, sizeColumns(u.Sizes))
+		}
+		w.Flush()
+
+		pm := project.NewManager(db)
+		fmt.Fprintln(out)
+		w = tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "PROJECT\tWORKTREES\tTOTAL\tTRACKED\tDEPS\tARTIFACTS\tOTHER\tQUOTA")
+		for _, pu := range projects {
+			fmt.Fprintf(w, "%s\t%d\t%s\t%s\n", pu.ProjectID, pu.Worktrees, sizeColumns(pu.Sizes), quotaLabel(pm, pu))
+		}
+		w.Flush()
+
+		for _, u := range usages {
+			if u.Err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: %s: %v\n", u.Worktree.ID, u.Err)
+			}
+		}
+	},
+}
+
+func sizeColumns(s worktree.Sizes) string {
+	return fmt.Sprintf("%s\t%s\t%s\t%s\t%s",
+		worktree.FormatSize(s.Total), worktree.FormatSize(s.Tracked),
+		worktree.FormatSize(s.Dependencies), worktree.FormatSize(s.Artifacts),
+		worktree.FormatSize(s.Other))
+}
+
+// quotaLabel renders a project's quota and how much of it is used, or "-"
+// when the project has none.
+func quotaLabel(pm *project.Manager, pu worktree.ProjectUsage) string {
+	p, err := pm.Get(pu.ProjectID)
+	if err != nil || p.Config.Quota.MaxSize == "" {
+		return "-"
+	}
+	limit, err := worktree.ParseSize(p.Config.Quota.MaxSize)
+	if err != nil || limit == 0 {
+		return "invalid"
+	}
+	label := fmt.Sprintf("%s (%d%%)", worktree.FormatSize(limit), pu.Total*100/limit)
+	if pu.Total > limit {
+		label += " over"
+	}
+	return label
+}
+
+// warnQuota prints a warning with cleanup suggestions to errOut when the
+// project's worktrees use more than its quota. exclude is the worktree that
+// was just started.
+func warnQuota(errOut io.Writer, manager *worktree.Manager, p *project.Project, exclude string) {
+	report, err := manager.CheckQuota(context.Background(), p, exclude, worktree.DefaultWorkers)
+	if err != nil {
+		fmt.Fprintf(errOut, "Warning: %v\n", err)
+		return
+	}
+	if report == nil || !report.Over() {
+		return
+	}
+
+	fmt.Fprintf(errOut, "Warning: worktrees of project %s use %s, over the %s quota.\n",
+		p.ID, worktree.FormatSize(report.Used), worktree.FormatSize(report.Limit))
+	if len(report.Candidates) == 0 {
+		return
+	}
+
+	fmt.Fprintln(errOut, "Cleanup candidates:")
+	now := time.Now()
+	w := tabwriter.NewWriter(errOut, 0, 0, 2, ' ', 0)
+	for i, c := range report.Candidates {
+		if i == maxQuotaCandidates {
+			break
+		}
+		fmt.Fprintf(w, "  #%d\t%s\t%s\tactive %s\tissue-flow worktree remove %s\n",
+			c.Worktree.IssueNumber, c.Worktree.Branch, worktree.FormatSize(c.Size),
+			timeAgo(now.Add(-c.Idle), now), c.Worktree.ID)
+	}
+	w.Flush()
+}
+
+func init() {
+	worktreeCmd.AddCommand(worktreeDuCmd)
+
+	worktreeDuCmd.Flags().StringVarP(&worktreeProject, "project", "p", "", "Only show worktrees of this project")
+	worktreeDuCmd.Flags().IntVarP(&worktreeJobs, "jobs", "j", worktree.DefaultWorkers, "Number of worktrees to measure concurrently")
+}
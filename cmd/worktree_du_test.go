@@ -0,0 +1,70 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/paolorechia/issue-flow/internal/project"
+	"github.com/paolorechia/issue-flow/internal/worktree"
+	"github.com/paolorechia/issue-flow/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWorktreeDuCommand(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	testutil.CreateGitProjectWithConfig(t, db, "app", `{"quota":{"max_size":"1MB"}}`)
+	testutil.CreateGitProject(t, db, "web")
+
+	testDB = db
+	t.Cleanup(func() { testDB = nil })
+	t.Cleanup(func() { worktreeProject, worktreeJobs = "", worktree.DefaultWorkers })
+
+	runStart(t, "1", "--project", "app", "--title", "Small")
+	runStart(t, "2", "--project", "app", "--title", "Large")
+	runStart(t, "3", "--project", "web", "--title", "Web")
+	large := testutil.AssertWorktreeExists(t, db, "wt-app-2")
+	require.NoError(t, os.MkdirAll(filepath.Join(large.Path, "node_modules"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(large.Path, "node_modules", "dep.js"), []byte(strings.Repeat("x", 4096)), 0644))
+
+	out := runWorktree(t, "du")
+	lines := strings.Split(out, "\n")
+	require.Greater(t, len(lines), 2)
+	assert.Contains(t, lines[0], "TOTAL")
+	assert.Contains(t, lines[1], "#2", "largest worktree first")
+	assert.Contains(t, lines[1], "4.0 KB")
+	assert.Contains(t, out, "WORKTREES")
+	assert.Regexp(t, `app\s+2\s+.*1\.0 MB \(0%\)`, out)
+	assert.Regexp(t, `web\s+1\s+.*-`, out)
+
+	out = runWorktree(t, "du", "--project", "web")
+	assert.Contains(t, out, "#3")
+	assert.NotContains(t, out, "#2")
+}
+
+func TestWarnQuota_OverQuotaListsCandidates(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	testutil.CreateGitProjectWithConfig(t, db, "app", `{"quota":{"max_size":"1KB"}}`)
+
+	testDB = db
+	t.Cleanup(func() { testDB = nil })
+
+	runStart(t, "1", "--project", "app", "--title", "Old")
+	old := testutil.AssertWorktreeExists(t, db, "wt-app-1")
+	require.NoError(t, os.WriteFile(filepath.Join(old.Path, "big.bin"), []byte(strings.Repeat("x", 4096)), 0644))
+	runStart(t, "2", "--project", "app", "--title", "New")
+
+	p, err := project.NewManager(db).Get("app")
+	require.NoError(t, err)
+
+	buf := new(bytes.Buffer)
+	warnQuota(buf, worktree.NewManager(db, getGit()), p, "wt-app-2")
+	stderr := buf.String()
+	assert.Contains(t, stderr, "Warning: worktrees of project app use")
+	assert.Contains(t, stderr, "over the 1.0 KB quota")
+	assert.Contains(t, stderr, "issue-flow worktree remove wt-app-1")
+	assert.NotContains(t, stderr, "wt-app-2")
+}
@@ -0,0 +1,175 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/paolorechia/issue-flow/internal/project"
+	"github.com/paolorechia/issue-flow/internal/storage"
+	"github.com/paolorechia/issue-flow/internal/worktree"
+	"github.com/spf13/cobra"
+)
+
+var worktreeEnvCmd = &cobra.Command{
+	Use:   "env <issue|id>",
+	Short: "Print a worktree's environment variables",
+	Long: `Print the variables describing a worktree as shell export statements:
+ISSUE_FLOW_PROJECT, ISSUE_FLOW_ISSUE, ISSUE_FLOW_BRANCH, ISSUE_FLOW_WORKTREE,
+ISSUE_FLOW_REPO (plus ISSUE_FLOW_ATTEMPT for attempts and ISSUE_FLOW_PR for
+review worktrees) and one variable per port allocated from the project's
+ports config (WEB_PORT for a port named "web" unless it sets env).
+
+Ports are allocated when the worktree is started; env also allocates any
+configured since.
+
+  eval "$(issue-flow worktree env 123)"`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		db, err := getDB()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error opening database: %v\n", err)
+			os.Exit(1)
+		}
+		if shouldCloseDB(db) {
+			defer db.Close()
+		}
+
+		p, w, err := resolveWorktree(db, args[0], worktreeProject)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error finding worktree: %v\n", err)
+			os.Exit(1)
+		}
+
+		manager := worktree.NewManager(db, getGit())
+		if _, err := manager.AllocatePorts(p, w); err != nil {
+			fmt.Fprintf(os.Stderr, "Error allocating ports: %v\n", err)
+			os.Exit(1)
+		}
+		env, err := manager.Env(p, w)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading worktree environment: %v\n", err)
+			os.Exit(1)
+		}
+
+		out := cmd.OutOrStdout()
+		for _, kv := range env {
+			key, value, _ := strings.Cut(kv, "=")
+			fmt.Fprintf(out, "export %s=%s\n", key, shellQuote(value))
+		}
+	},
+}
+
+var worktreeEnvRenderCmd = &cobra.Command{
+	Use:   "render [issue|id]",
+	Short: "Regenerate a worktree's env files from the project's templates",
+	Long: `Render the project's env_templates into the worktree again, e.g. after a
+template or the secrets file changed. Files whose content did not change are
+left alone.
+
+Without an argument every worktree (of --project, if given) is rendered.
+Templates can read {{.Issue}}, {{.Title}}, {{.Branch}}, {{.Path}}, {{.Repo}},
+{{.Ports.<name>}}, {{.Env.<VAR>}}, {{.Project.<Field>}} and
+{{.Secrets.<KEY>}} from the project's secrets file.`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		db, err := getDB()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error opening database: %v\n", err)
+			os.Exit(1)
+		}
+		if shouldCloseDB(db) {
+			defer db.Close()
+		}
+
+		var targets []*storage.Worktree
+		if len(args) == 1 {
+			_, w, err := resolveWorktree(db, args[0], worktreeProject)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error finding worktree: %v\n", err)
+				os.Exit(1)
+			}
+			targets = append(targets, w)
+		} else {
+			var worktrees []storage.Worktree
+			if worktreeProject != "" {
+				worktrees, err = db.ListWorktreesByProject(worktreeProject)
+			} else {
+				worktrees, err = db.ListWorktrees()
+			}
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error listing worktrees: %v\n", err)
+				os.Exit(1)
+			}
+			for i := range worktrees {
+				if worktrees[i].Status != storage.WorktreeStatusStale {
+					targets = append(targets, &worktrees[i])
+				}
+			}
+		}
+
+		pm := project.NewManager(db)
+		manager := worktree.NewManager(db, getGit())
+		failed := 0
+		for _, w := range targets {
+			p, err := pm.Get(w.ProjectID)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error loading project %s: %v\n", w.ProjectID, err)
+				os.Exit(1)
+			}
+			if len(p.Config.EnvTemplates) == 0 {
+				if len(args) == 1 {
+					fmt.Fprintf(cmd.OutOrStdout(), "Project %s has no env templates\n", p.ID)
+				}
+				continue
+			}
+			if !renderEnvFiles(cmd, manager, p, w) {
+				failed++
+			}
+		}
+		if failed > 0 {
+			os.Exit(1)
+		}
+	},
+}
+
+// renderEnvFiles renders the env templates for w, printing what changed, and
+// reports whether it succeeded.
+func renderEnvFiles(cmd *cobra.Command, manager *worktree.Manager, p *project.Project, w *storage.Worktree) bool {
+	out := cmd.OutOrStdout()
+	files, err := manager.RenderEnvFiles(p, w)
+	for _, f := range files {
+		rel, relErr := filepath.Rel(w.Path, f.Path)
+		if relErr != nil {
+			rel = f.Path
+		}
+		if f.Changed {
+			fmt.Fprintf(out, "✓ Wrote %s for %s\n", rel, worktreeLabel(w))
+		} else {
+			fmt.Fprintf(out, "  %s for %s is up to date\n", rel, worktreeLabel(w))
+		}
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error rendering env files for #%d: %v\n", w.IssueNumber, err)
+		return false
+	}
+	return true
+}
+
+// shellQuote single-quotes s for POSIX shells unless it only contains
+// characters that need no quoting.
+func shellQuote(s string) string {
+	if s != "" && strings.Trim(s, "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789-_./:@%+=,") == "" {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+func init() {
+	worktreeCmd.AddCommand(worktreeEnvCmd)
+	worktreeEnvCmd.AddCommand(worktreeEnvRenderCmd)
+
+	worktreeEnvCmd.Flags().StringVarP(&worktreeProject, "project", "p", "", "Project ID when selecting by issue number")
+	worktreeEnvRenderCmd.Flags().StringVarP(&worktreeProject, "project", "p", "", "Project ID when selecting by issue number, or the project to render")
+}
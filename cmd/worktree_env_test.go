@@ -0,0 +1,68 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/paolorechia/issue-flow/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWorktreeEnvCommand(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	testutil.CreateGitProjectWithConfig(t, db, "app", `{"ports":[{"name":"web","range":"47310-47329"}]}`)
+
+	testDB = db
+	t.Cleanup(func() { testDB = nil })
+	t.Cleanup(func() { worktreeProject = "" })
+
+	out := runStart(t, "4", "--project", "app", "--title", "Ports")
+	assert.Regexp(t, `Ports: WEB_PORT=473[12]\d`, out)
+	w := testutil.AssertWorktreeExists(t, db, "wt-app-4")
+
+	out = runWorktree(t, "env", "4", "--project", "app")
+	assert.Contains(t, out, "export ISSUE_FLOW_PROJECT=app\n")
+	assert.Contains(t, out, "export ISSUE_FLOW_ISSUE=4\n")
+	assert.Contains(t, out, "export ISSUE_FLOW_WORKTREE="+shellQuote(w.Path)+"\n")
+	assert.Regexp(t, `export WEB_PORT=473[12]\d\n`, out)
+}
+
+func TestWorktreeEnvRenderCommand(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	db := testutil.NewTestDB(t)
+	sp := testutil.CreateGitProjectWithConfig(t, db, "app", `{"env_templates":[{"source":"env.tmpl","path":".env.local"}]}`)
+	template := filepath.Join(sp.LocalPath, "env.tmpl")
+	require.NoError(t, os.WriteFile(template, []byte("BRANCH={{.Branch}}\n"), 0644))
+
+	testDB = db
+	t.Cleanup(func() { testDB = nil })
+	t.Cleanup(func() { worktreeProject = "" })
+
+	out := runStart(t, "6", "--project", "app", "--title", "Render")
+	assert.Contains(t, out, "✓ Wrote .env.local for #6")
+	w := testutil.AssertWorktreeExists(t, db, "wt-app-6")
+
+	out = runWorktree(t, "env", "render", "6", "--project", "app")
+	assert.Contains(t, out, ".env.local for #6 is up to date")
+
+	require.NoError(t, os.WriteFile(template, []byte("ISSUE={{.Issue}}\n"), 0644))
+	out = runWorktree(t, "env", "render")
+	assert.Contains(t, out, "✓ Wrote .env.local for #6")
+	content, err := os.ReadFile(filepath.Join(w.Path, ".env.local"))
+	require.NoError(t, err)
+	assert.Equal(t, "ISSUE=6\n", string(content))
+}
+
+func TestWorktreeEnvRenderCommand_MissingSecret(t *testing.T) {
+	t.Skip("Skipped: command calls os.Exit(1) which terminates test process")
+}
+
+func TestShellQuote(t *testing.T) {
+	assert.Equal(t, "/tmp/wt/issue-1", shellQuote("/tmp/wt/issue-1"))
+	assert.Equal(t, "''", shellQuote(""))
+	assert.Equal(t, "'my dir'", shellQuote("my dir"))
+	assert.Equal(t, `'it'\''s'`, shellQuote("it's"))
+	assert.Equal(t, "'$HOME'", shellQuote("$HOME"))
+}
@@ -0,0 +1,133 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/paolorechia/issue-flow/internal/project"
+	"github.com/paolorechia/issue-flow/internal/storage"
+	"github.com/paolorechia/issue-flow/internal/worktree"
+	"github.com/spf13/cobra"
+)
+
+var worktreeHooksAll bool
+
+var worktreeHooksCmd = &cobra.Command{
+	Use:   "hooks",
+	Short: "Manage the git hooks of worktrees",
+	Long: `Every worktree created by start gets a commit-msg hook and a
+commit.template, configured for that worktree only. The hook rejects
+commits on branches that do not follow the project's branch pattern,
+requires one of the issue type's commit_types as the conventional commit
+prefix of the subject, and adds the commit reference (default
+"Refs #{issue-number}") to the message. The repository's own hooks keep
+running.
+
+Set commits.disable_hooks in the project config to skip them.`,
+}
+
+var worktreeHooksInstallCmd = &cobra.Command{
+	Use:   "install [issue|id]",
+	Short: "Install or regenerate a worktree's hooks from the project config",
+	Long: `Write the commit-msg hook and commit template of a worktree again from
+the current project config, e.g. after commit_types, the branch pattern or
+the commit reference changed.
+
+With --all every worktree (of --project, if given) is updated; review,
+archived and stale worktrees are skipped, and worktrees of projects with
+commits.disable_hooks have hooks installed earlier removed.`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if (len(args) == 1) == worktreeHooksAll {
+			fmt.Fprintln(os.Stderr, "Error: pass a worktree or --all")
+			os.Exit(1)
+		}
+
+		db, err := getDB()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error opening database: %v\n", err)
+			os.Exit(1)
+		}
+		if shouldCloseDB(db) {
+			defer db.Close()
+		}
+
+		var targets []*storage.Worktree
+		if len(args) == 1 {
+			_, w, err := resolveWorktree(db, args[0], worktreeProject)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error finding worktree: %v\n", err)
+				os.Exit(1)
+			}
+			targets = append(targets, w)
+		} else {
+			var worktrees []storage.Worktree
+			if worktreeProject != "" {
+				worktrees, err = db.ListWorktreesByProject(worktreeProject)
+			} else {
+				worktrees, err = db.ListWorktrees()
+			}
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error listing worktrees: %v\n", err)
+				os.Exit(1)
+			}
+			for i := range worktrees {
+				switch worktrees[i].Status {
+				case storage.WorktreeStatusActive, storage.WorktreeStatusParked:
+					targets = append(targets, &worktrees[i])
+				}
+			}
+		}
+
+		pm := project.NewManager(db)
+		manager := worktree.NewManager(db, getGit())
+		out := cmd.OutOrStdout()
+		failed := 0
+		for _, w := range targets {
+			p, err := pm.Get(w.ProjectID)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error loading project %s: %v\n", w.ProjectID, err)
+				os.Exit(1)
+			}
+			if !worktree.HooksEnabled(p, w) {
+				if len(args) == 1 {
+					fmt.Fprintf(os.Stderr, "Error: %s does not get hooks (review worktree or commits.disable_hooks)\n", worktreeLabel(w))
+					os.Exit(1)
+				}
+				if p.Config.Commits.DisableHooks {
+					removed, err := manager.UninstallHooks(context.Background(), w)
+					if err != nil {
+						fmt.Fprintf(os.Stderr, "Error removing hooks of %s: %v\n", worktreeLabel(w), err)
+						failed++
+					} else if removed {
+						fmt.Fprintf(out, "✓ Removed hooks from %s (commits.disable_hooks)\n", worktreeLabel(w))
+					}
+				}
+				continue
+			}
+			result, err := manager.InstallHooks(context.Background(), p, w)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error installing hooks for %s: %v\n", worktreeLabel(w), err)
+				failed++
+				continue
+			}
+			fmt.Fprintf(out, "✓ Installed hooks for %s\n", worktreeLabel(w))
+			if len(result.Chained) > 0 {
+				fmt.Fprintf(out, "  Chained: %s\n", strings.Join(result.Chained, ", "))
+			}
+		}
+		if failed > 0 {
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	worktreeCmd.AddCommand(worktreeHooksCmd)
+	worktreeHooksCmd.AddCommand(worktreeHooksInstallCmd)
+
+	worktreeHooksInstallCmd.Flags().StringVarP(&worktreeProject, "project", "p", "", "Project ID when selecting by issue number, or the project to update")
+	worktreeHooksInstallCmd.Flags().BoolVar(&worktreeHooksAll, "all", false, "Install into every worktree")
+}
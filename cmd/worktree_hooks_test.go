@@ -0,0 +1,73 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/paolorechia/issue-flow/internal/project"
+	"github.com/paolorechia/issue-flow/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWorktreeHooksInstallCommand(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	sp := testutil.CreateGitProject(t, db, "app")
+
+	testDB = db
+	t.Cleanup(func() { testDB = nil })
+	t.Cleanup(func() { worktreeProject = "" })
+	t.Cleanup(func() { worktreeHooksAll = false })
+
+	runStart(t, "3", "--project", "app", "--title", "Hooks")
+	w := testutil.AssertWorktreeExists(t, db, "wt-app-3")
+	hooksPath := testutil.RunGit(t, w.Path, "config", "--get", "core.hooksPath")
+	assert.FileExists(t, filepath.Join(hooksPath, "commit-msg"))
+
+	hooks := filepath.Join(sp.LocalPath, ".git", "hooks")
+	require.NoError(t, os.MkdirAll(hooks, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(hooks, "pre-push"), []byte("#!/bin/sh\nexit 0\n"), 0755))
+
+	out := runWorktree(t, "hooks", "install", "--all")
+	assert.Contains(t, out, "✓ Installed hooks for #3")
+	assert.Contains(t, out, "Chained: pre-push")
+	assert.FileExists(t, filepath.Join(hooksPath, "pre-push"))
+
+	worktreeHooksAll = false
+	out = runWorktree(t, "hooks", "install", "3", "--project", "app")
+	assert.Contains(t, out, "✓ Installed hooks for #3")
+}
+
+func TestWorktreeHooksInstallCommand_AllRemovesDisabledHooks(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	testutil.CreateGitProject(t, db, "app")
+
+	testDB = db
+	t.Cleanup(func() { testDB = nil })
+	t.Cleanup(func() { worktreeProject = "" })
+	t.Cleanup(func() { worktreeHooksAll = false })
+
+	runStart(t, "3", "--project", "app", "--title", "Hooks")
+	w := testutil.AssertWorktreeExists(t, db, "wt-app-3")
+	assert.NotEmpty(t, testutil.RunGit(t, w.Path, "config", "--get", "core.hooksPath"))
+
+	pm := project.NewManager(db)
+	p, err := pm.Get("app")
+	require.NoError(t, err)
+	p.Config.Commits.DisableHooks = true
+	require.NoError(t, pm.Update(p))
+
+	out := runWorktree(t, "hooks", "install", "--all")
+	assert.Contains(t, out, "✓ Removed hooks from #3")
+	worktreeConfig := testutil.RunGit(t, w.Path, "config", "--worktree", "--list")
+	assert.NotContains(t, worktreeConfig, "core.hookspath")
+	assert.NotContains(t, worktreeConfig, "commit.template")
+
+	out = runWorktree(t, "hooks", "install", "--all")
+	assert.NotContains(t, out, "Removed hooks")
+}
+
+func TestWorktreeHooksInstallCommand_ReviewWorktree(t *testing.T) {
+	t.Skip("Skipped: command calls os.Exit(1) which terminates test process")
+}
@@ -0,0 +1,177 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/paolorechia/issue-flow/internal/config"
+	"github.com/paolorechia/issue-flow/internal/project"
+	"github.com/paolorechia/issue-flow/internal/storage"
+	"github.com/paolorechia/issue-flow/internal/worktree"
+	"github.com/spf13/cobra"
+)
+
+var (
+	moveAll          bool
+	moveKeepBranches bool
+	moveDryRun       bool
+)
+
+var worktreeMoveCmd = &cobra.Command{
+	Use:   "move [<issue|id> [new-path]]",
+	Short: "Move a worktree or re-home it after config changes",
+	Long: `Move a worktree directory with 'git worktree move' and update its row.
+
+Without a new path the worktree is re-homed: moved to where 'start' would put
+it today and its branch renamed to what the branch pattern gives today, e.g.
+after the project's worktree directory or branch pattern changed. Branches
+that track a remote branch are not renamed. With --all every worktree of the
+project is re-homed.
+
+Either every change is made or, if one fails, none is.`,
+	Args: cobra.MaximumNArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		if moveAll == (len(args) > 0) {
+			fmt.Fprintln(os.Stderr, "Error: give a worktree or --all")
+			os.Exit(1)
+		}
+
+		db, err := getDB()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error opening database: %v\n", err)
+			os.Exit(1)
+		}
+		if shouldCloseDB(db) {
+			defer db.Close()
+		}
+
+		var p *project.Project
+		var worktrees []storage.Worktree
+		if moveAll {
+			if p, err = resolveProject(db, worktreeProject); err != nil {
+				fmt.Fprintf(os.Stderr, "Error resolving project: %v\n", err)
+				os.Exit(1)
+			}
+			if worktrees, err = db.ListWorktreesByProject(p.ID); err != nil {
+				fmt.Fprintf(os.Stderr, "Error listing worktrees: %v\n", err)
+				os.Exit(1)
+			}
+		} else {
+			var w *storage.Worktree
+			if p, w, err = resolveWorktree(db, args[0], worktreeProject); err != nil {
+				fmt.Fprintf(os.Stderr, "Error finding worktree: %v\n", err)
+				os.Exit(1)
+			}
+			worktrees = []storage.Worktree{*w}
+		}
+
+		manager := worktree.NewManager(db, getGit())
+		var moves []worktree.Move
+		if len(args) == 2 {
+			path, err := filepath.Abs(config.ExpandPath(args[1]))
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			moves = []worktree.Move{{Worktree: worktrees[0], NewPath: path, NewBranch: worktrees[0].Branch}}
+		} else {
+			moves = planRehome(manager, p, worktrees)
+		}
+
+		out := cmd.OutOrStdout()
+		if len(moves) == 0 {
+			fmt.Fprintln(out, "Nothing to move.")
+			return
+		}
+		if moveDryRun {
+			printMoves(out, moves, true)
+			return
+		}
+		if err := manager.ApplyMoves(context.Background(), p, moves, false); err != nil {
+			fmt.Fprintf(os.Stderr, "Error moving worktrees: %v\n", err)
+			os.Exit(1)
+		}
+		printMoves(out, moves, false)
+		rerenderMoved(cmd, manager, p, moves)
+	},
+}
+
+// planRehome plans re-homing worktrees of p, printing skipped ones as
+// warnings.
+func planRehome(manager *worktree.Manager, p *project.Project, worktrees []storage.Worktree) []worktree.Move {
+	base, err := worktreeBase(p)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading config: %v\n", err)
+		os.Exit(1)
+	}
+	moves, warnings, err := manager.PlanRehome(context.Background(), p, worktrees, worktree.RehomeOptions{
+		WorktreeBase: base,
+		KeepBranches: moveKeepBranches,
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error planning moves: %v\n", err)
+		os.Exit(1)
+	}
+	for _, warning := range warnings {
+		fmt.Fprintf(os.Stderr, "Warning: %s\n", warning)
+	}
+	return moves
+}
+
+// worktreeBase returns the configured worktree base for projects without a
+// worktree directory of their own.
+func worktreeBase(p *project.Project) (string, error) {
+	if p.WorktreeDir != "" {
+		return "", nil
+	}
+	cfg, err := config.Load()
+	if err != nil {
+		return "", err
+	}
+	return cfg.Settings.WorktreeBase, nil
+}
+
+func printMoves(out io.Writer, moves []worktree.Move, dryRun bool) {
+	for _, mv := range moves {
+		label := worktreeLabel(&mv.Worktree)
+		if mv.RenamesBranch() {
+			if dryRun {
+				fmt.Fprintf(out, "Would rename branch of %s: %s -> %s\n", label, mv.Worktree.Branch, mv.NewBranch)
+			} else {
+				fmt.Fprintf(out, "✓ Renamed branch of %s: %s -> %s\n", label, mv.Worktree.Branch, mv.NewBranch)
+			}
+		}
+		if mv.MovesPath() {
+			if dryRun {
+				fmt.Fprintf(out, "Would move %s: %s -> %s\n", label, mv.Worktree.Path, mv.NewPath)
+			} else {
+				fmt.Fprintf(out, "✓ Moved %s: %s -> %s\n", label, mv.Worktree.Path, mv.NewPath)
+			}
+		}
+	}
+}
+
+// rerenderMoved renders env files again for moved worktrees, since templates
+// may use the path or branch.
+func rerenderMoved(cmd *cobra.Command, manager *worktree.Manager, p *project.Project, moves []worktree.Move) {
+	if len(p.Config.EnvTemplates) == 0 {
+		return
+	}
+	for _, mv := range moves {
+		w := mv.Worktree
+		w.Path, w.Branch = mv.NewPath, mv.NewBranch
+		renderEnvFiles(cmd, manager, p, &w)
+	}
+}
+
+func init() {
+	worktreeCmd.AddCommand(worktreeMoveCmd)
+
+	worktreeMoveCmd.Flags().StringVarP(&worktreeProject, "project", "p", "", "Project ID when selecting by issue number, or the project to re-home")
+	worktreeMoveCmd.Flags().BoolVar(&moveAll, "all", false, "Re-home every worktree of the project")
+	worktreeMoveCmd.Flags().BoolVar(&moveKeepBranches, "keep-branches", false, "Do not rename branches when re-homing")
+	worktreeMoveCmd.Flags().BoolVar(&moveDryRun, "dry-run", false, "Show what would change without changing anything")
+}
@@ -0,0 +1,74 @@
+package cmd
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/paolorechia/issue-flow/internal/project"
+	"github.com/paolorechia/issue-flow/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func resetMoveFlags(t *testing.T) {
+	t.Cleanup(func() {
+		worktreeProject, worktreeDir = "", ""
+		moveAll, moveKeepBranches, moveDryRun = false, false, false
+	})
+}
+
+func TestWorktreeMoveCommand(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	testutil.CreateGitProject(t, db, "app")
+
+	testDB = db
+	t.Cleanup(func() { testDB = nil })
+	resetMoveFlags(t)
+
+	runStart(t, "3", "--project", "app", "--title", "Move me")
+	w := testutil.AssertWorktreeExists(t, db, "wt-app-3")
+	newPath := filepath.Join(t.TempDir(), "elsewhere")
+
+	out := runWorktree(t, "move", "3", newPath, "--project", "app")
+	assert.Contains(t, out, "✓ Moved #3: "+w.Path+" -> "+newPath)
+	assert.Equal(t, newPath, testutil.AssertWorktreeExists(t, db, w.ID).Path)
+	assert.NoDirExists(t, w.Path)
+
+	out = runWorktree(t, "move", "--all", "--project", "app", "--dry-run")
+	assert.Contains(t, out, "Would move #3: "+newPath+" -> "+w.Path)
+	assert.Equal(t, newPath, testutil.AssertWorktreeExists(t, db, w.ID).Path, "dry run changes nothing")
+
+	moveAll, moveDryRun = false, false
+	out = runWorktree(t, "move", "3", "--project", "app")
+	assert.Contains(t, out, "✓ Moved #3")
+	assert.Equal(t, w.Path, testutil.AssertWorktreeExists(t, db, w.ID).Path)
+
+	out = runWorktree(t, "move", "--all", "--project", "app", "--dry-run=false")
+	assert.Contains(t, out, "Nothing to move.")
+}
+
+func TestWorktreeMoveCommand_RequiresTarget(t *testing.T) {
+	t.Skip("Skipped: command calls os.Exit(1) which terminates test process")
+}
+
+func TestProjectRelocateCommand(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	testutil.CreateGitProject(t, db, "app")
+
+	testDB = db
+	t.Cleanup(func() { testDB = nil })
+	resetMoveFlags(t)
+
+	runStart(t, "4", "--project", "app", "--title", "Relocate")
+	runStart(t, "5", "--project", "app", "--title", "Relocate too")
+	dir := filepath.Join(t.TempDir(), "wt")
+
+	out := runRoot(t, "project", "relocate", "app", "--worktree-dir", dir)
+	assert.Contains(t, out, "✓ Relocated app (2 worktrees moved)")
+	assert.Equal(t, filepath.Join(dir, "issue-4"), testutil.AssertWorktreeExists(t, db, "wt-app-4").Path)
+	assert.Equal(t, filepath.Join(dir, "issue-5"), testutil.AssertWorktreeExists(t, db, "wt-app-5").Path)
+
+	p, err := project.NewManager(db).Get("app")
+	require.NoError(t, err)
+	assert.Equal(t, dir, p.WorktreeDir)
+}
@@ -0,0 +1,126 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/paolorechia/issue-flow/internal/worktree"
+	"github.com/spf13/cobra"
+)
+
+var (
+	parkPush   bool
+	parkRemote string
+)
+
+var worktreeParkCmd = &cobra.Command{
+	Use:   "park <issue|id>",
+	Short: "Set aside a worktree's uncommitted work",
+	Long: `Save all staged, unstaged and untracked changes of a worktree as a snapshot
+under refs/issue-flow/wip/<id>, leave the worktree clean and mark it parked.
+Ignored files are not touched.
+
+With --push the snapshot (and the commits under it) is also pushed to the
+remote, so it can be restored even if the local clone is lost.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		db, err := getDB()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error opening database: %v\n", err)
+			os.Exit(1)
+		}
+		if shouldCloseDB(db) {
+			defer db.Close()
+		}
+
+		p, w, err := resolveWorktree(db, args[0], worktreeProject)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error finding worktree: %v\n", err)
+			os.Exit(1)
+		}
+
+		manager := worktree.NewManager(db, getGit())
+		result, err := manager.Park(context.Background(), p, w, worktree.ParkOptions{
+			Push:   parkPush,
+			Remote: parkRemote,
+		})
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error parking worktree: %v\n", err)
+			os.Exit(1)
+		}
+
+		for _, warning := range result.Warnings {
+			fmt.Fprintf(os.Stderr, "Warning: %s\n", warning)
+		}
+
+		out := cmd.OutOrStdout()
+		if result.Snapshot == "" {
+			fmt.Fprintf(out, "✓ Parked #%d (no uncommitted changes)\n", w.IssueNumber)
+			return
+		}
+		fmt.Fprintf(out, "✓ Parked #%d as %s (%s)\n", w.IssueNumber, worktree.WIPRef(w), result.Snapshot[:7])
+		if result.Pushed {
+			fmt.Fprintf(out, "✓ Pushed snapshot to %s\n", w.ParkedRemote)
+		}
+	},
+}
+
+var worktreeResumeCmd = &cobra.Command{
+	Use:   "resume <issue|id>",
+	Short: "Restore a parked worktree's uncommitted work",
+	Long: `Restore the snapshot saved by 'worktree park' exactly, including which
+changes were staged, and mark the worktree active again.
+
+If the worktree directory is gone it is checked out again first, and a
+snapshot that was pushed is fetched from the remote when it is missing
+locally.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		db, err := getDB()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error opening database: %v\n", err)
+			os.Exit(1)
+		}
+		if shouldCloseDB(db) {
+			defer db.Close()
+		}
+
+		p, w, err := resolveWorktree(db, args[0], worktreeProject)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error finding worktree: %v\n", err)
+			os.Exit(1)
+		}
+
+		manager := worktree.NewManager(db, getGit())
+		result, err := manager.Resume(context.Background(), p, w)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error resuming worktree: %v\n", err)
+			os.Exit(1)
+		}
+
+		for _, warning := range result.Warnings {
+			fmt.Fprintf(os.Stderr, "Warning: %s\n", warning)
+		}
+
+		out := cmd.OutOrStdout()
+		if result.Recreated {
+			fmt.Fprintf(out, "✓ Recreated worktree at %s\n", w.Path)
+		}
+		if result.Snapshot != "" {
+			fmt.Fprintf(out, "✓ Restored uncommitted changes from %s\n", result.Snapshot[:7])
+		}
+		fmt.Fprintf(out, "✓ Resumed #%d\n", w.IssueNumber)
+	},
+}
+
+func init() {
+	worktreeCmd.AddCommand(worktreeParkCmd)
+	worktreeCmd.AddCommand(worktreeResumeCmd)
+
+	worktreeParkCmd.Flags().StringVarP(&worktreeProject, "project", "p", "", "Project ID when selecting by issue number")
+	worktreeParkCmd.Flags().BoolVar(&parkPush, "push", false, "Also push the snapshot to the remote")
+	worktreeParkCmd.Flags().StringVar(&parkRemote, "remote", "", "Remote to push the snapshot to (default: the project's push remote)")
+
+	worktreeResumeCmd.Flags().StringVarP(&worktreeProject, "project", "p", "", "Project ID when selecting by issue number")
+}
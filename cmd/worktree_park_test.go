@@ -0,0 +1,43 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/paolorechia/issue-flow/internal/storage"
+	"github.com/paolorechia/issue-flow/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWorktreeParkAndResumeCommands(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	testutil.CreateGitProject(t, db, "app")
+
+	testDB = db
+	t.Cleanup(func() { testDB = nil })
+	t.Cleanup(func() { worktreeProject, parkPush, parkRemote = "", false, "" })
+
+	runStart(t, "5", "--project", "app", "--title", "Half done")
+	w := testutil.AssertWorktreeExists(t, db, "wt-app-5")
+	require.NoError(t, os.WriteFile(filepath.Join(w.Path, "draft.txt"), []byte("draft\n"), 0644))
+
+	out := runWorktree(t, "park", "5", "--project", "app")
+	assert.Contains(t, out, "✓ Parked #5 as refs/issue-flow/wip/wt-app-5")
+	assert.NoFileExists(t, filepath.Join(w.Path, "draft.txt"))
+	assert.Equal(t, storage.WorktreeStatusParked, testutil.AssertWorktreeExists(t, db, w.ID).Status)
+
+	out = runWorktree(t, "list", "--project", "app")
+	assert.Contains(t, out, "parked")
+
+	out = runWorktree(t, "resume", "wt-app-5")
+	assert.Contains(t, out, "✓ Restored uncommitted changes")
+	assert.Contains(t, out, "✓ Resumed #5")
+	assert.FileExists(t, filepath.Join(w.Path, "draft.txt"))
+	assert.Equal(t, storage.WorktreeStatusActive, testutil.AssertWorktreeExists(t, db, w.ID).Status)
+}
+
+func TestStartCommand_RefusesParked(t *testing.T) {
+	t.Skip("Skipped: command calls os.Exit(1) which terminates test process")
+}
@@ -0,0 +1,75 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/paolorechia/issue-flow/internal/worktree"
+	"github.com/spf13/cobra"
+)
+
+var (
+	prTitle string
+	prBody  string
+	prDraft bool
+)
+
+var worktreePRCmd = &cobra.Command{
+	Use:   "pr <issue|id>",
+	Short: "Open a pull request for a worktree's branch",
+	Long: `Open a pull request for the worktree's branch against the project's GitHub
+repository, into the branch the worktree is based on. For a project worked
+on from a fork the head is <fork_owner>:<branch>, so the pull request goes
+from the fork to upstream. Push the branch first.
+
+The title defaults to the issue's title and the body to "Closes #<issue>".`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		db, err := getDB()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error opening database: %v\n", err)
+			os.Exit(1)
+		}
+		if shouldCloseDB(db) {
+			defer db.Close()
+		}
+
+		p, w, err := resolveWorktree(db, args[0], worktreeProject)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error finding worktree: %v\n", err)
+			os.Exit(1)
+		}
+
+		ctx := context.Background()
+		client, err := getGitHub(ctx)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error connecting to GitHub: %v\n", err)
+			os.Exit(1)
+		}
+		manager := worktree.NewManager(db, getGit())
+		pr, err := manager.OpenPullRequest(ctx, client, p, w, worktree.PullRequestOptions{
+			Title: prTitle,
+			Body:  prBody,
+			Draft: prDraft,
+		})
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error opening pull request: %v\n", err)
+			os.Exit(1)
+		}
+
+		fmt.Fprintf(cmd.OutOrStdout(), "✓ Opened pull request #%d for %s\n", pr.Number, worktreeLabel(w))
+		if pr.HTMLURL != "" {
+			fmt.Fprintf(cmd.OutOrStdout(), "  %s\n", pr.HTMLURL)
+		}
+	},
+}
+
+func init() {
+	worktreeCmd.AddCommand(worktreePRCmd)
+
+	worktreePRCmd.Flags().StringVarP(&worktreeProject, "project", "p", "", "Project ID when selecting by issue number")
+	worktreePRCmd.Flags().StringVar(&prTitle, "title", "", "Pull request title (default: the issue's title)")
+	worktreePRCmd.Flags().StringVar(&prBody, "body", "", "Pull request body (default: Closes #<issue>)")
+	worktreePRCmd.Flags().BoolVar(&prDraft, "draft", false, "Open the pull request as a draft")
+}
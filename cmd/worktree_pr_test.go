@@ -0,0 +1,43 @@
+package cmd
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/paolorechia/issue-flow/internal/github"
+	"github.com/paolorechia/issue-flow/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWorktreePRCommand_FromFork(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	sp := testutil.CreateGitProjectWithConfig(t, db, "app",
+		`{"remotes": {"upstream": "origin", "fork": "fork", "fork_owner": "ada"}}`)
+	testutil.RunGit(t, sp.LocalPath, "remote", "add", "origin", sp.LocalPath)
+	testutil.RunGit(t, sp.LocalPath, "remote", "add", "fork", t.TempDir())
+
+	fake := testutil.NewFakeGitHub(t)
+	fake.On("POST", "/repos/testowner/app/pulls", 201, `{"number": 21, "html_url": "https://github.com/testowner/app/pull/21"}`)
+	client := github.NewRESTClient("test-token")
+	client.BaseURL = fake.URL
+
+	testDB = db
+	testGitHub = client
+	t.Cleanup(func() { testDB, testGitHub = nil, nil })
+	t.Cleanup(func() { worktreeProject, prTitle, prBody, prDraft = "", "", "", false })
+
+	runStart(t, "4", "--project", "app", "--title", "Dark mode")
+	w := testutil.AssertWorktreeExists(t, db, "wt-app-4")
+
+	out := runWorktree(t, "pr", "4", "--project", "app", "--draft")
+	assert.Contains(t, out, "✓ Opened pull request #21 for #4")
+	assert.Contains(t, out, "https://github.com/testowner/app/pull/21")
+
+	var sent map[string]any
+	require.NoError(t, json.Unmarshal([]byte(fake.LastRequest().Body), &sent))
+	assert.Equal(t, "ada:"+w.Branch, sent["head"])
+	assert.Equal(t, "main", sent["base"])
+	assert.Equal(t, "Dark mode", sent["title"])
+	assert.Equal(t, true, sent["draft"])
+}
@@ -0,0 +1,99 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/paolorechia/issue-flow/internal/storage"
+	"github.com/paolorechia/issue-flow/internal/worktree"
+	"github.com/spf13/cobra"
+)
+
+var worktreeSparseCmd = &cobra.Command{
+	Use:   "sparse",
+	Short: "Adjust a worktree's sparse-checkout",
+	Long: `Show or change which directories a worktree checks out.
+
+New worktrees start sparse when the project (or the issue's type) sets
+sparse_patterns. The patterns are cone-mode directories relative to the
+repository root; files at the root are always checked out.`,
+}
+
+var worktreeSparseListCmd = &cobra.Command{
+	Use:   "list <issue|id>",
+	Short: "Show a worktree's sparse-checkout directories",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		runSparse(cmd, args[0], nil)
+	},
+}
+
+var worktreeSparseAddCmd = &cobra.Command{
+	Use:   "add <issue|id> <path>...",
+	Short: "Add directories to a worktree's sparse-checkout",
+	Long: `Add directories to a worktree's sparse-checkout. A worktree with a full
+checkout becomes sparse, limited to the given directories.`,
+	Args: cobra.MinimumNArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		runSparse(cmd, args[0], func(m *worktree.Manager, w *storage.Worktree) error {
+			return m.SparseAdd(context.Background(), w, args[1:])
+		})
+	},
+}
+
+var worktreeSparseRemoveCmd = &cobra.Command{
+	Use:   "remove <issue|id> <path>...",
+	Short: "Remove directories from a worktree's sparse-checkout",
+	Long: `Remove directories from a worktree's sparse-checkout. Removing the last
+directory restores a full checkout.`,
+	Args: cobra.MinimumNArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		runSparse(cmd, args[0], func(m *worktree.Manager, w *storage.Worktree) error {
+			return m.SparseRemove(context.Background(), w, args[1:])
+		})
+	},
+}
+
+// runSparse applies change (if any) to the worktree selected by arg and
+// prints its resulting sparse-checkout.
+func runSparse(cmd *cobra.Command, arg string, change func(*worktree.Manager, *storage.Worktree) error) {
+	db, err := getDB()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error opening database: %v\n", err)
+		os.Exit(1)
+	}
+	if shouldCloseDB(db) {
+		defer db.Close()
+	}
+
+	_, w, err := resolveWorktree(db, arg, worktreeProject)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error finding worktree: %v\n", err)
+		os.Exit(1)
+	}
+
+	if change != nil {
+		if err := change(worktree.NewManager(db, getGit()), w); err != nil {
+			fmt.Fprintf(os.Stderr, "Error updating sparse-checkout: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	out := cmd.OutOrStdout()
+	if len(w.SparsePatterns) == 0 {
+		fmt.Fprintf(out, "#%d has a full checkout\n", w.IssueNumber)
+		return
+	}
+	fmt.Fprintf(out, "#%d checks out: %s\n", w.IssueNumber, strings.Join(w.SparsePatterns, ", "))
+}
+
+func init() {
+	worktreeCmd.AddCommand(worktreeSparseCmd)
+	worktreeSparseCmd.AddCommand(worktreeSparseListCmd)
+	worktreeSparseCmd.AddCommand(worktreeSparseAddCmd)
+	worktreeSparseCmd.AddCommand(worktreeSparseRemoveCmd)
+
+	worktreeSparseCmd.PersistentFlags().StringVarP(&worktreeProject, "project", "p", "", "Project ID when selecting by issue number")
+}
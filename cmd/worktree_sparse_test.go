@@ -0,0 +1,35 @@
+package cmd
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/paolorechia/issue-flow/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWorktreeSparseCommands(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	sp := testutil.CreateGitProjectWithConfig(t, db, "app", `{"issue_types":[{"name":"docs","sparse_patterns":["docs"]}]}`)
+	testutil.GitCommitFile(t, sp.LocalPath, "docs/guide.md", "# guide\n", "Add docs")
+	testutil.GitCommitFile(t, sp.LocalPath, "src/main.go", "package main\n", "Add src")
+
+	testDB = db
+	t.Cleanup(func() { testDB = nil })
+	t.Cleanup(func() { worktreeProject = "" })
+
+	runStart(t, "3", "--project", "app", "--title", "Fix typo", "--type", "docs")
+	w := testutil.AssertWorktreeExists(t, db, "wt-app-3")
+	assert.NoDirExists(t, filepath.Join(w.Path, "src"))
+
+	out := runWorktree(t, "sparse", "list", "wt-app-3")
+	assert.Contains(t, out, "#3 checks out: docs")
+
+	out = runWorktree(t, "sparse", "add", "3", "src", "--project", "app")
+	assert.Contains(t, out, "#3 checks out: docs, src")
+	assert.FileExists(t, filepath.Join(w.Path, "src", "main.go"))
+
+	out = runWorktree(t, "sparse", "remove", "3", "docs", "src", "--project", "app")
+	assert.Contains(t, out, "#3 has a full checkout")
+	assert.Empty(t, testutil.AssertWorktreeExists(t, db, w.ID).SparsePatterns)
+}
@@ -0,0 +1,182 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/paolorechia/issue-flow/internal/project"
+	"github.com/paolorechia/issue-flow/internal/storage"
+	"github.com/paolorechia/issue-flow/internal/worktree"
+	"github.com/spf13/cobra"
+)
+
+var worktreeRestackCmd = &cobra.Command{
+	Use:   "restack [<issue|id>]",
+	Short: "Rebase stacked worktrees onto their parents, in order",
+	Long: `Rebase each stack of worktrees created with 'start --on' from the bottom up:
+the root onto its base branch (after fetching upstream), then every worktree
+onto its parent's updated branch, replaying only its own commits.
+
+A worktree whose parent was removed, e.g. after it was merged, is moved onto
+its base branch. When a worktree is dirty or its rebase conflicts, the
+rebase is aborted and the worktrees stacked on it are skipped.
+
+With a worktree only its stack is restacked; otherwise every stack of the
+project is.`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		db, err := getDB()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error opening database: %v\n", err)
+			os.Exit(1)
+		}
+		if shouldCloseDB(db) {
+			defer db.Close()
+		}
+
+		var p *project.Project
+		var from *storage.Worktree
+		if len(args) == 1 {
+			p, from, err = resolveWorktree(db, args[0], worktreeProject)
+		} else {
+			p, err = resolveProject(db, worktreeProject)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		manager := worktree.NewManager(db, getGit())
+		outcomes, warnings, err := manager.Restack(context.Background(), p, from, worktree.RestackOptions{
+			Autostash: syncAutostash,
+			NoFetch:   syncNoFetch,
+		})
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error restacking worktrees: %v\n", err)
+			os.Exit(1)
+		}
+		for _, warning := range warnings {
+			fmt.Fprintf(os.Stderr, "Warning: %s\n", warning)
+		}
+
+		out := cmd.OutOrStdout()
+		if len(outcomes) == 0 {
+			fmt.Fprintln(out, "No stacked worktrees.")
+			return
+		}
+
+		failed := 0
+		w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "ISSUE\tBRANCH\tONTO\tRESULT\tDETAIL")
+		for _, o := range outcomes {
+			detail := "-"
+			switch {
+			case o.Err != nil:
+				detail = o.Err.Error()
+			case len(o.Conflicts) > 0:
+				detail = "conflicts in " + strings.Join(o.Conflicts, ", ")
+			}
+			if o.Result != worktree.SyncUpToDate && o.Result != worktree.SyncRebased {
+				failed++
+			}
+			onto := o.Onto
+			if onto == "" {
+				onto = "-"
+			}
+			fmt.Fprintf(w, "%s%s\t%s\t%s\t%s\t%s\n",
+				strings.Repeat("  ", o.Depth), worktreeLabel(&o.Worktree), o.Worktree.Branch, onto, o.Result, detail)
+		}
+		w.Flush()
+
+		if failed > 0 {
+			fmt.Fprintf(os.Stderr, "%d worktrees could not be restacked.\n", failed)
+			os.Exit(1)
+		}
+	},
+}
+
+var worktreeTreeCmd = &cobra.Command{
+	Use:   "tree",
+	Short: "Show stacked worktrees as a tree per project",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		db, err := getDB()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error opening database: %v\n", err)
+			os.Exit(1)
+		}
+		if shouldCloseDB(db) {
+			defer db.Close()
+		}
+
+		pm := project.NewManager(db)
+		var projects []project.Project
+		if worktreeProject != "" {
+			p, err := pm.Get(worktreeProject)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error loading project: %v\n", err)
+				os.Exit(1)
+			}
+			projects = []project.Project{*p}
+		} else if projects, err = pm.List(); err != nil {
+			fmt.Fprintf(os.Stderr, "Error listing projects: %v\n", err)
+			os.Exit(1)
+		}
+
+		manager := worktree.NewManager(db, getGit())
+		out := cmd.OutOrStdout()
+		for i := range projects {
+			p := &projects[i]
+			stacks, err := manager.Stacks(context.Background(), p)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error reading stacks of %s: %v\n", p.ID, err)
+				os.Exit(1)
+			}
+			if len(stacks) == 0 {
+				continue
+			}
+			fmt.Fprintln(out, p.ID)
+			printStacks(out, stacks, "")
+		}
+	},
+}
+
+func printStacks(out io.Writer, nodes []*worktree.StackNode, indent string) {
+	for i, node := range nodes {
+		branch, next := "├── ", "│   "
+		if i == len(nodes)-1 {
+			branch, next = "└── ", "    "
+		}
+		line := fmt.Sprintf("%s  %s", worktreeLabel(&node.Worktree), node.Worktree.Branch)
+		var notes []string
+		if node.Worktree.Status != storage.WorktreeStatusActive {
+			notes = append(notes, node.Worktree.Status)
+		}
+		if node.MissingParent != "" {
+			notes = append(notes, fmt.Sprintf("parent %s %s", node.Worktree.ParentID, node.MissingParent))
+		}
+		if node.NeedsRestack {
+			notes = append(notes, "needs restack")
+		}
+		if len(notes) > 0 {
+			line += "  (" + strings.Join(notes, ", ") + ")"
+		}
+		fmt.Fprintf(out, "%s%s%s\n", indent, branch, line)
+		printStacks(out, node.Children, indent+next)
+	}
+}
+
+func init() {
+	worktreeCmd.AddCommand(worktreeRestackCmd)
+	worktreeCmd.AddCommand(worktreeTreeCmd)
+
+	worktreeRestackCmd.Flags().StringVarP(&worktreeProject, "project", "p", "", "Project ID when selecting by issue number, or the project to restack")
+	worktreeRestackCmd.Flags().BoolVar(&syncAutostash, "autostash", false, "Stash uncommitted changes around each rebase instead of skipping")
+	worktreeRestackCmd.Flags().BoolVar(&syncNoFetch, "no-fetch", false, "Do not fetch the upstream remote first")
+
+	worktreeTreeCmd.Flags().StringVarP(&worktreeProject, "project", "p", "", "Only show this project")
+}
@@ -0,0 +1,175 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/paolorechia/issue-flow/internal/storage"
+	"github.com/paolorechia/issue-flow/internal/worktree"
+	"github.com/paolorechia/issue-flow/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func runWorktree(t *testing.T, args ...string) string {
+	t.Helper()
+
+	buf := new(bytes.Buffer)
+	rootCmd.SetOut(buf)
+	rootCmd.SetErr(buf)
+	rootCmd.SetArgs(append([]string{"worktree"}, args...))
+
+	err := rootCmd.Execute()
+	require.NoError(t, err)
+	return buf.String()
+}
+
+func TestWorktreeListCommand_Empty(t *testing.T) {
+	db := testutil.NewTestDB(t)
+
+	testDB = db
+	t.Cleanup(func() { testDB = nil })
+	t.Cleanup(func() { worktreeProject = "" })
+
+	out := runWorktree(t, "list")
+	assert.Contains(t, out, "No worktrees found")
+}
+
+func TestWorktreeListCommand_ShowsGitState(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	testutil.CreateGitProject(t, db, "app")
+	testutil.CreateGitProject(t, db, "other")
+
+	testDB = db
+	t.Cleanup(func() { testDB = nil })
+	t.Cleanup(func() { worktreeProject = "" })
+
+	runStart(t, "10", "--project", "app", "--title", "Clean one")
+	runStart(t, "11", "--project", "app", "--title", "Dirty one")
+	runStart(t, "12", "--project", "other", "--title", "Other project")
+	testutil.CreateTestWorktree(t, db, "app", 13)
+
+	dirty := testutil.AssertWorktreeExists(t, db, "wt-app-11")
+	require.NoError(t, os.WriteFile(filepath.Join(dirty.Path, "wip.txt"), []byte("wip\n"), 0644))
+
+	table := testutil.ParseTableOutput(t, runWorktree(t, "list", "--project", "app"))
+	require.Len(t, table, 4)
+	assert.Equal(t, []string{"PROJECT", "ISSUE", "BRANCH", "STATUS", "CHANGES", "SYNC", "LAST", "COMMIT", "MERGED", "PATH"}, table[0])
+
+	rows := make(map[string][]string)
+	for _, row := range table[1:] {
+		rows[row[1]] = row
+	}
+	assert.Equal(t, "clean", rows["#10"][4])
+	assert.Equal(t, "dirty", rows["#11"][4])
+	assert.Equal(t, "missing", rows["#13"][4])
+	assert.Equal(t, "no", rows["#10"][len(rows["#10"])-2])
+}
+
+func TestTimeAgo(t *testing.T) {
+	now := time.Date(2026, 1, 10, 12, 0, 0, 0, time.UTC)
+	assert.Equal(t, "-", timeAgo(time.Time{}, now))
+	assert.Equal(t, "just now", timeAgo(now.Add(-10*time.Second), now))
+	assert.Equal(t, "5m ago", timeAgo(now.Add(-5*time.Minute), now))
+	assert.Equal(t, "3h ago", timeAgo(now.Add(-3*time.Hour), now))
+	assert.Equal(t, "2d ago", timeAgo(now.Add(-49*time.Hour), now))
+}
+
+func TestWorktreeRemoveCommand(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	p := testutil.CreateGitProject(t, db, "app")
+
+	testDB = db
+	t.Cleanup(func() { testDB = nil })
+	t.Cleanup(func() { worktreeProject, worktreeForce, worktreeDeleteBranch = "", false, false })
+
+	runStart(t, "20", "--project", "app", "--title", "Short lived")
+	w := testutil.AssertWorktreeExists(t, db, "wt-app-20")
+
+	out := runWorktree(t, "remove", "20", "--project", "app", "--delete-branch")
+	assert.Contains(t, out, "Removed worktree for #20")
+	assert.Contains(t, out, "Deleted branch "+w.Branch)
+
+	testutil.AssertWorktreeCount(t, db, 0)
+	assert.NoDirExists(t, w.Path)
+	assert.Empty(t, testutil.RunGit(t, p.LocalPath, "branch", "--list", w.Branch))
+}
+
+func TestWorktreeRemoveCommand_ByID(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	testutil.CreateGitProject(t, db, "app")
+
+	testDB = db
+	t.Cleanup(func() { testDB = nil })
+	t.Cleanup(func() { worktreeProject, worktreeForce, worktreeDeleteBranch = "", false, false })
+
+	runStart(t, "21", "--project", "app", "--title", "By id")
+
+	out := runWorktree(t, "remove", "wt-app-21")
+	assert.Contains(t, out, "Removed worktree for #21")
+	testutil.AssertWorktreeCount(t, db, 0)
+}
+
+func TestWorktreeRemoveCommand_RefusesDirty(t *testing.T) {
+	t.Skip("Skipped: command calls os.Exit(1) which terminates test process")
+}
+
+func TestWorktreeReconcileCommand_JSON(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	testutil.CreateGitProject(t, db, "app")
+
+	testDB = db
+	t.Cleanup(func() { testDB = nil })
+	t.Cleanup(func() {
+		worktreeProject, worktreeJSON = "", false
+		reconcileActions = worktree.ReconcileActions{}
+	})
+
+	runStart(t, "30", "--project", "app", "--title", "Gone")
+	w := testutil.AssertWorktreeExists(t, db, "wt-app-30")
+	require.NoError(t, os.RemoveAll(w.Path))
+
+	out := runWorktree(t, "reconcile", "--json", "--mark-stale")
+
+	var report struct {
+		Drifts []worktree.Drift `json:"drifts"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(out), &report))
+	require.Len(t, report.Drifts, 1)
+	assert.Equal(t, worktree.DriftMissing, report.Drifts[0].Kind)
+	assert.Equal(t, worktree.ActionMarkStale, report.Drifts[0].Action)
+	assert.Equal(t, storage.WorktreeStatusStale, testutil.AssertWorktreeExists(t, db, w.ID).Status)
+}
+
+func TestWorktreeReconcileCommand_Clean(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	testutil.CreateGitProject(t, db, "app")
+
+	testDB = db
+	t.Cleanup(func() { testDB = nil })
+
+	runStart(t, "31", "--project", "app", "--title", "Fine")
+
+	out := runWorktree(t, "reconcile")
+	assert.Contains(t, out, "Worktrees table matches git")
+}
+
+func TestWorktreeSyncCommand(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	p := testutil.CreateGitProject(t, db, "app")
+
+	testDB = db
+	t.Cleanup(func() { testDB = nil })
+	t.Cleanup(func() { worktreeProject, syncStrategy, syncAutostash, syncNoFetch = "", "", false, false })
+
+	runStart(t, "40", "--project", "app", "--title", "Behind")
+	testutil.GitCommitFile(t, p.LocalPath, "new.txt", "new\n", "Upstream change")
+
+	table := testutil.ParseTableOutput(t, runWorktree(t, "sync", "--project", "app"))
+	require.Len(t, table, 2)
+	assert.Equal(t, []string{"app", "#40", "feature/40-behind", "main", "rebased", "1", "new", "commits"}, table[1])
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/spf13/viper"
 )
@@ -32,6 +33,9 @@ type Settings struct {
 type GitHubConfig struct {
 	AuthMethod string `mapstructure:"auth_method"`
 	Token      string `mapstructure:"token"`
+	// BaseURL is the REST API root, e.g. https://<host>/api/v3 for GitHub
+	// Enterprise. Empty means api.github.com.
+	BaseURL string `mapstructure:"base_url"`
 }
 
 type ProjectRef struct {
@@ -101,6 +105,17 @@ func GetConfigPath() string {
 	return filepath.Join(home, ".issue-flow")
 }
 
+// ExpandPath replaces a leading "~" in path with the user's home directory.
+func ExpandPath(path string) string {
+	if path == "~" {
+		return homeDir()
+	}
+	if strings.HasPrefix(path, "~/") {
+		return filepath.Join(homeDir(), path[2:])
+	}
+	return path
+}
+
 func homeDir() string {
 	home, err := os.UserHomeDir()
 	if err != nil {
@@ -0,0 +1,91 @@
+package git
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// Call is a single git invocation captured by RecordingRunner.
+type Call struct {
+	Dir  string
+	Args []string
+}
+
+// String renders the call as it would be typed, e.g. "git worktree prune".
+func (c Call) String() string {
+	return "git " + strings.Join(c.Args, " ")
+}
+
+// ExitError is a fake process failure with an exit status, for use with RecordingRunner.
+type ExitError struct {
+	Code int
+}
+
+func (e *ExitError) Error() string {
+	return fmt.Sprintf("exit status %d", e.Code)
+}
+
+func (e *ExitError) ExitCode() int {
+	return e.Code
+}
+
+type response struct {
+	output string
+	err    error
+}
+
+// RecordingRunner is a Runner that records every call instead of running git.
+// Responses are stubbed per command line with On; unstubbed calls succeed with
+// empty output.
+type RecordingRunner struct {
+	mu        sync.Mutex
+	calls     []Call
+	responses map[string]response
+}
+
+func NewRecordingRunner() *RecordingRunner {
+	return &RecordingRunner{responses: make(map[string]response)}
+}
+
+// On stubs the result for a command line such as "worktree list --porcelain".
+func (r *RecordingRunner) On(command string, output string, err error) *RecordingRunner {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.responses[command] = response{output: output, err: err}
+	return r
+}
+
+func (r *RecordingRunner) Run(ctx context.Context, dir string, args ...string) (string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.calls = append(r.calls, Call{Dir: dir, Args: append([]string(nil), args...)})
+	resp := r.responses[strings.Join(args, " ")]
+	return resp.output, resp.err
+}
+
+// Calls returns a copy of every call recorded so far.
+func (r *RecordingRunner) Calls() []Call {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]Call(nil), r.calls...)
+}
+
+// Commands returns the recorded calls as "git ..." strings.
+func (r *RecordingRunner) Commands() []string {
+	calls := r.Calls()
+	commands := make([]string, len(calls))
+	for i, c := range calls {
+		commands[i] = c.String()
+	}
+	return commands
+}
+
+// Reset forgets all recorded calls but keeps the stubbed responses.
+func (r *RecordingRunner) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.calls = nil
+}
@@ -0,0 +1,854 @@
+package git
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Git is the set of git operations issue-flow relies on.
+type Git interface {
+	WorktreeAdd(ctx context.Context, repo string, opts WorktreeAddOptions) error
+	WorktreeList(ctx context.Context, repo string) ([]Worktree, error)
+	WorktreeRemove(ctx context.Context, repo, path string, force bool) error
+	WorktreePrune(ctx context.Context, repo string) error
+	WorktreeMove(ctx context.Context, repo, from, to string) error
+	WorktreeRepair(ctx context.Context, repo string, paths ...string) error
+
+	CurrentBranch(ctx context.Context, dir string) (string, error)
+	DefaultBranch(ctx context.Context, repo string) (string, error)
+	RefExists(ctx context.Context, repo, ref string) (bool, error)
+	BranchExists(ctx context.Context, repo, name string) (bool, error)
+	CreateBranch(ctx context.Context, repo, name, startPoint string) error
+	DeleteBranch(ctx context.Context, repo, name string, force bool) error
+	RenameBranch(ctx context.Context, repo, from, to string) error
+	ListBranches(ctx context.Context, repo string, patterns ...string) ([]string, error)
+	ListRemoteBranches(ctx context.Context, repo, remote string, patterns ...string) ([]string, error)
+
+	Fetch(ctx context.Context, repo, remote string, refspecs ...string) error
+	Status(ctx context.Context, dir string) (*Status, error)
+	RevList(ctx context.Context, dir string, args ...string) ([]string, error)
+	RevParse(ctx context.Context, dir, ref string) (string, error)
+	IsAncestor(ctx context.Context, dir, ancestor, descendant string) (bool, error)
+	LastCommitTime(ctx context.Context, dir, ref string) (time.Time, error)
+	BranchStartCommit(ctx context.Context, repo, branch string) (string, error)
+	AheadBehind(ctx context.Context, dir, local, upstream string) (ahead, behind int, err error)
+	Log(ctx context.Context, dir string, revs ...string) ([]Commit, error)
+	Rebase(ctx context.Context, dir, upstream string, autostash bool) error
+	RebaseOnto(ctx context.Context, dir, onto, upstream string, autostash bool) error
+	RebaseAbort(ctx context.Context, dir string) error
+	Merge(ctx context.Context, dir, ref string, autostash bool) error
+	MergeAbort(ctx context.Context, dir string) error
+	CherryPick(ctx context.Context, dir string, commits ...string) error
+	CherryPickSkip(ctx context.Context, dir string) error
+	CherryPickAbort(ctx context.Context, dir string) error
+	StashList(ctx context.Context, dir string) ([]Stash, error)
+	StashPush(ctx context.Context, dir, message string, includeUntracked bool) error
+	StashApply(ctx context.Context, dir, stash string, index bool) error
+	StashDrop(ctx context.Context, dir, stash string) error
+	UpdateRef(ctx context.Context, repo, ref, value string) error
+	DeleteRef(ctx context.Context, repo, ref string) error
+	Push(ctx context.Context, repo, remote string, refspecs ...string) error
+	Checkout(ctx context.Context, dir, ref string) error
+	LsFiles(ctx context.Context, dir string, args ...string) ([]string, error)
+	DiffStat(ctx context.Context, dir string, revs ...string) (DiffStat, error)
+	BundleCreate(ctx context.Context, repo, file string, revs ...string) error
+
+	SparseCheckoutSet(ctx context.Context, dir string, patterns []string) error
+	SparseCheckoutAdd(ctx context.Context, dir string, patterns []string) error
+	SparseCheckoutList(ctx context.Context, dir string) ([]string, error)
+	SparseCheckoutDisable(ctx context.Context, dir string) error
+
+	Remotes(ctx context.Context, repo string) ([]Remote, error)
+	AddRemote(ctx context.Context, repo, name, url string) error
+	RemoteURL(ctx context.Context, repo, name string) (string, error)
+
+	GitDir(ctx context.Context, dir string) (string, error)
+	ConfigGet(ctx context.Context, dir, key string) (string, error)
+	ConfigSet(ctx context.Context, dir, key, value string) error
+	WorktreeConfigSet(ctx context.Context, dir, key, value string) error
+	WorktreeConfigUnset(ctx context.Context, dir, key string) error
+}
+
+// Client implements Git on top of a Runner.
+type Client struct {
+	runner Runner
+}
+
+var _ Git = (*Client)(nil)
+
+// NewClient returns a Client that runs git through r. A nil runner uses ExecRunner.
+func NewClient(r Runner) *Client {
+	if r == nil {
+		r = ExecRunner{}
+	}
+	return &Client{runner: r}
+}
+
+// Run executes an arbitrary git command through the client's runner.
+func (c *Client) Run(ctx context.Context, dir string, args ...string) (string, error) {
+	return c.runner.Run(ctx, dir, args...)
+}
+
+func (c *Client) WorktreeAdd(ctx context.Context, repo string, opts WorktreeAddOptions) error {
+	if opts.Path == "" {
+		return fmt.Errorf("worktree path is required")
+	}
+
+	args := []string{"worktree", "add"}
+	if opts.Force {
+		args = append(args, "--force")
+	}
+	if opts.NoCheckout {
+		args = append(args, "--no-checkout")
+	}
+	if opts.NewBranch {
+		if opts.Branch == "" {
+			return fmt.Errorf("branch name is required to create a new branch")
+		}
+		if opts.NoTrack {
+			args = append(args, "--no-track")
+		}
+		args = append(args, "-b", opts.Branch, opts.Path)
+		if opts.StartPoint != "" {
+			args = append(args, opts.StartPoint)
+		}
+	} else {
+		args = append(args, opts.Path)
+		if opts.Branch != "" {
+			args = append(args, opts.Branch)
+		}
+	}
+
+	_, err := c.runner.Run(ctx, repo, args...)
+	return err
+}
+
+func (c *Client) WorktreeList(ctx context.Context, repo string) ([]Worktree, error) {
+	out, err := c.runner.Run(ctx, repo, "worktree", "list", "--porcelain")
+	if err != nil {
+		return nil, err
+	}
+	return parseWorktreeList(out), nil
+}
+
+func (c *Client) WorktreeRemove(ctx context.Context, repo, path string, force bool) error {
+	args := []string{"worktree", "remove"}
+	if force {
+		args = append(args, "--force")
+	}
+	args = append(args, path)
+
+	_, err := c.runner.Run(ctx, repo, args...)
+	return err
+}
+
+func (c *Client) WorktreePrune(ctx context.Context, repo string) error {
+	_, err := c.runner.Run(ctx, repo, "worktree", "prune")
+	return err
+}
+
+// WorktreeMove moves a linked worktree and updates git's record of it.
+func (c *Client) WorktreeMove(ctx context.Context, repo, from, to string) error {
+	_, err := c.runner.Run(ctx, repo, "worktree", "move", from, to)
+	return err
+}
+
+func (c *Client) WorktreeRepair(ctx context.Context, repo string, paths ...string) error {
+	_, err := c.runner.Run(ctx, repo, append([]string{"worktree", "repair"}, paths...)...)
+	return err
+}
+
+func (c *Client) CurrentBranch(ctx context.Context, dir string) (string, error) {
+	out, err := c.runner.Run(ctx, dir, "symbolic-ref", "--quiet", "--short", "HEAD")
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(out), nil
+}
+
+// DefaultBranch returns the branch origin/HEAD points at, falling back to a
+// local main or master branch and finally to the repo's current branch.
+func (c *Client) DefaultBranch(ctx context.Context, repo string) (string, error) {
+	out, err := c.runner.Run(ctx, repo, "symbolic-ref", "--quiet", "--short", "refs/remotes/origin/HEAD")
+	if err == nil {
+		return strings.TrimPrefix(strings.TrimSpace(out), "origin/"), nil
+	}
+
+	for _, name := range []string{"main", "master"} {
+		exists, err := c.BranchExists(ctx, repo, name)
+		if err != nil {
+			return "", err
+		}
+		if exists {
+			return name, nil
+		}
+	}
+
+	return c.CurrentBranch(ctx, repo)
+}
+
+// RefExists reports whether a fully qualified ref such as refs/remotes/origin/main exists.
+func (c *Client) RefExists(ctx context.Context, repo, ref string) (bool, error) {
+	_, err := c.runner.Run(ctx, repo, "show-ref", "--verify", "--quiet", ref)
+	if err == nil {
+		return true, nil
+	}
+	if exitCode(err) == 1 {
+		return false, nil
+	}
+	return false, err
+}
+
+func (c *Client) BranchExists(ctx context.Context, repo, name string) (bool, error) {
+	return c.RefExists(ctx, repo, "refs/heads/"+name)
+}
+
+func (c *Client) CreateBranch(ctx context.Context, repo, name, startPoint string) error {
+	args := []string{"branch", name}
+	if startPoint != "" {
+		args = append(args, startPoint)
+	}
+	_, err := c.runner.Run(ctx, repo, args...)
+	return err
+}
+
+func (c *Client) DeleteBranch(ctx context.Context, repo, name string, force bool) error {
+	flag := "-d"
+	if force {
+		flag = "-D"
+	}
+	_, err := c.runner.Run(ctx, repo, "branch", flag, name)
+	return err
+}
+
+// RenameBranch renames a local branch, including in any worktree that has
+// it checked out.
+func (c *Client) RenameBranch(ctx context.Context, repo, from, to string) error {
+	_, err := c.runner.Run(ctx, repo, "branch", "-m", from, to)
+	return err
+}
+
+func (c *Client) ListBranches(ctx context.Context, repo string, patterns ...string) ([]string, error) {
+	args := []string{"for-each-ref", "--format=%(refname:short)"}
+	if len(patterns) == 0 {
+		args = append(args, "refs/heads/")
+	} else {
+		for _, p := range patterns {
+			args = append(args, "refs/heads/"+p)
+		}
+	}
+
+	out, err := c.runner.Run(ctx, repo, args...)
+	if err != nil {
+		return nil, err
+	}
+	return splitLines(out), nil
+}
+
+// ListRemoteBranches lists the remote-tracking branches of remote matching
+// patterns, without the remote's name, e.g. "release/1.4" for
+// origin/release/1.4.
+func (c *Client) ListRemoteBranches(ctx context.Context, repo, remote string, patterns ...string) ([]string, error) {
+	prefix := "refs/remotes/" + remote + "/"
+	args := []string{"for-each-ref", "--format=%(refname)"}
+	if len(patterns) == 0 {
+		args = append(args, prefix)
+	} else {
+		for _, p := range patterns {
+			args = append(args, prefix+p)
+		}
+	}
+
+	out, err := c.runner.Run(ctx, repo, args...)
+	if err != nil {
+		return nil, err
+	}
+	var branches []string
+	for _, ref := range splitLines(out) {
+		if name := strings.TrimPrefix(ref, prefix); name != "HEAD" {
+			branches = append(branches, name)
+		}
+	}
+	return branches, nil
+}
+
+func (c *Client) Fetch(ctx context.Context, repo, remote string, refspecs ...string) error {
+	args := []string{"fetch", "--quiet"}
+	if remote != "" {
+		args = append(args, remote)
+		args = append(args, refspecs...)
+	}
+	_, err := c.runner.Run(ctx, repo, args...)
+	return err
+}
+
+func (c *Client) Status(ctx context.Context, dir string) (*Status, error) {
+	out, err := c.runner.Run(ctx, dir, "status", "--porcelain=v2", "--branch", "--untracked-files=all")
+	if err != nil {
+		return nil, err
+	}
+	return parseStatus(out)
+}
+
+func (c *Client) RevList(ctx context.Context, dir string, args ...string) ([]string, error) {
+	out, err := c.runner.Run(ctx, dir, append([]string{"rev-list"}, args...)...)
+	if err != nil {
+		return nil, err
+	}
+	return splitLines(out), nil
+}
+
+func (c *Client) RevParse(ctx context.Context, dir, ref string) (string, error) {
+	out, err := c.runner.Run(ctx, dir, "rev-parse", "--verify", "--quiet", ref+"^{commit}")
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(out), nil
+}
+
+func (c *Client) IsAncestor(ctx context.Context, dir, ancestor, descendant string) (bool, error) {
+	_, err := c.runner.Run(ctx, dir, "merge-base", "--is-ancestor", ancestor, descendant)
+	if err == nil {
+		return true, nil
+	}
+	if exitCode(err) == 1 {
+		return false, nil
+	}
+	return false, err
+}
+
+func (c *Client) LastCommitTime(ctx context.Context, dir, ref string) (time.Time, error) {
+	out, err := c.runner.Run(ctx, dir, "log", "-1", "--format=%ct", ref)
+	if err != nil {
+		return time.Time{}, err
+	}
+	secs, err := strconv.ParseInt(strings.TrimSpace(out), 10, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to parse commit time: %w", err)
+	}
+	return time.Unix(secs, 0), nil
+}
+
+// BranchStartCommit returns the commit a branch pointed at when it was
+// created, taken from the oldest entry in the branch's reflog.
+func (c *Client) BranchStartCommit(ctx context.Context, repo, branch string) (string, error) {
+	out, err := c.runner.Run(ctx, repo, "reflog", "show", "--format=%H", "refs/heads/"+branch, "--")
+	if err != nil {
+		return "", err
+	}
+	lines := splitLines(out)
+	if len(lines) == 0 {
+		return "", fmt.Errorf("no reflog for branch %s", branch)
+	}
+	return lines[len(lines)-1], nil
+}
+
+func (c *Client) AheadBehind(ctx context.Context, dir, local, upstream string) (int, int, error) {
+	out, err := c.runner.Run(ctx, dir, "rev-list", "--left-right", "--count", local+"..."+upstream)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	fields := strings.Fields(out)
+	if len(fields) != 2 {
+		return 0, 0, fmt.Errorf("unexpected rev-list output: %q", out)
+	}
+	ahead, err := strconv.Atoi(fields[0])
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to parse ahead count: %w", err)
+	}
+	behind, err := strconv.Atoi(fields[1])
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to parse behind count: %w", err)
+	}
+	return ahead, behind, nil
+}
+
+// Log returns the commits selected by revs, newest first.
+func (c *Client) Log(ctx context.Context, dir string, revs ...string) ([]Commit, error) {
+	args := append([]string{"log", "--format=%H%x00%s"}, revs...)
+	args = append(args, "--")
+	out, err := c.runner.Run(ctx, dir, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	var commits []Commit
+	for _, line := range splitLines(out) {
+		hash, subject, _ := strings.Cut(line, "\x00")
+		commits = append(commits, Commit{Hash: hash, Subject: subject})
+	}
+	return commits, nil
+}
+
+func (c *Client) Rebase(ctx context.Context, dir, upstream string, autostash bool) error {
+	args := []string{"rebase", "--quiet"}
+	if autostash {
+		args = append(args, "--autostash")
+	}
+	_, err := c.runner.Run(ctx, dir, append(args, upstream)...)
+	return err
+}
+
+// RebaseOnto replays the commits of HEAD that are not in upstream onto onto.
+func (c *Client) RebaseOnto(ctx context.Context, dir, onto, upstream string, autostash bool) error {
+	args := []string{"rebase", "--quiet"}
+	if autostash {
+		args = append(args, "--autostash")
+	}
+	_, err := c.runner.Run(ctx, dir, append(args, "--onto", onto, upstream)...)
+	return err
+}
+
+func (c *Client) RebaseAbort(ctx context.Context, dir string) error {
+	_, err := c.runner.Run(ctx, dir, "rebase", "--abort")
+	return err
+}
+
+func (c *Client) Merge(ctx context.Context, dir, ref string, autostash bool) error {
+	args := []string{"merge", "--quiet", "--no-edit"}
+	if autostash {
+		args = append(args, "--autostash")
+	}
+	_, err := c.runner.Run(ctx, dir, append(args, ref)...)
+	return err
+}
+
+// CherryPick applies commits in order, recording their origin with -x.
+func (c *Client) CherryPick(ctx context.Context, dir string, commits ...string) error {
+	_, err := c.runner.Run(ctx, dir, append([]string{"cherry-pick", "-x"}, commits...)...)
+	return err
+}
+
+// CherryPickSkip skips the commit a cherry-pick stopped at, typically one
+// that became empty because its change is already there, and continues with
+// the remaining commits.
+func (c *Client) CherryPickSkip(ctx context.Context, dir string) error {
+	_, err := c.runner.Run(ctx, dir, "cherry-pick", "--skip")
+	return err
+}
+
+func (c *Client) CherryPickAbort(ctx context.Context, dir string) error {
+	_, err := c.runner.Run(ctx, dir, "cherry-pick", "--abort")
+	return err
+}
+
+func (c *Client) MergeAbort(ctx context.Context, dir string) error {
+	_, err := c.runner.Run(ctx, dir, "merge", "--abort")
+	return err
+}
+
+func (c *Client) StashList(ctx context.Context, dir string) ([]Stash, error) {
+	out, err := c.runner.Run(ctx, dir, "stash", "list", "--format=%gd%x00%gs")
+	if err != nil {
+		return nil, err
+	}
+	return parseStashList(out), nil
+}
+
+func (c *Client) StashPush(ctx context.Context, dir, message string, includeUntracked bool) error {
+	args := []string{"stash", "push", "--quiet"}
+	if includeUntracked {
+		args = append(args, "--include-untracked")
+	}
+	if message != "" {
+		args = append(args, "--message", message)
+	}
+	_, err := c.runner.Run(ctx, dir, args...)
+	return err
+}
+
+// StashApply applies a stash entry or any stash-like commit. With index the
+// staged changes are restored to the index as well.
+func (c *Client) StashApply(ctx context.Context, dir, stash string, index bool) error {
+	args := []string{"stash", "apply", "--quiet"}
+	if index {
+		args = append(args, "--index")
+	}
+	args = append(args, stash)
+	_, err := c.runner.Run(ctx, dir, args...)
+	return err
+}
+
+func (c *Client) StashDrop(ctx context.Context, dir, stash string) error {
+	_, err := c.runner.Run(ctx, dir, "stash", "drop", "--quiet", stash)
+	return err
+}
+
+func (c *Client) UpdateRef(ctx context.Context, repo, ref, value string) error {
+	_, err := c.runner.Run(ctx, repo, "update-ref", ref, value)
+	return err
+}
+
+func (c *Client) DeleteRef(ctx context.Context, repo, ref string) error {
+	_, err := c.runner.Run(ctx, repo, "update-ref", "-d", ref)
+	return err
+}
+
+func (c *Client) Push(ctx context.Context, repo, remote string, refspecs ...string) error {
+	args := append([]string{"push", "--quiet", remote}, refspecs...)
+	_, err := c.runner.Run(ctx, repo, args...)
+	return err
+}
+
+// Checkout checks out ref in dir. An empty ref populates the working tree from
+// HEAD, which completes a worktree created with NoCheckout.
+func (c *Client) Checkout(ctx context.Context, dir, ref string) error {
+	args := []string{"checkout", "--quiet"}
+	if ref != "" {
+		args = append(args, ref)
+	}
+	_, err := c.runner.Run(ctx, dir, args...)
+	return err
+}
+
+// LsFiles runs `git ls-files -z` with extra args and returns the paths,
+// relative to dir and slash-separated.
+func (c *Client) LsFiles(ctx context.Context, dir string, args ...string) ([]string, error) {
+	out, err := c.runner.Run(ctx, dir, append([]string{"ls-files", "-z"}, args...)...)
+	if err != nil {
+		return nil, err
+	}
+	var paths []string
+	for _, p := range strings.Split(out, "\x00") {
+		if p != "" {
+			paths = append(paths, p)
+		}
+	}
+	return paths, nil
+}
+
+// DiffStat runs `git diff --shortstat` with revs, e.g. "main...HEAD".
+func (c *Client) DiffStat(ctx context.Context, dir string, revs ...string) (DiffStat, error) {
+	out, err := c.runner.Run(ctx, dir, append([]string{"diff", "--shortstat"}, revs...)...)
+	if err != nil {
+		return DiffStat{}, err
+	}
+	return parseShortStat(out), nil
+}
+
+// parseShortStat parses " 3 files changed, 10 insertions(+), 2 deletions(-)".
+// Git omits the parts that are zero.
+func parseShortStat(out string) DiffStat {
+	var stat DiffStat
+	for _, part := range strings.Split(strings.TrimSpace(out), ",") {
+		fields := strings.Fields(part)
+		if len(fields) < 2 {
+			continue
+		}
+		n, err := strconv.Atoi(fields[0])
+		if err != nil {
+			continue
+		}
+		switch {
+		case strings.HasPrefix(fields[1], "file"):
+			stat.Files = n
+		case strings.HasPrefix(fields[1], "insertion"):
+			stat.Insertions = n
+		case strings.HasPrefix(fields[1], "deletion"):
+			stat.Deletions = n
+		}
+	}
+	return stat
+}
+
+// BundleCreate writes the refs and objects selected by revs, e.g.
+// "feature/1-x" "^origin/main", to a bundle file. Objects reachable from the
+// excluded revs are left out and must exist wherever the bundle is fetched.
+func (c *Client) BundleCreate(ctx context.Context, repo, file string, revs ...string) error {
+	_, err := c.runner.Run(ctx, repo, append([]string{"bundle", "create", "--quiet", file}, revs...)...)
+	return err
+}
+
+// SparseCheckoutSet enables a cone-mode sparse-checkout in dir limited to the
+// given directories. In a linked worktree the setting applies to that
+// worktree only.
+func (c *Client) SparseCheckoutSet(ctx context.Context, dir string, patterns []string) error {
+	args := append([]string{"sparse-checkout", "set", "--cone", "--"}, patterns...)
+	_, err := c.runner.Run(ctx, dir, args...)
+	return err
+}
+
+func (c *Client) SparseCheckoutAdd(ctx context.Context, dir string, patterns []string) error {
+	args := append([]string{"sparse-checkout", "add", "--"}, patterns...)
+	_, err := c.runner.Run(ctx, dir, args...)
+	return err
+}
+
+func (c *Client) SparseCheckoutList(ctx context.Context, dir string) ([]string, error) {
+	out, err := c.runner.Run(ctx, dir, "sparse-checkout", "list")
+	if err != nil {
+		return nil, err
+	}
+	return splitLines(out), nil
+}
+
+func (c *Client) SparseCheckoutDisable(ctx context.Context, dir string) error {
+	_, err := c.runner.Run(ctx, dir, "sparse-checkout", "disable")
+	return err
+}
+
+func (c *Client) Remotes(ctx context.Context, repo string) ([]Remote, error) {
+	out, err := c.runner.Run(ctx, repo, "remote", "-v")
+	if err != nil {
+		return nil, err
+	}
+	return parseRemotes(out), nil
+}
+
+func (c *Client) AddRemote(ctx context.Context, repo, name, url string) error {
+	_, err := c.runner.Run(ctx, repo, "remote", "add", name, url)
+	return err
+}
+
+func (c *Client) RemoteURL(ctx context.Context, repo, name string) (string, error) {
+	out, err := c.runner.Run(ctx, repo, "remote", "get-url", name)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(out), nil
+}
+
+// GitDir returns the absolute git directory of dir: .git/worktrees/<name>
+// for a linked worktree.
+func (c *Client) GitDir(ctx context.Context, dir string) (string, error) {
+	out, err := c.runner.Run(ctx, dir, "rev-parse", "--absolute-git-dir")
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(out), nil
+}
+
+// ConfigGet returns the value of key as git sees it in dir, or "" when it
+// is not set.
+func (c *Client) ConfigGet(ctx context.Context, dir, key string) (string, error) {
+	out, err := c.runner.Run(ctx, dir, "config", "--get", key)
+	if exitCode(err) == 1 {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(out), nil
+}
+
+// ConfigSet sets key in the repository's local config.
+func (c *Client) ConfigSet(ctx context.Context, dir, key, value string) error {
+	_, err := c.runner.Run(ctx, dir, "config", "--local", key, value)
+	return err
+}
+
+// WorktreeConfigSet sets key for the worktree at dir only. The repository
+// must have extensions.worktreeConfig enabled.
+func (c *Client) WorktreeConfigSet(ctx context.Context, dir, key, value string) error {
+	_, err := c.runner.Run(ctx, dir, "config", "--worktree", key, value)
+	return err
+}
+
+// WorktreeConfigUnset removes key from the config of the worktree at dir.
+// A key that is not set is not an error.
+func (c *Client) WorktreeConfigUnset(ctx context.Context, dir, key string) error {
+	_, err := c.runner.Run(ctx, dir, "config", "--worktree", "--unset", key)
+	if exitCode(err) == 5 {
+		return nil
+	}
+	return err
+}
+
+func parseWorktreeList(out string) []Worktree {
+	var worktrees []Worktree
+	var current *Worktree
+
+	scanner := bufio.NewScanner(strings.NewReader(out))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" {
+			current = nil
+			continue
+		}
+
+		key, value, _ := strings.Cut(line, " ")
+		if key == "worktree" {
+			worktrees = append(worktrees, Worktree{Path: value})
+			current = &worktrees[len(worktrees)-1]
+			continue
+		}
+		if current == nil {
+			continue
+		}
+
+		switch key {
+		case "HEAD":
+			current.Head = value
+		case "branch":
+			current.Branch = strings.TrimPrefix(value, "refs/heads/")
+		case "bare":
+			current.Bare = true
+		case "detached":
+			current.Detached = true
+		case "locked":
+			current.Locked = true
+		case "prunable":
+			current.Prunable = true
+		}
+	}
+
+	return worktrees
+}
+
+func parseStatus(out string) (*Status, error) {
+	s := &Status{}
+
+	scanner := bufio.NewScanner(strings.NewReader(out))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" {
+			continue
+		}
+
+		switch line[0] {
+		case '#':
+			fields := strings.Fields(line)
+			if len(fields) < 3 {
+				continue
+			}
+			switch fields[1] {
+			case "branch.oid":
+				s.Head = fields[2]
+			case "branch.head":
+				if fields[2] != "(detached)" {
+					s.Branch = fields[2]
+				}
+			case "branch.upstream":
+				s.Upstream = fields[2]
+			case "branch.ab":
+				if len(fields) != 4 {
+					return nil, fmt.Errorf("unexpected branch.ab line: %q", line)
+				}
+				ahead, err := strconv.Atoi(strings.TrimPrefix(fields[2], "+"))
+				if err != nil {
+					return nil, fmt.Errorf("failed to parse ahead count: %w", err)
+				}
+				behind, err := strconv.Atoi(strings.TrimPrefix(fields[3], "-"))
+				if err != nil {
+					return nil, fmt.Errorf("failed to parse behind count: %w", err)
+				}
+				s.Ahead, s.Behind = ahead, behind
+			}
+		case '1':
+			// 1 <XY> <sub> <mH> <mI> <mW> <hH> <hI> <path>
+			fields := strings.SplitN(line, " ", 9)
+			if len(fields) != 9 {
+				return nil, fmt.Errorf("unexpected status line: %q", line)
+			}
+			s.addChange(fields[1], fields[8])
+		case '2':
+			// 2 <XY> <sub> <mH> <mI> <mW> <hH> <hI> <X><score> <path>\t<origPath>
+			fields := strings.SplitN(line, " ", 10)
+			if len(fields) != 10 {
+				return nil, fmt.Errorf("unexpected status line: %q", line)
+			}
+			path, _, _ := strings.Cut(fields[9], "\t")
+			s.addChange(fields[1], path)
+		case 'u':
+			// u <XY> <sub> <m1> <m2> <m3> <mW> <h1> <h2> <h3> <path>
+			fields := strings.SplitN(line, " ", 11)
+			if len(fields) != 11 {
+				return nil, fmt.Errorf("unexpected status line: %q", line)
+			}
+			s.Conflicted = append(s.Conflicted, fields[10])
+		case '?':
+			s.Untracked = append(s.Untracked, strings.TrimPrefix(line, "? "))
+		}
+	}
+
+	return s, nil
+}
+
+func (s *Status) addChange(xy, path string) {
+	if len(xy) != 2 {
+		return
+	}
+	if xy[0] != '.' {
+		s.Staged = append(s.Staged, path)
+	}
+	if xy[1] != '.' {
+		s.Unstaged = append(s.Unstaged, path)
+	}
+}
+
+// parseStashList parses "stash@{N}\x00WIP on <branch>: ..." lines.
+func parseStashList(out string) []Stash {
+	var stashes []Stash
+	for _, line := range splitLines(out) {
+		ref, subject, _ := strings.Cut(line, "\x00")
+		st := Stash{Ref: ref, Message: subject}
+
+		rest := subject
+		if after, ok := strings.CutPrefix(rest, "WIP on "); ok {
+			rest = after
+		} else if after, ok := strings.CutPrefix(rest, "On "); ok {
+			rest = after
+		} else {
+			rest = ""
+		}
+		if branch, _, ok := strings.Cut(rest, ": "); ok {
+			st.Branch = branch
+		}
+		stashes = append(stashes, st)
+	}
+	return stashes
+}
+
+func parseRemotes(out string) []Remote {
+	var remotes []Remote
+	index := make(map[string]int)
+
+	for _, line := range splitLines(out) {
+		fields := strings.Fields(line)
+		if len(fields) != 3 {
+			continue
+		}
+		name, url, kind := fields[0], fields[1], fields[2]
+
+		i, ok := index[name]
+		if !ok {
+			remotes = append(remotes, Remote{Name: name})
+			i = len(remotes) - 1
+			index[name] = i
+		}
+		switch kind {
+		case "(fetch)":
+			remotes[i].FetchURL = url
+		case "(push)":
+			remotes[i].PushURL = url
+		}
+	}
+
+	return remotes
+}
+
+func splitLines(out string) []string {
+	var lines []string
+	for _, line := range strings.Split(out, "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+// exitCode returns the process exit status carried by err, or -1 if it has none.
+func exitCode(err error) int {
+	var exitErr interface{ ExitCode() int }
+	if errors.As(err, &exitErr) {
+		return exitErr.ExitCode()
+	}
+	return -1
+}
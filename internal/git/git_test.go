@@ -0,0 +1,372 @@
+package git
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/paolorechia/issue-flow/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_WorktreeAddCommands(t *testing.T) {
+	runner := NewRecordingRunner()
+	client := NewClient(runner)
+	ctx := context.Background()
+
+	err := client.WorktreeAdd(ctx, "/repo", WorktreeAddOptions{
+		Path:       "/wt/123",
+		Branch:     "feature/123-test",
+		NewBranch:  true,
+		StartPoint: "origin/main",
+	})
+	require.NoError(t, err)
+
+	err = client.WorktreeAdd(ctx, "/repo", WorktreeAddOptions{Path: "/wt/124", Branch: "feature/124-existing"})
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{
+		"git worktree add -b feature/123-test /wt/123 origin/main",
+		"git worktree add /wt/124 feature/124-existing",
+	}, runner.Commands())
+	assert.Equal(t, "/repo", runner.Calls()[0].Dir)
+}
+
+func TestClient_SparseCheckoutCommands(t *testing.T) {
+	runner := NewRecordingRunner()
+	client := NewClient(runner)
+	ctx := context.Background()
+
+	require.NoError(t, client.WorktreeAdd(ctx, "/repo", WorktreeAddOptions{Path: "/wt/1", Branch: "b", NoCheckout: true}))
+	require.NoError(t, client.SparseCheckoutSet(ctx, "/wt/1", []string{"api", "libs"}))
+	require.NoError(t, client.Checkout(ctx, "/wt/1", ""))
+	require.NoError(t, client.SparseCheckoutAdd(ctx, "/wt/1", []string{"docs"}))
+	require.NoError(t, client.SparseCheckoutDisable(ctx, "/wt/1"))
+
+	assert.Equal(t, []string{
+		"git worktree add --no-checkout /wt/1 b",
+		"git sparse-checkout set --cone -- api libs",
+		"git checkout --quiet",
+		"git sparse-checkout add -- docs",
+		"git sparse-checkout disable",
+	}, runner.Commands())
+}
+
+func TestClient_SnapshotCommands(t *testing.T) {
+	runner := NewRecordingRunner()
+	client := NewClient(runner)
+	ctx := context.Background()
+
+	require.NoError(t, client.StashPush(ctx, "/wt/1", "park", true))
+	require.NoError(t, client.UpdateRef(ctx, "/repo", "refs/issue-flow/wip/x", "abc"))
+	require.NoError(t, client.StashDrop(ctx, "/wt/1", "stash@{0}"))
+	require.NoError(t, client.Push(ctx, "/repo", "origin", "+refs/issue-flow/wip/x:refs/issue-flow/wip/x"))
+	require.NoError(t, client.StashApply(ctx, "/wt/1", "abc", true))
+	require.NoError(t, client.DeleteRef(ctx, "/repo", "refs/issue-flow/wip/x"))
+
+	assert.Equal(t, []string{
+		"git stash push --quiet --include-untracked --message park",
+		"git update-ref refs/issue-flow/wip/x abc",
+		"git stash drop --quiet stash@{0}",
+		"git push --quiet origin +refs/issue-flow/wip/x:refs/issue-flow/wip/x",
+		"git stash apply --quiet --index abc",
+		"git update-ref -d refs/issue-flow/wip/x",
+	}, runner.Commands())
+}
+
+func TestClient_MoveCommands(t *testing.T) {
+	runner := NewRecordingRunner()
+	client := NewClient(runner)
+	ctx := context.Background()
+
+	require.NoError(t, client.RenameBranch(ctx, "/repo", "feature/1-x", "1/x"))
+	require.NoError(t, client.WorktreeMove(ctx, "/repo", "/wt/old/issue-1", "/wt/new/issue-1"))
+
+	assert.Equal(t, []string{
+		"git branch -m feature/1-x 1/x",
+		"git worktree move /wt/old/issue-1 /wt/new/issue-1",
+	}, runner.Commands())
+}
+
+func TestClient_BundleCreate(t *testing.T) {
+	runner := NewRecordingRunner()
+	client := NewClient(runner)
+
+	require.NoError(t, client.BundleCreate(context.Background(), "/repo", "/archive/work.bundle", "refs/heads/feature/1-x", "^origin/main"))
+	assert.Equal(t, []string{"git bundle create --quiet /archive/work.bundle refs/heads/feature/1-x ^origin/main"}, runner.Commands())
+}
+
+func TestClient_ListRemoteBranches(t *testing.T) {
+	runner := NewRecordingRunner().
+		On("for-each-ref --format=%(refname) refs/remotes/origin/release/*", "refs/remotes/origin/release/1.4\nrefs/remotes/origin/release/1.10\n", nil).
+		On("for-each-ref --format=%(refname) refs/remotes/origin/", "refs/remotes/origin/HEAD\nrefs/remotes/origin/main\n", nil)
+	client := NewClient(runner)
+	ctx := context.Background()
+
+	branches, err := client.ListRemoteBranches(ctx, "/repo", "origin", "release/*")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"release/1.4", "release/1.10"}, branches)
+
+	branches, err = client.ListRemoteBranches(ctx, "/repo", "origin")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"main"}, branches)
+}
+
+func TestClient_CherryPick(t *testing.T) {
+	runner := NewRecordingRunner()
+	client := NewClient(runner)
+
+	require.NoError(t, client.CherryPick(context.Background(), "/wt/1", "abc123", "def456"))
+	require.NoError(t, client.CherryPickSkip(context.Background(), "/wt/1"))
+	assert.Equal(t, []string{"git cherry-pick -x abc123 def456", "git cherry-pick --skip"}, runner.Commands())
+}
+
+func TestClient_Config(t *testing.T) {
+	runner := NewRecordingRunner().
+		On("config --get core.hooksPath", "", &ExitError{Code: 1}).
+		On("config --get extensions.worktreeConfig", "true\n", nil).
+		On("config --worktree --unset core.hooksPath", "", &ExitError{Code: 5})
+	client := NewClient(runner)
+	ctx := context.Background()
+
+	value, err := client.ConfigGet(ctx, "/repo", "core.hooksPath")
+	require.NoError(t, err)
+	assert.Empty(t, value)
+
+	value, err = client.ConfigGet(ctx, "/repo", "extensions.worktreeConfig")
+	require.NoError(t, err)
+	assert.Equal(t, "true", value)
+
+	require.NoError(t, client.WorktreeConfigSet(ctx, "/wt/1", "commit.template", "/repo/.git/worktrees/1/issue-flow/commit-template"))
+	assert.Equal(t, "git config --worktree commit.template /repo/.git/worktrees/1/issue-flow/commit-template", runner.Commands()[2])
+
+	require.NoError(t, client.WorktreeConfigUnset(ctx, "/wt/1", "core.hooksPath"), "an unset key is not an error")
+}
+
+func TestClient_LsFilesSplitsOnNUL(t *testing.T) {
+	runner := NewRecordingRunner().
+		On("ls-files -z --others --directory", "build/\x00my file.txt\x00", nil)
+	client := NewClient(runner)
+
+	files, err := client.LsFiles(context.Background(), "/wt/1", "--others", "--directory")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"build/", "my file.txt"}, files)
+
+	files, err = client.LsFiles(context.Background(), "/wt/1")
+	require.NoError(t, err)
+	assert.Empty(t, files)
+}
+
+func TestClient_DiffStat(t *testing.T) {
+	runner := NewRecordingRunner().
+		On("diff --shortstat main...HEAD", " 3 files changed, 10 insertions(+), 2 deletions(-)\n", nil).
+		On("diff --shortstat main...a1", " 1 file changed, 1 deletion(-)\n", nil)
+	client := NewClient(runner)
+	ctx := context.Background()
+
+	stat, err := client.DiffStat(ctx, "/wt/1", "main...HEAD")
+	require.NoError(t, err)
+	assert.Equal(t, DiffStat{Files: 3, Insertions: 10, Deletions: 2}, stat)
+
+	stat, err = client.DiffStat(ctx, "/wt/1", "main...a1")
+	require.NoError(t, err)
+	assert.Equal(t, DiffStat{Files: 1, Deletions: 1}, stat)
+
+	stat, err = client.DiffStat(ctx, "/wt/1", "main...main")
+	require.NoError(t, err)
+	assert.Equal(t, DiffStat{}, stat, "an empty diff prints nothing")
+}
+
+func TestClient_WorktreeAddRequiresPath(t *testing.T) {
+	runner := NewRecordingRunner()
+	err := NewClient(runner).WorktreeAdd(context.Background(), "/repo", WorktreeAddOptions{Branch: "x"})
+	assert.Error(t, err)
+	assert.Empty(t, runner.Calls())
+}
+
+func TestClient_BranchExistsUsesExitCode(t *testing.T) {
+	runner := NewRecordingRunner().
+		On("show-ref --verify --quiet refs/heads/missing", "", &ExitError{Code: 1}).
+		On("show-ref --verify --quiet refs/heads/broken", "", &ExitError{Code: 128})
+	client := NewClient(runner)
+	ctx := context.Background()
+
+	exists, err := client.BranchExists(ctx, "/repo", "main")
+	require.NoError(t, err)
+	assert.True(t, exists)
+
+	exists, err = client.BranchExists(ctx, "/repo", "missing")
+	require.NoError(t, err)
+	assert.False(t, exists)
+
+	_, err = client.BranchExists(ctx, "/repo", "broken")
+	assert.Error(t, err)
+}
+
+func TestParseWorktreeList(t *testing.T) {
+	out := "worktree /repo\nHEAD 1111\nbranch refs/heads/main\n\n" +
+		"worktree /wt/123\nHEAD 2222\nbranch refs/heads/feature/123-x\nlocked\n\n" +
+		"worktree /wt/detached\nHEAD 3333\ndetached\nprunable gitdir file points to non-existent location\n\n"
+
+	worktrees := parseWorktreeList(out)
+	require.Len(t, worktrees, 3)
+	assert.Equal(t, Worktree{Path: "/repo", Head: "1111", Branch: "main"}, worktrees[0])
+	assert.Equal(t, Worktree{Path: "/wt/123", Head: "2222", Branch: "feature/123-x", Locked: true}, worktrees[1])
+	assert.Equal(t, Worktree{Path: "/wt/detached", Head: "3333", Detached: true, Prunable: true}, worktrees[2])
+}
+
+func TestParseStatus(t *testing.T) {
+	out := "# branch.oid abc123\n" +
+		"# branch.head feature/1-x\n" +
+		"# branch.upstream origin/feature/1-x\n" +
+		"# branch.ab +2 -3\n" +
+		"1 M. N... 100644 100644 100644 aaa bbb staged.go\n" +
+		"1 .M N... 100644 100644 100644 aaa bbb modified.go\n" +
+		"2 R. N... 100644 100644 100644 aaa bbb R100 new name.go\told.go\n" +
+		"u UU N... 100644 100644 100644 100644 aaa bbb ccc conflict.go\n" +
+		"? untracked.txt\n"
+
+	s, err := parseStatus(out)
+	require.NoError(t, err)
+	assert.Equal(t, "feature/1-x", s.Branch)
+	assert.Equal(t, "abc123", s.Head)
+	assert.Equal(t, "origin/feature/1-x", s.Upstream)
+	assert.Equal(t, 2, s.Ahead)
+	assert.Equal(t, 3, s.Behind)
+	assert.Equal(t, []string{"staged.go", "new name.go"}, s.Staged)
+	assert.Equal(t, []string{"modified.go"}, s.Unstaged)
+	assert.Equal(t, []string{"conflict.go"}, s.Conflicted)
+	assert.Equal(t, []string{"untracked.txt"}, s.Untracked)
+	assert.True(t, s.Dirty())
+	assert.False(t, s.Clean())
+}
+
+func TestExecRunner_ErrorIncludesStderr(t *testing.T) {
+	_, err := ExecRunner{}.Run(context.Background(), t.TempDir(), "rev-parse", "--verify", "nope")
+	require.Error(t, err)
+
+	var gitErr *Error
+	require.True(t, errors.As(err, &gitErr))
+	assert.Equal(t, []string{"rev-parse", "--verify", "nope"}, gitErr.Args)
+	assert.NotEmpty(t, gitErr.Stderr)
+}
+
+func TestClient_WorktreeLifecycle(t *testing.T) {
+	repo := testutil.NewGitRepo(t)
+	client := NewClient(nil)
+	ctx := context.Background()
+
+	wtPath := filepath.Join(t.TempDir(), "issue-123")
+	err := client.WorktreeAdd(ctx, repo, WorktreeAddOptions{
+		Path:       wtPath,
+		Branch:     "feature/123-test",
+		NewBranch:  true,
+		StartPoint: "main",
+	})
+	require.NoError(t, err)
+
+	exists, err := client.BranchExists(ctx, repo, "feature/123-test")
+	require.NoError(t, err)
+	assert.True(t, exists)
+
+	branch, err := client.CurrentBranch(ctx, wtPath)
+	require.NoError(t, err)
+	assert.Equal(t, "feature/123-test", branch)
+
+	worktrees, err := client.WorktreeList(ctx, repo)
+	require.NoError(t, err)
+	require.Len(t, worktrees, 2)
+	assert.Equal(t, "main", worktrees[0].Branch)
+	assert.Equal(t, "feature/123-test", worktrees[1].Branch)
+
+	branches, err := client.ListBranches(ctx, repo, "feature/")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"feature/123-test"}, branches)
+
+	require.NoError(t, client.WorktreeRemove(ctx, repo, wtPath, false))
+	require.NoError(t, client.DeleteBranch(ctx, repo, "feature/123-test", false))
+
+	worktrees, err = client.WorktreeList(ctx, repo)
+	require.NoError(t, err)
+	assert.Len(t, worktrees, 1)
+}
+
+func TestClient_WorktreePrune(t *testing.T) {
+	repo := testutil.NewGitRepo(t)
+	client := NewClient(nil)
+	ctx := context.Background()
+
+	wtPath := filepath.Join(t.TempDir(), "gone")
+	require.NoError(t, client.WorktreeAdd(ctx, repo, WorktreeAddOptions{Path: wtPath, Branch: "gone", NewBranch: true}))
+	require.NoError(t, os.RemoveAll(wtPath))
+
+	worktrees, err := client.WorktreeList(ctx, repo)
+	require.NoError(t, err)
+	require.Len(t, worktrees, 2)
+	assert.True(t, worktrees[1].Prunable)
+
+	require.NoError(t, client.WorktreePrune(ctx, repo))
+
+	worktrees, err = client.WorktreeList(ctx, repo)
+	require.NoError(t, err)
+	assert.Len(t, worktrees, 1)
+}
+
+func TestClient_StatusAndRevList(t *testing.T) {
+	repo := testutil.NewGitRepo(t)
+	client := NewClient(nil)
+	ctx := context.Background()
+
+	s, err := client.Status(ctx, repo)
+	require.NoError(t, err)
+	assert.True(t, s.Clean())
+	assert.Equal(t, "main", s.Branch)
+
+	require.NoError(t, client.CreateBranch(ctx, repo, "base", ""))
+	testutil.GitCommitFile(t, repo, "a.txt", "a\n", "Add a")
+	testutil.GitCommitFile(t, repo, "b.txt", "b\n", "Add b")
+
+	require.NoError(t, os.WriteFile(filepath.Join(repo, "a.txt"), []byte("changed\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(repo, "new.txt"), []byte("new\n"), 0644))
+
+	s, err = client.Status(ctx, repo)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a.txt"}, s.Unstaged)
+	assert.Equal(t, []string{"new.txt"}, s.Untracked)
+	assert.True(t, s.Dirty())
+
+	commits, err := client.RevList(ctx, repo, "base..main")
+	require.NoError(t, err)
+	assert.Len(t, commits, 2)
+
+	ahead, behind, err := client.AheadBehind(ctx, repo, "main", "base")
+	require.NoError(t, err)
+	assert.Equal(t, 2, ahead)
+	assert.Equal(t, 0, behind)
+}
+
+func TestClient_RemotesAndFetch(t *testing.T) {
+	upstream := testutil.NewGitRepo(t)
+	repo := testutil.NewGitRepo(t)
+	client := NewClient(nil)
+	ctx := context.Background()
+
+	require.NoError(t, client.AddRemote(ctx, repo, "origin", upstream))
+
+	remotes, err := client.Remotes(ctx, repo)
+	require.NoError(t, err)
+	require.Len(t, remotes, 1)
+	assert.Equal(t, Remote{Name: "origin", FetchURL: upstream, PushURL: upstream}, remotes[0])
+
+	url, err := client.RemoteURL(ctx, repo, "origin")
+	require.NoError(t, err)
+	assert.Equal(t, upstream, url)
+
+	require.NoError(t, client.Fetch(ctx, repo, "origin"))
+	commits, err := client.RevList(ctx, repo, "origin/main")
+	require.NoError(t, err)
+	assert.Len(t, commits, 1)
+}
@@ -0,0 +1,58 @@
+package git
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// Runner executes a git command in a directory and returns its stdout.
+type Runner interface {
+	Run(ctx context.Context, dir string, args ...string) (string, error)
+}
+
+// ExecRunner runs git by shelling out to the git binary on PATH.
+type ExecRunner struct {
+	// Binary overrides the git executable. Defaults to "git".
+	Binary string
+}
+
+// Error describes a failed git invocation, including git's stderr.
+type Error struct {
+	Args   []string
+	Stderr string
+	Err    error
+}
+
+func (e *Error) Error() string {
+	msg := strings.TrimSpace(e.Stderr)
+	if msg == "" {
+		msg = e.Err.Error()
+	}
+	return fmt.Sprintf("git %s: %s", strings.Join(e.Args, " "), msg)
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+func (r ExecRunner) Run(ctx context.Context, dir string, args ...string) (string, error) {
+	binary := r.Binary
+	if binary == "" {
+		binary = "git"
+	}
+
+	cmd := exec.CommandContext(ctx, binary, args...)
+	cmd.Dir = dir
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return stdout.String(), &Error{Args: args, Stderr: stderr.String(), Err: err}
+	}
+	return stdout.String(), nil
+}
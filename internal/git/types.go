@@ -0,0 +1,86 @@
+package git
+
+// Worktree is a single entry from `git worktree list --porcelain`.
+type Worktree struct {
+	Path     string
+	Head     string
+	Branch   string
+	Bare     bool
+	Detached bool
+	Locked   bool
+	Prunable bool
+}
+
+// Status is the parsed output of `git status --porcelain=v2 --branch`.
+type Status struct {
+	Branch     string
+	Head       string
+	Upstream   string
+	Ahead      int
+	Behind     int
+	Staged     []string
+	Unstaged   []string
+	Untracked  []string
+	Conflicted []string
+}
+
+// Clean reports whether the working tree has no changes of any kind.
+func (s *Status) Clean() bool {
+	return len(s.Staged) == 0 && len(s.Unstaged) == 0 && len(s.Untracked) == 0 && len(s.Conflicted) == 0
+}
+
+// Dirty reports whether tracked files have staged, unstaged or conflicting changes.
+func (s *Status) Dirty() bool {
+	return len(s.Staged) > 0 || len(s.Unstaged) > 0 || len(s.Conflicted) > 0
+}
+
+// Remote is a configured git remote.
+type Remote struct {
+	Name     string
+	FetchURL string
+	PushURL  string
+}
+
+// WorktreeAddOptions controls how `git worktree add` is invoked.
+type WorktreeAddOptions struct {
+	Path string
+	// Branch is checked out in the new worktree.
+	Branch string
+	// NewBranch creates Branch from StartPoint instead of checking out an existing one.
+	NewBranch  bool
+	StartPoint string
+	// NoTrack stops git from setting StartPoint as the new branch's upstream.
+	NoTrack bool
+	// NoCheckout creates the worktree without populating it, e.g. so a
+	// sparse-checkout can be configured before the first checkout.
+	NoCheckout bool
+	Force      bool
+}
+
+// Commit is a commit hash with its subject line.
+type Commit struct {
+	Hash    string
+	Subject string
+}
+
+// Short returns the abbreviated commit hash.
+func (c Commit) Short() string {
+	if len(c.Hash) > 7 {
+		return c.Hash[:7]
+	}
+	return c.Hash
+}
+
+// Stash is an entry from `git stash list`.
+type Stash struct {
+	Ref     string
+	Branch  string
+	Message string
+}
+
+// DiffStat summarizes a diff as `git diff --shortstat` does.
+type DiffStat struct {
+	Files      int
+	Insertions int
+	Deletions  int
+}
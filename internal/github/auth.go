@@ -0,0 +1,65 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/paolorechia/issue-flow/internal/config"
+)
+
+// Auth methods of the github.auth_method setting.
+const (
+	AuthGHCLI = "gh_cli"
+	AuthToken = "token"
+)
+
+// Token returns the API token for cfg: the output of 'gh auth token' for
+// gh_cli (the default), for the host of github.base_url when it is set, or
+// github.token, falling back to $GITHUB_TOKEN, for token.
+func Token(ctx context.Context, cfg config.GitHubConfig) (string, error) {
+	switch cfg.AuthMethod {
+	case "", AuthGHCLI:
+		args := []string{"auth", "token"}
+		if cfg.BaseURL != "" {
+			u, err := url.Parse(cfg.BaseURL)
+			if err != nil || u.Host == "" {
+				return "", fmt.Errorf("invalid github.base_url %q", cfg.BaseURL)
+			}
+			args = append(args, "--hostname", u.Host)
+		}
+		out, err := exec.CommandContext(ctx, "gh", args...).Output()
+		if err != nil {
+			return "", fmt.Errorf("failed to get a token from gh, run 'gh auth login': %w", err)
+		}
+		return strings.TrimSpace(string(out)), nil
+	case AuthToken:
+		token := cfg.Token
+		if token == "" {
+			token = os.Getenv("GITHUB_TOKEN")
+		}
+		if token == "" {
+			return "", fmt.Errorf("github.auth_method is token but neither github.token nor GITHUB_TOKEN is set")
+		}
+		return token, nil
+	default:
+		return "", fmt.Errorf("unsupported github.auth_method %q, use %s or %s", cfg.AuthMethod, AuthGHCLI, AuthToken)
+	}
+}
+
+// NewClient returns a REST client authenticated as configured in cfg, for
+// github.base_url when it is set.
+func NewClient(ctx context.Context, cfg config.GitHubConfig) (Client, error) {
+	token, err := Token(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+	client := NewRESTClient(token)
+	if cfg.BaseURL != "" {
+		client.BaseURL = cfg.BaseURL
+	}
+	return client, nil
+}
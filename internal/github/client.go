@@ -0,0 +1,56 @@
+package github
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// Client is the part of the GitHub API issue-flow uses. RESTClient
+// implements it against api.github.com or GitHub Enterprise.
+type Client interface {
+	GetIssue(ctx context.Context, repo Repo, number int) (*Issue, error)
+	ListIssues(ctx context.Context, repo Repo, opts ListIssuesOptions) ([]Issue, error)
+	CreateIssue(ctx context.Context, repo Repo, req IssueRequest) (*Issue, error)
+	UpdateIssue(ctx context.Context, repo Repo, number int, req IssueRequest) (*Issue, error)
+
+	ListLabels(ctx context.Context, repo Repo) ([]Label, error)
+	CreateLabel(ctx context.Context, repo Repo, label Label) (*Label, error)
+	AddLabels(ctx context.Context, repo Repo, number int, labels []string) ([]Label, error)
+	RemoveLabel(ctx context.Context, repo Repo, number int, label string) error
+
+	ListMilestones(ctx context.Context, repo Repo, state string) ([]Milestone, error)
+	CreateMilestone(ctx context.Context, repo Repo, req MilestoneRequest) (*Milestone, error)
+
+	ListComments(ctx context.Context, repo Repo, number int) ([]Comment, error)
+	CreateComment(ctx context.Context, repo Repo, number int, body string) (*Comment, error)
+
+	GetPullRequest(ctx context.Context, repo Repo, number int) (*PullRequest, error)
+	ListPullRequests(ctx context.Context, repo Repo, opts ListPullRequestsOptions) ([]PullRequest, error)
+	CreatePullRequest(ctx context.Context, repo Repo, req PullRequestRequest) (*PullRequest, error)
+}
+
+// ErrNotFound matches, with errors.Is, an APIError for a missing resource.
+var ErrNotFound = errors.New("not found")
+
+// APIError is a non-2xx response from the API.
+type APIError struct {
+	Method     string
+	Path       string
+	StatusCode int
+	// Message is GitHub's error message, if the response had one.
+	Message string
+}
+
+func (e *APIError) Error() string {
+	msg := e.Message
+	if msg == "" {
+		msg = fmt.Sprintf("status %d", e.StatusCode)
+	}
+	return fmt.Sprintf("github: %s %s: %s", e.Method, e.Path, msg)
+}
+
+// Is makes a 404 match ErrNotFound.
+func (e *APIError) Is(target error) bool {
+	return target == ErrNotFound && e.StatusCode == 404
+}
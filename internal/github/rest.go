@@ -0,0 +1,345 @@
+package github
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultBaseURL is the REST API of github.com.
+const DefaultBaseURL = "https://api.github.com"
+
+// apiVersion is the REST API version requested with every call.
+const apiVersion = "2022-11-28"
+
+// perPage is the page size of list calls; every page is fetched.
+const perPage = 100
+
+// RESTClient implements Client with the GitHub REST API.
+type RESTClient struct {
+	// BaseURL defaults to DefaultBaseURL; GitHub Enterprise uses
+	// https://<host>/api/v3.
+	BaseURL string
+	// Token is sent as a bearer token when set.
+	Token string
+	// HTTP defaults to a client with a 30 second timeout.
+	HTTP *http.Client
+}
+
+var _ Client = (*RESTClient)(nil)
+
+func NewRESTClient(token string) *RESTClient {
+	return &RESTClient{BaseURL: DefaultBaseURL, Token: token, HTTP: &http.Client{Timeout: 30 * time.Second}}
+}
+
+func (c *RESTClient) GetIssue(ctx context.Context, repo Repo, number int) (*Issue, error) {
+	var issue Issue
+	if err := c.do(ctx, http.MethodGet, repoPath(repo, "issues", strconv.Itoa(number)), nil, &issue); err != nil {
+		return nil, err
+	}
+	return &issue, nil
+}
+
+// ListIssues lists issues, leaving out pull requests.
+func (c *RESTClient) ListIssues(ctx context.Context, repo Repo, opts ListIssuesOptions) ([]Issue, error) {
+	query := url.Values{}
+	setQuery(query, "state", opts.State)
+	setQuery(query, "labels", strings.Join(opts.Labels, ","))
+	setQuery(query, "milestone", opts.Milestone)
+	setQuery(query, "assignee", opts.Assignee)
+
+	var all []Issue
+	err := c.list(ctx, repoPath(repo, "issues"), query, func(page []byte) error {
+		var issues []Issue
+		if err := json.Unmarshal(page, &issues); err != nil {
+			return err
+		}
+		for _, issue := range issues {
+			if !issue.IsPullRequest() {
+				all = append(all, issue)
+			}
+		}
+		return nil
+	})
+	return all, err
+}
+
+func (c *RESTClient) CreateIssue(ctx context.Context, repo Repo, req IssueRequest) (*Issue, error) {
+	if req.Title == "" {
+		return nil, fmt.Errorf("github: an issue needs a title")
+	}
+	var issue Issue
+	if err := c.do(ctx, http.MethodPost, repoPath(repo, "issues"), req, &issue); err != nil {
+		return nil, err
+	}
+	return &issue, nil
+}
+
+func (c *RESTClient) UpdateIssue(ctx context.Context, repo Repo, number int, req IssueRequest) (*Issue, error) {
+	var issue Issue
+	if err := c.do(ctx, http.MethodPatch, repoPath(repo, "issues", strconv.Itoa(number)), req, &issue); err != nil {
+		return nil, err
+	}
+	return &issue, nil
+}
+
+func (c *RESTClient) ListLabels(ctx context.Context, repo Repo) ([]Label, error) {
+	var all []Label
+	err := c.list(ctx, repoPath(repo, "labels"), nil, func(page []byte) error {
+		var labels []Label
+		err := json.Unmarshal(page, &labels)
+		all = append(all, labels...)
+		return err
+	})
+	return all, err
+}
+
+func (c *RESTClient) CreateLabel(ctx context.Context, repo Repo, label Label) (*Label, error) {
+	var created Label
+	if err := c.do(ctx, http.MethodPost, repoPath(repo, "labels"), label, &created); err != nil {
+		return nil, err
+	}
+	return &created, nil
+}
+
+// AddLabels adds labels to an issue or pull request and returns all of its
+// labels.
+func (c *RESTClient) AddLabels(ctx context.Context, repo Repo, number int, labels []string) ([]Label, error) {
+	var all []Label
+	body := map[string][]string{"labels": labels}
+	if err := c.do(ctx, http.MethodPost, repoPath(repo, "issues", strconv.Itoa(number), "labels"), body, &all); err != nil {
+		return nil, err
+	}
+	return all, nil
+}
+
+func (c *RESTClient) RemoveLabel(ctx context.Context, repo Repo, number int, label string) error {
+	return c.do(ctx, http.MethodDelete, repoPath(repo, "issues", strconv.Itoa(number), "labels", label), nil, nil)
+}
+
+// ListMilestones lists milestones in state open (the default), closed or
+// all.
+func (c *RESTClient) ListMilestones(ctx context.Context, repo Repo, state string) ([]Milestone, error) {
+	query := url.Values{}
+	setQuery(query, "state", state)
+	var all []Milestone
+	err := c.list(ctx, repoPath(repo, "milestones"), query, func(page []byte) error {
+		var milestones []Milestone
+		err := json.Unmarshal(page, &milestones)
+		all = append(all, milestones...)
+		return err
+	})
+	return all, err
+}
+
+func (c *RESTClient) CreateMilestone(ctx context.Context, repo Repo, req MilestoneRequest) (*Milestone, error) {
+	if req.Title == "" {
+		return nil, fmt.Errorf("github: a milestone needs a title")
+	}
+	var milestone Milestone
+	if err := c.do(ctx, http.MethodPost, repoPath(repo, "milestones"), req, &milestone); err != nil {
+		return nil, err
+	}
+	return &milestone, nil
+}
+
+func (c *RESTClient) ListComments(ctx context.Context, repo Repo, number int) ([]Comment, error) {
+	var all []Comment
+	err := c.list(ctx, repoPath(repo, "issues", strconv.Itoa(number), "comments"), nil, func(page []byte) error {
+		var comments []Comment
+		err := json.Unmarshal(page, &comments)
+		all = append(all, comments...)
+		return err
+	})
+	return all, err
+}
+
+func (c *RESTClient) CreateComment(ctx context.Context, repo Repo, number int, body string) (*Comment, error) {
+	var comment Comment
+	req := map[string]string{"body": body}
+	if err := c.do(ctx, http.MethodPost, repoPath(repo, "issues", strconv.Itoa(number), "comments"), req, &comment); err != nil {
+		return nil, err
+	}
+	return &comment, nil
+}
+
+func (c *RESTClient) GetPullRequest(ctx context.Context, repo Repo, number int) (*PullRequest, error) {
+	var pr PullRequest
+	if err := c.do(ctx, http.MethodGet, repoPath(repo, "pulls", strconv.Itoa(number)), nil, &pr); err != nil {
+		return nil, err
+	}
+	return &pr, nil
+}
+
+func (c *RESTClient) ListPullRequests(ctx context.Context, repo Repo, opts ListPullRequestsOptions) ([]PullRequest, error) {
+	query := url.Values{}
+	setQuery(query, "state", opts.State)
+	setQuery(query, "head", opts.Head)
+	setQuery(query, "base", opts.Base)
+	var all []PullRequest
+	err := c.list(ctx, repoPath(repo, "pulls"), query, func(page []byte) error {
+		var prs []PullRequest
+		err := json.Unmarshal(page, &prs)
+		all = append(all, prs...)
+		return err
+	})
+	return all, err
+}
+
+// CreatePullRequest opens a pull request in repo. For a branch of a fork,
+// req.Head is "<fork owner>:<branch>".
+func (c *RESTClient) CreatePullRequest(ctx context.Context, repo Repo, req PullRequestRequest) (*PullRequest, error) {
+	if req.Title == "" || req.Head == "" || req.Base == "" {
+		return nil, fmt.Errorf("github: a pull request needs a title, head and base")
+	}
+	var pr PullRequest
+	if err := c.do(ctx, http.MethodPost, repoPath(repo, "pulls"), req, &pr); err != nil {
+		return nil, err
+	}
+	return &pr, nil
+}
+
+// list fetches path and every following page named by the Link header,
+// handing each page's body to decode.
+func (c *RESTClient) list(ctx context.Context, path string, query url.Values, decode func([]byte) error) error {
+	if query == nil {
+		query = url.Values{}
+	}
+	query.Set("per_page", strconv.Itoa(perPage))
+	next := path + "?" + query.Encode()
+	for next != "" {
+		resp, body, err := c.send(ctx, http.MethodGet, next, nil)
+		if err != nil {
+			return err
+		}
+		if err := decode(body); err != nil {
+			return fmt.Errorf("github: failed to decode %s: %w", path, err)
+		}
+		next = nextPage(resp.Header.Get("Link"))
+	}
+	return nil
+}
+
+// do sends a request with an optional JSON body and decodes the JSON
+// response into out, if given.
+func (c *RESTClient) do(ctx context.Context, method, path string, in, out any) error {
+	_, body, err := c.send(ctx, method, path, in)
+	if err != nil {
+		return err
+	}
+	if out == nil || len(body) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(body, out); err != nil {
+		return fmt.Errorf("github: failed to decode %s %s: %w", method, path, err)
+	}
+	return nil
+}
+
+// send performs one request. target is a path below BaseURL or, for
+// pagination links, an absolute URL, which has to be on BaseURL's scheme
+// and host so the token is never sent elsewhere.
+func (c *RESTClient) send(ctx context.Context, method, target string, in any) (*http.Response, []byte, error) {
+	base := c.BaseURL
+	if base == "" {
+		base = DefaultBaseURL
+	}
+	u := target
+	if !strings.HasPrefix(target, "http://") && !strings.HasPrefix(target, "https://") {
+		u = strings.TrimSuffix(base, "/") + target
+	} else if !sameOrigin(base, target) {
+		return nil, nil, fmt.Errorf("github: refusing to follow %s, which is not on %s", target, base)
+	}
+
+	var reader io.Reader
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return nil, nil, fmt.Errorf("github: failed to encode request: %w", err)
+		}
+		reader = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u, reader)
+	if err != nil {
+		return nil, nil, fmt.Errorf("github: %w", err)
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("X-GitHub-Api-Version", apiVersion)
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.Token)
+	}
+
+	httpClient := c.HTTP
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, nil, fmt.Errorf("github: %s %s: %w", method, target, err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, nil, fmt.Errorf("github: failed to read response of %s %s: %w", method, target, err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		apiErr := &APIError{Method: method, Path: req.URL.Path, StatusCode: resp.StatusCode}
+		var msg struct {
+			Message string `json:"message"`
+		}
+		if json.Unmarshal(body, &msg) == nil {
+			apiErr.Message = msg.Message
+		}
+		return nil, nil, apiErr
+	}
+	return resp, body, nil
+}
+
+func repoPath(repo Repo, parts ...string) string {
+	path := "/repos/" + url.PathEscape(repo.Owner) + "/" + url.PathEscape(repo.Name)
+	for _, p := range parts {
+		path += "/" + url.PathEscape(p)
+	}
+	return path
+}
+
+func setQuery(query url.Values, key, value string) {
+	if value != "" {
+		query.Set(key, value)
+	}
+}
+
+// sameOrigin reports whether a and b have the same scheme and host.
+func sameOrigin(a, b string) bool {
+	ua, err := url.Parse(a)
+	if err != nil {
+		return false
+	}
+	ub, err := url.Parse(b)
+	if err != nil {
+		return false
+	}
+	return strings.EqualFold(ua.Scheme, ub.Scheme) && strings.EqualFold(ua.Host, ub.Host)
+}
+
+// nextPage returns the rel="next" URL of a Link header, or "".
+func nextPage(link string) string {
+	for _, part := range strings.Split(link, ",") {
+		target, params, ok := strings.Cut(strings.TrimSpace(part), ";")
+		if !ok || !strings.Contains(params, `rel="next"`) {
+			continue
+		}
+		return strings.Trim(strings.TrimSpace(target), "<>")
+	}
+	return ""
+}
@@ -0,0 +1,203 @@
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/paolorechia/issue-flow/internal/config"
+	"github.com/paolorechia/issue-flow/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var widgets = Repo{Owner: "acme", Name: "widgets"}
+
+func newTestClient(t *testing.T, fixtures ...string) (*RESTClient, *testutil.FakeGitHub) {
+	t.Helper()
+	fake := testutil.NewFakeGitHub(t)
+	for _, name := range fixtures {
+		fake.LoadFixtures(name)
+	}
+	client := NewRESTClient("test-token")
+	client.BaseURL = fake.URL
+	return client, fake
+}
+
+func TestRESTClient_GetIssue(t *testing.T) {
+	client, fake := newTestClient(t, "issues")
+
+	issue, err := client.GetIssue(context.Background(), widgets, 12)
+	require.NoError(t, err)
+	assert.Equal(t, "Login fails with SSO", issue.Title)
+	assert.Equal(t, []string{"bug", "priority:high"}, issue.LabelNames())
+	require.NotNil(t, issue.Milestone)
+	assert.Equal(t, "v1.2", issue.Milestone.Title)
+	assert.False(t, issue.IsPullRequest())
+
+	req := fake.LastRequest()
+	assert.Equal(t, "GET", req.Method)
+	assert.Equal(t, "/repos/acme/widgets/issues/12", req.Path)
+	assert.Equal(t, "Bearer test-token", req.Authorization)
+}
+
+func TestRESTClient_ListIssuesFollowsPagesAndSkipsPullRequests(t *testing.T) {
+	client, fake := newTestClient(t, "issues")
+
+	issues, err := client.ListIssues(context.Background(), widgets, ListIssuesOptions{State: StateOpen})
+	require.NoError(t, err)
+	var numbers []int
+	for _, issue := range issues {
+		numbers = append(numbers, issue.Number)
+	}
+	assert.Equal(t, []int{12, 9}, numbers)
+	assert.Len(t, fake.Requests(), 2)
+}
+
+func TestRESTClient_ListRefusesPagesOnOtherHosts(t *testing.T) {
+	client, fake := newTestClient(t)
+	fake.Add(testutil.GitHubFixture{
+		Method:  "GET",
+		Path:    "/repos/acme/widgets/labels",
+		Body:    json.RawMessage(`[{"name": "bug"}]`),
+		Headers: map[string]string{"Link": `<https://elsewhere.example/repos/acme/widgets/labels?page=2>; rel="next"`},
+	})
+
+	_, err := client.ListLabels(context.Background(), widgets)
+	assert.ErrorContains(t, err, "refusing to follow https://elsewhere.example/")
+	assert.Len(t, fake.Requests(), 1)
+}
+
+func TestRESTClient_NotFound(t *testing.T) {
+	client, _ := newTestClient(t)
+
+	_, err := client.GetIssue(context.Background(), widgets, 404)
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, ErrNotFound))
+	var apiErr *APIError
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, "Not Found", apiErr.Message)
+	assert.Contains(t, err.Error(), "GET /repos/acme/widgets/issues/404")
+}
+
+func TestRESTClient_APIErrorMessage(t *testing.T) {
+	client, fake := newTestClient(t)
+	fake.On("POST", "/repos/acme/widgets/labels", 422, `{"message":"Validation Failed"}`)
+
+	_, err := client.CreateLabel(context.Background(), widgets, Label{Name: "bug"})
+	require.Error(t, err)
+	assert.False(t, errors.Is(err, ErrNotFound))
+	assert.Contains(t, err.Error(), "Validation Failed")
+}
+
+func TestRESTClient_CreatePullRequest(t *testing.T) {
+	client, fake := newTestClient(t, "pulls")
+
+	pr, err := client.CreatePullRequest(context.Background(), widgets, PullRequestRequest{
+		Title: "Add dark mode",
+		Body:  "Closes #9",
+		Head:  "octocat:feature/9-dark-mode",
+		Base:  "main",
+		Draft: true,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 15, pr.Number)
+	assert.False(t, pr.Merged())
+
+	var sent map[string]any
+	require.NoError(t, json.Unmarshal([]byte(fake.LastRequest().Body), &sent))
+	assert.Equal(t, map[string]any{
+		"title": "Add dark mode",
+		"body":  "Closes #9",
+		"head":  "octocat:feature/9-dark-mode",
+		"base":  "main",
+		"draft": true,
+	}, sent)
+
+	_, err = client.CreatePullRequest(context.Background(), widgets, PullRequestRequest{Title: "No head"})
+	assert.Error(t, err)
+}
+
+func TestRESTClient_GetPullRequest(t *testing.T) {
+	client, _ := newTestClient(t, "pulls")
+
+	pr, err := client.GetPullRequest(context.Background(), widgets, 14)
+	require.NoError(t, err)
+	assert.True(t, pr.Merged())
+	assert.Equal(t, "fix/12-login-fails", pr.Head.Ref)
+	assert.Equal(t, "main", pr.Base.Ref)
+}
+
+func TestRESTClient_LabelsMilestonesAndComments(t *testing.T) {
+	client, fake := newTestClient(t, "issues")
+	ctx := context.Background()
+
+	labels, err := client.ListLabels(ctx, widgets)
+	require.NoError(t, err)
+	assert.Len(t, labels, 2)
+
+	milestones, err := client.ListMilestones(ctx, widgets, "")
+	require.NoError(t, err)
+	require.Len(t, milestones, 1)
+	require.NotNil(t, milestones[0].DueOn)
+
+	comment, err := client.CreateComment(ctx, widgets, 12, "Working on this in fix/12-login-fails")
+	require.NoError(t, err)
+	assert.Equal(t, int64(1001), comment.ID)
+	assert.JSONEq(t, `{"body":"Working on this in fix/12-login-fails"}`, fake.LastRequest().Body)
+
+	fake.On("DELETE", "/repos/acme/widgets/issues/12/labels/priority:high", 200, `[]`)
+	require.NoError(t, client.RemoveLabel(ctx, widgets, 12, "priority:high"))
+}
+
+func TestRESTClient_UpdateIssueSendsOnlySetFields(t *testing.T) {
+	client, fake := newTestClient(t)
+	fake.On("PATCH", "/repos/acme/widgets/issues/12", 200, `{"number":12,"state":"closed"}`)
+
+	issue, err := client.UpdateIssue(context.Background(), widgets, 12, IssueRequest{State: StateClosed})
+	require.NoError(t, err)
+	assert.Equal(t, StateClosed, issue.State)
+	assert.JSONEq(t, `{"state":"closed"}`, fake.LastRequest().Body)
+}
+
+func TestNextPage(t *testing.T) {
+	link := `<https://api.github.com/x?page=1>; rel="prev", <https://api.github.com/x?page=3>; rel="next"`
+	assert.Equal(t, "https://api.github.com/x?page=3", nextPage(link))
+	assert.Equal(t, "", nextPage(`<https://api.github.com/x?page=1>; rel="prev"`))
+	assert.Equal(t, "", nextPage(""))
+}
+
+func TestNewClientUsesBaseURL(t *testing.T) {
+	fake := testutil.NewFakeGitHub(t).LoadFixtures("issues")
+	client, err := NewClient(context.Background(), config.GitHubConfig{AuthMethod: AuthToken, Token: "ghe-token", BaseURL: fake.URL})
+	require.NoError(t, err)
+
+	_, err = client.GetIssue(context.Background(), widgets, 12)
+	require.NoError(t, err)
+	assert.Equal(t, "Bearer ghe-token", fake.LastRequest().Authorization)
+
+	client, err = NewClient(context.Background(), config.GitHubConfig{AuthMethod: AuthToken, Token: "token"})
+	require.NoError(t, err)
+	assert.Equal(t, DefaultBaseURL, client.(*RESTClient).BaseURL)
+}
+
+func TestToken(t *testing.T) {
+	ctx := context.Background()
+
+	token, err := Token(ctx, config.GitHubConfig{AuthMethod: AuthToken, Token: "from-config"})
+	require.NoError(t, err)
+	assert.Equal(t, "from-config", token)
+
+	t.Setenv("GITHUB_TOKEN", "from-env")
+	token, err = Token(ctx, config.GitHubConfig{AuthMethod: AuthToken})
+	require.NoError(t, err)
+	assert.Equal(t, "from-env", token)
+
+	t.Setenv("GITHUB_TOKEN", "")
+	_, err = Token(ctx, config.GitHubConfig{AuthMethod: AuthToken})
+	assert.Error(t, err)
+
+	_, err = Token(ctx, config.GitHubConfig{AuthMethod: "oauth"})
+	assert.Error(t, err)
+}
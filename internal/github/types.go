@@ -0,0 +1,155 @@
+package github
+
+import "time"
+
+// Repo names a GitHub repository.
+type Repo struct {
+	Owner string
+	Name  string
+}
+
+// String returns the repository as owner/name.
+func (r Repo) String() string {
+	return r.Owner + "/" + r.Name
+}
+
+// Issue and pull request states.
+const (
+	StateOpen   = "open"
+	StateClosed = "closed"
+	StateAll    = "all"
+)
+
+type User struct {
+	Login string `json:"login"`
+}
+
+type Label struct {
+	Name        string `json:"name"`
+	Color       string `json:"color,omitempty"`
+	Description string `json:"description,omitempty"`
+}
+
+type Milestone struct {
+	Number      int        `json:"number"`
+	Title       string     `json:"title"`
+	Description string     `json:"description"`
+	State       string     `json:"state"`
+	DueOn       *time.Time `json:"due_on"`
+}
+
+type Issue struct {
+	Number    int        `json:"number"`
+	Title     string     `json:"title"`
+	Body      string     `json:"body"`
+	State     string     `json:"state"`
+	User      User       `json:"user"`
+	Labels    []Label    `json:"labels"`
+	Assignees []User     `json:"assignees"`
+	Milestone *Milestone `json:"milestone"`
+	HTMLURL   string     `json:"html_url"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+	ClosedAt  *time.Time `json:"closed_at"`
+	// PullRequest is set when the issue is a pull request; the issues
+	// endpoints list both.
+	PullRequest *struct {
+		URL string `json:"url"`
+	} `json:"pull_request,omitempty"`
+}
+
+// IsPullRequest reports whether the issue is a pull request.
+func (i Issue) IsPullRequest() bool {
+	return i.PullRequest != nil
+}
+
+// LabelNames returns the names of the issue's labels.
+func (i Issue) LabelNames() []string {
+	names := make([]string, len(i.Labels))
+	for n, l := range i.Labels {
+		names[n] = l.Name
+	}
+	return names
+}
+
+type Comment struct {
+	ID        int64     `json:"id"`
+	Body      string    `json:"body"`
+	User      User      `json:"user"`
+	HTMLURL   string    `json:"html_url"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Branch is the head or base of a pull request.
+type Branch struct {
+	// Label is "owner:ref".
+	Label string `json:"label"`
+	Ref   string `json:"ref"`
+	SHA   string `json:"sha"`
+}
+
+type PullRequest struct {
+	Number    int        `json:"number"`
+	Title     string     `json:"title"`
+	Body      string     `json:"body"`
+	State     string     `json:"state"`
+	Draft     bool       `json:"draft"`
+	User      User       `json:"user"`
+	Head      Branch     `json:"head"`
+	Base      Branch     `json:"base"`
+	HTMLURL   string     `json:"html_url"`
+	MergedAt  *time.Time `json:"merged_at"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+}
+
+// Merged reports whether the pull request was merged.
+func (pr PullRequest) Merged() bool {
+	return pr.MergedAt != nil
+}
+
+// IssueRequest creates or edits an issue. Nil and empty fields are left
+// unchanged when editing.
+type IssueRequest struct {
+	Title     string   `json:"title,omitempty"`
+	Body      *string  `json:"body,omitempty"`
+	State     string   `json:"state,omitempty"`
+	Labels    []string `json:"labels,omitempty"`
+	Assignees []string `json:"assignees,omitempty"`
+	Milestone *int     `json:"milestone,omitempty"`
+}
+
+type ListIssuesOptions struct {
+	// State is open (the default), closed or all.
+	State string
+	// Labels only lists issues with all of these labels.
+	Labels []string
+	// Milestone is a milestone number, "none" or "*".
+	Milestone string
+	Assignee  string
+}
+
+type MilestoneRequest struct {
+	Title       string     `json:"title,omitempty"`
+	Description string     `json:"description,omitempty"`
+	State       string     `json:"state,omitempty"`
+	DueOn       *time.Time `json:"due_on,omitempty"`
+}
+
+type PullRequestRequest struct {
+	Title string `json:"title"`
+	Body  string `json:"body,omitempty"`
+	// Head is the branch to merge, "owner:branch" for a branch of a fork.
+	Head string `json:"head"`
+	// Base is the branch of the repository to merge into.
+	Base  string `json:"base"`
+	Draft bool   `json:"draft,omitempty"`
+}
+
+type ListPullRequestsOptions struct {
+	// State is open (the default), closed or all.
+	State string
+	// Head filters by "owner:branch".
+	Head string
+	Base string
+}
@@ -3,7 +3,10 @@ package project
 import (
 	"encoding/json"
 	"fmt"
+	"path/filepath"
+	"strings"
 
+	"github.com/paolorechia/issue-flow/internal/config"
 	"github.com/paolorechia/issue-flow/internal/storage"
 )
 
@@ -16,25 +19,33 @@ func NewManager(db *storage.Database) *Manager {
 }
 
 func (m *Manager) Add(p *Project) error {
-	if p.ID == "" {
-		return fmt.Errorf("project ID is required")
-	}
-	if p.Name == "" {
-		return fmt.Errorf("project name is required")
+	if err := p.Validate(); err != nil {
+		return err
 	}
-	if p.GitHubOwner == "" {
-		return fmt.Errorf("GitHub owner is required")
+
+	sp, err := p.storageProject()
+	if err != nil {
+		return err
 	}
-	if p.GitHubRepo == "" {
-		return fmt.Errorf("GitHub repo is required")
+	return m.db.CreateProject(sp)
+}
+
+// Update saves p over the stored project with the same ID.
+func (m *Manager) Update(p *Project) error {
+	sp, err := p.storageProject()
+	if err != nil {
+		return err
 	}
+	return m.db.UpdateProject(sp)
+}
 
+func (p *Project) storageProject() (*storage.Project, error) {
 	configJSON, err := json.Marshal(p.Config)
 	if err != nil {
-		return fmt.Errorf("failed to marshal config: %w", err)
+		return nil, fmt.Errorf("failed to marshal config: %w", err)
 	}
 
-	sp := &storage.Project{
+	return &storage.Project{
 		ID:          p.ID,
 		Name:        p.Name,
 		GitHubOwner: p.GitHubOwner,
@@ -42,9 +53,7 @@ func (m *Manager) Add(p *Project) error {
 		LocalPath:   p.LocalPath,
 		WorktreeDir: p.WorktreeDir,
 		Config:      string(configJSON),
-	}
-
-	return m.db.CreateProject(sp)
+	}, nil
 }
 
 func (m *Manager) Get(id string) (*Project, error) {
@@ -100,6 +109,53 @@ func (m *Manager) List() ([]Project, error) {
 	return result, nil
 }
 
+// Resolve picks the project a command should act on. An explicit id wins;
+// otherwise the project whose repository, worktree directory or one of whose
+// worktrees contains cwd is used, and finally the only configured project if
+// there is exactly one.
+func (m *Manager) Resolve(id, cwd string) (*Project, error) {
+	if id != "" {
+		return m.Get(id)
+	}
+
+	projects, err := m.List()
+	if err != nil {
+		return nil, err
+	}
+	if len(projects) == 0 {
+		return nil, fmt.Errorf("no projects configured; use 'issue-flow project add' first")
+	}
+
+	if cwd != "" {
+		for i := range projects {
+			if projects[i].Contains(cwd) {
+				return &projects[i], nil
+			}
+		}
+		// Worktrees under the default worktree base are not below any
+		// project directory, so fall back to the recorded worktree paths.
+		worktrees, err := m.db.ListWorktrees()
+		if err != nil {
+			return nil, fmt.Errorf("failed to list worktrees: %w", err)
+		}
+		for _, w := range worktrees {
+			if !pathContains(w.Path, cwd) {
+				continue
+			}
+			for i := range projects {
+				if projects[i].ID == w.ProjectID {
+					return &projects[i], nil
+				}
+			}
+		}
+	}
+
+	if len(projects) == 1 {
+		return &projects[0], nil
+	}
+	return nil, fmt.Errorf("multiple projects configured; specify one with --project")
+}
+
 func (m *Manager) Delete(id string) error {
 	return m.db.DeleteProject(id)
 }
@@ -117,9 +173,73 @@ func (p *Project) Validate() error {
 	if p.GitHubRepo == "" {
 		return fmt.Errorf("GitHub repo is required")
 	}
+	if p.IsFork() && p.Config.Remotes.ForkOwner == "" {
+		return fmt.Errorf("fork owner is required when pushing to remote %s", p.PushRemote())
+	}
 	return nil
 }
 
+// Contains reports whether path lies inside the project's LocalPath or
+// WorktreeDir.
+func (p *Project) Contains(path string) bool {
+	return pathContains(p.LocalPath, path) || pathContains(p.WorktreeDir, path)
+}
+
+// pathContains reports whether path is root or lies below it, after
+// expanding ~ and resolving symlinks in both.
+func pathContains(root, path string) bool {
+	if root == "" || path == "" {
+		return false
+	}
+	rel, err := filepath.Rel(canonicalPath(root), canonicalPath(path))
+	if err != nil {
+		return false
+	}
+	return rel == "." || (rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)))
+}
+
+func canonicalPath(path string) string {
+	path = config.ExpandPath(path)
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		return resolved
+	}
+	return filepath.Clean(path)
+}
+
 func (p *Project) GitHubFullName() string {
 	return p.GitHubOwner + "/" + p.GitHubRepo
 }
+
+// UpstreamRemote returns the remote new branches start from.
+func (p *Project) UpstreamRemote() string {
+	if p.Config.Remotes.Upstream != "" {
+		return p.Config.Remotes.Upstream
+	}
+	return "origin"
+}
+
+// PushRemote returns the remote branches are pushed to.
+func (p *Project) PushRemote() string {
+	if p.Config.Remotes.Fork != "" {
+		return p.Config.Remotes.Fork
+	}
+	return p.UpstreamRemote()
+}
+
+// IsFork reports whether branches are pushed to a fork rather than to the
+// upstream remote.
+func (p *Project) IsFork() bool {
+	return p.PushRemote() != p.UpstreamRemote()
+}
+
+// PullRequestHead returns the head of a pull request for branch against
+// the upstream repository: "<fork_owner>:<branch>" when working from a fork.
+func (p *Project) PullRequestHead(branch string) string {
+	if p.IsFork() {
+		return p.Config.Remotes.ForkOwner + ":" + branch
+	}
+	return branch
+}
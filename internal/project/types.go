@@ -15,9 +15,32 @@ type Project struct {
 }
 
 type ProjectConfig struct {
-	IssueTypes   []IssueType    `json:"issue_types" yaml:"issue_types"`
-	BranchConfig BranchConfig   `json:"branch_config" yaml:"branch_config"`
-	OpenCode     OpenCodeConfig `json:"opencode" yaml:"opencode"`
+	IssueTypes   []IssueType     `json:"issue_types" yaml:"issue_types"`
+	BranchConfig BranchConfig    `json:"branch_config" yaml:"branch_config"`
+	OpenCode     OpenCodeConfig  `json:"opencode" yaml:"opencode"`
+	Sync         SyncConfig      `json:"sync" yaml:"sync"`
+	Bootstrap    BootstrapConfig `json:"bootstrap" yaml:"bootstrap"`
+	// SparsePatterns limits new worktrees to these directories with a
+	// cone-mode sparse-checkout. Empty means a full checkout.
+	SparsePatterns []string     `json:"sparse_patterns" yaml:"sparse_patterns"`
+	Quota          QuotaConfig  `json:"quota" yaml:"quota"`
+	Ports          []PortConfig `json:"ports" yaml:"ports"`
+	// EnvTemplates are rendered into every new worktree.
+	EnvTemplates []EnvTemplate `json:"env_templates" yaml:"env_templates"`
+	// SecretsFile is a dotenv file whose values templates can read as
+	// .Secrets. Defaults to ~/.issue-flow/secrets/<project-id>.env.
+	SecretsFile string `json:"secrets_file" yaml:"secrets_file"`
+	// Test is how `worktree compare` tests each attempt.
+	Test TestConfig `json:"test" yaml:"test"`
+	// Commits configures the commit-msg hook and commit template installed
+	// into each worktree.
+	Commits CommitConfig `json:"commits" yaml:"commits"`
+	// Identity is the git author and signing setup of the project's
+	// worktrees, overriding the user's global git config.
+	Identity IdentityConfig `json:"identity" yaml:"identity"`
+	// Remotes separates the remote branches start from and the one they
+	// are pushed to, for working from a fork.
+	Remotes RemotesConfig `json:"remotes" yaml:"remotes"`
 }
 
 type IssueType struct {
@@ -27,6 +50,28 @@ type IssueType struct {
 	BranchPrefix string   `json:"branch_prefix" yaml:"branch_prefix"`
 	Template     string   `json:"template" yaml:"template"`
 	GuidesDir    string   `json:"guides_dir" yaml:"guides_dir"`
+	// SparsePatterns replaces the project's sparse patterns for this type.
+	SparsePatterns []string `json:"sparse_patterns" yaml:"sparse_patterns"`
+	// BaseBranch picks the branch new worktrees of this type start from
+	// instead of the default branch.
+	BaseBranch BaseBranchRule `json:"base_branch" yaml:"base_branch"`
+	// CommitTypes are the conventional commit types allowed in the subject
+	// of this type's commits, e.g. ["feat", "docs"]. Empty allows any
+	// subject.
+	CommitTypes []string `json:"commit_types" yaml:"commit_types"`
+}
+
+// BaseBranchRule chooses a base branch: a fixed Branch, the latest branch
+// matching Match, or a Prompt. None set means the default branch.
+type BaseBranchRule struct {
+	// Branch is a fixed branch, e.g. "develop".
+	Branch string `json:"branch" yaml:"branch"`
+	// Match picks the latest branch matching a glob, e.g. "release/*",
+	// comparing numbers in the names numerically.
+	Match string `json:"match" yaml:"match"`
+	// Prompt asks for the branch when the worktree is started, listing the
+	// branches matching Match if it is also set.
+	Prompt bool `json:"prompt" yaml:"prompt"`
 }
 
 type BranchConfig struct {
@@ -34,9 +79,135 @@ type BranchConfig struct {
 	MaxSlugLength int    `json:"max_slug_length" yaml:"max_slug_length"`
 }
 
+type CommitConfig struct {
+	// DisableHooks leaves new worktrees without the commit-msg hook and
+	// commit template.
+	DisableHooks bool `json:"disable_hooks" yaml:"disable_hooks"`
+	// Reference is the line the hook adds to every commit message, with
+	// {issue-number} replaced. Defaults to "Refs #{issue-number}".
+	Reference string `json:"reference" yaml:"reference"`
+}
+
+// Signing formats, as git's gpg.format.
+const (
+	SigningOpenPGP = "openpgp"
+	SigningSSH     = "ssh"
+	SigningX509    = "x509"
+)
+
+// IdentityConfig is a git identity profile. Empty fields are left to the
+// user's git config.
+type IdentityConfig struct {
+	Name  string `json:"name" yaml:"name"`
+	Email string `json:"email" yaml:"email"`
+	// SigningKey is a GPG key ID or, with SigningFormat "ssh", a public key
+	// file or "key::" literal. Setting it signs every commit.
+	SigningKey string `json:"signing_key" yaml:"signing_key"`
+	// SigningFormat is "openpgp" (git's default), "ssh" or "x509".
+	SigningFormat string `json:"signing_format" yaml:"signing_format"`
+}
+
+// RemotesConfig names the git remotes of a fork-based workflow. The
+// project's GitHubOwner/GitHubRepo is the upstream repository pull requests
+// target.
+type RemotesConfig struct {
+	// Upstream is the remote new branches start from and are synced with.
+	// Defaults to "origin".
+	Upstream string `json:"upstream" yaml:"upstream"`
+	// Fork is the remote branches are pushed to. Defaults to Upstream.
+	Fork string `json:"fork" yaml:"fork"`
+	// ForkOwner is the GitHub owner of the fork; pull request heads are
+	// "<fork_owner>:<branch>". Required when Fork differs from Upstream.
+	ForkOwner string `json:"fork_owner" yaml:"fork_owner"`
+}
+
 type OpenCodeConfig struct {
 	Enabled         bool   `json:"enabled" yaml:"enabled"`
 	AutoLaunch      bool   `json:"auto_launch" yaml:"auto_launch"`
 	ContextFile     string `json:"context_file" yaml:"context_file"`
 	ContextTemplate string `json:"context_template" yaml:"context_template"`
 }
+
+// Sync strategies.
+const (
+	SyncRebase = "rebase"
+	SyncMerge  = "merge"
+)
+
+type SyncConfig struct {
+	// Strategy is "rebase" (default) or "merge".
+	Strategy string `json:"strategy" yaml:"strategy"`
+}
+
+type QuotaConfig struct {
+	// MaxSize caps the disk usage of all of the project's worktrees, e.g.
+	// "50GB". Empty means no quota.
+	MaxSize string `json:"max_size" yaml:"max_size"`
+}
+
+// PortConfig names a port every worktree of the project gets its own
+// instance of.
+type PortConfig struct {
+	Name string `json:"name" yaml:"name"`
+	// Range is the inclusive range to allocate from, e.g. "3000-3099".
+	Range string `json:"range" yaml:"range"`
+	// Env is the environment variable the port is exported as. Defaults to
+	// the upper-cased name followed by _PORT.
+	Env string `json:"env" yaml:"env"`
+}
+
+type TestConfig struct {
+	// Command is a shell command run in the worktree, e.g. "go test ./...".
+	Command string `json:"command" yaml:"command"`
+	// Timeout bounds one run, e.g. "15m".
+	Timeout string `json:"timeout" yaml:"timeout"`
+}
+
+// EnvTemplate is a text/template rendered into a file in each worktree.
+type EnvTemplate struct {
+	// Source is the template file, relative to the project's local path.
+	Source string `json:"source" yaml:"source"`
+	// Content is an inline template, used when Source is empty.
+	Content string `json:"content" yaml:"content"`
+	// Path is the rendered file, relative to the worktree, e.g. ".env.local".
+	Path string `json:"path" yaml:"path"`
+}
+
+// Bootstrap file modes.
+const (
+	BootstrapCopy    = "copy"
+	BootstrapSymlink = "symlink"
+)
+
+// BootstrapConfig describes how to prepare a freshly created worktree.
+// Files are placed first, then commands run in order inside the worktree.
+type BootstrapConfig struct {
+	Files    []BootstrapFile    `json:"files" yaml:"files"`
+	Commands []BootstrapCommand `json:"commands" yaml:"commands"`
+	// Timeout is the default per-step timeout, e.g. "10m".
+	Timeout string `json:"timeout" yaml:"timeout"`
+}
+
+// BootstrapFile is a file or directory taken from the project's LocalPath.
+type BootstrapFile struct {
+	// Path is relative to LocalPath and to the worktree.
+	Path string `json:"path" yaml:"path"`
+	// Mode is "copy" (default) or "symlink".
+	Mode string `json:"mode" yaml:"mode"`
+	// Optional skips the step when the source does not exist.
+	Optional bool   `json:"optional" yaml:"optional"`
+	Timeout  string `json:"timeout" yaml:"timeout"`
+}
+
+// BootstrapCommand is a shell command run inside the worktree.
+type BootstrapCommand struct {
+	Run string `json:"run" yaml:"run"`
+	// Dir is relative to the worktree root.
+	Dir     string `json:"dir" yaml:"dir"`
+	Timeout string `json:"timeout" yaml:"timeout"`
+}
+
+// Empty reports whether no bootstrap steps are configured.
+func (c BootstrapConfig) Empty() bool {
+	return len(c.Files) == 0 && len(c.Commands) == 0
+}
@@ -2,16 +2,27 @@ package storage
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
 
-	_ "github.com/mattn/go-sqlite3"
+	"github.com/mattn/go-sqlite3"
 )
 
+// querier is satisfied by both *sql.DB and *sql.Tx so every Database method
+// can run inside or outside a transaction.
+type querier interface {
+	Exec(query string, args ...any) (sql.Result, error)
+	Query(query string, args ...any) (*sql.Rows, error)
+	QueryRow(query string, args ...any) *sql.Row
+}
+
 type Database struct {
-	db *sql.DB
+	conn *sql.DB
+	db   querier
 }
 
 type Project struct {
@@ -34,8 +45,90 @@ type Worktree struct {
 	Branch      string    `db:"branch"`
 	Status      string    `db:"status"`
 	CreatedAt   time.Time `db:"created_at"`
+	// BootstrapStatus is empty until the project's bootstrap steps have run.
+	BootstrapStatus string `db:"bootstrap_status"`
+	BootstrapError  string `db:"bootstrap_error"`
+	// SparsePatterns are the cone-mode sparse-checkout directories; empty
+	// means a full checkout. Stored newline-separated.
+	SparsePatterns []string `db:"sparse_patterns"`
+	// ParkedRemote is the remote a parked snapshot was pushed to, if any.
+	ParkedRemote string `db:"parked_remote"`
+	// ParkedSnapshot is the stash commit a parked worktree's changes were
+	// saved as; empty when it was parked without changes.
+	ParkedSnapshot string `db:"parked_snapshot"`
+	// Attempt names one of several competing worktrees for the same issue.
+	// It is empty for the issue's primary worktree.
+	Attempt string `db:"attempt"`
+	// ArchivePath is the directory holding an archived worktree's bundle.
+	ArchivePath string `db:"archive_path"`
+	// ParentID is the worktree this one is stacked on, if any.
+	ParentID string `db:"parent_id"`
+	// ParentBase is the commit of the parent's branch this worktree's branch
+	// was last based on, so a restack knows which commits are its own.
+	ParentBase string `db:"parent_base"`
+	// BaseBranch is the branch the worktree's branch is based on and synced
+	// with; empty means the repository's default branch.
+	BaseBranch string `db:"base_branch"`
+	// PRNumber is the pull request a review worktree checks out; it is 0
+	// for issue worktrees, whose IssueNumber is set instead.
+	PRNumber int `db:"pr_number"`
+}
+
+// Worktree statuses.
+const (
+	WorktreeStatusActive = "active"
+	// WorktreeStatusStale marks a row whose worktree no longer exists on disk.
+	WorktreeStatusStale = "stale"
+	// WorktreeStatusParked marks a worktree whose uncommitted work was saved
+	// to a WIP ref by `worktree park`.
+	WorktreeStatusParked = "parked"
+	// WorktreeStatusArchived marks a worktree that was removed after its
+	// work was saved to a bundle by `worktree archive`. ListWorktrees and
+	// ListWorktreesByProject leave archived rows out.
+	WorktreeStatusArchived = "archived"
+	// WorktreeStatusReview marks a checked-out review worktree, created by
+	// `review` for a pull request.
+	WorktreeStatusReview = "review"
+)
+
+// Worktree bootstrap statuses.
+const (
+	BootstrapSucceeded = "succeeded"
+	BootstrapFailed    = "failed"
+)
+
+const worktreeColumns = `id, project_id, issue_number, path, branch, status, created_at, bootstrap_status, bootstrap_error, sparse_patterns, parked_remote, attempt, archive_path, parent_id, parent_base, base_branch, pr_number, parked_snapshot`
+
+// rowScanner is satisfied by both *sql.Row and *sql.Rows.
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanWorktree(row rowScanner) (*Worktree, error) {
+	var w Worktree
+	var sparse string
+	err := row.Scan(&w.ID, &w.ProjectID, &w.IssueNumber, &w.Path, &w.Branch, &w.Status, &w.CreatedAt, &w.BootstrapStatus, &w.BootstrapError, &sparse, &w.ParkedRemote, &w.Attempt, &w.ArchivePath, &w.ParentID, &w.ParentBase, &w.BaseBranch, &w.PRNumber, &w.ParkedSnapshot)
+	if err != nil {
+		return nil, err
+	}
+	if sparse != "" {
+		w.SparsePatterns = strings.Split(sparse, "\n")
+	}
+	return &w, nil
 }
 
+// WorktreePort is a port allocated to a worktree under a configured name.
+// Ports are unique across all worktrees.
+type WorktreePort struct {
+	WorktreeID string `db:"worktree_id"`
+	Name       string `db:"name"`
+	Port       int    `db:"port"`
+}
+
+// ErrPortTaken is returned by CreateWorktreePort when the port is already
+// allocated to another worktree.
+var ErrPortTaken = errors.New("port is already allocated")
+
 type IssueCache struct {
 	ID          int       `db:"id"`
 	ProjectID   string    `db:"project_id"`
@@ -70,8 +163,12 @@ func NewWithDBPath(dbPath string) (*Database, error) {
 	if err != nil {
 		return nil, fmt.Errorf("failed to open database: %w", err)
 	}
+	if dbPath == ":memory:" {
+		// Every connection to :memory: opens a separate empty database.
+		db.SetMaxOpenConns(1)
+	}
 
-	d := &Database{db: db}
+	d := &Database{conn: db, db: db}
 	if err := d.initSchema(); err != nil {
 		db.Close()
 		return nil, fmt.Errorf("failed to initialize schema: %w", err)
@@ -102,12 +199,31 @@ func (d *Database) initSchema() error {
 		branch TEXT NOT NULL,
 		status TEXT NOT NULL DEFAULT 'active',
 		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		bootstrap_status TEXT NOT NULL DEFAULT '',
+		bootstrap_error TEXT NOT NULL DEFAULT '',
+		sparse_patterns TEXT NOT NULL DEFAULT '',
+		parked_remote TEXT NOT NULL DEFAULT '',
+		attempt TEXT NOT NULL DEFAULT '',
+		archive_path TEXT NOT NULL DEFAULT '',
+		parent_id TEXT NOT NULL DEFAULT '',
+		parent_base TEXT NOT NULL DEFAULT '',
+		base_branch TEXT NOT NULL DEFAULT '',
+		pr_number INTEGER NOT NULL DEFAULT 0,
+		parked_snapshot TEXT NOT NULL DEFAULT '',
 		FOREIGN KEY (project_id) REFERENCES projects(id)
 	);
 
 	CREATE INDEX IF NOT EXISTS idx_worktrees_project_id ON worktrees(project_id);
 	CREATE INDEX IF NOT EXISTS idx_worktrees_issue_number ON worktrees(issue_number);
 
+	CREATE TABLE IF NOT EXISTS worktree_ports (
+		worktree_id TEXT NOT NULL,
+		name TEXT NOT NULL,
+		port INTEGER NOT NULL UNIQUE,
+		PRIMARY KEY (worktree_id, name),
+		FOREIGN KEY (worktree_id) REFERENCES worktrees(id)
+	);
+
 	CREATE TABLE IF NOT EXISTS issue_cache (
 		id INTEGER PRIMARY KEY AUTOINCREMENT,
 		project_id TEXT NOT NULL,
@@ -124,12 +240,106 @@ func (d *Database) initSchema() error {
 	CREATE INDEX IF NOT EXISTS idx_issue_cache_project ON issue_cache(project_id);
 	`
 
-	_, err := d.db.Exec(schema)
-	return err
+	if _, err := d.db.Exec(schema); err != nil {
+		return err
+	}
+	return d.migrate()
+}
+
+// columnMigrations lists columns added to existing tables after their
+// CREATE TABLE statement was first released. Databases created before a
+// column existed get it through ALTER TABLE.
+var columnMigrations = []struct {
+	table, column, definition string
+}{
+	{"worktrees", "bootstrap_status", "TEXT NOT NULL DEFAULT ''"},
+	{"worktrees", "bootstrap_error", "TEXT NOT NULL DEFAULT ''"},
+	{"worktrees", "sparse_patterns", "TEXT NOT NULL DEFAULT ''"},
+	{"worktrees", "parked_remote", "TEXT NOT NULL DEFAULT ''"},
+	{"worktrees", "attempt", "TEXT NOT NULL DEFAULT ''"},
+	{"worktrees", "archive_path", "TEXT NOT NULL DEFAULT ''"},
+	{"worktrees", "parent_id", "TEXT NOT NULL DEFAULT ''"},
+	{"worktrees", "parent_base", "TEXT NOT NULL DEFAULT ''"},
+	{"worktrees", "base_branch", "TEXT NOT NULL DEFAULT ''"},
+	{"worktrees", "pr_number", "INTEGER NOT NULL DEFAULT 0"},
+	{"worktrees", "parked_snapshot", "TEXT NOT NULL DEFAULT ''"},
+}
+
+func (d *Database) migrate() error {
+	for _, m := range columnMigrations {
+		exists, err := d.columnExists(m.table, m.column)
+		if err != nil {
+			return err
+		}
+		if exists {
+			continue
+		}
+		query := fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", m.table, m.column, m.definition)
+		if _, err := d.db.Exec(query); err != nil {
+			return fmt.Errorf("failed to add column %s.%s: %w", m.table, m.column, err)
+		}
+	}
+	return nil
+}
+
+func (d *Database) columnExists(table, column string) (bool, error) {
+	rows, err := d.db.Query(fmt.Sprintf("PRAGMA table_info(%s)", table))
+	if err != nil {
+		return false, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			cid       int
+			name      string
+			colType   string
+			notNull   int
+			dfltValue sql.NullString
+			pk        int
+		)
+		if err := rows.Scan(&cid, &name, &colType, &notNull, &dfltValue, &pk); err != nil {
+			return false, err
+		}
+		if name == column {
+			return true, nil
+		}
+	}
+	return false, rows.Err()
 }
 
 func (d *Database) Close() error {
-	return d.db.Close()
+	if d.conn == nil {
+		return nil
+	}
+	return d.conn.Close()
+}
+
+// Transaction runs fn with a Database bound to a single transaction. The
+// transaction is committed if fn returns nil and rolled back otherwise.
+// Calling Transaction on a Database that is already transactional runs fn
+// in the enclosing transaction.
+func (d *Database) Transaction(fn func(tx *Database) error) error {
+	if d.conn == nil {
+		return fn(d)
+	}
+
+	tx, err := d.conn.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	if err := fn(&Database{db: tx}); err != nil {
+		if rbErr := tx.Rollback(); rbErr != nil {
+			return fmt.Errorf("%w (rollback failed: %v)", err, rbErr)
+		}
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
 }
 
 func (d *Database) CreateProject(p *Project) error {
@@ -175,6 +385,26 @@ func (d *Database) ListProjects() ([]Project, error) {
 	return projects, nil
 }
 
+func (d *Database) UpdateProject(p *Project) error {
+	query := `
+	UPDATE projects SET name = ?, github_owner = ?, github_repo = ?, local_path = ?, worktree_dir = ?, config = ?, updated_at = CURRENT_TIMESTAMP
+	WHERE id = ?
+	`
+
+	res, err := d.db.Exec(query, p.Name, p.GitHubOwner, p.GitHubRepo, p.LocalPath, p.WorktreeDir, p.Config, p.ID)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
 func (d *Database) DeleteProject(id string) error {
 	query := `DELETE FROM projects WHERE id = ?`
 	_, err := d.db.Exec(query, id)
@@ -183,28 +413,24 @@ func (d *Database) DeleteProject(id string) error {
 
 func (d *Database) CreateWorktree(w *Worktree) error {
 	query := `
-	INSERT INTO worktrees (id, project_id, issue_number, path, branch, status)
-	VALUES (?, ?, ?, ?, ?, ?)
+	INSERT INTO worktrees (id, project_id, issue_number, path, branch, status, bootstrap_status, bootstrap_error, sparse_patterns, parked_remote, attempt, archive_path, parent_id, parent_base, base_branch, pr_number, parked_snapshot)
+	VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`
 
-	_, err := d.db.Exec(query, w.ID, w.ProjectID, w.IssueNumber, w.Path, w.Branch, w.Status)
+	_, err := d.db.Exec(query, w.ID, w.ProjectID, w.IssueNumber, w.Path, w.Branch, w.Status, w.BootstrapStatus, w.BootstrapError, strings.Join(w.SparsePatterns, "\n"), w.ParkedRemote, w.Attempt, w.ArchivePath, w.ParentID, w.ParentBase, w.BaseBranch, w.PRNumber, w.ParkedSnapshot)
 	return err
 }
 
 func (d *Database) GetWorktree(id string) (*Worktree, error) {
-	query := `SELECT id, project_id, issue_number, path, branch, status, created_at FROM worktrees WHERE id = ?`
+	query := `SELECT ` + worktreeColumns + ` FROM worktrees WHERE id = ?`
 
 	row := d.db.QueryRow(query, id)
-	var w Worktree
-	err := row.Scan(&w.ID, &w.ProjectID, &w.IssueNumber, &w.Path, &w.Branch, &w.Status, &w.CreatedAt)
-	if err != nil {
-		return nil, err
-	}
-	return &w, nil
+	return scanWorktree(row)
 }
 
+// ListWorktrees returns all worktrees except archived ones.
 func (d *Database) ListWorktrees() ([]Worktree, error) {
-	query := `SELECT id, project_id, issue_number, path, branch, status, created_at FROM worktrees ORDER BY created_at`
+	query := `SELECT ` + worktreeColumns + ` FROM worktrees WHERE status != 'archived' ORDER BY created_at`
 
 	rows, err := d.db.Query(query)
 	if err != nil {
@@ -214,18 +440,42 @@ func (d *Database) ListWorktrees() ([]Worktree, error) {
 
 	var worktrees []Worktree
 	for rows.Next() {
-		var w Worktree
-		if err := rows.Scan(&w.ID, &w.ProjectID, &w.IssueNumber, &w.Path, &w.Branch, &w.Status, &w.CreatedAt); err != nil {
+		w, err := scanWorktree(rows)
+		if err != nil {
 			return nil, err
 		}
-		worktrees = append(worktrees, w)
+		worktrees = append(worktrees, *w)
 	}
 
 	return worktrees, nil
 }
 
+// ListArchivedWorktrees returns the archived worktrees of a project, or of
+// all projects when projectID is empty.
+func (d *Database) ListArchivedWorktrees(projectID string) ([]Worktree, error) {
+	query := `SELECT ` + worktreeColumns + ` FROM worktrees WHERE status = 'archived' AND (? = '' OR project_id = ?) ORDER BY project_id, issue_number, attempt`
+
+	rows, err := d.db.Query(query, projectID, projectID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var worktrees []Worktree
+	for rows.Next() {
+		w, err := scanWorktree(rows)
+		if err != nil {
+			return nil, err
+		}
+		worktrees = append(worktrees, *w)
+	}
+
+	return worktrees, rows.Err()
+}
+
+// ListWorktreesByProject returns a project's worktrees except archived ones.
 func (d *Database) ListWorktreesByProject(projectID string) ([]Worktree, error) {
-	query := `SELECT id, project_id, issue_number, path, branch, status, created_at FROM worktrees WHERE project_id = ? ORDER BY created_at`
+	query := `SELECT ` + worktreeColumns + ` FROM worktrees WHERE project_id = ? AND status != 'archived' ORDER BY created_at`
 
 	rows, err := d.db.Query(query, projectID)
 	if err != nil {
@@ -235,19 +485,156 @@ func (d *Database) ListWorktreesByProject(projectID string) ([]Worktree, error)
 
 	var worktrees []Worktree
 	for rows.Next() {
-		var w Worktree
-		if err := rows.Scan(&w.ID, &w.ProjectID, &w.IssueNumber, &w.Path, &w.Branch, &w.Status, &w.CreatedAt); err != nil {
+		w, err := scanWorktree(rows)
+		if err != nil {
 			return nil, err
 		}
-		worktrees = append(worktrees, w)
+		worktrees = append(worktrees, *w)
 	}
 
 	return worktrees, nil
 }
 
+// GetWorktreeByIssue returns the primary worktree of an issue, the one
+// without an attempt name.
+func (d *Database) GetWorktreeByIssue(projectID string, issueNumber int) (*Worktree, error) {
+	return d.GetWorktreeByAttempt(projectID, issueNumber, "")
+}
+
+func (d *Database) GetWorktreeByAttempt(projectID string, issueNumber int, attempt string) (*Worktree, error) {
+	query := `SELECT ` + worktreeColumns + ` FROM worktrees WHERE project_id = ? AND issue_number = ? AND attempt = ? ORDER BY created_at LIMIT 1`
+
+	row := d.db.QueryRow(query, projectID, issueNumber, attempt)
+	return scanWorktree(row)
+}
+
+// GetReviewWorktree returns the review worktree of a pull request.
+func (d *Database) GetReviewWorktree(projectID string, prNumber int) (*Worktree, error) {
+	query := `SELECT ` + worktreeColumns + ` FROM worktrees WHERE project_id = ? AND pr_number = ? ORDER BY created_at LIMIT 1`
+
+	row := d.db.QueryRow(query, projectID, prNumber)
+	return scanWorktree(row)
+}
+
+// ListWorktreesByIssue returns the primary worktree and all attempts of an
+// issue, primary first.
+func (d *Database) ListWorktreesByIssue(projectID string, issueNumber int) ([]Worktree, error) {
+	query := `SELECT ` + worktreeColumns + ` FROM worktrees WHERE project_id = ? AND issue_number = ? ORDER BY attempt != '', created_at`
+
+	rows, err := d.db.Query(query, projectID, issueNumber)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var worktrees []Worktree
+	for rows.Next() {
+		w, err := scanWorktree(rows)
+		if err != nil {
+			return nil, err
+		}
+		worktrees = append(worktrees, *w)
+	}
+
+	return worktrees, rows.Err()
+}
+
+func (d *Database) UpdateWorktree(w *Worktree) error {
+	query := `UPDATE worktrees SET path = ?, branch = ?, status = ?, bootstrap_status = ?, bootstrap_error = ?, sparse_patterns = ?, parked_remote = ?, attempt = ?, archive_path = ?, parent_id = ?, parent_base = ?, base_branch = ?, parked_snapshot = ? WHERE id = ?`
+
+	res, err := d.db.Exec(query, w.Path, w.Branch, w.Status, w.BootstrapStatus, w.BootstrapError, strings.Join(w.SparsePatterns, "\n"), w.ParkedRemote, w.Attempt, w.ArchivePath, w.ParentID, w.ParentBase, w.BaseBranch, w.ParkedSnapshot, w.ID)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// DeleteWorktree deletes a worktree row and releases its ports.
 func (d *Database) DeleteWorktree(id string) error {
-	query := `DELETE FROM worktrees WHERE id = ?`
-	_, err := d.db.Exec(query, id)
+	return d.Transaction(func(tx *Database) error {
+		if err := tx.DeleteWorktreePorts(id); err != nil {
+			return err
+		}
+		_, err := tx.db.Exec(`DELETE FROM worktrees WHERE id = ?`, id)
+		return err
+	})
+}
+
+// RenameWorktree changes a worktree's ID, moving its ports and the worktrees
+// stacked on it along.
+func (d *Database) RenameWorktree(oldID, newID string) error {
+	return d.Transaction(func(tx *Database) error {
+		res, err := tx.db.Exec(`UPDATE worktrees SET id = ? WHERE id = ?`, newID, oldID)
+		if err != nil {
+			return err
+		}
+		if n, err := res.RowsAffected(); err != nil {
+			return err
+		} else if n == 0 {
+			return sql.ErrNoRows
+		}
+		if _, err := tx.db.Exec(`UPDATE worktrees SET parent_id = ? WHERE parent_id = ?`, newID, oldID); err != nil {
+			return err
+		}
+		_, err = tx.db.Exec(`UPDATE worktree_ports SET worktree_id = ? WHERE worktree_id = ?`, newID, oldID)
+		return err
+	})
+}
+
+func (d *Database) CreateWorktreePort(p *WorktreePort) error {
+	query := `INSERT INTO worktree_ports (worktree_id, name, port) VALUES (?, ?, ?)`
+
+	_, err := d.db.Exec(query, p.WorktreeID, p.Name, p.Port)
+	var sqliteErr sqlite3.Error
+	if errors.As(err, &sqliteErr) && sqliteErr.ExtendedCode == sqlite3.ErrConstraintUnique {
+		return fmt.Errorf("%d: %w", p.Port, ErrPortTaken)
+	}
+	return err
+}
+
+func (d *Database) ListWorktreePorts(worktreeID string) ([]WorktreePort, error) {
+	return d.queryWorktreePorts(`SELECT worktree_id, name, port FROM worktree_ports WHERE worktree_id = ? ORDER BY name`, worktreeID)
+}
+
+// ListAllWorktreePorts returns every allocated port, ordered by port.
+func (d *Database) ListAllWorktreePorts() ([]WorktreePort, error) {
+	return d.queryWorktreePorts(`SELECT worktree_id, name, port FROM worktree_ports ORDER BY port`)
+}
+
+func (d *Database) queryWorktreePorts(query string, args ...any) ([]WorktreePort, error) {
+	rows, err := d.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ports []WorktreePort
+	for rows.Next() {
+		var p WorktreePort
+		if err := rows.Scan(&p.WorktreeID, &p.Name, &p.Port); err != nil {
+			return nil, err
+		}
+		ports = append(ports, p)
+	}
+	return ports, rows.Err()
+}
+
+func (d *Database) DeleteWorktreePort(worktreeID, name string) error {
+	query := `DELETE FROM worktree_ports WHERE worktree_id = ? AND name = ?`
+	_, err := d.db.Exec(query, worktreeID, name)
+	return err
+}
+
+func (d *Database) DeleteWorktreePorts(worktreeID string) error {
+	query := `DELETE FROM worktree_ports WHERE worktree_id = ?`
+	_, err := d.db.Exec(query, worktreeID)
 	return err
 }
 
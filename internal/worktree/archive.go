@@ -0,0 +1,332 @@
+package worktree
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/paolorechia/issue-flow/internal/config"
+	"github.com/paolorechia/issue-flow/internal/project"
+	"github.com/paolorechia/issue-flow/internal/storage"
+)
+
+// ErrArchived is returned by Start for a worktree that must be unarchived
+// first.
+var ErrArchived = errors.New("worktree is archived")
+
+const (
+	archiveManifestFile = "archive.json"
+	archiveBundleFile   = "work.bundle"
+)
+
+// ArchiveManifest describes an archive. It is written as archive.json next
+// to the bundle so an archive can be understood without the database.
+type ArchiveManifest struct {
+	ProjectID   string `json:"project_id"`
+	IssueNumber int    `json:"issue_number"`
+	Attempt     string `json:"attempt,omitempty"`
+	Branch      string `json:"branch"`
+	// Head is the commit the branch pointed to.
+	Head string `json:"head"`
+	// Base is the ref whose history the bundle leaves out; it must still
+	// have those commits when the archive is restored.
+	Base    string `json:"base"`
+	Commits int    `json:"commits"`
+	// Snapshot is the stash commit holding the uncommitted and untracked
+	// changes, if there were any.
+	Snapshot string `json:"snapshot,omitempty"`
+	// Bundle is the bundle file name; it is empty when the branch had no
+	// commits and no changes. Git leaves a branch without commits of its
+	// own out of the bundle, so then only Head is used to restore it.
+	Bundle     string    `json:"bundle,omitempty"`
+	ArchivedAt time.Time `json:"archived_at"`
+}
+
+type ArchiveOptions struct {
+	// KeepBranch keeps the local branch instead of deleting it once it is
+	// in the bundle.
+	KeepBranch bool
+}
+
+type ArchiveResult struct {
+	Manifest      *ArchiveManifest
+	Dir           string
+	BranchDeleted bool
+	Warnings      []string
+}
+
+type UnarchiveResult struct {
+	Manifest *ArchiveManifest
+	Ports    []Port
+	Warnings []string
+}
+
+// ArchiveDir returns where a worktree's archive is written:
+// ~/.issue-flow/archive/<project>/<issue>, with "-<attempt>" for attempts.
+func ArchiveDir(w *storage.Worktree) string {
+	name := strconv.Itoa(w.IssueNumber) + attemptSuffix(w.Attempt)
+	return filepath.Join(config.GetConfigPath(), "archive", w.ProjectID, name)
+}
+
+// Archive saves a worktree's work and removes it. Commits not on the base
+// branch and a snapshot of the uncommitted and untracked changes (the one
+// 'worktree park' made, for parked worktrees) go into a git bundle under
+// ArchiveDir; ignored files and stashes are not archived. The worktree and,
+// unless opts.KeepBranch, its branch are then removed, its ports released,
+// and the row kept with status archived.
+func (m *Manager) Archive(ctx context.Context, p *project.Project, w *storage.Worktree, opts ArchiveOptions) (*ArchiveResult, error) {
+	switch w.Status {
+	case storage.WorktreeStatusArchived:
+		return nil, fmt.Errorf("worktree %s is already archived at %s", w.ID, w.ArchivePath)
+	case storage.WorktreeStatusStale:
+		return nil, fmt.Errorf("worktree %s is stale; see 'issue-flow worktree reconcile'", w.ID)
+	}
+	if w.PRNumber > 0 {
+		return nil, fmt.Errorf("worktree %s reviews a pull request; remove it with 'issue-flow review done'", w.ID)
+	}
+
+	repo := config.ExpandPath(p.LocalPath)
+	unlock := m.lockRepo(repo)
+	defer unlock()
+
+	dir := ArchiveDir(w)
+	if _, err := os.Lstat(dir); err == nil {
+		return nil, fmt.Errorf("an archive already exists at %s", dir)
+	}
+	base, err := m.BranchBaseRef(ctx, repo, p.UpstreamRemote(), w.BaseBranch)
+	if err != nil {
+		return nil, err
+	}
+	head, err := m.git.RevParse(ctx, repo, "refs/heads/"+w.Branch)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve branch %s: %w", w.Branch, err)
+	}
+	commits, err := m.git.RevList(ctx, repo, base+".."+head)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list commits: %w", err)
+	}
+
+	manifest := &ArchiveManifest{
+		ProjectID:   p.ID,
+		IssueNumber: w.IssueNumber,
+		Attempt:     w.Attempt,
+		Branch:      w.Branch,
+		Head:        head,
+		Base:        base,
+		Commits:     len(commits),
+		ArchivedAt:  time.Now().UTC(),
+	}
+
+	// restore undoes a snapshot taken here if archiving fails later.
+	restore := func() {}
+	ref := WIPRef(w)
+	if w.Status == storage.WorktreeStatusParked {
+		if snapshot, err := m.git.RevParse(ctx, repo, ref); err == nil {
+			manifest.Snapshot = snapshot
+		} else if w.ParkedRemote != "" {
+			return nil, fmt.Errorf("the snapshot of %s is only on %s; resume it first", w.ID, w.ParkedRemote)
+		} else if w.ParkedSnapshot != "" {
+			return nil, fmt.Errorf("snapshot %s of worktree %s is missing from %s: %w", w.ParkedSnapshot, w.ID, ref, err)
+		}
+	} else {
+		if _, err := os.Stat(w.Path); err != nil {
+			return nil, fmt.Errorf("worktree %s is missing at %s", w.ID, w.Path)
+		}
+		status, err := m.git.Status(ctx, w.Path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get status: %w", err)
+		}
+		if len(status.Conflicted) > 0 {
+			return nil, fmt.Errorf("worktree %s has unresolved conflicts", w.ID)
+		}
+		if !status.Clean() {
+			if manifest.Snapshot, err = m.snapshot(ctx, repo, w); err != nil {
+				return nil, err
+			}
+			restore = func() {
+				if m.git.StashApply(ctx, w.Path, manifest.Snapshot, true) == nil {
+					_ = m.git.DeleteRef(ctx, repo, ref)
+				}
+			}
+		}
+	}
+
+	if err := m.writeArchive(ctx, repo, dir, w, manifest); err != nil {
+		os.RemoveAll(dir)
+		restore()
+		return nil, err
+	}
+
+	result := &ArchiveResult{Manifest: manifest, Dir: dir}
+	if err := m.removeFromGit(ctx, repo, w.Path, true); err != nil {
+		return result, err
+	}
+	if manifest.Snapshot != "" {
+		if err := m.git.DeleteRef(ctx, repo, ref); err != nil {
+			result.Warnings = append(result.Warnings, fmt.Sprintf("failed to delete %s: %v", ref, err))
+		}
+		if w.ParkedRemote != "" {
+			if err := m.git.Push(ctx, repo, w.ParkedRemote, ":"+ref); err != nil {
+				result.Warnings = append(result.Warnings, fmt.Sprintf("failed to delete %s on %s: %v", ref, w.ParkedRemote, err))
+			}
+		}
+	}
+
+	w.Status = storage.WorktreeStatusArchived
+	w.ArchivePath = dir
+	w.ParkedRemote, w.ParkedSnapshot = "", ""
+	err = m.withDB(func(db *storage.Database) error {
+		return db.Transaction(func(tx *storage.Database) error {
+			if err := tx.DeleteWorktreePorts(w.ID); err != nil {
+				return err
+			}
+			return tx.UpdateWorktree(w)
+		})
+	})
+	if err != nil {
+		return result, fmt.Errorf("worktree archived to %s but failed to update its record: %w", dir, err)
+	}
+
+	if !opts.KeepBranch {
+		if err := m.git.DeleteBranch(ctx, repo, w.Branch, true); err != nil {
+			result.Warnings = append(result.Warnings, fmt.Sprintf("failed to delete branch %s: %v", w.Branch, err))
+		} else {
+			result.BranchDeleted = true
+		}
+	}
+	return result, nil
+}
+
+// writeArchive writes the bundle and manifest into dir.
+func (m *Manager) writeArchive(ctx context.Context, repo, dir string, w *storage.Worktree, manifest *ArchiveManifest) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create archive directory: %w", err)
+	}
+	if manifest.Commits > 0 || manifest.Snapshot != "" {
+		revs := []string{"refs/heads/" + w.Branch}
+		if manifest.Snapshot != "" {
+			revs = append(revs, WIPRef(w))
+		}
+		revs = append(revs, "^"+manifest.Base)
+		if err := m.git.BundleCreate(ctx, repo, filepath.Join(dir, archiveBundleFile), revs...); err != nil {
+			return fmt.Errorf("failed to write bundle: %w", err)
+		}
+		manifest.Bundle = archiveBundleFile
+	}
+
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode archive manifest: %w", err)
+	}
+	if err := writeFileAtomic(filepath.Join(dir, archiveManifestFile), append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write archive manifest: %w", err)
+	}
+	return nil
+}
+
+// ReadArchive reads the manifest of the archive in dir.
+func ReadArchive(dir string) (*ArchiveManifest, error) {
+	data, err := os.ReadFile(filepath.Join(dir, archiveManifestFile))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read archive: %w", err)
+	}
+	var manifest ArchiveManifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", archiveManifestFile, err)
+	}
+	return &manifest, nil
+}
+
+// Unarchive recreates an archived worktree at its old path. The branch is
+// fetched back from the bundle, created at the archived commit, or kept if
+// it still points there; then the worktree is checked out and the
+// uncommitted changes restored. The row becomes active again, gets its ports
+// back, and the archive is deleted.
+func (m *Manager) Unarchive(ctx context.Context, p *project.Project, w *storage.Worktree) (*UnarchiveResult, error) {
+	if w.Status != storage.WorktreeStatusArchived {
+		return nil, fmt.Errorf("worktree %s is not archived", w.ID)
+	}
+	manifest, err := ReadArchive(w.ArchivePath)
+	if err != nil {
+		return nil, err
+	}
+	result := &UnarchiveResult{Manifest: manifest}
+
+	repo := config.ExpandPath(p.LocalPath)
+	unlock := m.lockRepo(repo)
+	locked := true
+	defer func() {
+		if locked {
+			unlock()
+		}
+	}()
+
+	if _, err := os.Lstat(w.Path); err == nil {
+		return nil, fmt.Errorf("cannot restore %s: %s already exists", w.ID, w.Path)
+	}
+	bundle := filepath.Join(w.ArchivePath, manifest.Bundle)
+
+	exists, err := m.git.BranchExists(ctx, repo, w.Branch)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check branch %s: %w", w.Branch, err)
+	}
+	switch {
+	case exists:
+		tip, err := m.git.RevParse(ctx, repo, "refs/heads/"+w.Branch)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve branch %s: %w", w.Branch, err)
+		}
+		if tip != manifest.Head {
+			return nil, fmt.Errorf("branch %s has changed since it was archived; rename or delete it first", w.Branch)
+		}
+	case manifest.Commits > 0:
+		ref := "refs/heads/" + w.Branch
+		if err := m.git.Fetch(ctx, repo, bundle, ref+":"+ref); err != nil {
+			return nil, fmt.Errorf("failed to restore branch %s from %s: %w", w.Branch, bundle, err)
+		}
+	default:
+		if err := m.git.CreateBranch(ctx, repo, w.Branch, manifest.Head); err != nil {
+			return nil, fmt.Errorf("failed to restore branch %s: %w", w.Branch, err)
+		}
+	}
+
+	if err := m.recreate(ctx, repo, w, ""); err != nil {
+		return nil, err
+	}
+
+	if manifest.Snapshot != "" {
+		ref := WIPRef(w)
+		if err := m.git.Fetch(ctx, repo, bundle, "+"+ref+":"+ref); err != nil {
+			return nil, fmt.Errorf("failed to restore snapshot from %s: %w", bundle, err)
+		}
+		if err := m.git.StashApply(ctx, w.Path, manifest.Snapshot, true); err != nil {
+			return nil, fmt.Errorf("failed to restore snapshot %s: %w", manifest.Snapshot, err)
+		}
+		if err := m.git.DeleteRef(ctx, repo, ref); err != nil {
+			result.Warnings = append(result.Warnings, fmt.Sprintf("failed to delete %s: %v", ref, err))
+		}
+	}
+	unlock()
+	locked = false
+
+	archive := w.ArchivePath
+	w.Status = storage.WorktreeStatusActive
+	w.ArchivePath = ""
+	if err := m.withDB(func(db *storage.Database) error { return db.UpdateWorktree(w) }); err != nil {
+		return result, fmt.Errorf("worktree restored but failed to update its record: %w", err)
+	}
+	result.Warnings = append(result.Warnings, m.installHooks(ctx, p, w)...)
+	result.Warnings = append(result.Warnings, m.applyIdentity(ctx, p, w)...)
+	if result.Ports, err = m.AllocatePorts(p, w); err != nil {
+		return result, fmt.Errorf("failed to allocate ports: %w", err)
+	}
+	if err := os.RemoveAll(archive); err != nil {
+		result.Warnings = append(result.Warnings, fmt.Sprintf("failed to delete archive %s: %v", archive, err))
+	}
+	return result, nil
+}
@@ -0,0 +1,150 @@
+package worktree
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/paolorechia/issue-flow/internal/storage"
+	"github.com/paolorechia/issue-flow/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestManager_ArchiveAndUnarchive(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	db := testutil.NewTestDB(t)
+	sp := testutil.CreateGitProject(t, db, "app")
+	p, manager, w := startTestWorktree(t, db, 1)
+	ctx := context.Background()
+
+	testutil.GitCommitFile(t, w.Path, "feature.txt", "feature\n", "Add feature")
+	head := testutil.RunGit(t, w.Path, "rev-parse", "HEAD")
+	require.NoError(t, os.WriteFile(filepath.Join(w.Path, "README.md"), []byte("edited\n"), 0644))
+	testutil.RunGit(t, w.Path, "add", "README.md")
+	require.NoError(t, os.WriteFile(filepath.Join(w.Path, "notes.txt"), []byte("notes\n"), 0644))
+
+	result, err := manager.Archive(ctx, p, w, ArchiveOptions{})
+	require.NoError(t, err)
+	assert.Empty(t, result.Warnings)
+	assert.True(t, result.BranchDeleted)
+	assert.Equal(t, ArchiveDir(w), result.Dir)
+	assert.Equal(t, 1, result.Manifest.Commits)
+	assert.Equal(t, head, result.Manifest.Head)
+	assert.NotEmpty(t, result.Manifest.Snapshot)
+	assert.FileExists(t, filepath.Join(result.Dir, "work.bundle"))
+
+	assert.NoDirExists(t, w.Path)
+	assert.Empty(t, testutil.RunGit(t, sp.LocalPath, "branch", "--list", w.Branch))
+	assert.Empty(t, testutil.RunGit(t, sp.LocalPath, "for-each-ref", WIPRefPrefix))
+	row := testutil.AssertWorktreeExists(t, db, w.ID)
+	assert.Equal(t, storage.WorktreeStatusArchived, row.Status)
+	assert.Equal(t, result.Dir, row.ArchivePath)
+
+	listed, err := db.ListWorktrees()
+	require.NoError(t, err)
+	assert.Empty(t, listed, "archived worktrees are not listed")
+
+	_, err = manager.Start(ctx, p, StartOptions{IssueNumber: 1})
+	assert.ErrorIs(t, err, ErrArchived)
+
+	restored, err := manager.Unarchive(ctx, p, row)
+	require.NoError(t, err)
+	assert.Empty(t, restored.Warnings)
+
+	assert.Equal(t, head, testutil.RunGit(t, w.Path, "rev-parse", "HEAD"))
+	assert.Equal(t, w.Branch, testutil.RunGit(t, w.Path, "branch", "--show-current"))
+	assert.Equal(t, "M  README.md\n?? notes.txt", testutil.RunGit(t, w.Path, "status", "--porcelain"))
+	assert.NoDirExists(t, result.Dir)
+	assert.Empty(t, testutil.RunGit(t, sp.LocalPath, "for-each-ref", WIPRefPrefix))
+	row = testutil.AssertWorktreeExists(t, db, w.ID)
+	assert.Equal(t, storage.WorktreeStatusActive, row.Status)
+	assert.Empty(t, row.ArchivePath)
+}
+
+func TestManager_ArchiveWithoutWork(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	db := testutil.NewTestDB(t)
+	sp := testutil.CreateGitProject(t, db, "app")
+	p, manager, w := startTestWorktree(t, db, 1)
+	ctx := context.Background()
+
+	result, err := manager.Archive(ctx, p, w, ArchiveOptions{KeepBranch: true})
+	require.NoError(t, err)
+	assert.Empty(t, result.Manifest.Bundle, "an empty branch needs no bundle")
+	assert.False(t, result.BranchDeleted)
+	assert.NotEmpty(t, testutil.RunGit(t, sp.LocalPath, "branch", "--list", w.Branch))
+
+	row := testutil.AssertWorktreeExists(t, db, w.ID)
+	_, err = manager.Archive(ctx, p, row, ArchiveOptions{})
+	assert.ErrorContains(t, err, "already archived")
+
+	testutil.RunGit(t, sp.LocalPath, "branch", "-D", w.Branch)
+	_, err = manager.Unarchive(ctx, p, row)
+	require.NoError(t, err)
+	assert.Equal(t, result.Manifest.Head, testutil.RunGit(t, w.Path, "rev-parse", "HEAD"))
+}
+
+func TestManager_ArchiveParked(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	db := testutil.NewTestDB(t)
+	testutil.CreateGitProject(t, db, "app")
+	p, manager, w := startTestWorktree(t, db, 1)
+	ctx := context.Background()
+
+	require.NoError(t, os.WriteFile(filepath.Join(w.Path, "wip.txt"), []byte("wip\n"), 0644))
+	parked, err := manager.Park(ctx, p, w, ParkOptions{})
+	require.NoError(t, err)
+
+	result, err := manager.Archive(ctx, p, w, ArchiveOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, parked.Snapshot, result.Manifest.Snapshot)
+
+	row := testutil.AssertWorktreeExists(t, db, w.ID)
+	_, err = manager.Unarchive(ctx, p, row)
+	require.NoError(t, err)
+	assert.FileExists(t, filepath.Join(w.Path, "wip.txt"))
+}
+
+func TestManager_UnarchiveRefusesMovedBranch(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	db := testutil.NewTestDB(t)
+	sp := testutil.CreateGitProject(t, db, "app")
+	p, manager, w := startTestWorktree(t, db, 1)
+	ctx := context.Background()
+
+	result, err := manager.Archive(ctx, p, w, ArchiveOptions{})
+	require.NoError(t, err)
+	testutil.RunGit(t, sp.LocalPath, "branch", w.Branch, "HEAD")
+	testutil.GitCommitFile(t, sp.LocalPath, "later.txt", "x\n", "Later commit")
+	testutil.RunGit(t, sp.LocalPath, "branch", "-f", w.Branch, "HEAD")
+
+	row := testutil.AssertWorktreeExists(t, db, w.ID)
+	_, err = manager.Unarchive(ctx, p, row)
+	assert.ErrorContains(t, err, "has changed since it was archived")
+	assert.DirExists(t, result.Dir)
+	assert.NoDirExists(t, w.Path)
+}
+
+func TestManager_RemoveArchived(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	db := testutil.NewTestDB(t)
+	testutil.CreateGitProject(t, db, "app")
+	p, manager, w := startTestWorktree(t, db, 1)
+	ctx := context.Background()
+
+	testutil.GitCommitFile(t, w.Path, "feature.txt", "feature\n", "Add feature")
+	result, err := manager.Archive(ctx, p, w, ArchiveOptions{})
+	require.NoError(t, err)
+	row := testutil.AssertWorktreeExists(t, db, w.ID)
+
+	removed, err := manager.Remove(ctx, p, row, RemoveOptions{})
+	require.ErrorIs(t, err, ErrWouldLoseWork)
+	assert.Equal(t, result.Dir, removed.Report.Archive)
+
+	_, err = manager.Remove(ctx, p, row, RemoveOptions{Force: true})
+	require.NoError(t, err)
+	assert.NoDirExists(t, result.Dir)
+	testutil.AssertWorktreeCount(t, db, 0)
+}
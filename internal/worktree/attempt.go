@@ -0,0 +1,343 @@
+package worktree
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/paolorechia/issue-flow/internal/config"
+	"github.com/paolorechia/issue-flow/internal/git"
+	"github.com/paolorechia/issue-flow/internal/project"
+	"github.com/paolorechia/issue-flow/internal/storage"
+)
+
+// AutoAttempt as StartOptions.Attempt picks the next free attempt name:
+// a1, a2, ...
+const AutoAttempt = "auto"
+
+// DefaultTestTimeout bounds a compare test run when the project's test
+// config sets no timeout.
+const DefaultTestTimeout = 30 * time.Minute
+
+// maxAttemptLength bounds attempt names, which end up in branch names,
+// directory names and worktree IDs.
+const maxAttemptLength = 32
+
+// Attempt names start with a letter so attempt IDs never look like the ID of
+// another issue.
+var attemptPattern = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9._-]{0,31}$`)
+
+// AttemptID returns the worktrees table key for an attempt; the primary
+// worktree (empty attempt) has the plain ID.
+func AttemptID(projectID string, issueNumber int, attempt string) string {
+	return ID(projectID, issueNumber) + attemptSuffix(attempt)
+}
+
+// attemptSuffix is appended to an attempt's ID, branch and directory.
+func attemptSuffix(attempt string) string {
+	if attempt == "" {
+		return ""
+	}
+	return "-" + attempt
+}
+
+// ValidateAttempt checks that name can be used in branch, directory and
+// worktree ID names.
+func ValidateAttempt(name string) error {
+	if name == AutoAttempt || !attemptPattern.MatchString(name) {
+		return fmt.Errorf("invalid attempt name %q: use up to 32 letters, digits, '.', '_' or '-', starting with a letter", name)
+	}
+	return nil
+}
+
+func (m *Manager) resolveAttempt(projectID string, opts StartOptions) (string, error) {
+	switch opts.Attempt {
+	case "":
+		return "", nil
+	case AutoAttempt:
+		worktrees, err := m.db.ListWorktreesByIssue(projectID, opts.IssueNumber)
+		if err != nil {
+			return "", fmt.Errorf("failed to list attempts: %w", err)
+		}
+		used := make(map[string]bool, len(worktrees))
+		for _, w := range worktrees {
+			used[w.Attempt] = true
+		}
+		for n := 1; ; n++ {
+			if name := fmt.Sprintf("a%d", n); !used[name] {
+				return name, nil
+			}
+		}
+	}
+	if err := ValidateAttempt(opts.Attempt); err != nil {
+		return "", err
+	}
+	return opts.Attempt, nil
+}
+
+type CompareOptions struct {
+	// TestCommand is run in every worktree when set.
+	TestCommand string
+	// Timeout bounds each test run; zero means the project's test timeout,
+	// or DefaultTestTimeout.
+	Timeout time.Duration
+	// Workers bounds how many worktrees are compared at once. Tests of
+	// different attempts get different ports, but may still contend for
+	// other resources, so callers usually keep this low.
+	Workers int
+}
+
+// Comparison is one attempt's changes relative to the base branch.
+type Comparison struct {
+	Worktree storage.Worktree
+	// Commits counts commits on the branch that are not in the base branch.
+	Commits int
+	// Diff is the committed change since the branch left the base branch.
+	Diff  git.DiffStat
+	Dirty bool
+	// Test is nil when no test command was run.
+	Test *TestResult
+	Err  error
+}
+
+type TestResult struct {
+	Passed   bool
+	ExitCode int
+	Duration time.Duration
+	// Output is the combined stdout and stderr of the run.
+	Output string
+	// Err is set when the test could not run or timed out.
+	Err error
+}
+
+// Compare gathers the changes and, with a test command, the test results of
+// the given worktrees, typically all attempts of one issue. Results are in
+// input order; per-worktree failures are reported in Comparison.Err.
+func (m *Manager) Compare(ctx context.Context, p *project.Project, worktrees []storage.Worktree, opts CompareOptions) ([]Comparison, error) {
+	repo := config.ExpandPath(p.LocalPath)
+	base, err := m.BaseRef(ctx, repo, p.UpstreamRemote())
+	if err != nil {
+		return nil, err
+	}
+	workers := opts.Workers
+	if workers <= 0 {
+		workers = 1
+	}
+	timeout := opts.Timeout
+	if timeout <= 0 {
+		if timeout, err = parseTimeout(p.Config.Test.Timeout, DefaultTestTimeout); err != nil {
+			return nil, fmt.Errorf("invalid test config: %w", err)
+		}
+	}
+
+	results := make([]Comparison, len(worktrees))
+	sem := make(chan struct{}, workers)
+	var wg sync.WaitGroup
+
+	for i, w := range worktrees {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, w storage.Worktree) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			results[i] = m.compare(ctx, p, w, base, opts.TestCommand, timeout)
+		}(i, w)
+	}
+	wg.Wait()
+
+	return results, nil
+}
+
+func (m *Manager) compare(ctx context.Context, p *project.Project, w storage.Worktree, base, testCommand string, timeout time.Duration) Comparison {
+	c := Comparison{Worktree: w}
+	if w.Status == storage.WorktreeStatusArchived {
+		c.Err = fmt.Errorf("worktree is archived at %s", w.ArchivePath)
+		return c
+	}
+	if _, err := os.Stat(w.Path); err != nil {
+		c.Err = fmt.Errorf("worktree is missing at %s", w.Path)
+		return c
+	}
+	if w.BaseBranch != "" {
+		var err error
+		if base, err = m.BranchBaseRef(ctx, config.ExpandPath(p.LocalPath), p.UpstreamRemote(), w.BaseBranch); err != nil {
+			c.Err = err
+			return c
+		}
+	}
+
+	commits, err := m.git.RevList(ctx, w.Path, base+"..HEAD")
+	if err != nil {
+		c.Err = fmt.Errorf("failed to count commits: %w", err)
+		return c
+	}
+	c.Commits = len(commits)
+	if c.Diff, err = m.git.DiffStat(ctx, w.Path, base+"...HEAD"); err != nil {
+		c.Err = fmt.Errorf("failed to diff against %s: %w", base, err)
+		return c
+	}
+	status, err := m.git.Status(ctx, w.Path)
+	if err != nil {
+		c.Err = fmt.Errorf("failed to get status: %w", err)
+		return c
+	}
+	c.Dirty = !status.Clean()
+
+	if testCommand != "" {
+		c.Test = m.runTest(ctx, p, &w, testCommand, timeout)
+	}
+	return c
+}
+
+func (m *Manager) runTest(ctx context.Context, p *project.Project, w *storage.Worktree, command string, timeout time.Duration) *TestResult {
+	result := &TestResult{}
+	env, err := m.Env(p, w)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	var output bytes.Buffer
+	cmd := shellCommand(ctx, command)
+	cmd.Dir = w.Path
+	cmd.Env = append(os.Environ(), env...)
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+	killProcessGroup(cmd)
+	cmd.WaitDelay = 5 * time.Second
+
+	start := time.Now()
+	err = cmd.Run()
+	result.Duration = time.Since(start)
+	result.Output = output.String()
+
+	var exitErr *exec.ExitError
+	switch {
+	case errors.Is(ctx.Err(), context.DeadlineExceeded):
+		result.Err = fmt.Errorf("timed out after %s", timeout)
+	case errors.As(err, &exitErr):
+		result.ExitCode = exitErr.ExitCode()
+	case err != nil:
+		result.Err = err
+	default:
+		result.Passed = true
+	}
+	return result
+}
+
+type PickOptions struct {
+	// Force discards uncommitted changes, untracked files, stashes and
+	// parked snapshots of the losing attempts.
+	Force bool
+	// KeepBranches keeps the losing attempts' branches.
+	KeepBranches bool
+}
+
+type PickResult struct {
+	Winner *storage.Worktree
+	// Removed are the losing worktrees that were removed.
+	Removed []storage.Worktree
+	// Blocked lists the losers holding work that would be lost when Pick
+	// refuses with ErrWouldLoseWork.
+	Blocked []BlockedAttempt
+	// Promoted is true when the winner was an attempt and became the
+	// issue's primary worktree.
+	Promoted bool
+	Warnings []string
+}
+
+type BlockedAttempt struct {
+	Worktree storage.Worktree
+	Report   *LossReport
+	Parked   bool
+}
+
+// Pick keeps winner and removes every other worktree of its issue on the
+// same base branch, deleting their branches unless opts.KeepBranches is set.
+// Commits on the losing branches are discarded by design, but other local
+// work (uncommitted changes, untracked files, stashes, parked snapshots)
+// makes Pick refuse with ErrWouldLoseWork before anything is removed, unless
+// opts.Force is set. A winning attempt then becomes the issue's primary
+// worktree, unless a primary on another base branch remains; its branch and
+// directory are kept.
+func (m *Manager) Pick(ctx context.Context, p *project.Project, winner *storage.Worktree, opts PickOptions) (*PickResult, error) {
+	if winner.Status == storage.WorktreeStatusParked {
+		return nil, fmt.Errorf("%s: %w", winner.ID, ErrParked)
+	}
+	if winner.Status == storage.WorktreeStatusArchived {
+		return nil, fmt.Errorf("%s: %w", winner.ID, ErrArchived)
+	}
+	worktrees, err := m.db.ListWorktreesByIssue(p.ID, winner.IssueNumber)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list attempts: %w", err)
+	}
+
+	result := &PickResult{Winner: winner}
+	var losers []storage.Worktree
+	primaryKept := false
+	for _, w := range worktrees {
+		if w.ID == winner.ID {
+			continue
+		}
+		if w.BaseBranch != winner.BaseBranch {
+			// Backports and other worktrees on another base do not compete.
+			primaryKept = primaryKept || w.Attempt == ""
+			continue
+		}
+		losers = append(losers, w)
+
+		report, err := m.Assess(ctx, p, &w)
+		if err != nil {
+			return nil, fmt.Errorf("failed to assess %s: %w", w.ID, err)
+		}
+		report.Unpushed = nil
+		parked := w.Status == storage.WorktreeStatusParked
+		if !report.Empty() || parked {
+			result.Blocked = append(result.Blocked, BlockedAttempt{Worktree: w, Report: report, Parked: parked})
+		}
+	}
+	if len(result.Blocked) > 0 && !opts.Force {
+		return result, ErrWouldLoseWork
+	}
+	result.Blocked = nil
+
+	for i := range losers {
+		w := &losers[i]
+		removed, err := m.Remove(ctx, p, w, RemoveOptions{Force: true, DeleteBranch: !opts.KeepBranches})
+		if removed != nil {
+			result.Warnings = append(result.Warnings, removed.Warnings...)
+		}
+		if err != nil {
+			return result, fmt.Errorf("failed to remove %s: %w", w.ID, err)
+		}
+		result.Removed = append(result.Removed, *w)
+	}
+
+	if winner.Attempt != "" && !primaryKept {
+		oldID := winner.ID
+		winner.ID = ID(p.ID, winner.IssueNumber)
+		winner.Attempt = ""
+		err := m.withDB(func(db *storage.Database) error {
+			return db.Transaction(func(tx *storage.Database) error {
+				if err := tx.RenameWorktree(oldID, winner.ID); err != nil {
+					return err
+				}
+				return tx.UpdateWorktree(winner)
+			})
+		})
+		if err != nil {
+			return result, fmt.Errorf("failed to make %s the primary worktree: %w", oldID, err)
+		}
+		result.Promoted = true
+	}
+	return result, nil
+}
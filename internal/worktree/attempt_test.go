@@ -0,0 +1,179 @@
+package worktree
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/paolorechia/issue-flow/internal/storage"
+	"github.com/paolorechia/issue-flow/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestManager_StartAttempts(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	sp := testutil.CreateGitProject(t, db, "app")
+	p, manager, primary := startTestWorktree(t, db, 5)
+	ctx := context.Background()
+
+	a1, err := manager.Start(ctx, p, StartOptions{IssueNumber: 5, Attempt: AutoAttempt})
+	require.NoError(t, err)
+	assert.True(t, a1.Created)
+	assert.Equal(t, "wt-app-5-a1", a1.Worktree.ID)
+	assert.Equal(t, "a1", a1.Worktree.Attempt)
+	assert.Equal(t, primary.Branch+"-a1", a1.Worktree.Branch)
+	assert.Equal(t, primary.Path+"-a1", a1.Worktree.Path)
+	assert.DirExists(t, a1.Worktree.Path)
+
+	a2, err := manager.Start(ctx, p, StartOptions{IssueNumber: 5, Attempt: AutoAttempt})
+	require.NoError(t, err)
+	assert.Equal(t, "a2", a2.Worktree.Attempt)
+
+	named, err := manager.Start(ctx, p, StartOptions{IssueNumber: 5, Attempt: "redis"})
+	require.NoError(t, err)
+	assert.Equal(t, "wt-app-5-redis", named.Worktree.ID)
+
+	again, err := manager.Start(ctx, p, StartOptions{IssueNumber: 5, Attempt: "a1"})
+	require.NoError(t, err)
+	assert.False(t, again.Created, "an existing attempt is reused")
+
+	_, err = manager.Start(ctx, p, StartOptions{IssueNumber: 5, Attempt: "1"})
+	assert.ErrorContains(t, err, "invalid attempt name")
+
+	worktrees, err := db.ListWorktreesByIssue(p.ID, 5)
+	require.NoError(t, err)
+	require.Len(t, worktrees, 4)
+	assert.Equal(t, primary.ID, worktrees[0].ID, "the primary comes first")
+
+	w, err := db.GetWorktreeByIssue(p.ID, 5)
+	require.NoError(t, err)
+	assert.Equal(t, primary.ID, w.ID)
+	assert.Contains(t, testutil.RunGit(t, sp.LocalPath, "branch", "--list", primary.Branch+"-*"), primary.Branch+"-redis")
+}
+
+func TestManager_CompareAttempts(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	testutil.CreateGitProjectWithConfig(t, db, "app", `{"test": {"command": "test -f ok.txt || { echo missing ok.txt; exit 3; }"}}`)
+	p, manager, primary := startTestWorktree(t, db, 5)
+	ctx := context.Background()
+
+	started, err := manager.Start(ctx, p, StartOptions{IssueNumber: 5, Attempt: "a1"})
+	require.NoError(t, err)
+	attempt := started.Worktree
+
+	testutil.GitCommitFile(t, primary.Path, "a.txt", "one\ntwo\n", "Add a")
+	testutil.GitCommitFile(t, attempt.Path, "ok.txt", "ok\n", "Add ok")
+	testutil.GitCommitFile(t, attempt.Path, "b.txt", "b\n", "Add b")
+	require.NoError(t, os.WriteFile(filepath.Join(attempt.Path, "scratch.txt"), []byte("x\n"), 0644))
+
+	worktrees, err := db.ListWorktreesByIssue(p.ID, 5)
+	require.NoError(t, err)
+	comparisons, err := manager.Compare(ctx, p, worktrees, CompareOptions{TestCommand: p.Config.Test.Command, Workers: 2})
+	require.NoError(t, err)
+	require.Len(t, comparisons, 2)
+
+	c := comparisons[0]
+	require.NoError(t, c.Err)
+	assert.Equal(t, primary.ID, c.Worktree.ID)
+	assert.Equal(t, 1, c.Commits)
+	assert.Equal(t, 1, c.Diff.Files)
+	assert.Equal(t, 2, c.Diff.Insertions)
+	assert.False(t, c.Dirty)
+	require.NotNil(t, c.Test)
+	assert.False(t, c.Test.Passed)
+	assert.Equal(t, 3, c.Test.ExitCode)
+	assert.Contains(t, c.Test.Output, "missing ok.txt")
+
+	c = comparisons[1]
+	require.NoError(t, c.Err)
+	assert.Equal(t, 2, c.Commits)
+	assert.Equal(t, 2, c.Diff.Files)
+	assert.True(t, c.Dirty)
+	require.NotNil(t, c.Test)
+	assert.True(t, c.Test.Passed)
+
+	comparisons, err = manager.Compare(ctx, p, worktrees, CompareOptions{})
+	require.NoError(t, err)
+	assert.Nil(t, comparisons[0].Test, "no test command, no test run")
+}
+
+func TestManager_CompareTestTimeout(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	testutil.CreateGitProjectWithConfig(t, db, "app", `{"test": {"command": "sleep 10", "timeout": "100ms"}}`)
+	p, manager, w := startTestWorktree(t, db, 1)
+
+	comparisons, err := manager.Compare(context.Background(), p, []storage.Worktree{*w}, CompareOptions{TestCommand: p.Config.Test.Command})
+	require.NoError(t, err)
+	require.NotNil(t, comparisons[0].Test)
+	assert.False(t, comparisons[0].Test.Passed)
+	assert.ErrorContains(t, comparisons[0].Test.Err, "timed out")
+}
+
+func TestManager_PickPromotesAttempt(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	sp := testutil.CreateGitProjectWithConfig(t, db, "app", `{"ports":[{"name":"web","range":"4400-4409"}]}`)
+	stubPortAvailable(t)
+	p, manager, primary := startTestWorktree(t, db, 5)
+	ctx := context.Background()
+
+	started, err := manager.Start(ctx, p, StartOptions{IssueNumber: 5, Attempt: "a1"})
+	require.NoError(t, err)
+	winner := started.Worktree
+	port := started.Ports[0].Port
+	testutil.GitCommitFile(t, primary.Path, "lost.txt", "x\n", "Commit on the losing attempt")
+
+	result, err := manager.Pick(ctx, p, winner, PickOptions{})
+	require.NoError(t, err)
+	assert.True(t, result.Promoted)
+	require.Len(t, result.Removed, 1)
+	assert.Equal(t, primary.ID, result.Removed[0].ID)
+	assert.NoDirExists(t, primary.Path)
+	assert.Empty(t, testutil.RunGit(t, sp.LocalPath, "branch", "--list", primary.Branch))
+
+	w, err := db.GetWorktreeByIssue(p.ID, 5)
+	require.NoError(t, err)
+	assert.Equal(t, "wt-app-5", w.ID)
+	assert.Empty(t, w.Attempt)
+	assert.Equal(t, winner.Branch, w.Branch)
+	assert.Equal(t, winner.Path, w.Path)
+	testutil.AssertWorktreeCount(t, db, 1)
+
+	ports, err := manager.Ports(p, w)
+	require.NoError(t, err)
+	require.Len(t, ports, 1)
+	assert.Equal(t, port, ports[0].Port, "ports move with the promoted attempt")
+}
+
+func TestManager_PickRefusesToLoseWork(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	sp := testutil.CreateGitProject(t, db, "app")
+	p, manager, primary := startTestWorktree(t, db, 5)
+	ctx := context.Background()
+
+	a1, err := manager.Start(ctx, p, StartOptions{IssueNumber: 5, Attempt: "a1"})
+	require.NoError(t, err)
+	a2, err := manager.Start(ctx, p, StartOptions{IssueNumber: 5, Attempt: "a2"})
+	require.NoError(t, err)
+
+	require.NoError(t, os.WriteFile(filepath.Join(a1.Worktree.Path, "notes.txt"), []byte("n\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(a2.Worktree.Path, "wip.txt"), []byte("w\n"), 0644))
+	_, err = manager.Park(ctx, p, a2.Worktree, ParkOptions{})
+	require.NoError(t, err)
+
+	result, err := manager.Pick(ctx, p, primary, PickOptions{KeepBranches: true})
+	require.ErrorIs(t, err, ErrWouldLoseWork)
+	require.Len(t, result.Blocked, 2)
+	assert.Equal(t, []string{"notes.txt"}, result.Blocked[0].Report.Untracked)
+	assert.True(t, result.Blocked[1].Parked)
+	testutil.AssertWorktreeCount(t, db, 3)
+
+	result, err = manager.Pick(ctx, p, primary, PickOptions{Force: true, KeepBranches: true})
+	require.NoError(t, err)
+	assert.False(t, result.Promoted)
+	assert.Len(t, result.Removed, 2)
+	testutil.AssertWorktreeCount(t, db, 1)
+	assert.NotEmpty(t, testutil.RunGit(t, sp.LocalPath, "branch", "--list", a1.Worktree.Branch), "branches are kept")
+	assert.Empty(t, testutil.RunGit(t, sp.LocalPath, "for-each-ref", WIPRefPrefix))
+}
@@ -0,0 +1,181 @@
+package worktree
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/paolorechia/issue-flow/internal/config"
+	"github.com/paolorechia/issue-flow/internal/project"
+	"github.com/paolorechia/issue-flow/internal/storage"
+)
+
+// Backport results.
+const (
+	BackportPicked   = "picked"
+	BackportExists   = "exists"
+	BackportConflict = "conflict"
+	BackportFailed   = "failed"
+)
+
+type BackportOptions struct {
+	// WorktreeBase is used when the project has no WorktreeDir.
+	WorktreeBase string
+	// Fetch refreshes the upstream remote once before the target branches are used.
+	Fetch bool
+}
+
+// BackportOutcome is the result of backporting to one target branch.
+type BackportOutcome struct {
+	Target string
+	// Worktree is nil when no worktree could be started.
+	Worktree *storage.Worktree
+	Ports    []Port
+	// Picked counts the commits applied; on a conflict, those before the
+	// conflicting one.
+	Picked int
+	// Skipped are the commits whose change the target already has.
+	Skipped   []string
+	Result    string
+	Conflicts []string
+	Warnings  []string
+	Err       error
+}
+
+// BackportAttempt returns the attempt name of an issue's backport to target,
+// e.g. "backport-release-1.4" for release/1.4. The prefix keeps names of
+// numeric targets such as 2.x valid attempt names, and characters attempt
+// names do not allow become '-'. Names too long for an attempt are cut and
+// end in a hash of the target, so long targets stay apart.
+func BackportAttempt(target string) string {
+	name := "backport-" + strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '.', r == '_', r == '-':
+			return r
+		}
+		return '-'
+	}, target)
+	if len(name) <= maxAttemptLength {
+		return name
+	}
+	sum := sha1.Sum([]byte(target))
+	suffix := "-" + hex.EncodeToString(sum[:])[:8]
+	return name[:maxAttemptLength-len(suffix)] + suffix
+}
+
+// BackportCommits lists the commits on source's branch that are not on its
+// base branch, oldest first, leaving out merges.
+func (m *Manager) BackportCommits(ctx context.Context, p *project.Project, source *storage.Worktree) ([]string, error) {
+	repo := config.ExpandPath(p.LocalPath)
+	base, err := m.BranchBaseRef(ctx, repo, p.UpstreamRemote(), source.BaseBranch)
+	if err != nil {
+		return nil, err
+	}
+	commits, err := m.git.RevList(ctx, repo, "--reverse", "--no-merges", base+"..refs/heads/"+source.Branch)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list commits of %s: %w", source.Branch, err)
+	}
+	if len(commits) == 0 {
+		return nil, fmt.Errorf("branch %s has no commits that are not on %s", source.Branch, base)
+	}
+	return commits, nil
+}
+
+// Backport starts a worktree for source's issue on each target branch, as an
+// attempt named after the target, and cherry-picks source's commits into it
+// with -x. Targets are independent: a conflict is left in progress in its
+// worktree, to be resolved there with 'git cherry-pick --continue', and
+// does not stop the other targets. A target whose backport worktree already
+// exists is left alone.
+func (m *Manager) Backport(ctx context.Context, p *project.Project, source *storage.Worktree, targets []string, opts BackportOptions) ([]BackportOutcome, []string, error) {
+	if source.Status == storage.WorktreeStatusArchived {
+		return nil, nil, fmt.Errorf("%s: %w", source.ID, ErrArchived)
+	}
+	repo := config.ExpandPath(p.LocalPath)
+
+	var warnings []string
+	if opts.Fetch {
+		result := &StartResult{}
+		if err := m.fetchUpstream(ctx, repo, p.UpstreamRemote(), result); err != nil {
+			return nil, nil, err
+		}
+		warnings = result.Warnings
+	}
+	commits, err := m.BackportCommits(ctx, p, source)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	outcomes := make([]BackportOutcome, len(targets))
+	for i, target := range targets {
+		outcomes[i] = m.backportOne(ctx, p, source, target, commits, opts)
+	}
+	return outcomes, warnings, nil
+}
+
+func (m *Manager) backportOne(ctx context.Context, p *project.Project, source *storage.Worktree, target string, commits []string, opts BackportOptions) BackportOutcome {
+	out := BackportOutcome{Target: target, Result: BackportFailed}
+	if target == source.BaseBranch {
+		out.Err = fmt.Errorf("%s is already based on %s", source.ID, target)
+		return out
+	}
+
+	started, err := m.Start(ctx, p, StartOptions{
+		IssueNumber:  source.IssueNumber,
+		WorktreeBase: opts.WorktreeBase,
+		Attempt:      BackportAttempt(target),
+		BaseBranch:   target,
+	})
+	if started != nil {
+		out.Worktree, out.Ports, out.Warnings = started.Worktree, started.Ports, started.Warnings
+	}
+	if err != nil {
+		out.Err = err
+		return out
+	}
+	if !started.Created {
+		out.Result = BackportExists
+		return out
+	}
+
+	w := started.Worktree
+	head, err := m.git.RevParse(ctx, w.Path, "HEAD")
+	if err != nil {
+		out.Err = fmt.Errorf("failed to resolve HEAD: %w", err)
+		return out
+	}
+	pickErr := m.git.CherryPick(ctx, w.Path, commits...)
+	for pickErr != nil {
+		// A commit whose change the target already has stops the
+		// cherry-pick as empty, with nothing staged; skip it and go on.
+		status, err := m.git.Status(ctx, w.Path)
+		if err != nil || status.Dirty() {
+			break
+		}
+		commit, err := m.git.RevParse(ctx, w.Path, "CHERRY_PICK_HEAD")
+		if err != nil {
+			break
+		}
+		out.Skipped = append(out.Skipped, commit)
+		pickErr = m.git.CherryPickSkip(ctx, w.Path)
+	}
+	if picked, err := m.git.RevList(ctx, w.Path, head+"..HEAD"); err == nil {
+		out.Picked = len(picked)
+	}
+	if pickErr == nil {
+		out.Result = BackportPicked
+		return out
+	}
+
+	if status, err := m.git.Status(ctx, w.Path); err == nil && len(status.Conflicted) > 0 {
+		out.Result, out.Conflicts = BackportConflict, status.Conflicted
+		return out
+	}
+	out.Err = fmt.Errorf("cherry-pick failed: %w", pickErr)
+	if err := m.git.CherryPickAbort(ctx, w.Path); err != nil {
+		out.Warnings = append(out.Warnings, fmt.Sprintf("failed to abort cherry-pick in %s: %v", w.Path, err))
+	}
+	return out
+}
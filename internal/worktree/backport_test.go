@@ -0,0 +1,121 @@
+package worktree
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/paolorechia/issue-flow/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBackportAttempt(t *testing.T) {
+	assert.Equal(t, "backport-release-1.4", BackportAttempt("release/1.4"))
+	assert.Equal(t, "backport-stable", BackportAttempt("stable"))
+	assert.Equal(t, "backport-hotfix-1.4-rc1", BackportAttempt("hotfix/1.4+rc1"))
+	long := "maintenance/2024.10-long-term-support"
+	for _, target := range []string{"1.4", "2.x", "v1/2024.10", "release/1.4+rc1", long} {
+		assert.NoError(t, ValidateAttempt(BackportAttempt(target)), target)
+	}
+	assert.Len(t, BackportAttempt(long), maxAttemptLength)
+	assert.NotEqual(t, BackportAttempt(long), BackportAttempt(long+"-2"))
+}
+
+func TestManager_Backport(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	sp := testutil.CreateGitProject(t, db, "app")
+	repo := sp.LocalPath
+	testutil.RunGit(t, repo, "branch", "release/1.5")
+	testutil.RunGit(t, repo, "checkout", "-q", "-b", "release/1.4")
+	testutil.GitCommitFile(t, repo, "README.md", "# release 1.4\n", "Diverge 1.4")
+	testutil.RunGit(t, repo, "checkout", "-q", "main")
+
+	p, manager, source := startTestWorktree(t, db, 1)
+	testutil.GitCommitFile(t, source.Path, "fix.txt", "fixed\n", "Fix the bug")
+	testutil.GitCommitFile(t, source.Path, "README.md", "# fixed\n", "Document the fix")
+	ctx := context.Background()
+
+	outcomes, _, err := manager.Backport(ctx, p, source, []string{"release/1.4", "release/1.5", "release/9.9"}, BackportOptions{})
+	require.NoError(t, err)
+	require.Len(t, outcomes, 3)
+
+	conflict := outcomes[0]
+	assert.Equal(t, BackportConflict, conflict.Result)
+	assert.Equal(t, 1, conflict.Picked, "the commit before the conflict is applied")
+	assert.Equal(t, []string{"README.md"}, conflict.Conflicts)
+	require.NotNil(t, conflict.Worktree)
+	assert.Equal(t, "wt-app-1-backport-release-1.4", conflict.Worktree.ID)
+	assert.Equal(t, "release/1.4", conflict.Worktree.BaseBranch)
+	assert.FileExists(t, filepath.Join(conflict.Worktree.Path, ".git"))
+
+	picked := outcomes[1]
+	assert.Equal(t, BackportPicked, picked.Result)
+	assert.Equal(t, 2, picked.Picked)
+	assert.FileExists(t, filepath.Join(picked.Worktree.Path, "fix.txt"))
+	assert.Equal(t, []string{"Document the fix", "Fix the bug"}, subjects(t, picked.Worktree.Path, "release/1.5..HEAD"))
+	body := testutil.RunGit(t, picked.Worktree.Path, "log", "-1", "--format=%b")
+	assert.Contains(t, body, "(cherry picked from commit ")
+
+	assert.Equal(t, BackportFailed, outcomes[2].Result)
+	assert.ErrorContains(t, outcomes[2].Err, "base branch release/9.9 does not exist")
+	testutil.AssertWorktreeCount(t, db, 3)
+
+	outcomes, _, err = manager.Backport(ctx, p, source, []string{"release/1.5"}, BackportOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, BackportExists, outcomes[0].Result)
+}
+
+func TestManager_BackportToNumericBranch(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	sp := testutil.CreateGitProject(t, db, "app")
+	testutil.RunGit(t, sp.LocalPath, "branch", "1.4")
+
+	p, manager, source := startTestWorktree(t, db, 1)
+	testutil.GitCommitFile(t, source.Path, "fix.txt", "fixed\n", "Fix the bug")
+
+	outcomes, _, err := manager.Backport(context.Background(), p, source, []string{"1.4"}, BackportOptions{})
+	require.NoError(t, err)
+	require.Len(t, outcomes, 1)
+	require.NoError(t, outcomes[0].Err)
+	assert.Equal(t, BackportPicked, outcomes[0].Result)
+	assert.Equal(t, "wt-app-1-backport-1.4", outcomes[0].Worktree.ID)
+	assert.FileExists(t, filepath.Join(outcomes[0].Worktree.Path, "fix.txt"))
+}
+
+func TestManager_BackportSkipsCommitsAlreadyOnTarget(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	sp := testutil.CreateGitProject(t, db, "app")
+	repo := sp.LocalPath
+
+	p, manager, source := startTestWorktree(t, db, 1)
+	testutil.GitCommitFile(t, source.Path, "fix.txt", "fixed\n", "Fix the bug")
+	fix := testutil.RunGit(t, source.Path, "rev-parse", "HEAD")
+	testutil.GitCommitFile(t, source.Path, "docs.txt", "documented\n", "Document the fix")
+
+	long := "maintenance/2024.10-long-term-support"
+	testutil.RunGit(t, repo, "branch", long)
+	testutil.RunGit(t, repo, "checkout", "-q", long)
+	testutil.RunGit(t, repo, "cherry-pick", fix)
+	testutil.RunGit(t, repo, "checkout", "-q", "main")
+
+	outcomes, _, err := manager.Backport(context.Background(), p, source, []string{long}, BackportOptions{})
+	require.NoError(t, err)
+	require.Len(t, outcomes, 1)
+	o := outcomes[0]
+	require.NoError(t, o.Err)
+	assert.Equal(t, BackportPicked, o.Result)
+	assert.Equal(t, 1, o.Picked)
+	assert.Equal(t, []string{fix}, o.Skipped)
+	assert.Equal(t, BackportAttempt(long), o.Worktree.Attempt)
+	assert.Equal(t, []string{"Document the fix"}, subjects(t, o.Worktree.Path, long+"..HEAD"))
+}
+
+func TestManager_BackportWithoutCommits(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	testutil.CreateGitProject(t, db, "app")
+	p, manager, source := startTestWorktree(t, db, 1)
+
+	_, _, err := manager.Backport(context.Background(), p, source, []string{"main"}, BackportOptions{})
+	assert.ErrorContains(t, err, "has no commits that are not on main")
+}
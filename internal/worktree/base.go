@@ -0,0 +1,153 @@
+package worktree
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+
+	"github.com/paolorechia/issue-flow/internal/project"
+)
+
+// BaseBranchPrompt is returned by Start when the issue type's base branch
+// rule asks the user to choose. Callers ask and start again with
+// StartOptions.BaseBranch set.
+type BaseBranchPrompt struct {
+	IssueType string
+	// Candidates are the branches matching the rule's glob, latest first.
+	Candidates []string
+}
+
+func (e *BaseBranchPrompt) Error() string {
+	return fmt.Sprintf("issue type %s asks for a base branch", e.IssueType)
+}
+
+// BaseBranchRule returns the base branch rule configured for an issue type.
+func BaseBranchRule(p *project.Project, issueType string) project.BaseBranchRule {
+	for _, it := range p.Config.IssueTypes {
+		if it.Name == issueType {
+			return it.BaseBranch
+		}
+	}
+	return project.BaseBranchRule{}
+}
+
+// resolveBaseBranch returns the base branch of a new worktree, "" meaning
+// the default branch. A stacked worktree shares its parent's base branch.
+// When the rule matches a glob, upstream is fetched first (once, clearing
+// opts.Fetch) so the latest branch is known.
+func (m *Manager) resolveBaseBranch(ctx context.Context, repo string, p *project.Project, issueType string, opts *StartOptions, result *StartResult) (string, error) {
+	if opts.Parent != nil {
+		if opts.BaseBranch != "" && opts.BaseBranch != opts.Parent.BaseBranch {
+			return "", fmt.Errorf("cannot base a worktree stacked on %s on %s", opts.Parent.ID, opts.BaseBranch)
+		}
+		return opts.Parent.BaseBranch, nil
+	}
+	if opts.BaseBranch != "" {
+		if _, err := m.BranchBaseRef(ctx, repo, p.UpstreamRemote(), opts.BaseBranch); err != nil {
+			return "", err
+		}
+		return opts.BaseBranch, nil
+	}
+
+	rule := BaseBranchRule(p, issueType)
+	var candidates []string
+	if rule.Match != "" {
+		if opts.Fetch {
+			if err := m.fetchUpstream(ctx, repo, p.UpstreamRemote(), result); err != nil {
+				return "", err
+			}
+			opts.Fetch = false
+		}
+		var err error
+		if candidates, err = m.MatchingBranches(ctx, repo, p.UpstreamRemote(), rule.Match); err != nil {
+			return "", err
+		}
+	}
+
+	switch {
+	case rule.Prompt:
+		return "", &BaseBranchPrompt{IssueType: issueType, Candidates: candidates}
+	case rule.Match != "":
+		if len(candidates) == 0 {
+			return "", fmt.Errorf("no branch matches %q, the base branch of issue type %s", rule.Match, issueType)
+		}
+		return candidates[0], nil
+	case rule.Branch != "":
+		if _, err := m.BranchBaseRef(ctx, repo, p.UpstreamRemote(), rule.Branch); err != nil {
+			return "", err
+		}
+		return rule.Branch, nil
+	}
+	return "", nil
+}
+
+// MatchingBranches lists the local and upstream branches matching glob, the
+// latest version first.
+func (m *Manager) MatchingBranches(ctx context.Context, repo, upstream, glob string) ([]string, error) {
+	local, err := m.git.ListBranches(ctx, repo, glob)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list branches: %w", err)
+	}
+	remote, err := m.git.ListRemoteBranches(ctx, repo, upstream, glob)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list branches: %w", err)
+	}
+	branches := uniqueStrings(local, remote)
+	sort.Slice(branches, func(i, j int) bool { return versionLess(branches[j], branches[i]) })
+	return branches, nil
+}
+
+// BranchBaseRef returns the ref to branch from and sync with for a base
+// branch: <upstream>/<branch> when it exists, otherwise the local branch. An
+// empty branch means the default branch, as returned by BaseRef.
+func (m *Manager) BranchBaseRef(ctx context.Context, repo, upstream, branch string) (string, error) {
+	if branch == "" {
+		return m.BaseRef(ctx, repo, upstream)
+	}
+	remote, err := m.git.RefExists(ctx, repo, "refs/remotes/"+upstream+"/"+branch)
+	if err != nil {
+		return "", err
+	}
+	if remote {
+		return upstream + "/" + branch, nil
+	}
+	local, err := m.git.RefExists(ctx, repo, "refs/heads/"+branch)
+	if err != nil {
+		return "", err
+	}
+	if local {
+		return branch, nil
+	}
+	return "", fmt.Errorf("base branch %s does not exist", branch)
+}
+
+// versionLess orders strings comparing runs of digits by their value, so
+// release/1.9 sorts before release/1.10.
+func versionLess(a, b string) bool {
+	for a != "" && b != "" {
+		da, db := digitPrefix(a), digitPrefix(b)
+		if da != "" && db != "" {
+			na, _ := strconv.ParseUint(da, 10, 64)
+			nb, _ := strconv.ParseUint(db, 10, 64)
+			if na != nb {
+				return na < nb
+			}
+			a, b = a[len(da):], b[len(db):]
+			continue
+		}
+		if a[0] != b[0] {
+			return a[0] < b[0]
+		}
+		a, b = a[1:], b[1:]
+	}
+	return len(a) < len(b)
+}
+
+func digitPrefix(s string) string {
+	i := 0
+	for i < len(s) && s[i] >= '0' && s[i] <= '9' {
+		i++
+	}
+	return s[:i]
+}
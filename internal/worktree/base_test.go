@@ -0,0 +1,106 @@
+package worktree
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/paolorechia/issue-flow/internal/git"
+	"github.com/paolorechia/issue-flow/internal/project"
+	"github.com/paolorechia/issue-flow/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const releaseConfig = `{"issue_types": [
+	{"name": "hotfix", "base_branch": {"match": "release/*"}},
+	{"name": "backport", "base_branch": {"match": "release/*", "prompt": true}},
+	{"name": "feature", "base_branch": {"branch": "develop"}}
+]}`
+
+// createReleaseBranches adds release/1.9 and release/1.10 to repo, each with
+// a file named after it.
+func createReleaseBranches(t *testing.T, repo string) {
+	t.Helper()
+	for _, version := range []string{"1.9", "1.10"} {
+		testutil.RunGit(t, repo, "checkout", "-q", "-b", "release/"+version, "main")
+		testutil.GitCommitFile(t, repo, "release-"+version+".txt", version+"\n", "Release "+version)
+	}
+	testutil.RunGit(t, repo, "checkout", "-q", "main")
+}
+
+func TestVersionLess(t *testing.T) {
+	assert.True(t, versionLess("release/1.9", "release/1.10"))
+	assert.False(t, versionLess("release/1.10", "release/1.9"))
+	assert.True(t, versionLess("release/1.4", "release/1.4.1"))
+	assert.True(t, versionLess("release/a", "release/b"))
+	assert.False(t, versionLess("release/2", "release/2"))
+}
+
+func TestManager_StartBaseBranchMatch(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	sp := testutil.CreateGitProjectWithConfig(t, db, "app", releaseConfig)
+	createReleaseBranches(t, sp.LocalPath)
+	p, err := project.NewManager(db).Get("app")
+	require.NoError(t, err)
+	manager := NewManager(db, git.NewClient(nil))
+	ctx := context.Background()
+
+	result, err := manager.Start(ctx, p, StartOptions{IssueNumber: 1, Title: "Fix crash", Type: "hotfix"})
+	require.NoError(t, err)
+	w := result.Worktree
+	assert.Equal(t, "release/1.10", w.BaseBranch)
+	assert.FileExists(t, filepath.Join(w.Path, "release-1.10.txt"))
+	assert.Equal(t, "release/1.10", testutil.AssertWorktreeExists(t, db, w.ID).BaseBranch)
+
+	_, err = manager.Start(ctx, p, StartOptions{IssueNumber: 1, BaseBranch: "release/1.9"})
+	assert.ErrorContains(t, err, "already exists and is not based on release/1.9")
+
+	testutil.RunGit(t, sp.LocalPath, "checkout", "-q", "release/1.10")
+	testutil.GitCommitFile(t, sp.LocalPath, "patch.txt", "p\n", "Patch release")
+	testutil.RunGit(t, sp.LocalPath, "checkout", "-q", "main")
+	testutil.GitCommitFile(t, sp.LocalPath, "main.txt", "m\n", "Main change")
+
+	outcomes, _, err := manager.Sync(ctx, SyncOptions{NoFetch: true}, 1)
+	require.NoError(t, err)
+	require.Len(t, outcomes, 1)
+	assert.Equal(t, SyncRebased, outcomes[0].Result)
+	assert.Equal(t, "release/1.10", outcomes[0].Base)
+	assert.FileExists(t, filepath.Join(w.Path, "patch.txt"))
+	assert.NoFileExists(t, filepath.Join(w.Path, "main.txt"), "synced with its base branch, not main")
+
+	_, err = manager.Start(ctx, p, StartOptions{IssueNumber: 2, Title: "New thing", Type: "feature"})
+	assert.ErrorContains(t, err, "base branch develop does not exist")
+}
+
+func TestManager_StartBaseBranchPrompt(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	sp := testutil.CreateGitProjectWithConfig(t, db, "app", releaseConfig)
+	createReleaseBranches(t, sp.LocalPath)
+	p, err := project.NewManager(db).Get("app")
+	require.NoError(t, err)
+	manager := NewManager(db, git.NewClient(nil))
+	ctx := context.Background()
+
+	opts := StartOptions{IssueNumber: 1, Title: "Port fix", Type: "backport"}
+	_, err = manager.Start(ctx, p, opts)
+	var prompt *BaseBranchPrompt
+	require.True(t, errors.As(err, &prompt), "got %v", err)
+	assert.Equal(t, []string{"release/1.10", "release/1.9"}, prompt.Candidates)
+	testutil.AssertWorktreeCount(t, db, 0)
+
+	opts.BaseBranch = "release/2.0"
+	_, err = manager.Start(ctx, p, opts)
+	assert.ErrorContains(t, err, "base branch release/2.0 does not exist")
+
+	opts.BaseBranch = "release/1.9"
+	result, err := manager.Start(ctx, p, opts)
+	require.NoError(t, err)
+	assert.Equal(t, "release/1.9", result.Worktree.BaseBranch)
+	assert.FileExists(t, filepath.Join(result.Worktree.Path, "release-1.9.txt"))
+
+	child, err := manager.Start(ctx, p, StartOptions{IssueNumber: 2, Title: "On top", Type: "backport", Parent: result.Worktree})
+	require.NoError(t, err, "stacked worktrees share their parent's base branch")
+	assert.Equal(t, "release/1.9", child.Worktree.BaseBranch)
+}
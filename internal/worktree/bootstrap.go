@@ -0,0 +1,246 @@
+package worktree
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"time"
+
+	"github.com/paolorechia/issue-flow/internal/config"
+	"github.com/paolorechia/issue-flow/internal/project"
+	"github.com/paolorechia/issue-flow/internal/storage"
+)
+
+// DefaultBootstrapTimeout bounds each bootstrap step when neither the step
+// nor the bootstrap config sets a timeout.
+const DefaultBootstrapTimeout = 10 * time.Minute
+
+// bootstrapStep is one resolved file or command step.
+type bootstrapStep struct {
+	name    string
+	timeout time.Duration
+	run     func(ctx context.Context) error
+}
+
+// Bootstrap runs the project's bootstrap steps in the worktree, streaming
+// step headers and command output to stdout and stderr, and records the
+// outcome on the worktree row. It stops at the first failing step.
+func (m *Manager) Bootstrap(ctx context.Context, p *project.Project, w *storage.Worktree, stdout, stderr io.Writer) error {
+	env, err := m.Env(p, w)
+	var steps []bootstrapStep
+	if err == nil {
+		steps, err = bootstrapSteps(p, w, append(os.Environ(), env...), stdout, stderr)
+	}
+	if err == nil {
+		for i, step := range steps {
+			fmt.Fprintf(stdout, "→ [%d/%d] %s\n", i+1, len(steps), step.name)
+			if err = runStep(ctx, step); err != nil {
+				err = fmt.Errorf("bootstrap step %d (%s) failed: %w", i+1, step.name, err)
+				break
+			}
+		}
+	}
+
+	w.BootstrapStatus, w.BootstrapError = storage.BootstrapSucceeded, ""
+	if err != nil {
+		w.BootstrapStatus, w.BootstrapError = storage.BootstrapFailed, err.Error()
+	}
+	if dbErr := m.withDB(func(db *storage.Database) error { return db.UpdateWorktree(w) }); dbErr != nil {
+		return errors.Join(err, fmt.Errorf("failed to record bootstrap status: %w", dbErr))
+	}
+	return err
+}
+
+func runStep(ctx context.Context, step bootstrapStep) error {
+	ctx, cancel := context.WithTimeout(ctx, step.timeout)
+	defer cancel()
+
+	err := step.run(ctx)
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return fmt.Errorf("timed out after %s", step.timeout)
+	}
+	return err
+}
+
+func bootstrapSteps(p *project.Project, w *storage.Worktree, env []string, stdout, stderr io.Writer) ([]bootstrapStep, error) {
+	cfg := p.Config.Bootstrap
+	defaultTimeout, err := parseTimeout(cfg.Timeout, DefaultBootstrapTimeout)
+	if err != nil {
+		return nil, err
+	}
+	repo := config.ExpandPath(p.LocalPath)
+
+	var steps []bootstrapStep
+	for _, f := range cfg.Files {
+		timeout, err := parseTimeout(f.Timeout, defaultTimeout)
+		if err != nil {
+			return nil, err
+		}
+		if f.Path == "" || filepath.IsAbs(f.Path) || !filepath.IsLocal(f.Path) {
+			return nil, fmt.Errorf("bootstrap file path %q must be relative and inside the repository", f.Path)
+		}
+		src := filepath.Join(repo, f.Path)
+		dst := filepath.Join(w.Path, f.Path)
+
+		var place func(ctx context.Context, src, dst string) error
+		switch f.Mode {
+		case "", project.BootstrapCopy:
+			place = copyPath
+		case project.BootstrapSymlink:
+			place = func(_ context.Context, src, dst string) error { return symlinkPath(src, dst) }
+		default:
+			return nil, fmt.Errorf("unknown bootstrap file mode %q", f.Mode)
+		}
+
+		optional := f.Optional
+		mode := f.Mode
+		if mode == "" {
+			mode = project.BootstrapCopy
+		}
+		steps = append(steps, bootstrapStep{
+			name:    mode + " " + f.Path,
+			timeout: timeout,
+			run: func(ctx context.Context) error {
+				if _, err := os.Lstat(src); err != nil {
+					if optional && errors.Is(err, fs.ErrNotExist) {
+						return nil
+					}
+					return err
+				}
+				return place(ctx, src, dst)
+			},
+		})
+	}
+
+	for _, c := range cfg.Commands {
+		timeout, err := parseTimeout(c.Timeout, defaultTimeout)
+		if err != nil {
+			return nil, err
+		}
+		if c.Run == "" {
+			return nil, fmt.Errorf("bootstrap command has no run field")
+		}
+		run, dir := c.Run, filepath.Join(w.Path, c.Dir)
+		steps = append(steps, bootstrapStep{
+			name:    run,
+			timeout: timeout,
+			run: func(ctx context.Context) error {
+				cmd := shellCommand(ctx, run)
+				cmd.Dir = dir
+				cmd.Env = env
+				cmd.Stdout = stdout
+				cmd.Stderr = stderr
+				killProcessGroup(cmd)
+				cmd.WaitDelay = 5 * time.Second
+				return cmd.Run()
+			},
+		})
+	}
+
+	return steps, nil
+}
+
+func parseTimeout(value string, fallback time.Duration) (time.Duration, error) {
+	if value == "" {
+		return fallback, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid timeout %q: %w", value, err)
+	}
+	if d <= 0 {
+		return 0, fmt.Errorf("timeout %q must be positive", value)
+	}
+	return d, nil
+}
+
+func shellCommand(ctx context.Context, script string) *exec.Cmd {
+	if runtime.GOOS == "windows" {
+		return exec.CommandContext(ctx, "cmd", "/C", script)
+	}
+	return exec.CommandContext(ctx, "sh", "-c", script)
+}
+
+// copyPath copies a file or directory tree, preserving permissions and
+// recreating symlinks. Existing files at dst are overwritten.
+func copyPath(ctx context.Context, src, dst string) error {
+	return filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		switch {
+		case d.IsDir():
+			return os.MkdirAll(target, info.Mode().Perm())
+		case d.Type()&fs.ModeSymlink != 0:
+			link, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+			if err := os.Remove(target); err != nil && !errors.Is(err, fs.ErrNotExist) {
+				return err
+			}
+			return os.Symlink(link, target)
+		default:
+			return copyFile(path, target, info.Mode().Perm())
+		}
+	})
+}
+
+func copyFile(src, dst string, perm fs.FileMode) error {
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return err
+	}
+
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, perm)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// symlinkPath links dst to src. An existing symlink at dst is replaced; any
+// other existing file is left alone and reported as an error.
+func symlinkPath(src, dst string) error {
+	if info, err := os.Lstat(dst); err == nil {
+		if info.Mode()&fs.ModeSymlink == 0 {
+			return fmt.Errorf("%s already exists and is not a symlink", dst)
+		}
+		if err := os.Remove(dst); err != nil {
+			return err
+		}
+	}
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return err
+	}
+	return os.Symlink(src, dst)
+}
@@ -0,0 +1,116 @@
+package worktree
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/paolorechia/issue-flow/internal/project"
+	"github.com/paolorechia/issue-flow/internal/storage"
+	"github.com/paolorechia/issue-flow/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestManager_Bootstrap(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("bootstrap commands in this test use sh")
+	}
+
+	db := testutil.NewTestDB(t)
+	sp := testutil.CreateGitProject(t, db, "app")
+	p, manager, w := startTestWorktree(t, db, 1)
+
+	require.NoError(t, os.WriteFile(filepath.Join(sp.LocalPath, ".env"), []byte("SECRET=1\n"), 0600))
+	require.NoError(t, os.MkdirAll(filepath.Join(sp.LocalPath, "cache", "deep"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(sp.LocalPath, "cache", "deep", "blob"), []byte("x"), 0644))
+
+	p.Config.Bootstrap = project.BootstrapConfig{
+		Files: []project.BootstrapFile{
+			{Path: ".env"},
+			{Path: "cache", Mode: project.BootstrapSymlink},
+			{Path: ".env.local", Optional: true},
+		},
+		Commands: []project.BootstrapCommand{
+			{Run: `echo "issue $ISSUE_FLOW_ISSUE" > bootstrapped.txt && echo done`},
+		},
+	}
+
+	var stdout, stderr bytes.Buffer
+	require.NoError(t, manager.Bootstrap(context.Background(), p, w, &stdout, &stderr))
+
+	content, err := os.ReadFile(filepath.Join(w.Path, ".env"))
+	require.NoError(t, err)
+	assert.Equal(t, "SECRET=1\n", string(content))
+	info, err := os.Stat(filepath.Join(w.Path, ".env"))
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	link, err := os.Readlink(filepath.Join(w.Path, "cache"))
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(sp.LocalPath, "cache"), link)
+
+	content, err = os.ReadFile(filepath.Join(w.Path, "bootstrapped.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, "issue 1\n", string(content))
+	assert.Contains(t, stdout.String(), "[4/4]")
+	assert.Contains(t, stdout.String(), "done\n")
+
+	row := testutil.AssertWorktreeExists(t, db, w.ID)
+	assert.Equal(t, storage.BootstrapSucceeded, row.BootstrapStatus)
+	assert.Empty(t, row.BootstrapError)
+}
+
+func TestManager_BootstrapRecordsFailure(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("bootstrap commands in this test use sh")
+	}
+
+	db := testutil.NewTestDB(t)
+	testutil.CreateGitProject(t, db, "app")
+	p, manager, w := startTestWorktree(t, db, 1)
+	ctx := context.Background()
+
+	p.Config.Bootstrap = project.BootstrapConfig{
+		Commands: []project.BootstrapCommand{
+			{Run: "exit 3"},
+			{Run: "touch never.txt"},
+		},
+	}
+	var out bytes.Buffer
+	err := manager.Bootstrap(ctx, p, w, &out, &out)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "step 1 (exit 3)")
+	assert.NoFileExists(t, filepath.Join(w.Path, "never.txt"))
+
+	row := testutil.AssertWorktreeExists(t, db, w.ID)
+	assert.Equal(t, storage.BootstrapFailed, row.BootstrapStatus)
+	assert.Contains(t, row.BootstrapError, "exit status 3")
+
+	p.Config.Bootstrap.Commands = []project.BootstrapCommand{{Run: "sleep 5", Timeout: "50ms"}}
+	err = manager.Bootstrap(ctx, p, w, &out, &out)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "timed out after 50ms")
+
+	p.Config.Bootstrap.Commands = []project.BootstrapCommand{{Run: "true"}}
+	require.NoError(t, manager.Bootstrap(ctx, p, w, &out, &out))
+	assert.Equal(t, storage.BootstrapSucceeded, testutil.AssertWorktreeExists(t, db, w.ID).BootstrapStatus)
+}
+
+func TestManager_BootstrapRejectsPathsOutsideWorktree(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	testutil.CreateGitProject(t, db, "app")
+	p, manager, w := startTestWorktree(t, db, 1)
+
+	for _, path := range []string{"../../.ssh/config", "..", "sub/../../escape", "/etc/passwd"} {
+		p.Config.Bootstrap = project.BootstrapConfig{Files: []project.BootstrapFile{{Path: path, Optional: true}}}
+		var out bytes.Buffer
+		err := manager.Bootstrap(context.Background(), p, w, &out, &out)
+		require.Error(t, err, path)
+		assert.Contains(t, err.Error(), "must be relative and inside the repository", path)
+	}
+	assert.Equal(t, storage.BootstrapFailed, testutil.AssertWorktreeExists(t, db, w.ID).BootstrapStatus)
+}
@@ -0,0 +1,81 @@
+package worktree
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/paolorechia/issue-flow/internal/project"
+)
+
+const (
+	DefaultBranchPattern = "{prefix}/{issue-number}-{slug}"
+	DefaultMaxSlugLength = 50
+	DefaultBranchPrefix  = "feature"
+)
+
+// BranchName expands cfg.Pattern for an issue. Supported placeholders are
+// {prefix}, {issue-number} and {slug}.
+func BranchName(cfg project.BranchConfig, prefix string, issueNumber int, title string) string {
+	pattern := cfg.Pattern
+	if pattern == "" {
+		pattern = DefaultBranchPattern
+	}
+	maxLen := cfg.MaxSlugLength
+	if maxLen <= 0 {
+		maxLen = DefaultMaxSlugLength
+	}
+	if prefix == "" {
+		prefix = DefaultBranchPrefix
+	}
+
+	slug := Slugify(title, maxLen)
+	if slug == "" {
+		slug = "issue"
+	}
+
+	name := strings.NewReplacer(
+		"{prefix}", prefix,
+		"{issue-number}", strconv.Itoa(issueNumber),
+		"{slug}", slug,
+	).Replace(pattern)
+	return strings.Trim(name, "-/")
+}
+
+// Slugify lowercases s, replaces runs of non-alphanumerics with a single dash
+// and truncates the result to maxLen without leaving a trailing dash.
+func Slugify(s string, maxLen int) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(s) {
+		if r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)) {
+			b.WriteRune(r)
+			dash = false
+			continue
+		}
+		if !dash && b.Len() > 0 {
+			b.WriteByte('-')
+			dash = true
+		}
+	}
+
+	slug := b.String()
+	if maxLen > 0 && len(slug) > maxLen {
+		slug = slug[:maxLen]
+	}
+	return strings.Trim(slug, "-")
+}
+
+// BranchPrefix returns the branch prefix for an issue type, preferring the
+// project's IssueType configuration.
+func BranchPrefix(p *project.Project, issueType string) string {
+	for _, it := range p.Config.IssueTypes {
+		if it.Name == issueType && it.BranchPrefix != "" {
+			return it.BranchPrefix
+		}
+	}
+	if issueType != "" {
+		return issueType
+	}
+	return DefaultBranchPrefix
+}
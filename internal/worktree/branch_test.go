@@ -0,0 +1,51 @@
+package worktree
+
+import (
+	"testing"
+
+	"github.com/paolorechia/issue-flow/internal/project"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSlugify(t *testing.T) {
+	assert.Equal(t, "add-oauth-login", Slugify("Add OAuth login!", 50))
+	assert.Equal(t, "fix-crash-in-v2-parser", Slugify("  Fix: crash in v2 parser ", 50))
+	assert.Equal(t, "a-very", Slugify("A very long title", 7))
+	assert.Equal(t, "caf", Slugify("Café", 50))
+	assert.Equal(t, "", Slugify("!!!", 50))
+}
+
+func TestBranchName(t *testing.T) {
+	cfg := project.BranchConfig{Pattern: "{prefix}/{issue-number}-{slug}", MaxSlugLength: 10}
+	assert.Equal(t, "fix/12-login-brok", BranchName(cfg, "fix", 12, "Login broken on Safari"))
+
+	assert.Equal(t, "feature/3-issue", BranchName(project.BranchConfig{}, "", 3, ""))
+
+	custom := project.BranchConfig{Pattern: "issue-{issue-number}"}
+	assert.Equal(t, "issue-99", BranchName(custom, "feature", 99, "Ignored"))
+}
+
+func TestBranchPrefix(t *testing.T) {
+	p := &project.Project{Config: project.ProjectConfig{
+		IssueTypes: []project.IssueType{{Name: "compliance", BranchPrefix: "compliance"}, {Name: "bug", BranchPrefix: "fix"}},
+	}}
+
+	assert.Equal(t, "fix", BranchPrefix(p, "bug"))
+	assert.Equal(t, "chore", BranchPrefix(p, "chore"))
+	assert.Equal(t, "feature", BranchPrefix(p, ""))
+}
+
+func TestPath(t *testing.T) {
+	p := &project.Project{ID: "app", WorktreeDir: "/work/app"}
+	path, err := Path(p, 5, "/base")
+	assert.NoError(t, err)
+	assert.Equal(t, "/work/app/issue-5", path)
+
+	p.WorktreeDir = ""
+	path, err = Path(p, 5, "/base")
+	assert.NoError(t, err)
+	assert.Equal(t, "/base/app/issue-5", path)
+
+	_, err = Path(p, 5, "")
+	assert.Error(t, err)
+}
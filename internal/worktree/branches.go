@@ -0,0 +1,258 @@
+package worktree
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/paolorechia/issue-flow/internal/config"
+	"github.com/paolorechia/issue-flow/internal/git"
+	"github.com/paolorechia/issue-flow/internal/project"
+	"github.com/paolorechia/issue-flow/internal/storage"
+)
+
+// Branch GC reasons, besides ReasonMerged.
+const (
+	ReasonNoWorktree = "no-worktree"
+	ReasonArchived   = "archived"
+)
+
+type BranchGCOptions struct {
+	ProjectID string
+	// Remote also selects the remote-tracking branches of each project's
+	// push remote that are merged or belong to an archived worktree.
+	Remote bool
+}
+
+// BranchCandidate is an issue branch selected for deletion and why.
+type BranchCandidate struct {
+	ProjectID string
+	// Branch is the branch name, prefixed with the remote for a
+	// remote-tracking branch, e.g. origin/feature/12-login.
+	Branch string
+	// Ref is the full ref, e.g. refs/heads/feature/12-login.
+	Ref        string
+	Remote     bool
+	Reasons    []string
+	LastCommit time.Time
+	// Unpushed are the commits of a local branch that are on no remote and
+	// not on the base branch. Branches with unpushed commits are kept.
+	Unpushed []git.Commit
+
+	repo string
+}
+
+// Safe reports whether deleting the branch loses no commits.
+func (c BranchCandidate) Safe() bool {
+	return len(c.Unpushed) == 0
+}
+
+// BranchGCOutcome is the result of deleting one candidate.
+type BranchGCOutcome struct {
+	Candidate BranchCandidate
+	// Kept is true when the candidate was not deleted because it has
+	// unpushed commits.
+	Kept bool
+	Err  error
+}
+
+// PlanBranchGC lists the local branches matching each project's branch
+// pattern that are merged into their base branch, have no worktree row or
+// belong to an archived worktree. Branches checked out in a worktree or
+// used by an active, parked or review worktree are never selected.
+// Candidates are ordered by project, local branches first.
+func (m *Manager) PlanBranchGC(ctx context.Context, opts BranchGCOptions) ([]BranchCandidate, error) {
+	pm := project.NewManager(m.db)
+	var projects []project.Project
+	if opts.ProjectID != "" {
+		p, err := pm.Get(opts.ProjectID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load project %s: %w", opts.ProjectID, err)
+		}
+		projects = append(projects, *p)
+	} else {
+		var err error
+		if projects, err = pm.List(); err != nil {
+			return nil, fmt.Errorf("failed to list projects: %w", err)
+		}
+	}
+
+	var candidates []BranchCandidate
+	for i := range projects {
+		p := &projects[i]
+		if p.LocalPath == "" {
+			continue
+		}
+		found, err := m.planProjectBranchGC(ctx, p, opts)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", p.ID, err)
+		}
+		candidates = append(candidates, found...)
+	}
+	return candidates, nil
+}
+
+func (m *Manager) planProjectBranchGC(ctx context.Context, p *project.Project, opts BranchGCOptions) ([]BranchCandidate, error) {
+	repo := config.ExpandPath(p.LocalPath)
+	pattern, err := regexp.Compile(IssueBranchRegexp(p.Config.BranchConfig))
+	if err != nil {
+		return nil, fmt.Errorf("invalid branch pattern: %w", err)
+	}
+
+	// status maps each branch with a worktree row to the row's status; base
+	// branches are recorded as active so they are never selected.
+	status := make(map[string]string)
+	var rows []storage.Worktree
+	err = m.withDB(func(db *storage.Database) error {
+		live, err := db.ListWorktreesByProject(p.ID)
+		if err != nil {
+			return err
+		}
+		archived, err := db.ListArchivedWorktrees(p.ID)
+		rows = append(live, archived...)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list worktrees: %w", err)
+	}
+	for _, w := range rows {
+		status[w.Branch] = w.Status
+		if w.BaseBranch != "" {
+			status[w.BaseBranch] = storage.WorktreeStatusActive
+		}
+	}
+	checkedOut, err := m.git.WorktreeList(ctx, repo)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list worktrees: %w", err)
+	}
+	for _, wt := range checkedOut {
+		if wt.Branch != "" {
+			status[wt.Branch] = storage.WorktreeStatusActive
+		}
+	}
+
+	base, err := m.BaseRef(ctx, repo, p.UpstreamRemote())
+	if err != nil {
+		return nil, err
+	}
+	defaultBranch, err := m.git.DefaultBranch(ctx, repo)
+	if err != nil {
+		return nil, fmt.Errorf("failed to determine default branch: %w", err)
+	}
+	status[defaultBranch] = storage.WorktreeStatusActive
+
+	reasons := func(branch string, merged bool) []string {
+		var reasons []string
+		if merged {
+			reasons = append(reasons, ReasonMerged)
+		}
+		switch s, ok := status[branch]; {
+		case !ok:
+			reasons = append(reasons, ReasonNoWorktree)
+		case s == storage.WorktreeStatusArchived:
+			reasons = append(reasons, ReasonArchived)
+		}
+		return reasons
+	}
+	protected := func(branch string) bool {
+		switch status[branch] {
+		case storage.WorktreeStatusActive, storage.WorktreeStatusParked, storage.WorktreeStatusReview:
+			return true
+		}
+		return !pattern.MatchString(branch)
+	}
+
+	var candidates []BranchCandidate
+	local, err := m.git.ListBranches(ctx, repo)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list branches: %w", err)
+	}
+	for _, branch := range local {
+		if protected(branch) {
+			continue
+		}
+		merged, err := m.isMerged(ctx, repo, branch, base)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check whether %s is merged: %w", branch, err)
+		}
+		c := BranchCandidate{
+			ProjectID: p.ID,
+			Branch:    branch,
+			Ref:       "refs/heads/" + branch,
+			Reasons:   reasons(branch, merged),
+			repo:      repo,
+		}
+		if len(c.Reasons) == 0 {
+			continue
+		}
+		if c.Unpushed, err = m.git.Log(ctx, repo, c.Ref, "--not", "--remotes", base); err != nil {
+			return nil, fmt.Errorf("failed to list unpushed commits of %s: %w", branch, err)
+		}
+		c.LastCommit, _ = m.git.LastCommitTime(ctx, repo, c.Ref)
+		candidates = append(candidates, c)
+	}
+
+	if !opts.Remote {
+		return candidates, nil
+	}
+	remote := p.PushRemote()
+	tracking, err := m.git.ListRemoteBranches(ctx, repo, remote)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list branches of %s: %w", remote, err)
+	}
+	for _, branch := range tracking {
+		if protected(branch) {
+			continue
+		}
+		ref := "refs/remotes/" + remote + "/" + branch
+		merged, err := m.git.IsAncestor(ctx, repo, ref, base)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check whether %s/%s is merged: %w", remote, branch, err)
+		}
+		// A remote branch without a worktree row may be someone else's, and
+		// deleting its remote-tracking branch only lasts until the next
+		// fetch, so only merged and archived ones are selected.
+		c := BranchCandidate{
+			ProjectID: p.ID,
+			Branch:    remote + "/" + branch,
+			Ref:       ref,
+			Remote:    true,
+			repo:      repo,
+		}
+		for _, reason := range reasons(branch, merged) {
+			if reason != ReasonNoWorktree {
+				c.Reasons = append(c.Reasons, reason)
+			}
+		}
+		if len(c.Reasons) == 0 {
+			continue
+		}
+		c.LastCommit, _ = m.git.LastCommitTime(ctx, repo, ref)
+		candidates = append(candidates, c)
+	}
+	return candidates, nil
+}
+
+// ApplyBranchGC deletes the safe candidates, local branches with
+// 'git branch -D' after PlanBranchGC checked their commits are pushed or
+// merged, remote-tracking branches by deleting the ref. Candidates with
+// unpushed commits are kept. Outcomes are returned in candidate order.
+func (m *Manager) ApplyBranchGC(ctx context.Context, candidates []BranchCandidate) []BranchGCOutcome {
+	outcomes := make([]BranchGCOutcome, len(candidates))
+	for i, c := range candidates {
+		outcomes[i].Candidate = c
+		if !c.Safe() {
+			outcomes[i].Kept = true
+			continue
+		}
+		unlock := m.lockRepo(c.repo)
+		if c.Remote {
+			outcomes[i].Err = m.git.DeleteRef(ctx, c.repo, c.Ref)
+		} else {
+			outcomes[i].Err = m.git.DeleteBranch(ctx, c.repo, c.Branch, true)
+		}
+		unlock()
+	}
+	return outcomes
+}
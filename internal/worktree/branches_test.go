@@ -0,0 +1,70 @@
+package worktree
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/paolorechia/issue-flow/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// branchOff creates branch from main with one commit and returns to main.
+func branchOff(t *testing.T, repo, branch string) {
+	t.Helper()
+	testutil.RunGit(t, repo, "checkout", "-q", "-b", branch, "main")
+	testutil.GitCommitFile(t, repo, branch+".txt", branch+"\n", "Work on "+branch)
+	testutil.RunGit(t, repo, "checkout", "-q", "main")
+}
+
+func TestManager_BranchGC(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	db := testutil.NewTestDB(t)
+	sp := testutil.CreateGitProject(t, db, "app")
+	repo := sp.LocalPath
+	remote := t.TempDir()
+	testutil.RunGit(t, remote, "init", "--quiet", "--bare")
+	testutil.RunGit(t, repo, "remote", "add", "origin", remote)
+
+	branchOff(t, repo, "feature/1-merged")
+	testutil.RunGit(t, repo, "merge", "-q", "--ff-only", "feature/1-merged")
+	branchOff(t, repo, "feature/2-pushed")
+	branchOff(t, repo, "feature/3-local")
+	branchOff(t, repo, "scratch")
+	testutil.RunGit(t, repo, "push", "-q", "origin", "main", "feature/1-merged", "feature/2-pushed")
+
+	p, manager, active := startTestWorktree(t, db, 4)
+	_, _, archived := startTestWorktree(t, db, 5)
+	ctx := context.Background()
+	_, err := manager.Archive(ctx, p, archived, ArchiveOptions{KeepBranch: true})
+	require.NoError(t, err)
+
+	candidates, err := manager.PlanBranchGC(ctx, BranchGCOptions{Remote: true})
+	require.NoError(t, err)
+	byBranch := make(map[string]BranchCandidate)
+	for _, c := range candidates {
+		byBranch[c.Branch] = c
+	}
+	require.Len(t, byBranch, 5, "%v", candidates)
+	assert.Equal(t, []string{ReasonMerged, ReasonNoWorktree}, byBranch["feature/1-merged"].Reasons)
+	assert.Equal(t, []string{ReasonNoWorktree}, byBranch["feature/2-pushed"].Reasons)
+	assert.Equal(t, []string{ReasonArchived}, byBranch[archived.Branch].Reasons)
+	assert.Equal(t, []string{ReasonMerged}, byBranch["origin/feature/1-merged"].Reasons)
+	assert.True(t, byBranch["origin/feature/1-merged"].Remote)
+	local := byBranch["feature/3-local"]
+	assert.False(t, local.Safe())
+	require.Len(t, local.Unpushed, 1)
+	assert.Equal(t, "Work on feature/3-local", local.Unpushed[0].Subject)
+	assert.NotContains(t, byBranch, active.Branch)
+
+	outcomes := manager.ApplyBranchGC(ctx, candidates)
+	for _, o := range outcomes {
+		require.NoError(t, o.Err)
+		assert.Equal(t, o.Candidate.Branch == "feature/3-local", o.Kept)
+	}
+	assert.Equal(t, []string{"feature/3-local", active.Branch, "main", "scratch"},
+		strings.Split(testutil.RunGit(t, repo, "for-each-ref", "--format=%(refname:short)", "refs/heads/"), "\n"))
+	assert.Equal(t, "refs/remotes/origin/feature/2-pushed\nrefs/remotes/origin/main",
+		testutil.RunGit(t, repo, "for-each-ref", "--format=%(refname)", "refs/remotes/origin/"))
+}
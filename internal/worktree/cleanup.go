@@ -0,0 +1,160 @@
+package worktree
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/paolorechia/issue-flow/internal/project"
+	"github.com/paolorechia/issue-flow/internal/storage"
+)
+
+// Cleanup reasons.
+const (
+	ReasonMerged   = "merged"
+	ReasonClosed   = "closed"
+	ReasonInactive = "inactive"
+)
+
+// CleanupCriteria selects which worktrees a cleanup plan includes. A worktree
+// is included if it matches any enabled criterion.
+type CleanupCriteria struct {
+	ProjectID string
+	Merged    bool
+	Closed    bool
+	// InactiveDays selects worktrees with no commit (or, lacking commits,
+	// no creation) in that many days. Zero disables the check.
+	InactiveDays int
+	// Now is the reference time for inactivity; defaults to time.Now().
+	Now time.Time
+}
+
+// CleanupCandidate is a worktree selected for removal and why.
+type CleanupCandidate struct {
+	State
+	Reasons []string
+}
+
+// CleanupOutcome is the result of removing one candidate.
+type CleanupOutcome struct {
+	Candidate CleanupCandidate
+	Result    *RemoveResult
+	Err       error
+}
+
+// PlanCleanup inspects worktrees and returns those matching criteria, in
+// the same order as the worktrees table. Review worktrees are never
+// selected: ReviewDone removes them along with their pull request refs.
+func (m *Manager) PlanCleanup(ctx context.Context, criteria CleanupCriteria, workers int) ([]CleanupCandidate, error) {
+	var listed []storage.Worktree
+	var err error
+	if criteria.ProjectID != "" {
+		listed, err = m.db.ListWorktreesByProject(criteria.ProjectID)
+	} else {
+		listed, err = m.db.ListWorktrees()
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to list worktrees: %w", err)
+	}
+	var worktrees []storage.Worktree
+	for _, w := range listed {
+		if w.Status != storage.WorktreeStatusReview {
+			worktrees = append(worktrees, w)
+		}
+	}
+
+	closed := make(map[string]bool)
+	if criteria.Closed {
+		for _, w := range worktrees {
+			issue, err := m.db.GetIssueCache(w.ProjectID, w.IssueNumber)
+			if errors.Is(err, sql.ErrNoRows) {
+				continue
+			}
+			if err != nil {
+				return nil, fmt.Errorf("failed to look up issue #%d: %w", w.IssueNumber, err)
+			}
+			closed[w.ID] = issue.Status == "closed"
+		}
+	}
+
+	states, err := m.Inspect(ctx, worktrees, workers)
+	if err != nil {
+		return nil, err
+	}
+
+	now := criteria.Now
+	if now.IsZero() {
+		now = time.Now()
+	}
+	cutoff := now.AddDate(0, 0, -criteria.InactiveDays)
+
+	var candidates []CleanupCandidate
+	for _, s := range states {
+		var reasons []string
+		if criteria.Merged && s.Merged {
+			reasons = append(reasons, ReasonMerged)
+		}
+		if criteria.Closed && closed[s.ID] {
+			reasons = append(reasons, ReasonClosed)
+		}
+		if criteria.InactiveDays > 0 && lastActivity(s).Before(cutoff) {
+			reasons = append(reasons, ReasonInactive)
+		}
+		if len(reasons) > 0 {
+			candidates = append(candidates, CleanupCandidate{State: s, Reasons: reasons})
+		}
+	}
+
+	return candidates, nil
+}
+
+// ApplyCleanup removes every candidate using at most workers concurrent
+// removals. Outcomes are returned in candidate order.
+func (m *Manager) ApplyCleanup(ctx context.Context, candidates []CleanupCandidate, opts RemoveOptions, workers int) ([]CleanupOutcome, error) {
+	if workers <= 0 {
+		workers = DefaultWorkers
+	}
+
+	projects := make(map[string]*project.Project)
+	pm := project.NewManager(m.db)
+	for _, c := range candidates {
+		if _, ok := projects[c.ProjectID]; ok {
+			continue
+		}
+		p, err := pm.Get(c.ProjectID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load project %s: %w", c.ProjectID, err)
+		}
+		projects[c.ProjectID] = p
+	}
+
+	outcomes := make([]CleanupOutcome, len(candidates))
+	sem := make(chan struct{}, workers)
+	var wg sync.WaitGroup
+
+	for i, c := range candidates {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, c CleanupCandidate) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			w := c.Worktree
+			result, err := m.Remove(ctx, projects[c.ProjectID], &w, opts)
+			outcomes[i] = CleanupOutcome{Candidate: c, Result: result, Err: err}
+		}(i, c)
+	}
+	wg.Wait()
+
+	return outcomes, nil
+}
+
+func lastActivity(s State) time.Time {
+	if s.LastCommit.After(s.CreatedAt) {
+		return s.LastCommit
+	}
+	return s.CreatedAt
+}
@@ -0,0 +1,79 @@
+package worktree
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/paolorechia/issue-flow/internal/storage"
+	"github.com/paolorechia/issue-flow/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestManager_PlanCleanupInactive(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	testutil.CreateGitProject(t, db, "app")
+	_, manager, w := startTestWorktree(t, db, 1)
+	ctx := context.Background()
+
+	candidates, err := manager.PlanCleanup(ctx, CleanupCriteria{InactiveDays: 14}, 2)
+	require.NoError(t, err)
+	assert.Empty(t, candidates)
+
+	candidates, err = manager.PlanCleanup(ctx, CleanupCriteria{InactiveDays: 14, Now: time.Now().AddDate(0, 0, 30)}, 2)
+	require.NoError(t, err)
+	require.Len(t, candidates, 1)
+	assert.Equal(t, w.ID, candidates[0].ID)
+	assert.Equal(t, []string{ReasonInactive}, candidates[0].Reasons)
+}
+
+func TestManager_ApplyCleanupSkipsLosingWork(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	testutil.CreateGitProject(t, db, "app")
+	_, manager, dirty := startTestWorktree(t, db, 1)
+	startTestWorktree(t, db, 2)
+	startTestWorktree(t, db, 3)
+	ctx := context.Background()
+
+	require.NoError(t, os.WriteFile(filepath.Join(dirty.Path, "wip.txt"), []byte("wip\n"), 0644))
+
+	candidates, err := manager.PlanCleanup(ctx, CleanupCriteria{InactiveDays: 1, Now: time.Now().AddDate(0, 0, 2)}, 3)
+	require.NoError(t, err)
+	require.Len(t, candidates, 3)
+
+	outcomes, err := manager.ApplyCleanup(ctx, candidates, RemoveOptions{DeleteBranch: true}, 3)
+	require.NoError(t, err)
+	require.Len(t, outcomes, 3)
+
+	assert.ErrorIs(t, outcomes[0].Err, ErrWouldLoseWork)
+	assert.NoError(t, outcomes[1].Err)
+	assert.NoError(t, outcomes[2].Err)
+
+	testutil.AssertWorktreeCount(t, db, 1)
+	testutil.AssertWorktreeExists(t, db, dirty.ID)
+}
+
+func TestManager_PlanCleanupSkipsReviewWorktrees(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	sp := testutil.CreateGitProject(t, db, "app")
+	repo := sp.LocalPath
+	testutil.RunGit(t, repo, "remote", "add", "origin", repo)
+	pushPullRequest(t, repo, 7, "main", "pr.txt")
+	testutil.RunGit(t, repo, "merge", "-q", "--ff-only", "refs/pull/7/head")
+
+	p, manager, w := startTestWorktree(t, db, 7)
+	require.NoError(t, db.CacheIssue(&storage.IssueCache{ProjectID: "app", IssueNumber: 7, Title: "Test issue", Status: "closed"}))
+	review, err := manager.Review(context.Background(), p, ReviewOptions{PRNumber: 7})
+	require.NoError(t, err)
+
+	candidates, err := manager.PlanCleanup(context.Background(), CleanupCriteria{
+		Merged: true, Closed: true, InactiveDays: 14, Now: time.Now().AddDate(0, 0, 30),
+	}, 2)
+	require.NoError(t, err)
+	require.Len(t, candidates, 1)
+	assert.Equal(t, w.ID, candidates[0].ID)
+	assert.NotEqual(t, review.Worktree.ID, candidates[0].ID)
+}
@@ -0,0 +1,346 @@
+package worktree
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"math"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/paolorechia/issue-flow/internal/project"
+	"github.com/paolorechia/issue-flow/internal/storage"
+)
+
+// DependencyDirs are directory names whose untracked contents count as
+// installed dependencies rather than build artifacts.
+var DependencyDirs = []string{
+	"node_modules", "bower_components", "vendor", ".venv", "venv",
+	"__pypackages__", ".gradle", ".bundle", "Pods",
+}
+
+// Sizes breaks disk usage down by what the files are. Sizes are apparent
+// file sizes in bytes.
+type Sizes struct {
+	Total   int64
+	Tracked int64
+	// Dependencies are untracked files under one of DependencyDirs.
+	Dependencies int64
+	// Artifacts are other ignored files, such as build output.
+	Artifacts int64
+	// Other is untracked files that are not ignored, plus git metadata.
+	Other int64
+}
+
+func (s *Sizes) add(o Sizes) {
+	s.Total += o.Total
+	s.Tracked += o.Tracked
+	s.Dependencies += o.Dependencies
+	s.Artifacts += o.Artifacts
+	s.Other += o.Other
+}
+
+// Usage is the disk usage of one worktree.
+type Usage struct {
+	Worktree storage.Worktree
+	Sizes
+	Err error
+}
+
+// ProjectUsage sums the usage of a project's worktrees.
+type ProjectUsage struct {
+	ProjectID string
+	Worktrees int
+	Sizes
+}
+
+// DiskUsage measures each worktree, running at most workers walks
+// concurrently. Without classify only Total is computed, which skips the
+// git calls. Results are returned in input order; missing worktrees and
+// other failures are reported in Usage.Err.
+func (m *Manager) DiskUsage(ctx context.Context, worktrees []storage.Worktree, workers int, classify bool) []Usage {
+	if workers <= 0 {
+		workers = DefaultWorkers
+	}
+
+	usages := make([]Usage, len(worktrees))
+	sem := make(chan struct{}, workers)
+	var wg sync.WaitGroup
+
+	for i, w := range worktrees {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, w storage.Worktree) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			usages[i] = m.usage(ctx, w, classify)
+		}(i, w)
+	}
+	wg.Wait()
+
+	return usages
+}
+
+func (m *Manager) usage(ctx context.Context, w storage.Worktree, classify bool) Usage {
+	u := Usage{Worktree: w}
+	if !classify {
+		u.Total, u.Err = dirSize(ctx, w.Path)
+		return u
+	}
+
+	tracked, err := m.git.LsFiles(ctx, w.Path)
+	if err != nil {
+		u.Err = fmt.Errorf("failed to list tracked files: %w", err)
+		return u
+	}
+	ignored, err := m.git.LsFiles(ctx, w.Path, "--others", "--ignored", "--exclude-standard", "--directory")
+	if err != nil {
+		u.Err = fmt.Errorf("failed to list ignored files: %w", err)
+		return u
+	}
+
+	trackedFiles := make(map[string]bool, len(tracked))
+	trackedDirs := make(map[string]bool)
+	for _, f := range tracked {
+		trackedFiles[f] = true
+		for dir := path.Dir(f); dir != "." && !trackedDirs[dir]; dir = path.Dir(dir) {
+			trackedDirs[dir] = true
+		}
+	}
+	ignoredPaths := make(map[string]bool, len(ignored))
+	for _, p := range ignored {
+		ignoredPaths[strings.TrimSuffix(p, "/")] = true
+	}
+
+	u.Err = filepath.WalkDir(w.Path, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(w.Path, p)
+		if err != nil {
+			return err
+		}
+		if rel == "." {
+			return nil
+		}
+		rel = filepath.ToSlash(rel)
+
+		if d.IsDir() {
+			dependency := isDependencyDir(d.Name()) && !trackedDirs[rel]
+			if !dependency && !ignoredPaths[rel] {
+				return nil
+			}
+			size, err := dirSize(ctx, p)
+			if err != nil {
+				return err
+			}
+			u.Total += size
+			if dependency {
+				u.Dependencies += size
+			} else {
+				u.Artifacts += size
+			}
+			return filepath.SkipDir
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		size := info.Size()
+		u.Total += size
+		switch {
+		case trackedFiles[rel]:
+			u.Tracked += size
+		case ignoredPaths[rel]:
+			u.Artifacts += size
+		default:
+			u.Other += size
+		}
+		return nil
+	})
+	return u
+}
+
+func isDependencyDir(name string) bool {
+	for _, d := range DependencyDirs {
+		if name == d {
+			return true
+		}
+	}
+	return false
+}
+
+// dirSize sums the apparent size of every file under root without following
+// symlinks.
+func dirSize(ctx context.Context, root string) (int64, error) {
+	var total int64
+	err := filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		total += info.Size()
+		return nil
+	})
+	return total, err
+}
+
+// SummarizeUsage totals usages per project, ordered by project ID.
+// Worktrees whose usage could not be measured are not counted.
+func SummarizeUsage(usages []Usage) []ProjectUsage {
+	byProject := make(map[string]*ProjectUsage)
+	var ids []string
+	for _, u := range usages {
+		if u.Err != nil {
+			continue
+		}
+		pu, ok := byProject[u.Worktree.ProjectID]
+		if !ok {
+			pu = &ProjectUsage{ProjectID: u.Worktree.ProjectID}
+			byProject[pu.ProjectID] = pu
+			ids = append(ids, pu.ProjectID)
+		}
+		pu.Worktrees++
+		pu.add(u.Sizes)
+	}
+
+	sort.Strings(ids)
+	result := make([]ProjectUsage, len(ids))
+	for i, id := range ids {
+		result[i] = *byProject[id]
+	}
+	return result
+}
+
+// QuotaReport compares a project's worktree disk usage with its quota.
+type QuotaReport struct {
+	ProjectID string
+	Used      int64
+	Limit     int64
+	// Candidates lists worktrees worth cleaning up when the quota is
+	// exceeded, largest and longest idle first.
+	Candidates []QuotaCandidate
+}
+
+// Over reports whether the project uses more than its quota.
+func (r *QuotaReport) Over() bool {
+	return r.Used > r.Limit
+}
+
+type QuotaCandidate struct {
+	Worktree storage.Worktree
+	Size     int64
+	Idle     time.Duration
+}
+
+// CheckQuota measures the disk usage of a project's worktrees against its
+// quota. It returns nil when the project has no quota. The worktree with ID
+// exclude, typically the one just started, is never suggested for cleanup.
+func (m *Manager) CheckQuota(ctx context.Context, p *project.Project, exclude string, workers int) (*QuotaReport, error) {
+	if p.Config.Quota.MaxSize == "" {
+		return nil, nil
+	}
+	limit, err := ParseSize(p.Config.Quota.MaxSize)
+	if err != nil {
+		return nil, fmt.Errorf("invalid quota for project %s: %w", p.ID, err)
+	}
+
+	worktrees, err := m.db.ListWorktreesByProject(p.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list worktrees: %w", err)
+	}
+	usages := m.DiskUsage(ctx, worktrees, workers, false)
+
+	report := &QuotaReport{ProjectID: p.ID, Limit: limit}
+	for _, u := range usages {
+		report.Used += u.Total
+	}
+	if !report.Over() {
+		return report, nil
+	}
+
+	states, err := m.Inspect(ctx, worktrees, workers)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	for i, s := range states {
+		if s.ID == exclude || usages[i].Err != nil {
+			continue
+		}
+		report.Candidates = append(report.Candidates, QuotaCandidate{
+			Worktree: s.Worktree,
+			Size:     usages[i].Total,
+			Idle:     now.Sub(lastActivity(s)),
+		})
+	}
+	sort.SliceStable(report.Candidates, func(i, j int) bool {
+		return cleanupScore(report.Candidates[i]) > cleanupScore(report.Candidates[j])
+	})
+	return report, nil
+}
+
+// cleanupScore weighs size by idle time so a large worktree untouched for
+// weeks ranks above an equally large one used today.
+func cleanupScore(c QuotaCandidate) float64 {
+	idleDays := math.Max(c.Idle.Hours()/24, 0)
+	return float64(c.Size) * (1 + idleDays)
+}
+
+var sizeUnits = []string{"B", "KB", "MB", "GB", "TB"}
+
+// ParseSize parses sizes like "512MB", "1.5G" or "2048" (bytes). Units are
+// binary multiples, as with du; a trailing "B" or "iB" is optional.
+func ParseSize(s string) (int64, error) {
+	str := strings.ToUpper(strings.TrimSpace(s))
+	str = strings.TrimSuffix(strings.TrimSuffix(str, "B"), "I")
+
+	multiplier := 1.0
+	for i := len(sizeUnits) - 1; i > 0; i-- {
+		unit := sizeUnits[i][:1]
+		if strings.HasSuffix(str, unit) {
+			str = strings.TrimSuffix(str, unit)
+			multiplier = math.Pow(1024, float64(i))
+			break
+		}
+	}
+
+	n, err := strconv.ParseFloat(strings.TrimSpace(str), 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid size %q", s)
+	}
+	return int64(n * multiplier), nil
+}
+
+// FormatSize renders bytes in the largest binary unit that keeps the value
+// at or above 1, e.g. "1.5 GB".
+func FormatSize(n int64) string {
+	value := float64(n)
+	i := 0
+	for value >= 1024 && i < len(sizeUnits)-1 {
+		value /= 1024
+		i++
+	}
+	if i == 0 {
+		return fmt.Sprintf("%d B", n)
+	}
+	return fmt.Sprintf("%.1f %s", value, sizeUnits[i])
+}
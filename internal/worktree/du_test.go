@@ -0,0 +1,164 @@
+package worktree
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/paolorechia/issue-flow/internal/git"
+	"github.com/paolorechia/issue-flow/internal/project"
+	"github.com/paolorechia/issue-flow/internal/storage"
+	"github.com/paolorechia/issue-flow/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeSized(t *testing.T, path string, size int) {
+	t.Helper()
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+	require.NoError(t, os.WriteFile(path, []byte(strings.Repeat("x", size)), 0644))
+}
+
+func TestManager_DiskUsage(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	testutil.CreateGitProject(t, db, "app")
+	_, manager, w := startTestWorktree(t, db, 1)
+
+	testutil.GitCommitFile(t, w.Path, ".gitignore", "node_modules/\nbuild/\n*.log\n", "Ignore")
+	testutil.GitCommitFile(t, w.Path, "vendor/lib.go", strings.Repeat("v", 300), "Vendor")
+	writeSized(t, filepath.Join(w.Path, "node_modules", "pkg", "index.js"), 1000)
+	writeSized(t, filepath.Join(w.Path, ".venv", "lib", "site.py"), 500)
+	writeSized(t, filepath.Join(w.Path, "build", "app.bin"), 2000)
+	writeSized(t, filepath.Join(w.Path, "debug.log"), 100)
+	writeSized(t, filepath.Join(w.Path, "notes.txt"), 10)
+
+	usages := manager.DiskUsage(context.Background(), []storage.Worktree{*w}, 2, true)
+	require.Len(t, usages, 1)
+	u := usages[0]
+	require.NoError(t, u.Err)
+
+	assert.Equal(t, int64(1500), u.Dependencies, "node_modules and .venv")
+	assert.Equal(t, int64(2100), u.Artifacts, "build/ and *.log")
+	assert.GreaterOrEqual(t, u.Tracked, int64(300), "a tracked vendor/ counts as sources")
+	assert.Greater(t, u.Other, int64(10), "untracked files plus the .git file")
+	assert.Equal(t, u.Total, u.Tracked+u.Dependencies+u.Artifacts+u.Other)
+
+	totals := manager.DiskUsage(context.Background(), []storage.Worktree{*w}, 1, false)
+	require.NoError(t, totals[0].Err)
+	assert.Equal(t, u.Total, totals[0].Total)
+	assert.Zero(t, totals[0].Tracked)
+}
+
+func TestManager_DiskUsageMissingWorktree(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	testutil.CreateGitProject(t, db, "app")
+	_, manager, w := startTestWorktree(t, db, 1)
+	require.NoError(t, os.RemoveAll(w.Path))
+
+	usages := manager.DiskUsage(context.Background(), []storage.Worktree{*w}, 0, true)
+	assert.Error(t, usages[0].Err)
+	assert.Empty(t, SummarizeUsage(usages))
+}
+
+func TestSummarizeUsage(t *testing.T) {
+	usages := []Usage{
+		{Worktree: storage.Worktree{ProjectID: "web"}, Sizes: Sizes{Total: 5, Tracked: 5}},
+		{Worktree: storage.Worktree{ProjectID: "api"}, Sizes: Sizes{Total: 10, Artifacts: 10}},
+		{Worktree: storage.Worktree{ProjectID: "web"}, Sizes: Sizes{Total: 7, Dependencies: 7}},
+	}
+
+	summary := SummarizeUsage(usages)
+	require.Len(t, summary, 2)
+	assert.Equal(t, ProjectUsage{ProjectID: "api", Worktrees: 1, Sizes: Sizes{Total: 10, Artifacts: 10}}, summary[0])
+	assert.Equal(t, ProjectUsage{ProjectID: "web", Worktrees: 2, Sizes: Sizes{Total: 12, Tracked: 5, Dependencies: 7}}, summary[1])
+}
+
+func TestManager_CheckQuota(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	testutil.CreateGitProjectWithConfig(t, db, "app", `{"quota":{"max_size":"4KB"}}`)
+	p, err := project.NewManager(db).Get("app")
+	require.NoError(t, err)
+	manager := NewManager(db, git.NewClient(nil))
+	ctx := context.Background()
+
+	report, err := manager.CheckQuota(ctx, p, "", 0)
+	require.NoError(t, err)
+	require.NotNil(t, report)
+	assert.Equal(t, int64(4096), report.Limit)
+	assert.False(t, report.Over())
+
+	var worktrees []*storage.Worktree
+	for i := 1; i <= 3; i++ {
+		result, err := manager.Start(ctx, p, StartOptions{IssueNumber: i, Title: "Quota"})
+		require.NoError(t, err)
+		worktrees = append(worktrees, result.Worktree)
+	}
+	writeSized(t, filepath.Join(worktrees[0].Path, "small.bin"), 1000)
+	writeSized(t, filepath.Join(worktrees[1].Path, "big.bin"), 8000)
+
+	report, err = manager.CheckQuota(ctx, p, worktrees[2].ID, 0)
+	require.NoError(t, err)
+	assert.True(t, report.Over())
+	assert.Greater(t, report.Used, int64(9000))
+	require.Len(t, report.Candidates, 2, "the excluded worktree is not a candidate")
+	assert.Equal(t, worktrees[1].ID, report.Candidates[0].Worktree.ID)
+	assert.Equal(t, worktrees[0].ID, report.Candidates[1].Worktree.ID)
+}
+
+func TestManager_CheckQuotaWithoutQuota(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	testutil.CreateGitProject(t, db, "app")
+	p, err := project.NewManager(db).Get("app")
+	require.NoError(t, err)
+
+	report, err := NewManager(db, git.NewClient(nil)).CheckQuota(context.Background(), p, "", 0)
+	require.NoError(t, err)
+	assert.Nil(t, report)
+}
+
+func TestCleanupScore(t *testing.T) {
+	day := 24 * time.Hour
+	fresh := QuotaCandidate{Size: 1000}
+	idle := QuotaCandidate{Size: 1000, Idle: 10 * day}
+	huge := QuotaCandidate{Size: 100000, Idle: day}
+
+	assert.Greater(t, cleanupScore(idle), cleanupScore(fresh))
+	assert.Greater(t, cleanupScore(huge), cleanupScore(idle))
+}
+
+func TestParseSize(t *testing.T) {
+	tests := []struct {
+		in   string
+		want int64
+	}{
+		{"2048", 2048},
+		{"512B", 512},
+		{"1K", 1024},
+		{"1kb", 1024},
+		{"1.5M", 1536 * 1024},
+		{"2GiB", 2 << 30},
+		{" 50 GB ", 50 << 30},
+		{"1T", 1 << 40},
+	}
+	for _, tt := range tests {
+		got, err := ParseSize(tt.in)
+		require.NoError(t, err, tt.in)
+		assert.Equal(t, tt.want, got, tt.in)
+	}
+
+	for _, bad := range []string{"", "GB", "-1G", "ten"} {
+		_, err := ParseSize(bad)
+		assert.Error(t, err, bad)
+	}
+}
+
+func TestFormatSize(t *testing.T) {
+	assert.Equal(t, "0 B", FormatSize(0))
+	assert.Equal(t, "1023 B", FormatSize(1023))
+	assert.Equal(t, "1.0 KB", FormatSize(1024))
+	assert.Equal(t, "1.5 MB", FormatSize(1536*1024))
+	assert.Equal(t, "50.0 GB", FormatSize(50<<30))
+}
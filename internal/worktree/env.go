@@ -0,0 +1,278 @@
+package worktree
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/paolorechia/issue-flow/internal/config"
+	"github.com/paolorechia/issue-flow/internal/project"
+	"github.com/paolorechia/issue-flow/internal/storage"
+)
+
+// Env returns the variables that describe a worktree to scripts as
+// KEY=VALUE pairs: the ISSUE_FLOW_* variables (ISSUE_FLOW_ATTEMPT only for
+// attempts, ISSUE_FLOW_PR only for review worktrees) followed by the
+// worktree's allocated ports.
+func (m *Manager) Env(p *project.Project, w *storage.Worktree) ([]string, error) {
+	ports, err := m.Ports(p, w)
+	if err != nil {
+		return nil, err
+	}
+
+	env := []string{
+		"ISSUE_FLOW_PROJECT=" + p.ID,
+		"ISSUE_FLOW_ISSUE=" + strconv.Itoa(w.IssueNumber),
+		"ISSUE_FLOW_BRANCH=" + w.Branch,
+		"ISSUE_FLOW_WORKTREE=" + w.Path,
+		"ISSUE_FLOW_REPO=" + config.ExpandPath(p.LocalPath),
+	}
+	if w.Attempt != "" {
+		env = append(env, "ISSUE_FLOW_ATTEMPT="+w.Attempt)
+	}
+	if w.PRNumber > 0 {
+		env = append(env, "ISSUE_FLOW_PR="+strconv.Itoa(w.PRNumber))
+	}
+	for _, port := range ports {
+		env = append(env, port.Env+"="+strconv.Itoa(port.Port))
+	}
+	return env, nil
+}
+
+// EnvData is what env templates are executed with, e.g.
+// {{.Branch}}, {{.Ports.web}}, {{.Project.GitHubRepo}} or {{.Secrets.API_KEY}}.
+type EnvData struct {
+	Project *project.Project
+	Issue   int
+	// Attempt is empty for the issue's primary worktree.
+	Attempt string
+	// Title is the cached issue title, if any.
+	Title  string
+	Branch string
+	Path   string
+	Repo   string
+	// Ports maps port names to the worktree's allocated ports.
+	Ports map[string]int
+	// Env holds the variables returned by Env.
+	Env     map[string]string
+	Secrets map[string]string
+}
+
+// RenderedFile is an env file written by RenderEnvFiles.
+type RenderedFile struct {
+	Path string
+	// Changed is false when the file already had the rendered content.
+	Changed bool
+}
+
+// RenderEnvFiles renders the project's env templates into the worktree.
+// Every template is rendered before any file is written, so an error (such
+// as a secret missing from the secrets file) leaves the worktree untouched.
+// Files are written with mode 0600 since they may hold secrets; files whose
+// content is unchanged are not rewritten.
+func (m *Manager) RenderEnvFiles(p *project.Project, w *storage.Worktree) ([]RenderedFile, error) {
+	templates := p.Config.EnvTemplates
+	if len(templates) == 0 {
+		return nil, nil
+	}
+	if _, err := os.Stat(w.Path); err != nil {
+		return nil, fmt.Errorf("worktree %s is missing at %s", w.ID, w.Path)
+	}
+
+	data, err := m.envData(p, w)
+	if err != nil {
+		return nil, err
+	}
+
+	type rendered struct {
+		path    string
+		content []byte
+	}
+	var files []rendered
+	for _, t := range templates {
+		path, err := envFilePath(w.Path, t.Path)
+		if err != nil {
+			return nil, err
+		}
+		text, name := t.Content, t.Path
+		if t.Source != "" {
+			name = t.Source
+			raw, err := os.ReadFile(filepath.Join(data.Repo, t.Source))
+			if err != nil {
+				return nil, fmt.Errorf("failed to read env template: %w", err)
+			}
+			text = string(raw)
+		}
+
+		tmpl, err := template.New(name).Option("missingkey=error").Parse(text)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse env template: %w", err)
+		}
+		var buf bytes.Buffer
+		if err := tmpl.Execute(&buf, data); err != nil {
+			return nil, fmt.Errorf("failed to render env template: %w", err)
+		}
+		files = append(files, rendered{path: path, content: buf.Bytes()})
+	}
+
+	result := make([]RenderedFile, len(files))
+	for i, f := range files {
+		result[i].Path = f.path
+		if current, err := os.ReadFile(f.path); err == nil && bytes.Equal(current, f.content) {
+			continue
+		}
+		if err := writeFileAtomic(f.path, f.content, 0600); err != nil {
+			return result[:i], fmt.Errorf("failed to write %s: %w", f.path, err)
+		}
+		result[i].Changed = true
+	}
+	return result, nil
+}
+
+func (m *Manager) envData(p *project.Project, w *storage.Worktree) (*EnvData, error) {
+	ports, err := m.Ports(p, w)
+	if err != nil {
+		return nil, err
+	}
+	env, err := m.Env(p, w)
+	if err != nil {
+		return nil, err
+	}
+	secrets, err := LoadSecrets(p)
+	if err != nil {
+		return nil, err
+	}
+
+	data := &EnvData{
+		Project: p,
+		Issue:   w.IssueNumber,
+		Attempt: w.Attempt,
+		Branch:  w.Branch,
+		Path:    w.Path,
+		Repo:    config.ExpandPath(p.LocalPath),
+		Ports:   make(map[string]int, len(ports)),
+		Env:     make(map[string]string, len(env)),
+		Secrets: secrets,
+	}
+	for _, port := range ports {
+		data.Ports[port.Name] = port.Port
+	}
+	for _, kv := range env {
+		key, value, _ := strings.Cut(kv, "=")
+		data.Env[key] = value
+	}
+	_ = m.withDB(func(db *storage.Database) error {
+		if issue, err := db.GetIssueCache(p.ID, w.IssueNumber); err == nil {
+			data.Title = issue.Title
+		}
+		return nil
+	})
+	return data, nil
+}
+
+// envFilePath resolves a template's output path, which must stay inside the
+// worktree.
+func envFilePath(worktreePath, rel string) (string, error) {
+	if rel == "" {
+		return "", fmt.Errorf("env template has no path")
+	}
+	if filepath.IsAbs(rel) || !filepath.IsLocal(rel) {
+		return "", fmt.Errorf("env template path %s must be inside the worktree", rel)
+	}
+	return filepath.Join(worktreePath, rel), nil
+}
+
+// writeFileAtomic writes data to a temporary file next to path and renames
+// it into place, so readers never see a partial file.
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(perm); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// SecretsPath returns the project's secrets file: SecretsFile from its
+// config, or ~/.issue-flow/secrets/<project-id>.env.
+func SecretsPath(p *project.Project) string {
+	if p.Config.SecretsFile != "" {
+		return config.ExpandPath(p.Config.SecretsFile)
+	}
+	return filepath.Join(config.GetConfigPath(), "secrets", p.ID+".env")
+}
+
+// LoadSecrets reads the project's secrets file. A missing default secrets
+// file means no secrets; a missing configured one is an error.
+func LoadSecrets(p *project.Project) (map[string]string, error) {
+	path := SecretsPath(p)
+	f, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) && p.Config.SecretsFile == "" {
+		return map[string]string{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open secrets file: %w", err)
+	}
+	defer f.Close()
+
+	secrets, err := ParseDotenv(f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse secrets file %s: %w", path, err)
+	}
+	return secrets, nil
+}
+
+// ParseDotenv parses KEY=VALUE lines. Blank lines, lines starting with #
+// and a leading "export " are ignored. Values may be single-quoted (taken
+// literally) or double-quoted (with \n, \", and \\ escapes); unquoted values
+// are trimmed.
+func ParseDotenv(r io.Reader) (map[string]string, error) {
+	values := make(map[string]string)
+	scanner := bufio.NewScanner(r)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+
+		key, value, found := strings.Cut(line, "=")
+		key = strings.TrimSpace(key)
+		if !found || key == "" || strings.ContainsAny(key, " \t") {
+			return nil, fmt.Errorf("line %d: expected KEY=VALUE", n)
+		}
+
+		value = strings.TrimSpace(value)
+		switch {
+		case len(value) >= 2 && value[0] == '\'' && value[len(value)-1] == '\'':
+			value = value[1 : len(value)-1]
+		case len(value) >= 2 && value[0] == '"' && value[len(value)-1] == '"':
+			value = strings.NewReplacer(`\n`, "\n", `\"`, `"`, `\\`, `\`).Replace(value[1 : len(value)-1])
+		}
+		values[key] = value
+	}
+	return values, scanner.Err()
+}
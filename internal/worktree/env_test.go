@@ -0,0 +1,137 @@
+package worktree
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/paolorechia/issue-flow/internal/git"
+	"github.com/paolorechia/issue-flow/internal/project"
+	"github.com/paolorechia/issue-flow/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestManager_RenderEnvFiles(t *testing.T) {
+	stubPortAvailable(t)
+	secrets := filepath.Join(t.TempDir(), "app.env")
+	require.NoError(t, os.WriteFile(secrets, []byte("# local only\nAPI_KEY=s3cret\n"), 0600))
+
+	db := testutil.NewTestDB(t)
+	sp := testutil.CreateGitProjectWithConfig(t, db, "app", `{
+		"ports": [{"name": "web", "range": "4300-4309"}],
+		"secrets_file": "`+secrets+`",
+		"env_templates": [
+			{"source": "env.tmpl", "path": ".env.local"},
+			{"content": "export BRANCH={{.Branch}}\n", "path": "config/.envrc"}
+		]
+	}`)
+	require.NoError(t, os.WriteFile(filepath.Join(sp.LocalPath, "env.tmpl"), []byte(
+		"ISSUE={{.Issue}}\nTITLE={{.Title}}\nPORT={{.Ports.web}}\nSAME_PORT={{.Env.WEB_PORT}}\nDB=app_{{.Issue}}_{{.Project.ID}}\nAPI_KEY={{.Secrets.API_KEY}}\n"), 0644))
+
+	p, err := project.NewManager(db).Get("app")
+	require.NoError(t, err)
+	manager := NewManager(db, git.NewClient(nil))
+	result, err := manager.Start(context.Background(), p, StartOptions{IssueNumber: 9, Title: "Env files"})
+	require.NoError(t, err)
+	w := result.Worktree
+
+	files, err := manager.RenderEnvFiles(p, w)
+	require.NoError(t, err)
+	require.Len(t, files, 2)
+	assert.True(t, files[0].Changed)
+	assert.True(t, files[1].Changed)
+
+	content, err := os.ReadFile(filepath.Join(w.Path, ".env.local"))
+	require.NoError(t, err)
+	assert.Equal(t, "ISSUE=9\nTITLE=Env files\nPORT=4300\nSAME_PORT=4300\nDB=app_9_app\nAPI_KEY=s3cret\n", string(content))
+	info, err := os.Stat(filepath.Join(w.Path, ".env.local"))
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	content, err = os.ReadFile(filepath.Join(w.Path, "config", ".envrc"))
+	require.NoError(t, err)
+	assert.Equal(t, "export BRANCH="+w.Branch+"\n", string(content))
+
+	files, err = manager.RenderEnvFiles(p, w)
+	require.NoError(t, err)
+	assert.False(t, files[0].Changed, "unchanged files are not rewritten")
+
+	require.NoError(t, os.WriteFile(filepath.Join(sp.LocalPath, "env.tmpl"), []byte("ISSUE={{.Issue}}\n"), 0644))
+	files, err = manager.RenderEnvFiles(p, w)
+	require.NoError(t, err)
+	assert.True(t, files[0].Changed)
+	assert.False(t, files[1].Changed)
+}
+
+func TestManager_RenderEnvFilesMissingSecret(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	db := testutil.NewTestDB(t)
+	testutil.CreateGitProjectWithConfig(t, db, "app", `{"env_templates": [
+		{"content": "A=1\n", "path": "a.env"},
+		{"content": "TOKEN={{.Secrets.TOKEN}}\n", "path": "b.env"}
+	]}`)
+	p, manager, w := startTestWorktree(t, db, 1)
+
+	_, err := manager.RenderEnvFiles(p, w)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "TOKEN")
+	assert.NoFileExists(t, filepath.Join(w.Path, "a.env"), "nothing is written when a template fails")
+
+	secrets := filepath.Join(os.Getenv("HOME"), ".issue-flow", "secrets", "app.env")
+	require.NoError(t, os.MkdirAll(filepath.Dir(secrets), 0700))
+	require.NoError(t, os.WriteFile(secrets, []byte(`TOKEN="abc"`+"\n"), 0600))
+
+	_, err = manager.RenderEnvFiles(p, w)
+	require.NoError(t, err)
+	content, err := os.ReadFile(filepath.Join(w.Path, "b.env"))
+	require.NoError(t, err)
+	assert.Equal(t, "TOKEN=abc\n", string(content))
+}
+
+func TestManager_RenderEnvFilesRejectsEscapingPath(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	testutil.CreateGitProjectWithConfig(t, db, "app", `{"env_templates": [{"content": "x", "path": "../outside.env"}]}`)
+	p, manager, w := startTestWorktree(t, db, 1)
+
+	_, err := manager.RenderEnvFiles(p, w)
+	assert.ErrorContains(t, err, "must be inside the worktree")
+	assert.NoFileExists(t, filepath.Join(filepath.Dir(w.Path), "outside.env"))
+}
+
+func TestLoadSecrets(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	secrets, err := LoadSecrets(&project.Project{ID: "app"})
+	require.NoError(t, err, "the default secrets file is optional")
+	assert.Empty(t, secrets)
+
+	_, err = LoadSecrets(&project.Project{ID: "app", Config: project.ProjectConfig{SecretsFile: "~/missing.env"}})
+	assert.Error(t, err, "a configured secrets file must exist")
+}
+
+func TestParseDotenv(t *testing.T) {
+	values, err := ParseDotenv(strings.NewReader(`
+# comment
+PLAIN = value with spaces
+export EXPORTED=1
+SINGLE='$not #expanded'
+DOUBLE="line1\nline2 \"quoted\""
+EMPTY=
+URL=postgres://u:p@host/db?x=1
+`))
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"PLAIN":    "value with spaces",
+		"EXPORTED": "1",
+		"SINGLE":   "$not #expanded",
+		"DOUBLE":   "line1\nline2 \"quoted\"",
+		"EMPTY":    "",
+		"URL":      "postgres://u:p@host/db?x=1",
+	}, values)
+
+	_, err = ParseDotenv(strings.NewReader("NOEQUALS\n"))
+	assert.ErrorContains(t, err, "line 1")
+}
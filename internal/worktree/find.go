@@ -0,0 +1,130 @@
+package worktree
+
+import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/paolorechia/issue-flow/internal/storage"
+)
+
+// QualifiedName identifies a worktree across projects as "<project>#<issue>",
+// "<project>#<issue>:<attempt>" for an attempt or "<project>#pr-<n>" for a
+// review worktree.
+func QualifiedName(w storage.Worktree) string {
+	return fmt.Sprintf("%s#%s", w.ProjectID, issueName(w))
+}
+
+// issueName is "<issue>", "<issue>:<attempt>" or "pr-<n>".
+func issueName(w storage.Worktree) string {
+	if w.PRNumber > 0 {
+		return fmt.Sprintf("pr-%d", w.PRNumber)
+	}
+	if w.Attempt != "" {
+		return fmt.Sprintf("%d:%s", w.IssueNumber, w.Attempt)
+	}
+	return strconv.Itoa(w.IssueNumber)
+}
+
+// ShortNames returns the shortest unambiguous name for each worktree: the
+// bare issue number (with ":<attempt>" for attempts) when no worktree of
+// another project shares it, and the qualified name otherwise.
+func ShortNames(worktrees []storage.Worktree) []string {
+	count := make(map[string]int)
+	for _, w := range worktrees {
+		count[issueName(w)]++
+	}
+
+	names := make([]string, len(worktrees))
+	for i, w := range worktrees {
+		if count[issueName(w)] == 1 {
+			names[i] = issueName(w)
+		} else {
+			names[i] = QualifiedName(w)
+		}
+	}
+	return names
+}
+
+// Find returns the worktrees matching query. Queries are tried as a worktree
+// ID, a qualified name, an issue reference ("123", "#123" or "123:a1"), an
+// exact branch name, and finally a case-insensitive fuzzy match on branch
+// and directory names. The first form that matches anything wins; fuzzy
+// substring matches are preferred over subsequence matches.
+func Find(worktrees []storage.Worktree, query string) []storage.Worktree {
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return nil
+	}
+
+	if matches := filterWorktrees(worktrees, func(w storage.Worktree) bool {
+		return w.ID == query || QualifiedName(w) == query
+	}); len(matches) > 0 {
+		return matches
+	}
+
+	if n, attempt, ok := ParseIssueRef(query); ok {
+		matches := filterWorktrees(worktrees, func(w storage.Worktree) bool {
+			return w.IssueNumber == n && w.Attempt == attempt
+		})
+		if len(matches) > 0 || attempt != "" {
+			return matches
+		}
+		// An issue with only attempts matches all of them.
+		return filterWorktrees(worktrees, func(w storage.Worktree) bool { return w.IssueNumber == n })
+	}
+
+	if matches := filterWorktrees(worktrees, func(w storage.Worktree) bool { return w.Branch == query }); len(matches) > 0 {
+		return matches
+	}
+
+	q := strings.ToLower(query)
+	if matches := filterWorktrees(worktrees, func(w storage.Worktree) bool {
+		return strings.Contains(fuzzyHaystack(w), q)
+	}); len(matches) > 0 {
+		return matches
+	}
+	return filterWorktrees(worktrees, func(w storage.Worktree) bool {
+		return isSubsequence(q, fuzzyHaystack(w))
+	})
+}
+
+// ParseIssueRef parses "123", "#123" or, for an attempt, "123:a1".
+func ParseIssueRef(s string) (issueNumber int, attempt string, ok bool) {
+	num, attempt, hasAttempt := strings.Cut(strings.TrimPrefix(s, "#"), ":")
+	n, err := strconv.Atoi(num)
+	if err != nil || n <= 0 || (hasAttempt && attempt == "") {
+		return 0, "", false
+	}
+	return n, attempt, true
+}
+
+func fuzzyHaystack(w storage.Worktree) string {
+	return strings.ToLower(w.Branch + " " + filepath.Base(w.Path))
+}
+
+func filterWorktrees(worktrees []storage.Worktree, keep func(storage.Worktree) bool) []storage.Worktree {
+	var result []storage.Worktree
+	for _, w := range worktrees {
+		if keep(w) {
+			result = append(result, w)
+		}
+	}
+	return result
+}
+
+// isSubsequence reports whether every rune of needle appears in haystack in
+// order, so "auth" matches "feature/12-add-oauth-token".
+func isSubsequence(needle, haystack string) bool {
+	rest := []rune(needle)
+	for _, r := range haystack {
+		if len(rest) == 0 {
+			break
+		}
+		if r == rest[0] {
+			rest = rest[1:]
+		}
+	}
+	return len(rest) == 0
+}
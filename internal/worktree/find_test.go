@@ -0,0 +1,79 @@
+package worktree
+
+import (
+	"testing"
+
+	"github.com/paolorechia/issue-flow/internal/storage"
+	"github.com/stretchr/testify/assert"
+)
+
+func findIDs(worktrees []storage.Worktree, query string) []string {
+	var ids []string
+	for _, w := range Find(worktrees, query) {
+		ids = append(ids, w.ID)
+	}
+	return ids
+}
+
+func TestFind(t *testing.T) {
+	worktrees := []storage.Worktree{
+		{ID: "wt-app-12", ProjectID: "app", IssueNumber: 12, Branch: "feature/12-add-oauth", Path: "/w/app/issue-12"},
+		{ID: "wt-web-12", ProjectID: "web", IssueNumber: 12, Branch: "fix/12-crash", Path: "/w/web/issue-12"},
+		{ID: "wt-web-7", ProjectID: "web", IssueNumber: 7, Branch: "feature/7-dark-mode", Path: "/w/web/issue-7"},
+	}
+
+	assert.Equal(t, []string{"wt-web-7"}, findIDs(worktrees, "wt-web-7"))
+	assert.Equal(t, []string{"wt-web-12"}, findIDs(worktrees, "web#12"))
+	assert.Equal(t, []string{"wt-app-12", "wt-web-12"}, findIDs(worktrees, "#12"))
+	assert.Equal(t, []string{"wt-web-7"}, findIDs(worktrees, "7"))
+	assert.Empty(t, findIDs(worktrees, "99"))
+	assert.Equal(t, []string{"wt-web-12"}, findIDs(worktrees, "fix/12-crash"))
+	assert.Equal(t, []string{"wt-web-7"}, findIDs(worktrees, "Dark"))
+	assert.Equal(t, []string{"wt-app-12"}, findIDs(worktrees, "oath"), "subsequence match")
+	assert.Equal(t, []string{"wt-app-12", "wt-web-7"}, findIDs(worktrees, "feature"))
+	assert.Empty(t, findIDs(worktrees, ""))
+}
+
+func TestFind_Attempts(t *testing.T) {
+	worktrees := []storage.Worktree{
+		{ID: "wt-app-12", ProjectID: "app", IssueNumber: 12, Branch: "feature/12-x"},
+		{ID: "wt-app-12-a1", ProjectID: "app", IssueNumber: 12, Attempt: "a1", Branch: "feature/12-x-a1"},
+		{ID: "wt-app-7-a1", ProjectID: "app", IssueNumber: 7, Attempt: "a1", Branch: "feature/7-y-a1"},
+		{ID: "wt-app-7-a2", ProjectID: "app", IssueNumber: 7, Attempt: "a2", Branch: "feature/7-y-a2"},
+	}
+
+	assert.Equal(t, []string{"wt-app-12"}, findIDs(worktrees, "12"), "a bare number prefers the primary")
+	assert.Equal(t, []string{"wt-app-12-a1"}, findIDs(worktrees, "#12:a1"))
+	assert.Equal(t, []string{"wt-app-12-a1"}, findIDs(worktrees, "app#12:a1"))
+	assert.Equal(t, []string{"wt-app-7-a1", "wt-app-7-a2"}, findIDs(worktrees, "7"))
+	assert.Empty(t, findIDs(worktrees, "12:a3"))
+}
+
+func TestParseIssueRef(t *testing.T) {
+	n, attempt, ok := ParseIssueRef("#12")
+	assert.True(t, ok)
+	assert.Equal(t, 12, n)
+	assert.Empty(t, attempt)
+
+	n, attempt, ok = ParseIssueRef("12:a1")
+	assert.True(t, ok)
+	assert.Equal(t, 12, n)
+	assert.Equal(t, "a1", attempt)
+
+	for _, s := range []string{"", "0", "12:", "x:a1", "wt-app-12"} {
+		_, _, ok := ParseIssueRef(s)
+		assert.False(t, ok, s)
+	}
+}
+
+func TestShortNames(t *testing.T) {
+	worktrees := []storage.Worktree{
+		{ProjectID: "app", IssueNumber: 12},
+		{ProjectID: "web", IssueNumber: 12},
+		{ProjectID: "web", IssueNumber: 7},
+	}
+	assert.Equal(t, []string{"app#12", "web#12", "7"}, ShortNames(worktrees))
+
+	worktrees = append(worktrees, storage.Worktree{ProjectID: "web", IssueNumber: 7, Attempt: "a1"})
+	assert.Equal(t, []string{"app#12", "web#12", "7", "7:a1"}, ShortNames(worktrees))
+}
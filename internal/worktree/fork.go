@@ -0,0 +1,81 @@
+package worktree
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/paolorechia/issue-flow/internal/config"
+	"github.com/paolorechia/issue-flow/internal/project"
+)
+
+type ForkSyncResult struct {
+	// Branch is the default branch that was synced.
+	Branch string
+	// From is the fork's previous tip, empty when the fork lacked the branch.
+	From string
+	// To is the upstream tip the fork now has.
+	To string
+	// Updated is false when the fork was already up to date.
+	Updated bool
+}
+
+// ForkSync fast-forwards the default branch of the project's fork to the
+// upstream one: it fetches both remotes and pushes upstream's tip to the
+// fork. A fork whose branch has commits upstream lacks is left alone.
+func (m *Manager) ForkSync(ctx context.Context, p *project.Project) (*ForkSyncResult, error) {
+	if !p.IsFork() {
+		return nil, fmt.Errorf("project %s does not push to a fork (set remotes.fork in its config)", p.ID)
+	}
+	repo := config.ExpandPath(p.LocalPath)
+	upstream, fork := p.UpstreamRemote(), p.PushRemote()
+	for _, remote := range []string{upstream, fork} {
+		if ok, err := m.hasRemote(ctx, repo, remote); err != nil {
+			return nil, err
+		} else if !ok {
+			return nil, fmt.Errorf("project %s has no remote %s", p.ID, remote)
+		}
+		if err := m.git.Fetch(ctx, repo, remote); err != nil {
+			return nil, fmt.Errorf("failed to fetch %s: %w", remote, err)
+		}
+	}
+
+	branch, err := m.git.DefaultBranch(ctx, repo)
+	if err != nil {
+		return nil, fmt.Errorf("failed to determine default branch: %w", err)
+	}
+	result := &ForkSyncResult{Branch: branch}
+	upstreamRef := upstream + "/" + branch
+	if result.To, err = m.git.RevParse(ctx, repo, "refs/remotes/"+upstreamRef); err != nil {
+		return nil, fmt.Errorf("failed to resolve %s: %w", upstreamRef, err)
+	}
+
+	forkRef := "refs/remotes/" + fork + "/" + branch
+	exists, err := m.git.RefExists(ctx, repo, forkRef)
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		if result.From, err = m.git.RevParse(ctx, repo, forkRef); err != nil {
+			return nil, fmt.Errorf("failed to resolve %s/%s: %w", fork, branch, err)
+		}
+		if result.From == result.To {
+			return result, nil
+		}
+		ancestor, err := m.git.IsAncestor(ctx, repo, result.From, result.To)
+		if err != nil {
+			return nil, fmt.Errorf("failed to compare %s/%s with %s: %w", fork, branch, upstreamRef, err)
+		}
+		if !ancestor {
+			return nil, fmt.Errorf("%s/%s has commits that are not on %s; not overwriting it", fork, branch, upstreamRef)
+		}
+	}
+
+	if err := m.git.Push(ctx, repo, fork, result.To+":refs/heads/"+branch); err != nil {
+		return nil, fmt.Errorf("failed to push %s to %s: %w", branch, fork, err)
+	}
+	if err := m.git.UpdateRef(ctx, repo, forkRef, result.To); err != nil {
+		return nil, fmt.Errorf("failed to update %s: %w", forkRef, err)
+	}
+	result.Updated = true
+	return result, nil
+}
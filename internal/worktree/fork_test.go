@@ -0,0 +1,99 @@
+package worktree
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/paolorechia/issue-flow/internal/git"
+	"github.com/paolorechia/issue-flow/internal/project"
+	"github.com/paolorechia/issue-flow/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// forkProject creates project app whose local repo has an upstream remote
+// one commit ahead and a bare fork as origin. It returns the project, a
+// manager and the upstream and fork directories.
+func forkProject(t *testing.T) (*project.Project, *Manager, string, string) {
+	t.Helper()
+	db := testutil.NewTestDB(t)
+	sp := testutil.CreateGitProjectWithConfig(t, db, "app",
+		`{"remotes": {"upstream": "upstream", "fork": "origin", "fork_owner": "ada"}}`)
+	repo := sp.LocalPath
+
+	upstream := t.TempDir()
+	testutil.RunGit(t, upstream, "clone", "--quiet", repo, ".")
+	testutil.RunGit(t, upstream, "config", "user.name", "Upstream")
+	testutil.RunGit(t, upstream, "config", "user.email", "upstream@issue-flow.local")
+	testutil.GitCommitFile(t, upstream, "upstream.txt", "new\n", "Upstream change")
+	fork := t.TempDir()
+	testutil.RunGit(t, fork, "init", "--quiet", "--bare")
+
+	testutil.RunGit(t, repo, "remote", "add", "upstream", upstream)
+	testutil.RunGit(t, repo, "remote", "add", "origin", fork)
+	testutil.RunGit(t, repo, "push", "--quiet", "origin", "main")
+
+	p, err := project.NewManager(db).Get("app")
+	require.NoError(t, err)
+	return p, NewManager(db, git.NewClient(nil)), upstream, fork
+}
+
+func TestManager_StartFromFork(t *testing.T) {
+	p, manager, upstream, _ := forkProject(t)
+	assert.True(t, p.IsFork())
+	assert.Equal(t, "ada:feature/3-x", p.PullRequestHead("feature/3-x"))
+
+	result, err := manager.Start(context.Background(), p, StartOptions{IssueNumber: 3, Title: "From upstream", Fetch: true})
+	require.NoError(t, err)
+	require.Empty(t, result.Warnings)
+	w := result.Worktree
+	assert.Equal(t, testutil.RunGit(t, upstream, "rev-parse", "HEAD"), testutil.RunGit(t, w.Path, "rev-parse", "HEAD"),
+		"branches start from upstream")
+	assert.Equal(t, "origin", testutil.RunGit(t, p.LocalPath, "config", "branch."+w.Branch+".pushRemote"))
+}
+
+func TestManager_ForkSync(t *testing.T) {
+	p, manager, upstream, fork := forkProject(t)
+	ctx := context.Background()
+	before := testutil.RunGit(t, fork, "rev-parse", "main")
+	head := testutil.RunGit(t, upstream, "rev-parse", "HEAD")
+
+	result, err := manager.ForkSync(ctx, p)
+	require.NoError(t, err)
+	assert.True(t, result.Updated)
+	assert.Equal(t, "main", result.Branch)
+	assert.Equal(t, before, result.From)
+	assert.Equal(t, head, result.To)
+	assert.Equal(t, head, testutil.RunGit(t, fork, "rev-parse", "main"))
+
+	result, err = manager.ForkSync(ctx, p)
+	require.NoError(t, err)
+	assert.False(t, result.Updated)
+
+	testutil.RunGit(t, p.LocalPath, "checkout", "-q", "-b", "diverged", before)
+	testutil.GitCommitFile(t, p.LocalPath, "fork.txt", "fork only\n", "Fork-only change")
+	testutil.RunGit(t, p.LocalPath, "push", "--quiet", "--force", "origin", "diverged:main")
+	_, err = manager.ForkSync(ctx, p)
+	assert.ErrorContains(t, err, "origin/main has commits that are not on upstream/main")
+
+	p.Config.Remotes = project.RemotesConfig{}
+	_, err = manager.ForkSync(ctx, p)
+	assert.ErrorContains(t, err, "does not push to a fork")
+}
+
+func TestManager_ReviewInForkProject(t *testing.T) {
+	p, manager, upstream, _ := forkProject(t)
+	ctx := context.Background()
+	head := pushPullRequest(t, upstream, 9, "main", "pr.txt")
+
+	result, err := manager.Review(ctx, p, ReviewOptions{PRNumber: 9})
+	require.NoError(t, err, "pull refs are fetched from upstream, not from the fork")
+	assert.Equal(t, head, result.Head)
+	assert.Equal(t, head, testutil.RunGit(t, p.LocalPath, "rev-parse", "refs/remotes/upstream/pr/9"))
+	assert.FileExists(t, filepath.Join(result.Worktree.Path, "pr.txt"))
+
+	_, err = manager.ReviewDone(ctx, p, result.Worktree, false)
+	require.NoError(t, err)
+	assert.Empty(t, testutil.RunGit(t, p.LocalPath, "for-each-ref", "refs/remotes/upstream/pr/"))
+}
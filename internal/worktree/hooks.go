@@ -0,0 +1,329 @@
+package worktree
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/paolorechia/issue-flow/internal/config"
+	"github.com/paolorechia/issue-flow/internal/project"
+	"github.com/paolorechia/issue-flow/internal/storage"
+)
+
+// DefaultCommitReference is the line the commit-msg hook adds to messages
+// when the project does not configure one.
+const DefaultCommitReference = "Refs #{issue-number}"
+
+// hooksDirName is the directory under a worktree's git directory that holds
+// its generated hooks and commit template.
+const hooksDirName = "issue-flow"
+
+// HooksResult describes the hooks installed into a worktree.
+type HooksResult struct {
+	// Dir is the worktree's core.hooksPath.
+	Dir string
+	// Template is the worktree's commit.template.
+	Template string
+	// Chained are the repository's own hooks that the worktree's hooks run.
+	Chained []string
+}
+
+// HooksEnabled reports whether w gets the commit-msg hook and template.
+// Review worktrees never do, since their commits are someone else's.
+func HooksEnabled(p *project.Project, w *storage.Worktree) bool {
+	return !p.Config.Commits.DisableHooks && w.PRNumber == 0
+}
+
+// CommitReference returns the line commits of an issue must contain.
+func CommitReference(p *project.Project, issueNumber int) string {
+	ref := p.Config.Commits.Reference
+	if ref == "" {
+		ref = DefaultCommitReference
+	}
+	return strings.ReplaceAll(ref, "{issue-number}", strconv.Itoa(issueNumber))
+}
+
+// CommitTypes returns the conventional commit types allowed for an issue
+// type, or nil when any subject is allowed.
+func CommitTypes(p *project.Project, issueType string) []string {
+	for _, it := range p.Config.IssueTypes {
+		if it.Name == issueType {
+			return it.CommitTypes
+		}
+	}
+	return nil
+}
+
+// BranchRegexp returns an extended regular expression matching the branches
+// the project's branch pattern gives an issue, including attempts.
+func BranchRegexp(cfg project.BranchConfig, issueNumber int) string {
+	return branchPatternRegexp(cfg, strconv.Itoa(issueNumber))
+}
+
+// IssueBranchRegexp is like BranchRegexp but matches the branches of any
+// issue.
+func IssueBranchRegexp(cfg project.BranchConfig) string {
+	return branchPatternRegexp(cfg, `[0-9]+`)
+}
+
+func branchPatternRegexp(cfg project.BranchConfig, issueNumber string) string {
+	pattern := cfg.Pattern
+	if pattern == "" {
+		pattern = DefaultBranchPattern
+	}
+	re := strings.NewReplacer(
+		regexp.QuoteMeta("{prefix}"), `[A-Za-z0-9._/-]+`,
+		regexp.QuoteMeta("{issue-number}"), issueNumber,
+		regexp.QuoteMeta("{slug}"), `[a-z0-9-]+`,
+	).Replace(regexp.QuoteMeta(strings.Trim(pattern, "-/")))
+	return "^" + re + `(-[A-Za-z0-9._-]+)?$`
+}
+
+// CommitMsgHook renders the commit-msg hook of an issue worktree. It rejects
+// commits on branches outside the project's branch pattern and, when types
+// are given, subjects without one of those conventional commit types, then
+// adds reference to the message unless it is there. Fixups, merges and
+// reverts keep their generated subjects. chained, if set, is the
+// repository's own commit-msg hook, run last.
+func CommitMsgHook(branchRegexp string, types []string, reference, chained string) string {
+	var b strings.Builder
+	b.WriteString("#!/bin/sh\n")
+	b.WriteString("# Generated by issue-flow; reinstall with 'issue-flow worktree hooks install'.\n")
+	b.WriteString("msg=\"$1\"\n\n")
+
+	fmt.Fprintf(&b, "branch=$(git symbolic-ref --quiet --short HEAD)\n")
+	fmt.Fprintf(&b, "if [ -n \"$branch\" ] && ! printf '%%s\\n' \"$branch\" | grep -Eq %s; then\n", shQuote(branchRegexp))
+	b.WriteString("\techo \"issue-flow: branch $branch does not follow the project's branch pattern\" >&2\n")
+	b.WriteString("\texit 1\n")
+	b.WriteString("fi\n\n")
+
+	if len(types) > 0 {
+		subject := `^(` + strings.Join(quoteMetaAll(types), "|") + `)(\([^)]+\))?!?: .`
+		b.WriteString("subject=$(grep -v '^#' \"$msg\" | grep -m 1 -v '^[[:space:]]*$')\n")
+		b.WriteString("case \"$subject\" in\n")
+		b.WriteString("\"fixup! \"*|\"squash! \"*|\"amend! \"*|\"Merge \"*|\"Revert \"*) ;;\n")
+		b.WriteString("*)\n")
+		fmt.Fprintf(&b, "\tif ! printf '%%s\\n' \"$subject\" | grep -Eq %s; then\n", shQuote(subject))
+		fmt.Fprintf(&b, "\t\techo %s >&2\n", shQuote(fmt.Sprintf("issue-flow: start the subject with one of %s, e.g. \"%s: ...\"", strings.Join(types, ", "), types[0])))
+		b.WriteString("\t\texit 1\n")
+		b.WriteString("\tfi\n")
+		b.WriteString("\t;;\n")
+		b.WriteString("esac\n\n")
+	}
+
+	fmt.Fprintf(&b, "if ! grep -qxF %s \"$msg\"; then\n", shQuote(reference))
+	fmt.Fprintf(&b, "\tprintf '\\n%%s\\n' %s >> \"$msg\"\n", shQuote(reference))
+	b.WriteString("fi\n")
+
+	if chained != "" {
+		fmt.Fprintf(&b, "\nexec %s \"$@\"\n", shQuote(chained))
+	}
+	return b.String()
+}
+
+// chainHook renders a hook that only runs the repository's own hook.
+func chainHook(chained string) string {
+	return fmt.Sprintf("#!/bin/sh\n# Generated by issue-flow; runs the repository's hook.\nexec %s \"$@\"\n", shQuote(chained))
+}
+
+// CommitTemplate renders the commit.template of an issue worktree.
+func CommitTemplate(types []string, reference string) string {
+	var b strings.Builder
+	b.WriteString("\n\n" + reference + "\n")
+	if len(types) > 0 {
+		fmt.Fprintf(&b, "# Subject: <type>[(scope)]: <summary>, with type one of %s.\n", strings.Join(types, ", "))
+	}
+	fmt.Fprintf(&b, "# The commit-msg hook adds %q if it is removed.\n", reference)
+	return b.String()
+}
+
+// InstallHooks writes the commit-msg hook and commit template of w into
+// its git directory and points the worktree's core.hooksPath and
+// commit.template at them, using per-worktree config so other worktrees of
+// the repository are unaffected. The repository's own hooks keep running:
+// the generated commit-msg hook ends by running the repository's, and
+// every other hook is linked through. Installing again regenerates
+// everything from the current project config.
+func (m *Manager) InstallHooks(ctx context.Context, p *project.Project, w *storage.Worktree) (*HooksResult, error) {
+	if !HooksEnabled(p, w) {
+		return nil, fmt.Errorf("hooks are not installed into %s", w.ID)
+	}
+	if _, err := os.Stat(w.Path); err != nil {
+		return nil, fmt.Errorf("worktree %s is missing at %s", w.ID, w.Path)
+	}
+	repo := config.ExpandPath(p.LocalPath)
+
+	var issueType string
+	err := m.withDB(func(db *storage.Database) error {
+		issue, err := db.GetIssueCache(p.ID, w.IssueNumber)
+		if err == nil {
+			issueType = issue.Type
+		}
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil
+		}
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up issue #%d: %w", w.IssueNumber, err)
+	}
+
+	original, err := m.repoHooksDir(ctx, repo, w.Path)
+	if err != nil {
+		return nil, err
+	}
+	gitDir, err := m.git.GitDir(ctx, w.Path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find the git directory of %s: %w", w.Path, err)
+	}
+	result := &HooksResult{
+		Dir:      filepath.Join(gitDir, hooksDirName, "hooks"),
+		Template: filepath.Join(gitDir, hooksDirName, "commit-template"),
+	}
+	if err := os.RemoveAll(result.Dir); err != nil {
+		return nil, fmt.Errorf("failed to clear %s: %w", result.Dir, err)
+	}
+	if err := os.MkdirAll(result.Dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create %s: %w", result.Dir, err)
+	}
+
+	var chainedCommitMsg string
+	entries, err := os.ReadDir(original)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read hooks in %s: %w", original, err)
+	}
+	for _, e := range entries {
+		hook := filepath.Join(original, e.Name())
+		if e.IsDir() || strings.HasSuffix(e.Name(), ".sample") || !isExecutable(hook) {
+			continue
+		}
+		result.Chained = append(result.Chained, e.Name())
+		if e.Name() == "commit-msg" {
+			chainedCommitMsg = hook
+			continue
+		}
+		if err := os.WriteFile(filepath.Join(result.Dir, e.Name()), []byte(chainHook(hook)), 0755); err != nil {
+			return nil, fmt.Errorf("failed to write hook %s: %w", e.Name(), err)
+		}
+	}
+
+	types := CommitTypes(p, issueType)
+	reference := CommitReference(p, w.IssueNumber)
+	hook := CommitMsgHook(BranchRegexp(p.Config.BranchConfig, w.IssueNumber), types, reference, chainedCommitMsg)
+	if err := os.WriteFile(filepath.Join(result.Dir, "commit-msg"), []byte(hook), 0755); err != nil {
+		return nil, fmt.Errorf("failed to write commit-msg hook: %w", err)
+	}
+	if err := os.WriteFile(result.Template, []byte(CommitTemplate(types, reference)), 0644); err != nil {
+		return nil, fmt.Errorf("failed to write commit template: %w", err)
+	}
+
+	if err := m.enableWorktreeConfig(ctx, repo); err != nil {
+		return nil, err
+	}
+	if err := m.git.WorktreeConfigSet(ctx, w.Path, "core.hooksPath", result.Dir); err != nil {
+		return nil, fmt.Errorf("failed to set core.hooksPath: %w", err)
+	}
+	if err := m.git.WorktreeConfigSet(ctx, w.Path, "commit.template", result.Template); err != nil {
+		return nil, fmt.Errorf("failed to set commit.template: %w", err)
+	}
+	return result, nil
+}
+
+// UninstallHooks undoes InstallHooks for w: it unsets the worktree's
+// core.hooksPath and commit.template and deletes the generated files, so
+// the repository's own hooks apply again. It reports whether w had hooks.
+func (m *Manager) UninstallHooks(ctx context.Context, w *storage.Worktree) (bool, error) {
+	if _, err := os.Stat(w.Path); err != nil {
+		return false, fmt.Errorf("worktree %s is missing at %s", w.ID, w.Path)
+	}
+	for _, key := range []string{"core.hooksPath", "commit.template"} {
+		if err := m.git.WorktreeConfigUnset(ctx, w.Path, key); err != nil {
+			return false, fmt.Errorf("failed to unset %s: %w", key, err)
+		}
+	}
+	gitDir, err := m.git.GitDir(ctx, w.Path)
+	if err != nil {
+		return false, fmt.Errorf("failed to find the git directory of %s: %w", w.Path, err)
+	}
+	dir := filepath.Join(gitDir, hooksDirName)
+	if _, err := os.Stat(dir); os.IsNotExist(err) {
+		return false, nil
+	}
+	if err := os.RemoveAll(dir); err != nil {
+		return false, fmt.Errorf("failed to remove hooks of %s: %w", w.ID, err)
+	}
+	return true, nil
+}
+
+// installHooks installs hooks into a new worktree when the project wants
+// them, turning a failure into a warning.
+func (m *Manager) installHooks(ctx context.Context, p *project.Project, w *storage.Worktree) []string {
+	if !HooksEnabled(p, w) {
+		return nil
+	}
+	if _, err := m.InstallHooks(ctx, p, w); err != nil {
+		return []string{fmt.Sprintf("failed to install git hooks: %v", err)}
+	}
+	return nil
+}
+
+// repoHooksDir returns the hooks directory the repository uses without
+// issue-flow: its core.hooksPath, relative to the worktree, or the common
+// hooks directory.
+func (m *Manager) repoHooksDir(ctx context.Context, repo, worktreePath string) (string, error) {
+	hooksPath, err := m.git.ConfigGet(ctx, repo, "core.hooksPath")
+	if err != nil {
+		return "", fmt.Errorf("failed to read core.hooksPath: %w", err)
+	}
+	if hooksPath != "" {
+		hooksPath = config.ExpandPath(hooksPath)
+		if !filepath.IsAbs(hooksPath) {
+			hooksPath = filepath.Join(worktreePath, hooksPath)
+		}
+		return hooksPath, nil
+	}
+	gitDir, err := m.git.GitDir(ctx, repo)
+	if err != nil {
+		return "", fmt.Errorf("failed to find the git directory of %s: %w", repo, err)
+	}
+	return filepath.Join(gitDir, "hooks"), nil
+}
+
+// enableWorktreeConfig turns on per-worktree config for the repository.
+func (m *Manager) enableWorktreeConfig(ctx context.Context, repo string) error {
+	enabled, err := m.git.ConfigGet(ctx, repo, "extensions.worktreeConfig")
+	if err != nil {
+		return fmt.Errorf("failed to read extensions.worktreeConfig: %w", err)
+	}
+	if enabled == "true" {
+		return nil
+	}
+	if err := m.git.ConfigSet(ctx, repo, "extensions.worktreeConfig", "true"); err != nil {
+		return fmt.Errorf("failed to enable per-worktree config: %w", err)
+	}
+	return nil
+}
+
+func isExecutable(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && !info.IsDir() && info.Mode()&0111 != 0
+}
+
+func quoteMetaAll(list []string) []string {
+	quoted := make([]string, len(list))
+	for i, s := range list {
+		quoted[i] = regexp.QuoteMeta(s)
+	}
+	return quoted
+}
+
+// shQuote single-quotes s for POSIX shells.
+func shQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
@@ -0,0 +1,150 @@
+package worktree
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"testing"
+
+	"github.com/paolorechia/issue-flow/internal/git"
+	"github.com/paolorechia/issue-flow/internal/project"
+	"github.com/paolorechia/issue-flow/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// tryCommit commits an empty change in dir and returns git's output.
+func tryCommit(t *testing.T, dir, message string) (string, error) {
+	t.Helper()
+	cmd := exec.Command("git", "commit", "--allow-empty", "-q", "-m", message)
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	return string(out), err
+}
+
+func TestBranchRegexp(t *testing.T) {
+	cfg := project.BranchConfig{}
+	re := regexp.MustCompile(BranchRegexp(cfg, 12))
+	assert.True(t, re.MatchString(BranchName(cfg, "feature", 12, "Add login page")))
+	assert.True(t, re.MatchString(BranchName(cfg, "fix/ui", 12, "Typo")+"-a1"))
+	assert.False(t, re.MatchString(BranchName(cfg, "feature", 13, "Add login page")))
+	assert.False(t, re.MatchString("main"))
+
+	re = regexp.MustCompile(IssueBranchRegexp(cfg))
+	assert.True(t, re.MatchString("feature/12-add-login-page"))
+	assert.True(t, re.MatchString("fix/7-typo-a2"))
+	assert.False(t, re.MatchString("release/1.4"))
+
+	cfg.Pattern = "{issue-number}.{slug}"
+	re = regexp.MustCompile(BranchRegexp(cfg, 12))
+	assert.True(t, re.MatchString("12.add-login"))
+	assert.False(t, re.MatchString("12xadd-login"))
+}
+
+func TestCommitMsgHook(t *testing.T) {
+	hook := CommitMsgHook("^feature/1-[a-z0-9-]+$", []string{"feat", "fix"}, "Refs #1", "/repo/.git/hooks/commit-msg")
+	assert.Contains(t, hook, `grep -Eq '^(feat|fix)(\([^)]+\))?!?: .'`)
+	assert.Contains(t, hook, "grep -qxF 'Refs #1' \"$msg\"")
+	assert.Contains(t, hook, "exec '/repo/.git/hooks/commit-msg' \"$@\"\n")
+
+	hook = CommitMsgHook("^x$", nil, "Refs #1", "")
+	assert.NotContains(t, hook, "subject")
+	assert.NotContains(t, hook, "exec")
+}
+
+func TestManager_StartInstallsHooks(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	sp := testutil.CreateGitProjectWithConfig(t, db, "app",
+		`{"issue_types": [{"name": "bug", "branch_prefix": "fix", "commit_types": ["fix", "test"]}]}`)
+	repo := sp.LocalPath
+	p, err := project.NewManager(db).Get("app")
+	require.NoError(t, err)
+	manager := NewManager(db, git.NewClient(nil))
+	ctx := context.Background()
+
+	result, err := manager.Start(ctx, p, StartOptions{IssueNumber: 4, Title: "Crash on save", Type: "bug"})
+	require.NoError(t, err)
+	require.Empty(t, result.Warnings)
+	w := result.Worktree
+
+	out, err := tryCommit(t, w.Path, "Fix the crash")
+	require.Error(t, err)
+	assert.Contains(t, out, "start the subject with one of fix, test")
+
+	_, err = tryCommit(t, w.Path, "fix(save): handle a missing file")
+	require.NoError(t, err)
+	assert.Equal(t, "fix(save): handle a missing file\n\nRefs #4", testutil.RunGit(t, w.Path, "log", "-1", "--format=%B"))
+	_, err = tryCommit(t, w.Path, "fixup! fix(save): handle a missing file")
+	require.NoError(t, err)
+
+	template := testutil.RunGit(t, w.Path, "config", "--get", "commit.template")
+	content, err := os.ReadFile(template)
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "\n\nRefs #4\n")
+
+	testutil.RunGit(t, w.Path, "checkout", "-q", "-b", "scratch")
+	out, err = tryCommit(t, w.Path, "fix: elsewhere")
+	require.Error(t, err)
+	assert.Contains(t, out, "branch scratch does not follow the project's branch pattern")
+	testutil.RunGit(t, w.Path, "checkout", "-q", w.Branch)
+
+	_, err = tryCommit(t, repo, "Anything goes in the main worktree")
+	assert.NoError(t, err, "hooks are configured per worktree")
+}
+
+func TestManager_InstallHooksChainsRepoHooks(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	sp := testutil.CreateGitProject(t, db, "app")
+	p, manager, w := startTestWorktree(t, db, 1)
+
+	hooks := filepath.Join(sp.LocalPath, ".git", "hooks")
+	marker := filepath.Join(t.TempDir(), "ran")
+	require.NoError(t, os.MkdirAll(hooks, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(hooks, "commit-msg"), []byte("#!/bin/sh\necho commit-msg >> '"+marker+"'\n"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(hooks, "pre-commit"), []byte("#!/bin/sh\necho pre-commit >> '"+marker+"'\n"), 0755))
+
+	result, err := manager.InstallHooks(context.Background(), p, w)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"commit-msg", "pre-commit"}, result.Chained)
+
+	_, err = tryCommit(t, w.Path, "Work for 1")
+	require.NoError(t, err)
+	ran, err := os.ReadFile(marker)
+	require.NoError(t, err)
+	assert.Equal(t, "pre-commit\ncommit-msg\n", string(ran))
+	assert.Contains(t, testutil.RunGit(t, w.Path, "log", "-1", "--format=%B"), "Refs #1")
+}
+
+func TestManager_StartWithoutHooks(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	testutil.CreateGitProjectWithConfig(t, db, "app", `{"commits": {"disable_hooks": true}}`)
+	_, _, w := startTestWorktree(t, db, 1)
+
+	_, err := tryCommit(t, w.Path, "Work for 1")
+	require.NoError(t, err)
+	assert.Equal(t, "Work for 1", testutil.RunGit(t, w.Path, "log", "-1", "--format=%B"))
+}
+
+func TestManager_UninstallHooks(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	testutil.CreateGitProject(t, db, "app")
+	_, manager, w := startTestWorktree(t, db, 1)
+	ctx := context.Background()
+
+	removed, err := manager.UninstallHooks(ctx, w)
+	require.NoError(t, err)
+	assert.True(t, removed)
+	worktreeConfig := testutil.RunGit(t, w.Path, "config", "--worktree", "--list")
+	assert.NotContains(t, worktreeConfig, "core.hookspath")
+	assert.NotContains(t, worktreeConfig, "commit.template")
+	assert.NoDirExists(t, filepath.Join(testutil.RunGit(t, w.Path, "rev-parse", "--absolute-git-dir"), hooksDirName))
+
+	_, err = tryCommit(t, w.Path, "Work without a reference")
+	require.NoError(t, err)
+
+	removed, err = manager.UninstallHooks(ctx, w)
+	require.NoError(t, err)
+	assert.False(t, removed)
+}
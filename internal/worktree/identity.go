@@ -0,0 +1,125 @@
+package worktree
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/paolorechia/issue-flow/internal/config"
+	"github.com/paolorechia/issue-flow/internal/project"
+	"github.com/paolorechia/issue-flow/internal/storage"
+)
+
+// ConfigSetting is a git config key and the value a worktree should have.
+type ConfigSetting struct {
+	Key   string
+	Value string
+}
+
+// IdentityMismatch is a setting of a project's identity profile that a
+// worktree does not have.
+type IdentityMismatch struct {
+	Key  string
+	Want string
+	// Got is the worktree's effective value, "" when unset.
+	Got string
+}
+
+// IdentitySettings returns the git config an identity profile sets, in the
+// order it is applied. A signing key also turns on commit.gpgsign.
+func IdentitySettings(cfg project.IdentityConfig) ([]ConfigSetting, error) {
+	var settings []ConfigSetting
+	if cfg.Name != "" {
+		settings = append(settings, ConfigSetting{"user.name", cfg.Name})
+	}
+	if cfg.Email != "" {
+		settings = append(settings, ConfigSetting{"user.email", cfg.Email})
+	}
+	switch cfg.SigningFormat {
+	case "":
+	case project.SigningOpenPGP, project.SigningSSH, project.SigningX509:
+		if cfg.SigningKey == "" {
+			return nil, fmt.Errorf("identity signing_format %s needs a signing_key", cfg.SigningFormat)
+		}
+		settings = append(settings, ConfigSetting{"gpg.format", cfg.SigningFormat})
+	default:
+		return nil, fmt.Errorf("unknown identity signing_format %q (want openpgp, ssh or x509)", cfg.SigningFormat)
+	}
+	if cfg.SigningKey != "" {
+		settings = append(settings,
+			ConfigSetting{"user.signingkey", cfg.SigningKey},
+			ConfigSetting{"commit.gpgsign", "true"})
+	}
+	return settings, nil
+}
+
+// ApplyIdentity writes the project's identity profile into the per-worktree
+// config of w, so commits made there use it whatever the user's global
+// config says. It does nothing when the project has no profile.
+func (m *Manager) ApplyIdentity(ctx context.Context, p *project.Project, w *storage.Worktree) error {
+	settings, err := IdentitySettings(p.Config.Identity)
+	if err != nil {
+		return err
+	}
+	if len(settings) == 0 {
+		return nil
+	}
+	if _, err := os.Stat(w.Path); err != nil {
+		return fmt.Errorf("worktree %s is missing at %s", w.ID, w.Path)
+	}
+	if err := m.enableWorktreeConfig(ctx, config.ExpandPath(p.LocalPath)); err != nil {
+		return err
+	}
+	for _, s := range settings {
+		if err := m.git.WorktreeConfigSet(ctx, w.Path, s.Key, s.Value); err != nil {
+			return fmt.Errorf("failed to set %s: %w", s.Key, err)
+		}
+	}
+	return nil
+}
+
+// applyIdentity applies the identity profile to a new worktree, turning a
+// failure into a warning.
+func (m *Manager) applyIdentity(ctx context.Context, p *project.Project, w *storage.Worktree) []string {
+	if err := m.ApplyIdentity(ctx, p, w); err != nil {
+		return []string{fmt.Sprintf("failed to apply git identity: %v", err)}
+	}
+	return nil
+}
+
+// CheckIdentity compares the effective git config of w with the project's
+// identity profile and returns the settings that differ.
+func (m *Manager) CheckIdentity(ctx context.Context, p *project.Project, w *storage.Worktree) ([]IdentityMismatch, error) {
+	settings, err := IdentitySettings(p.Config.Identity)
+	if err != nil {
+		return nil, err
+	}
+	if len(settings) == 0 {
+		return nil, nil
+	}
+	if _, err := os.Stat(w.Path); err != nil {
+		return nil, fmt.Errorf("worktree %s is missing at %s", w.ID, w.Path)
+	}
+	var mismatches []IdentityMismatch
+	for _, s := range settings {
+		got, err := m.git.ConfigGet(ctx, w.Path, s.Key)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", s.Key, err)
+		}
+		if s.Key == "commit.gpgsign" && gitBool(got) || got == s.Value {
+			continue
+		}
+		mismatches = append(mismatches, IdentityMismatch{Key: s.Key, Want: s.Value, Got: got})
+	}
+	return mismatches, nil
+}
+
+// gitBool reports whether a git config value means true.
+func gitBool(value string) bool {
+	switch strings.ToLower(value) {
+	case "true", "yes", "on", "1":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,61 @@
+package worktree
+
+import (
+	"context"
+	"testing"
+
+	"github.com/paolorechia/issue-flow/internal/project"
+	"github.com/paolorechia/issue-flow/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIdentitySettings(t *testing.T) {
+	settings, err := IdentitySettings(project.IdentityConfig{})
+	require.NoError(t, err)
+	assert.Empty(t, settings)
+
+	settings, err = IdentitySettings(project.IdentityConfig{
+		Name: "Ada", Email: "ada@work.example", SigningKey: "~/.ssh/work.pub", SigningFormat: "ssh",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []ConfigSetting{
+		{"user.name", "Ada"},
+		{"user.email", "ada@work.example"},
+		{"gpg.format", "ssh"},
+		{"user.signingkey", "~/.ssh/work.pub"},
+		{"commit.gpgsign", "true"},
+	}, settings)
+
+	_, err = IdentitySettings(project.IdentityConfig{SigningFormat: "ssh"})
+	assert.ErrorContains(t, err, "needs a signing_key")
+	_, err = IdentitySettings(project.IdentityConfig{SigningKey: "ABC", SigningFormat: "pgp"})
+	assert.ErrorContains(t, err, `unknown identity signing_format "pgp"`)
+}
+
+func TestManager_StartAppliesIdentity(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	sp := testutil.CreateGitProjectWithConfig(t, db, "app",
+		`{"identity": {"name": "Ada Work", "email": "ada@work.example"}}`)
+	p, manager, w := startTestWorktree(t, db, 1)
+	ctx := context.Background()
+
+	testutil.GitCommitFile(t, w.Path, "work.txt", "work\n", "Work for 1")
+	assert.Equal(t, "Ada Work <ada@work.example>", testutil.RunGit(t, w.Path, "log", "-1", "--format=%an <%ae>"))
+	assert.NotEqual(t, "ada@work.example", testutil.RunGit(t, sp.LocalPath, "config", "user.email"),
+		"the identity is configured per worktree")
+
+	mismatches, err := manager.CheckIdentity(ctx, p, w)
+	require.NoError(t, err)
+	assert.Empty(t, mismatches)
+
+	testutil.RunGit(t, w.Path, "config", "--worktree", "user.email", "ada@home.example")
+	mismatches, err = manager.CheckIdentity(ctx, p, w)
+	require.NoError(t, err)
+	assert.Equal(t, []IdentityMismatch{{Key: "user.email", Want: "ada@work.example", Got: "ada@home.example"}}, mismatches)
+
+	require.NoError(t, manager.ApplyIdentity(ctx, p, w))
+	mismatches, err = manager.CheckIdentity(ctx, p, w)
+	require.NoError(t, err)
+	assert.Empty(t, mismatches)
+}
@@ -0,0 +1,138 @@
+package worktree
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/paolorechia/issue-flow/internal/config"
+	"github.com/paolorechia/issue-flow/internal/project"
+	"github.com/paolorechia/issue-flow/internal/storage"
+)
+
+// DefaultWorkers bounds how many worktrees are inspected at the same time.
+const DefaultWorkers = 8
+
+// State is a worktree row joined with its live git state.
+type State struct {
+	storage.Worktree
+
+	// Exists is false when the worktree directory is missing on disk.
+	Exists   bool
+	Dirty    bool
+	Upstream string
+	Ahead    int
+	Behind   int
+
+	LastCommit time.Time
+	// Merged is true when the branch has commits of its own and all of them
+	// are contained in the project's default branch.
+	Merged bool
+
+	Err error
+}
+
+type inspectTarget struct {
+	repo     string
+	upstream string
+	base     string
+}
+
+// Inspect gathers live git state for each worktree, running at most workers
+// inspections concurrently. Results are returned in input order; per-worktree
+// failures are reported in State.Err.
+func (m *Manager) Inspect(ctx context.Context, worktrees []storage.Worktree, workers int) ([]State, error) {
+	if workers <= 0 {
+		workers = DefaultWorkers
+	}
+
+	projects, err := project.NewManager(m.db).List()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list projects: %w", err)
+	}
+
+	targets := make(map[string]inspectTarget)
+	for _, p := range projects {
+		t := inspectTarget{repo: config.ExpandPath(p.LocalPath), upstream: p.UpstreamRemote()}
+		if t.repo != "" {
+			if base, err := m.BaseRef(ctx, t.repo, t.upstream); err == nil {
+				t.base = base
+			}
+		}
+		targets[p.ID] = t
+	}
+
+	states := make([]State, len(worktrees))
+	sem := make(chan struct{}, workers)
+	var wg sync.WaitGroup
+
+	for i, w := range worktrees {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, w storage.Worktree) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			states[i] = m.inspect(ctx, w, targets[w.ProjectID])
+		}(i, w)
+	}
+	wg.Wait()
+
+	return states, nil
+}
+
+func (m *Manager) inspect(ctx context.Context, w storage.Worktree, t inspectTarget) State {
+	s := State{Worktree: w}
+
+	if _, err := os.Stat(w.Path); err != nil {
+		return s
+	}
+	s.Exists = true
+
+	status, err := m.git.Status(ctx, w.Path)
+	if err != nil {
+		s.Err = fmt.Errorf("failed to get status: %w", err)
+		return s
+	}
+	s.Dirty = !status.Clean()
+	s.Upstream = status.Upstream
+	s.Ahead = status.Ahead
+	s.Behind = status.Behind
+
+	if last, err := m.git.LastCommitTime(ctx, w.Path, "HEAD"); err == nil {
+		s.LastCommit = last
+	}
+
+	base := t.base
+	if w.BaseBranch != "" && t.repo != "" {
+		if base, err = m.BranchBaseRef(ctx, t.repo, t.upstream, w.BaseBranch); err != nil {
+			s.Err = err
+			return s
+		}
+	}
+	if t.repo == "" || base == "" {
+		return s
+	}
+	merged, err := m.isMerged(ctx, t.repo, w.Branch, base)
+	if err != nil {
+		s.Err = fmt.Errorf("failed to check merge state: %w", err)
+		return s
+	}
+	s.Merged = merged
+	return s
+}
+
+// isMerged reports whether branch has been merged into base. A branch that
+// still points at the commit it was created from has no work to merge and is
+// not considered merged.
+func (m *Manager) isMerged(ctx context.Context, repo, branch, base string) (bool, error) {
+	tip, err := m.git.RevParse(ctx, repo, "refs/heads/"+branch)
+	if err != nil {
+		return false, nil
+	}
+	if start, err := m.git.BranchStartCommit(ctx, repo, branch); err == nil && start == tip {
+		return false, nil
+	}
+	return m.git.IsAncestor(ctx, repo, tip, base)
+}
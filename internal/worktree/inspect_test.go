@@ -0,0 +1,67 @@
+package worktree
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/paolorechia/issue-flow/internal/git"
+	"github.com/paolorechia/issue-flow/internal/project"
+	"github.com/paolorechia/issue-flow/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestManager_Inspect(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	sp := testutil.CreateGitProject(t, db, "app")
+	p, err := project.NewManager(db).Get(sp.ID)
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	manager := NewManager(db, git.NewClient(nil))
+
+	merged, err := manager.Start(ctx, p, StartOptions{IssueNumber: 1, Title: "Merged work"})
+	require.NoError(t, err)
+	testutil.GitCommitFile(t, merged.Worktree.Path, "done.txt", "done\n", "Finish issue 1")
+	testutil.RunGit(t, sp.LocalPath, "merge", "--ff-only", "--quiet", merged.Worktree.Branch)
+
+	dirty, err := manager.Start(ctx, p, StartOptions{IssueNumber: 2, Title: "Dirty work"})
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(dirty.Worktree.Path, "wip.txt"), []byte("wip\n"), 0644))
+
+	fresh, err := manager.Start(ctx, p, StartOptions{IssueNumber: 3, Title: "Fresh"})
+	require.NoError(t, err)
+
+	missing := testutil.CreateTestWorktree(t, db, "app", 4)
+
+	worktrees, err := db.ListWorktreesByProject("app")
+	require.NoError(t, err)
+	require.Len(t, worktrees, 4)
+
+	states, err := manager.Inspect(ctx, worktrees, 2)
+	require.NoError(t, err)
+	require.Len(t, states, 4)
+
+	byIssue := make(map[int]State)
+	for _, s := range states {
+		require.NoError(t, s.Err)
+		byIssue[s.IssueNumber] = s
+	}
+
+	assert.True(t, byIssue[1].Exists)
+	assert.True(t, byIssue[1].Merged)
+	assert.False(t, byIssue[1].Dirty)
+	assert.False(t, byIssue[1].LastCommit.IsZero())
+
+	assert.True(t, byIssue[2].Dirty)
+	assert.False(t, byIssue[2].Merged)
+
+	assert.Equal(t, fresh.Worktree.ID, byIssue[3].ID)
+	assert.False(t, byIssue[3].Merged)
+	assert.False(t, byIssue[3].Dirty)
+
+	assert.Equal(t, missing.ID, byIssue[4].ID)
+	assert.False(t, byIssue[4].Exists)
+}
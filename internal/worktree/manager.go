@@ -0,0 +1,395 @@
+package worktree
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/paolorechia/issue-flow/internal/config"
+	"github.com/paolorechia/issue-flow/internal/git"
+	"github.com/paolorechia/issue-flow/internal/github"
+	"github.com/paolorechia/issue-flow/internal/project"
+	"github.com/paolorechia/issue-flow/internal/storage"
+)
+
+type Manager struct {
+	db  *storage.Database
+	git git.Git
+
+	// dbMu serializes database access from concurrent operations; SQLite
+	// allows a single writer.
+	dbMu sync.Mutex
+	// repoLocks holds a *sync.Mutex per repository so mutating git commands
+	// on the same repo never race for ref or worktree locks.
+	repoLocks sync.Map
+}
+
+func NewManager(db *storage.Database, g git.Git) *Manager {
+	return &Manager{db: db, git: g}
+}
+
+// Start makes sure a worktree exists for an issue and is recorded in the
+// database. Calling it again for the same issue reuses the existing worktree.
+func (m *Manager) Start(ctx context.Context, p *project.Project, opts StartOptions) (*StartResult, error) {
+	if opts.IssueNumber <= 0 {
+		return nil, fmt.Errorf("issue number must be positive")
+	}
+	if p.LocalPath == "" {
+		return nil, fmt.Errorf("project %s has no local path", p.ID)
+	}
+	repo := config.ExpandPath(p.LocalPath)
+
+	result := &StartResult{}
+
+	attempt, err := m.resolveAttempt(p.ID, opts)
+	if err != nil {
+		return nil, err
+	}
+	existing, err := m.db.GetWorktreeByAttempt(p.ID, opts.IssueNumber, attempt)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("failed to look up worktree: %w", err)
+	}
+	if err := checkParent(p, AttemptID(p.ID, opts.IssueNumber, attempt), existing, opts.Parent); err != nil {
+		return nil, err
+	}
+	if existing != nil && opts.BaseBranch != "" && opts.BaseBranch != existing.BaseBranch {
+		return nil, fmt.Errorf("%s already exists and is not based on %s", existing.ID, opts.BaseBranch)
+	}
+
+	if existing != nil {
+		if existing.Status == storage.WorktreeStatusParked {
+			return nil, fmt.Errorf("%s: %w", existing.ID, ErrParked)
+		}
+		if existing.Status == storage.WorktreeStatusArchived {
+			return nil, fmt.Errorf("%s: %w", existing.ID, ErrArchived)
+		}
+		registered, err := m.isRegistered(ctx, repo, existing.Path)
+		if err != nil {
+			return nil, err
+		}
+		if !registered {
+			if err := m.git.WorktreePrune(ctx, repo); err != nil {
+				return nil, fmt.Errorf("failed to prune worktrees: %w", err)
+			}
+			if err := m.checkout(ctx, p, existing.Path, existing.Branch, existing.SparsePatterns, opts, result); err != nil {
+				return nil, err
+			}
+			result.Created = true
+		}
+		if existing.Status != storage.WorktreeStatusActive || result.Created {
+			existing.Status = storage.WorktreeStatusActive
+			if err := m.db.UpdateWorktree(existing); err != nil {
+				return nil, fmt.Errorf("failed to update worktree: %w", err)
+			}
+		}
+		result.Worktree = existing
+		if result.Created {
+			result.Warnings = append(result.Warnings, m.installHooks(ctx, p, existing)...)
+			result.Warnings = append(result.Warnings, m.applyIdentity(ctx, p, existing)...)
+		}
+		if result.Ports, err = m.AllocatePorts(p, existing); err != nil {
+			return result, fmt.Errorf("failed to allocate ports: %w", err)
+		}
+		return result, nil
+	}
+
+	issue, err := m.resolveIssue(ctx, p, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	path, err := Path(p, opts.IssueNumber, opts.WorktreeBase)
+	if err != nil {
+		return nil, err
+	}
+	if opts.BaseBranch, err = m.resolveBaseBranch(ctx, repo, p, issue.Type, &opts, result); err != nil {
+		return nil, err
+	}
+
+	branch := BranchName(p.Config.BranchConfig, BranchPrefix(p, issue.Type), opts.IssueNumber, issue.Title)
+	path += attemptSuffix(attempt)
+	branch += attemptSuffix(attempt)
+	sparse := SparsePatterns(p, issue.Type)
+
+	var parentID, parentBase string
+	if opts.Parent != nil {
+		parentID = opts.Parent.ID
+		if parentBase, err = m.git.RevParse(ctx, repo, "refs/heads/"+opts.Parent.Branch); err != nil {
+			return nil, fmt.Errorf("failed to resolve branch %s of %s: %w", opts.Parent.Branch, opts.Parent.ID, err)
+		}
+	}
+
+	registered, err := m.isRegistered(ctx, repo, path)
+	if err != nil {
+		return nil, err
+	}
+	if registered {
+		if current, err := m.git.CurrentBranch(ctx, path); err == nil {
+			branch = current
+		}
+		// An adopted worktree keeps whatever checkout it already has.
+		sparse = nil
+	} else {
+		if err := m.checkout(ctx, p, path, branch, sparse, opts, result); err != nil {
+			return nil, err
+		}
+		result.Created = true
+	}
+
+	w := &storage.Worktree{
+		ID:             AttemptID(p.ID, opts.IssueNumber, attempt),
+		ProjectID:      p.ID,
+		IssueNumber:    opts.IssueNumber,
+		Path:           path,
+		Branch:         branch,
+		Status:         storage.WorktreeStatusActive,
+		SparsePatterns: sparse,
+		Attempt:        attempt,
+		ParentID:       parentID,
+		ParentBase:     parentBase,
+		BaseBranch:     opts.BaseBranch,
+	}
+	if err := m.db.CreateWorktree(w); err != nil {
+		return nil, fmt.Errorf("failed to record worktree: %w", err)
+	}
+
+	result.Worktree = w
+	result.Issue = issue
+	if result.Created {
+		result.Warnings = append(result.Warnings, m.installHooks(ctx, p, w)...)
+		result.Warnings = append(result.Warnings, m.applyIdentity(ctx, p, w)...)
+	}
+	if result.Ports, err = m.AllocatePorts(p, w); err != nil {
+		return result, fmt.Errorf("failed to allocate ports: %w", err)
+	}
+	return result, nil
+}
+
+// withDB runs fn while holding the database lock.
+func (m *Manager) withDB(fn func(db *storage.Database) error) error {
+	m.dbMu.Lock()
+	defer m.dbMu.Unlock()
+	return fn(m.db)
+}
+
+// lockRepo locks repo for mutating git commands and returns the unlock func.
+func (m *Manager) lockRepo(repo string) func() {
+	mu, _ := m.repoLocks.LoadOrStore(canonical(repo), &sync.Mutex{})
+	mu.(*sync.Mutex).Lock()
+	return mu.(*sync.Mutex).Unlock
+}
+
+// ID returns the worktrees table key for an issue worktree.
+func ID(projectID string, issueNumber int) string {
+	return fmt.Sprintf("wt-%s-%d", projectID, issueNumber)
+}
+
+// Path returns where the worktree for an issue lives: under the project's
+// WorktreeDir, or under base/<project-id> when the project does not set one.
+func Path(p *project.Project, issueNumber int, base string) (string, error) {
+	dir, err := projectWorktreeDir(p, base)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, fmt.Sprintf("issue-%d", issueNumber)), nil
+}
+
+func projectWorktreeDir(p *project.Project, base string) (string, error) {
+	dir := p.WorktreeDir
+	if dir == "" {
+		if base == "" {
+			return "", fmt.Errorf("project %s has no worktree directory and no worktree base is configured", p.ID)
+		}
+		dir = filepath.Join(base, p.ID)
+	}
+	return config.ExpandPath(dir), nil
+}
+
+func (m *Manager) resolveIssue(ctx context.Context, p *project.Project, opts StartOptions) (*storage.IssueCache, error) {
+	cached, err := m.db.GetIssueCache(p.ID, opts.IssueNumber)
+	if err == nil {
+		return cached, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("failed to look up issue: %w", err)
+	}
+
+	issue := &storage.IssueCache{
+		ProjectID:   p.ID,
+		IssueNumber: opts.IssueNumber,
+		Title:       opts.Title,
+		Type:        opts.Type,
+		Status:      "open",
+		CachedAt:    time.Now(),
+	}
+	if issue.Title == "" && opts.GitHub != nil {
+		remote, err := opts.GitHub.GetIssue(ctx, github.Repo{Owner: p.GitHubOwner, Name: p.GitHubRepo}, opts.IssueNumber)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch issue #%d from GitHub: %w", opts.IssueNumber, err)
+		}
+		issue.Title, issue.Status = remote.Title, remote.State
+		if issue.Type == "" {
+			issue.Type = labelledType(p, remote.LabelNames())
+		}
+	}
+	if issue.Title != "" {
+		if err := m.db.CacheIssue(issue); err != nil {
+			return nil, fmt.Errorf("failed to cache issue: %w", err)
+		}
+	}
+	return issue, nil
+}
+
+// labelledType returns the first of the project's issue types whose label
+// is among labels, or "" when none is.
+func labelledType(p *project.Project, labels []string) string {
+	for _, t := range p.Config.IssueTypes {
+		if t.Label != "" && slices.Contains(labels, t.Label) {
+			return t.Name
+		}
+	}
+	return ""
+}
+
+// checkout adds a worktree at path for branch, creating the branch from the
+// default branch when it does not exist yet. With sparse patterns the
+// worktree is created empty and populated only after the sparse-checkout is
+// configured, so excluded directories are never written. A new branch of a
+// project working from a fork is pushed to the fork.
+func (m *Manager) checkout(ctx context.Context, p *project.Project, path, branch string, sparse []string, opts StartOptions, result *StartResult) error {
+	repo := config.ExpandPath(p.LocalPath)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create worktree directory: %w", err)
+	}
+
+	exists, err := m.git.BranchExists(ctx, repo, branch)
+	if err != nil {
+		return fmt.Errorf("failed to check branch %s: %w", branch, err)
+	}
+	noCheckout := len(sparse) > 0
+	if exists {
+		if err := m.git.WorktreeAdd(ctx, repo, git.WorktreeAddOptions{Path: path, Branch: branch, NoCheckout: noCheckout}); err != nil {
+			return fmt.Errorf("failed to create worktree: %w", err)
+		}
+		return m.populateSparse(ctx, path, sparse)
+	}
+
+	startPoint, err := m.startPoint(ctx, repo, p.UpstreamRemote(), opts, result)
+	if err != nil {
+		return err
+	}
+
+	err = m.git.WorktreeAdd(ctx, repo, git.WorktreeAddOptions{
+		Path:       path,
+		Branch:     branch,
+		NewBranch:  true,
+		StartPoint: startPoint,
+		NoTrack:    true,
+		NoCheckout: noCheckout,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create worktree: %w", err)
+	}
+	if p.IsFork() {
+		if err := m.git.ConfigSet(ctx, repo, "branch."+branch+".pushRemote", p.PushRemote()); err != nil {
+			result.Warnings = append(result.Warnings, fmt.Sprintf("failed to set the push remote of %s: %v", branch, err))
+		}
+	}
+	return m.populateSparse(ctx, path, sparse)
+}
+
+// startPoint returns the ref new branches are created from: the parent's
+// branch for stacked worktrees, otherwise the upstream copy of the base
+// branch after an optional fetch.
+func (m *Manager) startPoint(ctx context.Context, repo, upstream string, opts StartOptions, result *StartResult) (string, error) {
+	if opts.Parent != nil {
+		return opts.Parent.Branch, nil
+	}
+	if opts.Fetch {
+		if err := m.fetchUpstream(ctx, repo, upstream, result); err != nil {
+			return "", err
+		}
+	}
+	return m.BranchBaseRef(ctx, repo, upstream, opts.BaseBranch)
+}
+
+// fetchUpstream fetches the upstream remote if the repo has it. A failed
+// fetch is only a warning.
+func (m *Manager) fetchUpstream(ctx context.Context, repo, upstream string, result *StartResult) error {
+	hasUpstream, err := m.hasRemote(ctx, repo, upstream)
+	if err != nil {
+		return err
+	}
+	if hasUpstream {
+		if err := m.git.Fetch(ctx, repo, upstream); err != nil {
+			result.Warnings = append(result.Warnings, fmt.Sprintf("fetch from %s failed: %v", upstream, err))
+		}
+	}
+	return nil
+}
+
+// BaseRef returns the ref of the repo's default branch, using the
+// <upstream>/<branch> remote-tracking ref when one exists.
+func (m *Manager) BaseRef(ctx context.Context, repo, upstream string) (string, error) {
+	base, err := m.git.DefaultBranch(ctx, repo)
+	if err != nil {
+		return "", fmt.Errorf("failed to determine default branch: %w", err)
+	}
+	remote, err := m.git.RefExists(ctx, repo, "refs/remotes/"+upstream+"/"+base)
+	if err != nil {
+		return "", err
+	}
+	if remote {
+		return upstream + "/" + base, nil
+	}
+	return base, nil
+}
+
+func (m *Manager) hasRemote(ctx context.Context, repo, name string) (bool, error) {
+	remotes, err := m.git.Remotes(ctx, repo)
+	if err != nil {
+		return false, fmt.Errorf("failed to list remotes: %w", err)
+	}
+	for _, r := range remotes {
+		if r.Name == name {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// isRegistered reports whether git knows a worktree at path that still exists on disk.
+func (m *Manager) isRegistered(ctx context.Context, repo, path string) (bool, error) {
+	worktrees, err := m.git.WorktreeList(ctx, repo)
+	if err != nil {
+		return false, fmt.Errorf("failed to list worktrees: %w", err)
+	}
+	for _, wt := range worktrees {
+		if wt.Prunable || !SamePath(wt.Path, path) {
+			continue
+		}
+		if _, err := os.Stat(path); err == nil {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// SamePath compares two paths after cleaning and resolving symlinks.
+func SamePath(a, b string) bool {
+	return canonical(a) == canonical(b)
+}
+
+func canonical(path string) string {
+	path = filepath.Clean(path)
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		return resolved
+	}
+	return path
+}
@@ -0,0 +1,224 @@
+package worktree
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/paolorechia/issue-flow/internal/config"
+	"github.com/paolorechia/issue-flow/internal/project"
+	"github.com/paolorechia/issue-flow/internal/storage"
+)
+
+// Move changes a worktree's directory, branch, or both. Worktree holds the
+// current values.
+type Move struct {
+	Worktree  storage.Worktree
+	NewPath   string
+	NewBranch string
+}
+
+func (mv Move) MovesPath() bool {
+	return !SamePath(mv.Worktree.Path, mv.NewPath)
+}
+
+func (mv Move) RenamesBranch() bool {
+	return mv.NewBranch != mv.Worktree.Branch
+}
+
+type RehomeOptions struct {
+	// WorktreeBase is where worktrees go when the project sets no
+	// WorktreeDir, as in StartOptions.
+	WorktreeBase string
+	// KeepBranches leaves branch names alone.
+	KeepBranches bool
+}
+
+// PlanRehome returns the moves that bring worktrees to the directory and
+// branch Start would give them today, e.g. after the project's WorktreeDir
+// or branch pattern changed. Branches are only renamed for issues whose
+// title is cached, and never when they track a remote branch, since the
+// remote branch (and any pull request) would keep the old name. Skipped
+// worktrees are reported as warnings.
+func (m *Manager) PlanRehome(ctx context.Context, p *project.Project, worktrees []storage.Worktree, opts RehomeOptions) ([]Move, []string, error) {
+	var moves []Move
+	var warnings []string
+	for _, w := range worktrees {
+		if w.Status == storage.WorktreeStatusStale {
+			warnings = append(warnings, fmt.Sprintf("%s is stale; see 'issue-flow worktree reconcile'", w.ID))
+			continue
+		}
+		if w.Status == storage.WorktreeStatusArchived || w.PRNumber > 0 {
+			continue
+		}
+		if _, err := os.Stat(w.Path); err != nil {
+			warnings = append(warnings, fmt.Sprintf("%s is missing at %s; see 'issue-flow worktree reconcile'", w.ID, w.Path))
+			continue
+		}
+
+		path, err := Path(p, w.IssueNumber, opts.WorktreeBase)
+		if err != nil {
+			return nil, nil, err
+		}
+		mv := Move{Worktree: w, NewPath: path + attemptSuffix(w.Attempt), NewBranch: w.Branch}
+
+		if !opts.KeepBranches {
+			branch, warning, err := m.rehomeBranch(ctx, p, &w)
+			if err != nil {
+				return nil, nil, err
+			}
+			if warning != "" {
+				warnings = append(warnings, warning)
+			}
+			if branch != "" {
+				mv.NewBranch = branch
+			}
+		}
+
+		if mv.MovesPath() || mv.RenamesBranch() {
+			moves = append(moves, mv)
+		}
+	}
+	return moves, warnings, nil
+}
+
+// rehomeBranch returns the branch name the pattern gives w now, or "" to
+// keep the current one.
+func (m *Manager) rehomeBranch(ctx context.Context, p *project.Project, w *storage.Worktree) (string, string, error) {
+	var issue *storage.IssueCache
+	err := m.withDB(func(db *storage.Database) error {
+		var err error
+		issue, err = db.GetIssueCache(p.ID, w.IssueNumber)
+		return err
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", "", nil
+	}
+	if err != nil {
+		return "", "", fmt.Errorf("failed to look up issue: %w", err)
+	}
+
+	branch := BranchName(p.Config.BranchConfig, BranchPrefix(p, issue.Type), w.IssueNumber, issue.Title) + attemptSuffix(w.Attempt)
+	if branch == w.Branch {
+		return "", "", nil
+	}
+	status, err := m.git.Status(ctx, w.Path)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to get status of %s: %w", w.ID, err)
+	}
+	if status.Upstream != "" {
+		return "", fmt.Sprintf("not renaming %s to %s: it tracks %s", w.Branch, branch, status.Upstream), nil
+	}
+	return branch, "", nil
+}
+
+// ApplyMoves renames branches with 'git branch -m', moves directories with
+// 'git worktree move' and then updates the worktree rows (and, with
+// saveProject, the project row) in one transaction. It is all or nothing:
+// when any step fails, the git changes already made are undone.
+func (m *Manager) ApplyMoves(ctx context.Context, p *project.Project, moves []Move, saveProject bool) error {
+	repo := config.ExpandPath(p.LocalPath)
+	unlock := m.lockRepo(repo)
+	defer unlock()
+
+	if err := m.checkMoves(ctx, repo, moves); err != nil {
+		return err
+	}
+
+	var undo []func() error
+	rollback := func(err error) error {
+		for i := len(undo) - 1; i >= 0; i-- {
+			if undoErr := undo[i](); undoErr != nil {
+				err = fmt.Errorf("%w (rollback failed: %v)", err, undoErr)
+			}
+		}
+		return err
+	}
+
+	for _, mv := range moves {
+		w := mv.Worktree
+		if mv.RenamesBranch() {
+			if err := m.git.RenameBranch(ctx, repo, w.Branch, mv.NewBranch); err != nil {
+				return rollback(fmt.Errorf("failed to rename branch %s: %w", w.Branch, err))
+			}
+			undo = append(undo, func() error { return m.git.RenameBranch(ctx, repo, mv.NewBranch, w.Branch) })
+		}
+		if mv.MovesPath() {
+			if err := os.MkdirAll(filepath.Dir(mv.NewPath), 0755); err != nil {
+				return rollback(fmt.Errorf("failed to create worktree directory: %w", err))
+			}
+			if err := m.git.WorktreeMove(ctx, repo, w.Path, mv.NewPath); err != nil {
+				return rollback(fmt.Errorf("failed to move %s: %w", w.ID, err))
+			}
+			undo = append(undo, func() error { return m.git.WorktreeMove(ctx, repo, mv.NewPath, w.Path) })
+		}
+	}
+
+	err := m.withDB(func(db *storage.Database) error {
+		return db.Transaction(func(tx *storage.Database) error {
+			for _, mv := range moves {
+				w := mv.Worktree
+				w.Path, w.Branch = mv.NewPath, mv.NewBranch
+				if err := tx.UpdateWorktree(&w); err != nil {
+					return fmt.Errorf("failed to update worktree %s: %w", w.ID, err)
+				}
+			}
+			if saveProject {
+				if err := project.NewManager(tx).Update(p); err != nil {
+					return fmt.Errorf("failed to update project: %w", err)
+				}
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		return rollback(err)
+	}
+	return nil
+}
+
+// checkMoves fails before anything is changed when a move cannot work.
+func (m *Manager) checkMoves(ctx context.Context, repo string, moves []Move) error {
+	paths := make(map[string]bool)
+	branches := make(map[string]bool)
+	for _, mv := range moves {
+		w := mv.Worktree
+		if w.Status == storage.WorktreeStatusStale {
+			return fmt.Errorf("%s is stale; see 'issue-flow worktree reconcile'", w.ID)
+		}
+		if w.Status == storage.WorktreeStatusArchived {
+			return fmt.Errorf("%s is archived; unarchive it first", w.ID)
+		}
+		if _, err := os.Stat(w.Path); err != nil {
+			return fmt.Errorf("worktree %s is missing at %s", w.ID, w.Path)
+		}
+
+		if mv.MovesPath() {
+			if !filepath.IsAbs(mv.NewPath) {
+				return fmt.Errorf("new path %s for %s is not absolute", mv.NewPath, w.ID)
+			}
+			if _, err := os.Lstat(mv.NewPath); err == nil {
+				return fmt.Errorf("cannot move %s: %s already exists", w.ID, mv.NewPath)
+			}
+			if paths[canonical(mv.NewPath)] {
+				return fmt.Errorf("cannot move %s: another worktree is moving to %s", w.ID, mv.NewPath)
+			}
+			paths[canonical(mv.NewPath)] = true
+		}
+
+		if mv.RenamesBranch() {
+			exists, err := m.git.BranchExists(ctx, repo, mv.NewBranch)
+			if err != nil {
+				return fmt.Errorf("failed to check branch %s: %w", mv.NewBranch, err)
+			}
+			if exists || branches[mv.NewBranch] {
+				return fmt.Errorf("cannot rename %s: branch %s already exists", w.Branch, mv.NewBranch)
+			}
+			branches[mv.NewBranch] = true
+		}
+	}
+	return nil
+}
@@ -0,0 +1,130 @@
+package worktree
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/paolorechia/issue-flow/internal/project"
+	"github.com/paolorechia/issue-flow/internal/storage"
+	"github.com/paolorechia/issue-flow/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestManager_ApplyMovesMovesDirectory(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	sp := testutil.CreateGitProject(t, db, "app")
+	p, manager, w := startTestWorktree(t, db, 1)
+	newPath := filepath.Join(t.TempDir(), "nested", "issue-1")
+
+	err := manager.ApplyMoves(context.Background(), p, []Move{{Worktree: *w, NewPath: newPath, NewBranch: w.Branch}}, false)
+	require.NoError(t, err)
+
+	assert.NoDirExists(t, w.Path)
+	assert.FileExists(t, filepath.Join(newPath, "README.md"))
+	assert.Contains(t, testutil.RunGit(t, sp.LocalPath, "worktree", "list"), newPath)
+	row := testutil.AssertWorktreeExists(t, db, w.ID)
+	assert.Equal(t, newPath, row.Path)
+	assert.Equal(t, w.Branch, row.Branch)
+}
+
+func TestManager_RelocateProject(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	sp := testutil.CreateGitProject(t, db, "app")
+	p, manager, first := startTestWorktree(t, db, 1)
+	ctx := context.Background()
+
+	second, err := manager.Start(ctx, p, StartOptions{IssueNumber: 2, Title: "Pushed work"})
+	require.NoError(t, err)
+	testutil.RunGit(t, second.Worktree.Path, "branch", "--set-upstream-to=main")
+	attempt, err := manager.Start(ctx, p, StartOptions{IssueNumber: 1, Attempt: "a1"})
+	require.NoError(t, err)
+
+	dir := filepath.Join(t.TempDir(), "relocated")
+	p.WorktreeDir = dir
+	p.Config.BranchConfig.Pattern = "{issue-number}/{slug}"
+
+	worktrees, err := db.ListWorktreesByProject(p.ID)
+	require.NoError(t, err)
+	moves, warnings, err := manager.PlanRehome(ctx, p, worktrees, RehomeOptions{})
+	require.NoError(t, err)
+	require.Len(t, moves, 3)
+	require.Len(t, warnings, 1)
+	assert.Contains(t, warnings[0], "tracks main")
+
+	require.NoError(t, manager.ApplyMoves(ctx, p, moves, true))
+
+	row := testutil.AssertWorktreeExists(t, db, first.ID)
+	assert.Equal(t, filepath.Join(dir, "issue-1"), row.Path)
+	assert.Equal(t, "1/test-issue", row.Branch)
+	assert.Equal(t, "1/test-issue", testutil.RunGit(t, row.Path, "branch", "--show-current"))
+	assert.NoDirExists(t, first.Path)
+
+	row = testutil.AssertWorktreeExists(t, db, second.Worktree.ID)
+	assert.Equal(t, filepath.Join(dir, "issue-2"), row.Path)
+	assert.Equal(t, second.Worktree.Branch, row.Branch, "branches with an upstream keep their name")
+
+	row = testutil.AssertWorktreeExists(t, db, attempt.Worktree.ID)
+	assert.Equal(t, filepath.Join(dir, "issue-1-a1"), row.Path)
+	assert.Equal(t, "1/test-issue-a1", row.Branch)
+
+	stored, err := project.NewManager(db).Get(p.ID)
+	require.NoError(t, err)
+	assert.Equal(t, dir, stored.WorktreeDir)
+	assert.Equal(t, "{issue-number}/{slug}", stored.Config.BranchConfig.Pattern)
+	assert.Empty(t, testutil.RunGit(t, sp.LocalPath, "branch", "--list", first.Branch))
+
+	worktrees, err = db.ListWorktreesByProject(p.ID)
+	require.NoError(t, err)
+	moves, _, err = manager.PlanRehome(ctx, p, worktrees, RehomeOptions{})
+	require.NoError(t, err)
+	assert.Empty(t, moves, "re-homing again is a no-op")
+}
+
+func TestManager_ApplyMovesRollsBack(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	sp := testutil.CreateGitProject(t, db, "app")
+	p, manager, first := startTestWorktree(t, db, 1)
+	ctx := context.Background()
+	second, err := manager.Start(ctx, p, StartOptions{IssueNumber: 2})
+	require.NoError(t, err)
+
+	blocker := filepath.Join(t.TempDir(), "file")
+	require.NoError(t, os.WriteFile(blocker, []byte("x"), 0644))
+	moves := []Move{
+		{Worktree: *first, NewPath: filepath.Join(t.TempDir(), "issue-1"), NewBranch: "renamed/1"},
+		{Worktree: *second.Worktree, NewPath: filepath.Join(blocker, "issue-2"), NewBranch: second.Worktree.Branch},
+	}
+
+	err = manager.ApplyMoves(ctx, p, moves, false)
+	require.Error(t, err)
+	assert.NotContains(t, err.Error(), "rollback failed")
+
+	assert.DirExists(t, first.Path)
+	assert.Equal(t, first.Branch, testutil.RunGit(t, first.Path, "branch", "--show-current"))
+	assert.Empty(t, testutil.RunGit(t, sp.LocalPath, "branch", "--list", "renamed/1"))
+	row := testutil.AssertWorktreeExists(t, db, first.ID)
+	assert.Equal(t, first.Path, row.Path)
+	assert.Equal(t, first.Branch, row.Branch)
+}
+
+func TestManager_ApplyMovesChecksFirst(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	testutil.CreateGitProject(t, db, "app")
+	p, manager, w := startTestWorktree(t, db, 1)
+	ctx := context.Background()
+
+	err := manager.ApplyMoves(ctx, p, []Move{{Worktree: *w, NewPath: t.TempDir(), NewBranch: w.Branch}}, false)
+	assert.ErrorContains(t, err, "already exists")
+
+	err = manager.ApplyMoves(ctx, p, []Move{{Worktree: *w, NewPath: w.Path, NewBranch: "main"}}, false)
+	assert.ErrorContains(t, err, "branch main already exists")
+
+	stale := *w
+	stale.Status = storage.WorktreeStatusStale
+	err = manager.ApplyMoves(ctx, p, []Move{{Worktree: stale, NewPath: filepath.Join(t.TempDir(), "x"), NewBranch: w.Branch}}, false)
+	assert.ErrorContains(t, err, "stale")
+	assert.DirExists(t, w.Path)
+}
@@ -0,0 +1,226 @@
+package worktree
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/paolorechia/issue-flow/internal/config"
+	"github.com/paolorechia/issue-flow/internal/git"
+	"github.com/paolorechia/issue-flow/internal/project"
+	"github.com/paolorechia/issue-flow/internal/storage"
+)
+
+// WIPRefPrefix is the namespace of parked snapshots. Refs under it are shared
+// by all worktrees of a repository and are not fetched or pushed by default.
+const WIPRefPrefix = "refs/issue-flow/wip/"
+
+// ErrParked is returned by Start for a worktree that must be resumed first.
+var ErrParked = errors.New("worktree is parked")
+
+// WIPRef returns the ref holding a worktree's parked snapshot.
+func WIPRef(w *storage.Worktree) string {
+	return WIPRefPrefix + w.ID
+}
+
+type ParkOptions struct {
+	// Push also pushes the snapshot ref to Remote so it survives the loss of
+	// the local repository.
+	Push bool
+	// Remote defaults to the project's push remote.
+	Remote string
+}
+
+type ParkResult struct {
+	// Snapshot is the stash commit saved under WIPRef, or empty when the
+	// worktree had no changes.
+	Snapshot string
+	Pushed   bool
+	Warnings []string
+}
+
+type ResumeResult struct {
+	Snapshot string
+	// Recreated is true when the worktree directory had to be checked out
+	// again before restoring the snapshot.
+	Recreated bool
+	Warnings  []string
+}
+
+// Park saves every staged, unstaged and untracked change in the worktree as
+// a stash commit under WIPRef, leaves the worktree clean, and marks the row
+// parked. Ignored files are left in place.
+func (m *Manager) Park(ctx context.Context, p *project.Project, w *storage.Worktree, opts ParkOptions) (*ParkResult, error) {
+	if w.Status == storage.WorktreeStatusParked {
+		return nil, fmt.Errorf("worktree %s is already parked", w.ID)
+	}
+	if w.PRNumber > 0 {
+		return nil, fmt.Errorf("worktree %s reviews a pull request and cannot be parked", w.ID)
+	}
+	if _, err := os.Stat(w.Path); err != nil {
+		return nil, fmt.Errorf("worktree %s is missing at %s", w.ID, w.Path)
+	}
+	remote := opts.Remote
+	if remote == "" {
+		remote = p.PushRemote()
+	}
+
+	repo := config.ExpandPath(p.LocalPath)
+	unlock := m.lockRepo(repo)
+	defer unlock()
+
+	status, err := m.git.Status(ctx, w.Path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get status: %w", err)
+	}
+	if len(status.Conflicted) > 0 {
+		return nil, fmt.Errorf("worktree %s has unresolved conflicts", w.ID)
+	}
+
+	result := &ParkResult{}
+	ref := WIPRef(w)
+	if !status.Clean() {
+		snapshot, err := m.snapshot(ctx, repo, w)
+		if err != nil {
+			return nil, err
+		}
+		result.Snapshot = snapshot
+
+		if opts.Push {
+			if err := m.git.Push(ctx, repo, remote, "+"+ref+":"+ref); err != nil {
+				result.Warnings = append(result.Warnings, fmt.Sprintf("failed to push snapshot to %s: %v", remote, err))
+			} else {
+				result.Pushed = true
+			}
+		}
+	}
+
+	w.Status = storage.WorktreeStatusParked
+	w.ParkedSnapshot = result.Snapshot
+	w.ParkedRemote = ""
+	if result.Pushed {
+		w.ParkedRemote = remote
+	}
+	if err := m.withDB(func(db *storage.Database) error { return db.UpdateWorktree(w) }); err != nil {
+		return result, fmt.Errorf("failed to update worktree: %w", err)
+	}
+	return result, nil
+}
+
+// snapshot stashes the worktree's changes, moves the stash commit to WIPRef
+// and drops it from the shared stash list.
+func (m *Manager) snapshot(ctx context.Context, repo string, w *storage.Worktree) (string, error) {
+	if err := m.git.StashPush(ctx, w.Path, "issue-flow park "+w.ID, true); err != nil {
+		return "", fmt.Errorf("failed to stash changes: %w", err)
+	}
+	snapshot, err := m.git.RevParse(ctx, w.Path, "refs/stash")
+	if err != nil {
+		return "", fmt.Errorf("failed to read stash: %w", err)
+	}
+	if err := m.git.UpdateRef(ctx, repo, WIPRef(w), snapshot); err != nil {
+		return "", fmt.Errorf("failed to save snapshot (it is still in 'git stash list'): %w", err)
+	}
+	if err := m.git.StashDrop(ctx, w.Path, "stash@{0}"); err != nil {
+		return "", fmt.Errorf("failed to drop stash after saving snapshot: %w", err)
+	}
+	return snapshot, nil
+}
+
+// Resume restores a parked worktree's snapshot, including what was staged,
+// and marks the row active again. A snapshot that only exists on the remote
+// it was pushed to is fetched, and a worktree directory that was lost is
+// checked out again first. When the snapshot recorded by Park cannot be
+// found, Resume fails and leaves the worktree parked.
+func (m *Manager) Resume(ctx context.Context, p *project.Project, w *storage.Worktree) (*ResumeResult, error) {
+	if w.Status != storage.WorktreeStatusParked {
+		return nil, fmt.Errorf("worktree %s is not parked", w.ID)
+	}
+
+	repo := config.ExpandPath(p.LocalPath)
+	unlock := m.lockRepo(repo)
+	defer unlock()
+
+	result := &ResumeResult{}
+	ref := WIPRef(w)
+
+	snapshot, err := m.git.RevParse(ctx, repo, ref)
+	if err != nil && w.ParkedRemote != "" {
+		if fetchErr := m.git.Fetch(ctx, repo, w.ParkedRemote, "+"+ref+":"+ref); fetchErr != nil {
+			return nil, fmt.Errorf("failed to fetch snapshot from %s: %w", w.ParkedRemote, fetchErr)
+		}
+		snapshot, err = m.git.RevParse(ctx, repo, ref)
+	}
+	if err != nil {
+		if w.ParkedSnapshot != "" {
+			return nil, fmt.Errorf("snapshot %s of worktree %s is missing from %s: %w", w.ParkedSnapshot, w.ID, ref, err)
+		}
+		snapshot = ""
+	}
+	result.Snapshot = snapshot
+
+	registered, err := m.isRegistered(ctx, repo, w.Path)
+	if err != nil {
+		return nil, err
+	}
+	if _, statErr := os.Stat(w.Path); statErr != nil || !registered {
+		if err := m.recreate(ctx, repo, w, snapshot); err != nil {
+			return nil, err
+		}
+		result.Recreated = true
+	}
+
+	if snapshot != "" {
+		status, err := m.git.Status(ctx, w.Path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get status: %w", err)
+		}
+		if !status.Clean() {
+			return nil, fmt.Errorf("worktree %s has changes made while parked; commit or stash them first", w.ID)
+		}
+		if err := m.git.StashApply(ctx, w.Path, snapshot, true); err != nil {
+			return nil, fmt.Errorf("failed to restore snapshot %s: %w", snapshot, err)
+		}
+		if err := m.git.DeleteRef(ctx, repo, ref); err != nil {
+			result.Warnings = append(result.Warnings, fmt.Sprintf("failed to delete %s: %v", ref, err))
+		}
+		if w.ParkedRemote != "" {
+			if err := m.git.Push(ctx, repo, w.ParkedRemote, ":"+ref); err != nil {
+				result.Warnings = append(result.Warnings, fmt.Sprintf("failed to delete %s on %s: %v", ref, w.ParkedRemote, err))
+			}
+		}
+	}
+
+	w.Status = storage.WorktreeStatusActive
+	w.ParkedRemote, w.ParkedSnapshot = "", ""
+	if err := m.withDB(func(db *storage.Database) error { return db.UpdateWorktree(w) }); err != nil {
+		return result, fmt.Errorf("failed to update worktree: %w", err)
+	}
+	return result, nil
+}
+
+// recreate checks a lost worktree out again, restoring its branch from the
+// snapshot's base commit when the branch is gone too.
+func (m *Manager) recreate(ctx context.Context, repo string, w *storage.Worktree, snapshot string) error {
+	if err := m.git.WorktreePrune(ctx, repo); err != nil {
+		return fmt.Errorf("failed to prune worktrees: %w", err)
+	}
+
+	exists, err := m.git.BranchExists(ctx, repo, w.Branch)
+	if err != nil {
+		return fmt.Errorf("failed to check branch %s: %w", w.Branch, err)
+	}
+	opts := git.WorktreeAddOptions{Path: w.Path, Branch: w.Branch, NoCheckout: len(w.SparsePatterns) > 0}
+	if !exists {
+		if snapshot == "" {
+			return fmt.Errorf("branch %s and the worktree are gone and there is no snapshot to restore them from", w.Branch)
+		}
+		opts.NewBranch = true
+		opts.StartPoint = snapshot + "^1"
+		opts.NoTrack = true
+	}
+	if err := m.git.WorktreeAdd(ctx, repo, opts); err != nil {
+		return fmt.Errorf("failed to recreate worktree: %w", err)
+	}
+	return m.populateSparse(ctx, w.Path, w.SparsePatterns)
+}
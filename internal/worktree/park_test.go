@@ -0,0 +1,164 @@
+package worktree
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/paolorechia/issue-flow/internal/storage"
+	"github.com/paolorechia/issue-flow/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestManager_ParkAndResume(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	sp := testutil.CreateGitProject(t, db, "app")
+	p, manager, w := startTestWorktree(t, db, 1)
+	ctx := context.Background()
+
+	testutil.GitCommitFile(t, w.Path, "tracked.txt", "v1\n", "Add tracked")
+	require.NoError(t, os.WriteFile(filepath.Join(w.Path, "tracked.txt"), []byte("staged\n"), 0644))
+	testutil.RunGit(t, w.Path, "add", "tracked.txt")
+	require.NoError(t, os.WriteFile(filepath.Join(w.Path, "tracked.txt"), []byte("unstaged\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(w.Path, "new.txt"), []byte("untracked\n"), 0644))
+	before := testutil.RunGit(t, w.Path, "status", "--porcelain")
+
+	parked, err := manager.Park(ctx, p, w, ParkOptions{})
+	require.NoError(t, err)
+	assert.NotEmpty(t, parked.Snapshot)
+	assert.False(t, parked.Pushed)
+	assert.Empty(t, testutil.RunGit(t, w.Path, "status", "--porcelain"))
+	assert.Empty(t, testutil.RunGit(t, sp.LocalPath, "stash", "list"))
+	assert.Equal(t, parked.Snapshot, testutil.RunGit(t, sp.LocalPath, "rev-parse", WIPRef(w)))
+	assert.Equal(t, storage.WorktreeStatusParked, testutil.AssertWorktreeExists(t, db, w.ID).Status)
+
+	_, err = manager.Start(ctx, p, StartOptions{IssueNumber: 1})
+	assert.ErrorIs(t, err, ErrParked)
+	_, err = manager.Park(ctx, p, w, ParkOptions{})
+	assert.Error(t, err, "parking twice")
+
+	resumed, err := manager.Resume(ctx, p, w)
+	require.NoError(t, err)
+	assert.Empty(t, resumed.Warnings)
+	assert.False(t, resumed.Recreated)
+	assert.Equal(t, before, testutil.RunGit(t, w.Path, "status", "--porcelain"))
+	assert.Equal(t, "staged", testutil.RunGit(t, w.Path, "show", ":tracked.txt"))
+	content, err := os.ReadFile(filepath.Join(w.Path, "tracked.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, "unstaged\n", string(content))
+
+	row := testutil.AssertWorktreeExists(t, db, w.ID)
+	assert.Equal(t, storage.WorktreeStatusActive, row.Status)
+	assert.Empty(t, testutil.RunGit(t, sp.LocalPath, "for-each-ref", WIPRefPrefix))
+}
+
+func TestManager_ParkCleanWorktree(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	testutil.CreateGitProject(t, db, "app")
+	p, manager, w := startTestWorktree(t, db, 1)
+	ctx := context.Background()
+
+	parked, err := manager.Park(ctx, p, w, ParkOptions{Push: true})
+	require.NoError(t, err)
+	assert.Empty(t, parked.Snapshot)
+	assert.False(t, parked.Pushed, "nothing to push")
+
+	resumed, err := manager.Resume(ctx, p, w)
+	require.NoError(t, err)
+	assert.Empty(t, resumed.Snapshot)
+	assert.Equal(t, storage.WorktreeStatusActive, testutil.AssertWorktreeExists(t, db, w.ID).Status)
+}
+
+func TestManager_ResumeAfterDiskLoss(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	sp := testutil.CreateGitProject(t, db, "app")
+	remote := t.TempDir()
+	testutil.RunGit(t, remote, "init", "--quiet", "--bare")
+	testutil.RunGit(t, sp.LocalPath, "remote", "add", "origin", remote)
+
+	p, manager, w := startTestWorktree(t, db, 1)
+	ctx := context.Background()
+
+	testutil.GitCommitFile(t, w.Path, "work.txt", "committed\n", "Unpushed work")
+	require.NoError(t, os.WriteFile(filepath.Join(w.Path, "wip.txt"), []byte("wip\n"), 0644))
+
+	parked, err := manager.Park(ctx, p, w, ParkOptions{Push: true})
+	require.NoError(t, err)
+	require.True(t, parked.Pushed)
+	assert.Equal(t, parked.Snapshot, testutil.RunGit(t, remote, "rev-parse", WIPRef(w)))
+	assert.Equal(t, "origin", testutil.AssertWorktreeExists(t, db, w.ID).ParkedRemote)
+
+	require.NoError(t, os.RemoveAll(w.Path))
+	testutil.RunGit(t, sp.LocalPath, "worktree", "prune")
+	testutil.RunGit(t, sp.LocalPath, "branch", "-D", w.Branch)
+	testutil.RunGit(t, sp.LocalPath, "update-ref", "-d", WIPRef(w))
+
+	w = testutil.AssertWorktreeExists(t, db, w.ID)
+	resumed, err := manager.Resume(ctx, p, w)
+	require.NoError(t, err)
+	assert.Empty(t, resumed.Warnings)
+	assert.True(t, resumed.Recreated)
+	assert.Equal(t, parked.Snapshot, resumed.Snapshot)
+
+	assert.FileExists(t, filepath.Join(w.Path, "work.txt"))
+	assert.FileExists(t, filepath.Join(w.Path, "wip.txt"))
+	assert.Equal(t, "Unpushed work", testutil.RunGit(t, w.Path, "log", "-1", "--format=%s"))
+	assert.Empty(t, testutil.RunGit(t, remote, "for-each-ref", WIPRefPrefix), "remote snapshot is deleted after resume")
+}
+
+func TestManager_RemoveRefusesParkedWorktree(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	sp := testutil.CreateGitProject(t, db, "app")
+	remote := t.TempDir()
+	testutil.RunGit(t, remote, "init", "--quiet", "--bare")
+	testutil.RunGit(t, sp.LocalPath, "remote", "add", "origin", remote)
+
+	p, manager, w := startTestWorktree(t, db, 1)
+	ctx := context.Background()
+
+	require.NoError(t, os.WriteFile(filepath.Join(w.Path, "wip.txt"), []byte("wip\n"), 0644))
+	parked, err := manager.Park(ctx, p, w, ParkOptions{Push: true})
+	require.NoError(t, err)
+	require.True(t, parked.Pushed)
+
+	result, err := manager.Remove(ctx, p, w, RemoveOptions{})
+	require.ErrorIs(t, err, ErrWouldLoseWork)
+	assert.Equal(t, WIPRef(w), result.Report.Snapshot)
+	testutil.AssertWorktreeExists(t, db, w.ID)
+	assert.Equal(t, parked.Snapshot, testutil.RunGit(t, sp.LocalPath, "rev-parse", WIPRef(w)))
+
+	result, err = manager.Remove(ctx, p, w, RemoveOptions{Force: true})
+	require.NoError(t, err)
+	assert.Empty(t, result.Warnings)
+	testutil.AssertWorktreeCount(t, db, 0)
+	assert.Empty(t, testutil.RunGit(t, sp.LocalPath, "for-each-ref", WIPRefPrefix))
+	assert.Empty(t, testutil.RunGit(t, remote, "for-each-ref", WIPRefPrefix))
+}
+
+func TestManager_ResumeRefusesWhenSnapshotIsMissing(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	sp := testutil.CreateGitProject(t, db, "app")
+	p, manager, w := startTestWorktree(t, db, 1)
+	ctx := context.Background()
+
+	require.NoError(t, os.WriteFile(filepath.Join(w.Path, "wip.txt"), []byte("wip\n"), 0644))
+	parked, err := manager.Park(ctx, p, w, ParkOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, parked.Snapshot, testutil.AssertWorktreeExists(t, db, w.ID).ParkedSnapshot)
+
+	testutil.RunGit(t, sp.LocalPath, "update-ref", "-d", WIPRef(w))
+	_, err = manager.Resume(ctx, p, w)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "is missing")
+	row := testutil.AssertWorktreeExists(t, db, w.ID)
+	assert.Equal(t, storage.WorktreeStatusParked, row.Status)
+	assert.Equal(t, parked.Snapshot, row.ParkedSnapshot)
+
+	testutil.RunGit(t, sp.LocalPath, "update-ref", WIPRef(w), parked.Snapshot)
+	_, err = manager.Resume(ctx, p, row)
+	require.NoError(t, err)
+	assert.FileExists(t, filepath.Join(w.Path, "wip.txt"))
+	assert.Empty(t, testutil.AssertWorktreeExists(t, db, w.ID).ParkedSnapshot)
+}
@@ -0,0 +1,182 @@
+package worktree
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/paolorechia/issue-flow/internal/project"
+	"github.com/paolorechia/issue-flow/internal/storage"
+)
+
+// ErrNoFreePort is returned by AllocatePorts when every port in a configured
+// range is allocated or in use.
+var ErrNoFreePort = errors.New("no free port")
+
+// Port is a port allocated to a worktree.
+type Port struct {
+	Name string
+	// Env is the environment variable the port is exported as.
+	Env  string
+	Port int
+}
+
+// portAvailable reports whether the port can be listened on. It is a
+// variable so tests can simulate ports taken by other processes.
+var portAvailable = func(port int) bool {
+	l, err := net.Listen("tcp", ":"+strconv.Itoa(port))
+	if err != nil {
+		return false
+	}
+	l.Close()
+	return true
+}
+
+// ParsePortRange parses an inclusive "first-last" range. A single port is
+// a range of one.
+func ParsePortRange(s string) (first, last int, err error) {
+	lo, hi, found := strings.Cut(strings.TrimSpace(s), "-")
+	if !found {
+		hi = lo
+	}
+	first, err1 := strconv.Atoi(strings.TrimSpace(lo))
+	last, err2 := strconv.Atoi(strings.TrimSpace(hi))
+	if err1 != nil || err2 != nil || first < 1 || last > 65535 || first > last {
+		return 0, 0, fmt.Errorf("invalid port range %q", s)
+	}
+	return first, last, nil
+}
+
+// PortEnv returns the environment variable a configured port is exported
+// as: its Env, or the name upper-cased with _PORT appended ("web" becomes
+// WEB_PORT).
+func PortEnv(c project.PortConfig) string {
+	if c.Env != "" {
+		return c.Env
+	}
+	name := strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return unicode.ToUpper(r)
+		}
+		return '_'
+	}, c.Name)
+	return name + "_PORT"
+}
+
+// AllocatePorts makes sure w has a port for every port configured for its
+// project. Allocations that are still inside their configured range are
+// kept and allocations for names no longer configured are released. New
+// ports are the lowest in range that no other worktree holds and nothing on
+// this machine is listening on.
+func (m *Manager) AllocatePorts(p *project.Project, w *storage.Worktree) ([]Port, error) {
+	ranges := make(map[string]portRange, len(p.Config.Ports))
+	for _, c := range p.Config.Ports {
+		if c.Name == "" {
+			return nil, fmt.Errorf("port config has no name")
+		}
+		if _, dup := ranges[c.Name]; dup {
+			return nil, fmt.Errorf("port %s is configured twice", c.Name)
+		}
+		first, last, err := ParsePortRange(c.Range)
+		if err != nil {
+			return nil, fmt.Errorf("port %s: %w", c.Name, err)
+		}
+		ranges[c.Name] = portRange{first, last}
+	}
+
+	err := m.withDB(func(db *storage.Database) error {
+		return db.Transaction(func(tx *storage.Database) error {
+			return allocatePorts(tx, p, w.ID, ranges)
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return m.Ports(p, w)
+}
+
+type portRange struct{ first, last int }
+
+func allocatePorts(db *storage.Database, p *project.Project, worktreeID string, ranges map[string]portRange) error {
+	existing, err := db.ListWorktreePorts(worktreeID)
+	if err != nil {
+		return fmt.Errorf("failed to list ports: %w", err)
+	}
+	kept := make(map[string]bool)
+	for _, e := range existing {
+		r, ok := ranges[e.Name]
+		if ok && e.Port >= r.first && e.Port <= r.last {
+			kept[e.Name] = true
+			continue
+		}
+		if err := db.DeleteWorktreePort(worktreeID, e.Name); err != nil {
+			return fmt.Errorf("failed to release port %d: %w", e.Port, err)
+		}
+	}
+
+	all, err := db.ListAllWorktreePorts()
+	if err != nil {
+		return fmt.Errorf("failed to list allocated ports: %w", err)
+	}
+	taken := make(map[int]bool, len(all))
+	for _, a := range all {
+		taken[a.Port] = true
+	}
+
+	for _, c := range p.Config.Ports {
+		if kept[c.Name] {
+			continue
+		}
+		if err := allocatePort(db, worktreeID, c, ranges[c.Name].first, ranges[c.Name].last, taken); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func allocatePort(db *storage.Database, worktreeID string, c project.PortConfig, first, last int, taken map[int]bool) error {
+	for port := first; port <= last; port++ {
+		if taken[port] || !portAvailable(port) {
+			continue
+		}
+		err := db.CreateWorktreePort(&storage.WorktreePort{WorktreeID: worktreeID, Name: c.Name, Port: port})
+		if errors.Is(err, storage.ErrPortTaken) {
+			// Allocated by another issue-flow process since we listed.
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("failed to allocate port %s: %w", c.Name, err)
+		}
+		taken[port] = true
+		return nil
+	}
+	return fmt.Errorf("port %s: %w in %s", c.Name, ErrNoFreePort, c.Range)
+}
+
+// Ports returns the ports allocated to w, ordered by name.
+func (m *Manager) Ports(p *project.Project, w *storage.Worktree) ([]Port, error) {
+	var rows []storage.WorktreePort
+	err := m.withDB(func(db *storage.Database) error {
+		var err error
+		rows, err = db.ListWorktreePorts(w.ID)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list ports: %w", err)
+	}
+
+	ports := make([]Port, len(rows))
+	for i, r := range rows {
+		cfg := project.PortConfig{Name: r.Name}
+		for _, c := range p.Config.Ports {
+			if c.Name == r.Name {
+				cfg = c
+			}
+		}
+		ports[i] = Port{Name: r.Name, Env: PortEnv(cfg), Port: r.Port}
+	}
+	return ports, nil
+}
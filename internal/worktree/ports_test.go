@@ -0,0 +1,129 @@
+package worktree
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"testing"
+
+	"github.com/paolorechia/issue-flow/internal/git"
+	"github.com/paolorechia/issue-flow/internal/project"
+	"github.com/paolorechia/issue-flow/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stubPortAvailable makes the given ports look in use for the test.
+func stubPortAvailable(t *testing.T, inUse ...int) {
+	t.Helper()
+	busy := make(map[int]bool)
+	for _, p := range inUse {
+		busy[p] = true
+	}
+	orig := portAvailable
+	portAvailable = func(port int) bool { return !busy[port] }
+	t.Cleanup(func() { portAvailable = orig })
+}
+
+const portsConfig = `{"ports":[{"name":"web","range":"4100-4102"},{"name":"db","range":"4200","env":"PGPORT"}]}`
+
+func TestManager_AllocatePorts(t *testing.T) {
+	stubPortAvailable(t, 4100)
+	db := testutil.NewTestDB(t)
+	testutil.CreateGitProjectWithConfig(t, db, "app", portsConfig)
+	p, err := project.NewManager(db).Get("app")
+	require.NoError(t, err)
+	manager := NewManager(db, git.NewClient(nil))
+	ctx := context.Background()
+
+	first, err := manager.Start(ctx, p, StartOptions{IssueNumber: 1, Title: "One"})
+	require.NoError(t, err)
+	assert.Equal(t, []Port{
+		{Name: "db", Env: "PGPORT", Port: 4200},
+		{Name: "web", Env: "WEB_PORT", Port: 4101},
+	}, first.Ports, "4100 is in use on the machine")
+
+	again, err := manager.Start(ctx, p, StartOptions{IssueNumber: 1})
+	require.NoError(t, err)
+	assert.Equal(t, first.Ports, again.Ports, "reusing a worktree keeps its ports")
+
+	_, err = manager.Start(ctx, p, StartOptions{IssueNumber: 2, Title: "Two"})
+	assert.ErrorIs(t, err, ErrNoFreePort, "the single db port is taken")
+
+	p.Config.Ports = p.Config.Ports[:1]
+	second, err := manager.Start(ctx, p, StartOptions{IssueNumber: 2})
+	require.NoError(t, err)
+	assert.Equal(t, []Port{{Name: "web", Env: "WEB_PORT", Port: 4102}}, second.Ports)
+
+	ports, err := manager.AllocatePorts(p, first.Worktree)
+	require.NoError(t, err)
+	assert.Equal(t, []Port{{Name: "web", Env: "WEB_PORT", Port: 4101}}, ports, "unconfigured ports are released")
+
+	_, err = manager.Remove(ctx, p, first.Worktree, RemoveOptions{})
+	require.NoError(t, err)
+	third, err := manager.Start(ctx, p, StartOptions{IssueNumber: 3, Title: "Three"})
+	require.NoError(t, err)
+	assert.Equal(t, 4101, third.Ports[0].Port, "removal releases ports")
+}
+
+func TestManager_AllocatePortsSkipsListeningPorts(t *testing.T) {
+	l, err := net.Listen("tcp", ":0")
+	require.NoError(t, err)
+	defer l.Close()
+	busy := l.Addr().(*net.TCPAddr).Port
+	assert.False(t, portAvailable(busy))
+
+	db := testutil.NewTestDB(t)
+	testutil.CreateGitProject(t, db, "app")
+	p, manager, w := startTestWorktree(t, db, 1)
+	p.Config.Ports = []project.PortConfig{{Name: "web", Range: fmt.Sprintf("%d-%d", busy, busy+1)}}
+
+	ports, err := manager.AllocatePorts(p, w)
+	if err != nil {
+		// busy+1 may be taken by another process on this machine.
+		assert.ErrorIs(t, err, ErrNoFreePort)
+		return
+	}
+	assert.Equal(t, busy+1, ports[0].Port)
+}
+
+func TestManager_Env(t *testing.T) {
+	stubPortAvailable(t)
+	db := testutil.NewTestDB(t)
+	testutil.CreateGitProjectWithConfig(t, db, "app", portsConfig)
+	p, err := project.NewManager(db).Get("app")
+	require.NoError(t, err)
+	manager := NewManager(db, git.NewClient(nil))
+
+	result, err := manager.Start(context.Background(), p, StartOptions{IssueNumber: 7, Title: "Env"})
+	require.NoError(t, err)
+
+	env, err := manager.Env(p, result.Worktree)
+	require.NoError(t, err)
+	assert.Contains(t, env, "ISSUE_FLOW_PROJECT=app")
+	assert.Contains(t, env, "ISSUE_FLOW_ISSUE=7")
+	assert.Contains(t, env, "ISSUE_FLOW_WORKTREE="+result.Worktree.Path)
+	assert.Contains(t, env, "WEB_PORT=4100")
+	assert.Contains(t, env, "PGPORT=4200")
+}
+
+func TestParsePortRange(t *testing.T) {
+	first, last, err := ParsePortRange("3000-3099")
+	require.NoError(t, err)
+	assert.Equal(t, [2]int{3000, 3099}, [2]int{first, last})
+
+	first, last, err = ParsePortRange(" 8080 ")
+	require.NoError(t, err)
+	assert.Equal(t, [2]int{8080, 8080}, [2]int{first, last})
+
+	for _, bad := range []string{"", "abc", "3100-3000", "0-10", "65000-70000", "1-2-3"} {
+		_, _, err := ParsePortRange(bad)
+		assert.Error(t, err, bad)
+	}
+}
+
+func TestPortEnv(t *testing.T) {
+	assert.Equal(t, "WEB_PORT", PortEnv(project.PortConfig{Name: "web"}))
+	assert.Equal(t, "DEV_SERVER_PORT", PortEnv(project.PortConfig{Name: "dev-server"}))
+	assert.Equal(t, "PGPORT", PortEnv(project.PortConfig{Name: "db", Env: "PGPORT"}))
+}
@@ -0,0 +1,17 @@
+//go:build !windows
+
+package worktree
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// killProcessGroup makes a cancelled command kill its whole process group so
+// that children of the shell, such as `npm ci`, do not outlive a timeout.
+func killProcessGroup(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	cmd.Cancel = func() error {
+		return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+	}
+}
@@ -0,0 +1,9 @@
+//go:build windows
+
+package worktree
+
+import "os/exec"
+
+// killProcessGroup is a no-op on Windows; the command itself is killed on
+// cancellation.
+func killProcessGroup(cmd *exec.Cmd) {}
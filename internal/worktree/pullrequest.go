@@ -0,0 +1,85 @@
+package worktree
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/paolorechia/issue-flow/internal/config"
+	"github.com/paolorechia/issue-flow/internal/github"
+	"github.com/paolorechia/issue-flow/internal/project"
+	"github.com/paolorechia/issue-flow/internal/storage"
+)
+
+type PullRequestOptions struct {
+	// Title defaults to the cached title of the issue.
+	Title string
+	// Body defaults to "Closes #<issue>".
+	Body  string
+	Draft bool
+}
+
+// OpenPullRequest opens a pull request for w's branch against the project's
+// GitHub repository, into the branch w is based on. For a project worked on
+// from a fork the head is "<fork_owner>:<branch>". The branch has to be
+// pushed already.
+func (m *Manager) OpenPullRequest(ctx context.Context, client github.Client, p *project.Project, w *storage.Worktree, opts PullRequestOptions) (*github.PullRequest, error) {
+	if w.Status == storage.WorktreeStatusArchived {
+		return nil, fmt.Errorf("%s: %w", w.ID, ErrArchived)
+	}
+	if w.PRNumber > 0 {
+		return nil, fmt.Errorf("worktree %s reviews pull request #%d", w.ID, w.PRNumber)
+	}
+	if p.GitHubOwner == "" || p.GitHubRepo == "" {
+		return nil, fmt.Errorf("project %s has no GitHub repository", p.ID)
+	}
+
+	base := w.BaseBranch
+	if base == "" {
+		upstream := p.UpstreamRemote()
+		ref, err := m.BaseRef(ctx, config.ExpandPath(p.LocalPath), upstream)
+		if err != nil {
+			return nil, err
+		}
+		base = strings.TrimPrefix(ref, upstream+"/")
+	}
+
+	title := opts.Title
+	if title == "" {
+		err := m.withDB(func(db *storage.Database) error {
+			issue, err := db.GetIssueCache(p.ID, w.IssueNumber)
+			if err == nil {
+				title = issue.Title
+			}
+			if errors.Is(err, sql.ErrNoRows) {
+				return nil
+			}
+			return err
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to look up issue #%d: %w", w.IssueNumber, err)
+		}
+		if title == "" {
+			return nil, fmt.Errorf("issue #%d has no cached title; pass a title", w.IssueNumber)
+		}
+	}
+	body := opts.Body
+	if body == "" {
+		body = fmt.Sprintf("Closes #%d", w.IssueNumber)
+	}
+
+	repo := github.Repo{Owner: p.GitHubOwner, Name: p.GitHubRepo}
+	pr, err := client.CreatePullRequest(ctx, repo, github.PullRequestRequest{
+		Title: title,
+		Body:  body,
+		Head:  p.PullRequestHead(w.Branch),
+		Base:  base,
+		Draft: opts.Draft,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open pull request for %s: %w", w.Branch, err)
+	}
+	return pr, nil
+}
@@ -0,0 +1,62 @@
+package worktree
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/paolorechia/issue-flow/internal/github"
+	"github.com/paolorechia/issue-flow/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestManager_OpenPullRequestFromFork(t *testing.T) {
+	p, manager, _, _ := forkProject(t)
+	ctx := context.Background()
+	started, err := manager.Start(ctx, p, StartOptions{IssueNumber: 3, Title: "From upstream", Fetch: true})
+	require.NoError(t, err)
+	w := started.Worktree
+
+	fake := testutil.NewFakeGitHub(t)
+	fake.On("POST", "/repos/testowner/app/pulls", 201, `{"number": 21, "html_url": "https://github.com/testowner/app/pull/21"}`)
+	client := github.NewRESTClient("test-token")
+	client.BaseURL = fake.URL
+
+	pr, err := manager.OpenPullRequest(ctx, client, p, w, PullRequestOptions{Draft: true})
+	require.NoError(t, err)
+	assert.Equal(t, 21, pr.Number)
+
+	var sent map[string]any
+	require.NoError(t, json.Unmarshal([]byte(fake.LastRequest().Body), &sent))
+	assert.Equal(t, "ada:"+w.Branch, sent["head"])
+	assert.Equal(t, "main", sent["base"])
+	assert.Equal(t, "From upstream", sent["title"])
+	assert.Equal(t, "Closes #3", sent["body"])
+	assert.Equal(t, true, sent["draft"])
+}
+
+func TestManager_OpenPullRequestIntoBaseBranch(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	sp := testutil.CreateGitProject(t, db, "app")
+	testutil.RunGit(t, sp.LocalPath, "branch", "release/1.4")
+	p, manager, _ := startTestWorktree(t, db, 1)
+	ctx := context.Background()
+	started, err := manager.Start(ctx, p, StartOptions{IssueNumber: 2, Title: "Backported", BaseBranch: "release/1.4"})
+	require.NoError(t, err)
+	w := started.Worktree
+
+	fake := testutil.NewFakeGitHub(t)
+	fake.On("POST", "/repos/testowner/app/pulls", 201, `{"number": 22}`)
+	client := github.NewRESTClient("test-token")
+	client.BaseURL = fake.URL
+
+	_, err = manager.OpenPullRequest(ctx, client, p, w, PullRequestOptions{Title: "Fix it", Body: "Refs #2"})
+	require.NoError(t, err)
+	var sent map[string]any
+	require.NoError(t, json.Unmarshal([]byte(fake.LastRequest().Body), &sent))
+	assert.Equal(t, w.Branch, sent["head"], "the head of a branch of the repository itself has no owner")
+	assert.Equal(t, "release/1.4", sent["base"])
+	assert.Equal(t, "Fix it", sent["title"])
+	assert.Equal(t, "Refs #2", sent["body"])
+}
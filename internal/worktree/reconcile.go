@@ -0,0 +1,296 @@
+package worktree
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/paolorechia/issue-flow/internal/config"
+	"github.com/paolorechia/issue-flow/internal/git"
+	"github.com/paolorechia/issue-flow/internal/project"
+	"github.com/paolorechia/issue-flow/internal/storage"
+)
+
+// Drift kinds found by Reconcile.
+const (
+	// DriftMissing is a row whose worktree directory no longer exists.
+	DriftMissing = "missing"
+	// DriftUnregistered is a row whose directory exists but git does not
+	// list it as a worktree, typically after the directory was moved.
+	DriftUnregistered = "unregistered"
+	// DriftUntracked is a git worktree with no row in the database.
+	DriftUntracked = "untracked"
+	// DriftBranchMismatch is a row whose branch differs from the branch
+	// checked out in the worktree.
+	DriftBranchMismatch = "branch-mismatch"
+)
+
+// Reconcile actions.
+const (
+	ActionAdopt     = "adopt"
+	ActionRepair    = "repair"
+	ActionMarkStale = "mark-stale"
+	ActionDrop      = "drop"
+	ActionFixBranch = "fix-branch"
+)
+
+// Drift is one difference between the worktrees table and git.
+type Drift struct {
+	Kind        string `json:"kind"`
+	ProjectID   string `json:"project_id"`
+	WorktreeID  string `json:"worktree_id,omitempty"`
+	IssueNumber int    `json:"issue_number,omitempty"`
+	// Attempt is the attempt an untracked worktree would be adopted as,
+	// inferred from an "issue-<N>-<attempt>" directory name.
+	Attempt   string `json:"attempt,omitempty"`
+	Path      string `json:"path"`
+	DBBranch  string `json:"db_branch,omitempty"`
+	GitBranch string `json:"git_branch,omitempty"`
+	// Action is the action taken, or empty when none applied.
+	Action string `json:"action,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+// ReconcileActions selects which fixes ApplyReconcile performs.
+type ReconcileActions struct {
+	Adopt       bool
+	Repair      bool
+	MarkStale   bool
+	Drop        bool
+	FixBranches bool
+}
+
+// Reconcile compares the worktrees table with `git worktree list` for every
+// project (or only projectID) and returns the differences.
+func (m *Manager) Reconcile(ctx context.Context, projectID string) ([]Drift, error) {
+	var projects []project.Project
+	pm := project.NewManager(m.db)
+	if projectID != "" {
+		p, err := pm.Get(projectID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load project %s: %w", projectID, err)
+		}
+		projects = []project.Project{*p}
+	} else {
+		var err error
+		projects, err = pm.List()
+		if err != nil {
+			return nil, fmt.Errorf("failed to list projects: %w", err)
+		}
+	}
+
+	var drifts []Drift
+	for _, p := range projects {
+		if p.LocalPath == "" {
+			continue
+		}
+		found, err := m.reconcileProject(ctx, &p)
+		if err != nil {
+			return nil, fmt.Errorf("failed to reconcile project %s: %w", p.ID, err)
+		}
+		drifts = append(drifts, found...)
+	}
+	return drifts, nil
+}
+
+func (m *Manager) reconcileProject(ctx context.Context, p *project.Project) ([]Drift, error) {
+	repo := config.ExpandPath(p.LocalPath)
+
+	rows, err := m.db.ListWorktreesByProject(p.ID)
+	if err != nil {
+		return nil, err
+	}
+	listed, err := m.git.WorktreeList(ctx, repo)
+	if err != nil {
+		return nil, err
+	}
+
+	var linked []git.Worktree
+	for _, wt := range listed {
+		if wt.Bare || SamePath(wt.Path, repo) {
+			continue
+		}
+		linked = append(linked, wt)
+	}
+
+	var drifts []Drift
+	matched := make(map[int]bool)
+	for _, row := range rows {
+		drift := Drift{
+			ProjectID:   p.ID,
+			WorktreeID:  row.ID,
+			IssueNumber: row.IssueNumber,
+			Path:        row.Path,
+			DBBranch:    row.Branch,
+		}
+
+		idx := -1
+		for i, wt := range linked {
+			if SamePath(wt.Path, row.Path) {
+				idx = i
+				break
+			}
+		}
+
+		_, statErr := os.Stat(row.Path)
+		switch {
+		case statErr != nil:
+			drift.Kind = DriftMissing
+			if idx >= 0 {
+				matched[idx] = true
+			}
+		case idx < 0 || linked[idx].Prunable:
+			drift.Kind = DriftUnregistered
+			if idx >= 0 {
+				matched[idx] = true
+			}
+		default:
+			matched[idx] = true
+			if linked[idx].Branch == row.Branch {
+				continue
+			}
+			drift.Kind = DriftBranchMismatch
+			drift.GitBranch = linked[idx].Branch
+		}
+		drifts = append(drifts, drift)
+	}
+
+	for i, wt := range linked {
+		if matched[i] || wt.Prunable {
+			continue
+		}
+		drifts = append(drifts, Drift{
+			Kind:        DriftUntracked,
+			ProjectID:   p.ID,
+			IssueNumber: InferIssueNumber(p.Config.BranchConfig, wt.Path, wt.Branch),
+			Attempt:     InferAttempt(wt.Path),
+			Path:        wt.Path,
+			GitBranch:   wt.Branch,
+		})
+	}
+
+	return drifts, nil
+}
+
+// ApplyReconcile performs the selected actions on drifts and returns them
+// with Action or Error filled in.
+func (m *Manager) ApplyReconcile(ctx context.Context, drifts []Drift, actions ReconcileActions) []Drift {
+	pm := project.NewManager(m.db)
+	result := make([]Drift, len(drifts))
+
+	for i, d := range drifts {
+		var action string
+		var err error
+
+		switch {
+		case d.Kind == DriftMissing && actions.Drop:
+			action, err = ActionDrop, m.db.DeleteWorktree(d.WorktreeID)
+		case d.Kind == DriftMissing && actions.MarkStale:
+			action, err = ActionMarkStale, m.setRow(d.WorktreeID, func(w *storage.Worktree) {
+				w.Status = storage.WorktreeStatusStale
+			})
+		case d.Kind == DriftUnregistered && actions.Repair:
+			action = ActionRepair
+			var p *project.Project
+			if p, err = pm.Get(d.ProjectID); err == nil {
+				err = m.git.WorktreeRepair(ctx, config.ExpandPath(p.LocalPath), d.Path)
+			}
+		case d.Kind == DriftBranchMismatch && actions.FixBranches:
+			action, err = ActionFixBranch, m.setRow(d.WorktreeID, func(w *storage.Worktree) {
+				w.Branch = d.GitBranch
+			})
+		case d.Kind == DriftUntracked && actions.Adopt:
+			action, err = ActionAdopt, m.adopt(d)
+		}
+
+		if action != "" {
+			d.Action = action
+			if err != nil {
+				d.Error = err.Error()
+			}
+		}
+		result[i] = d
+	}
+
+	return result
+}
+
+func (m *Manager) setRow(id string, update func(w *storage.Worktree)) error {
+	w, err := m.db.GetWorktree(id)
+	if err != nil {
+		return err
+	}
+	update(w)
+	return m.db.UpdateWorktree(w)
+}
+
+func (m *Manager) adopt(d Drift) error {
+	if d.IssueNumber <= 0 {
+		return fmt.Errorf("cannot infer issue number from %s", d.Path)
+	}
+	if d.GitBranch == "" {
+		return fmt.Errorf("worktree %s has a detached HEAD", d.Path)
+	}
+	id := AttemptID(d.ProjectID, d.IssueNumber, d.Attempt)
+	existing, err := m.db.GetWorktree(id)
+	if err == nil {
+		return fmt.Errorf("%s is already tracked at %s; rename %s to issue-%d-<attempt> to adopt it as an attempt", id, existing.Path, d.Path, d.IssueNumber)
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("failed to look up worktree %s: %w", id, err)
+	}
+	return m.db.CreateWorktree(&storage.Worktree{
+		ID:          id,
+		ProjectID:   d.ProjectID,
+		IssueNumber: d.IssueNumber,
+		Path:        d.Path,
+		Branch:      d.GitBranch,
+		Status:      storage.WorktreeStatusActive,
+		Attempt:     d.Attempt,
+	})
+}
+
+var (
+	issueDirPattern  = regexp.MustCompile(`^issue-(\d+)(?:-([A-Za-z][A-Za-z0-9._-]{0,31}))?$`)
+	reviewDirPattern = regexp.MustCompile(`^review-\d+$`)
+)
+
+// InferIssueNumber guesses the issue number of a worktree from an
+// "issue-<N>" or "issue-<N>-<attempt>" directory name or a branch following
+// the project's branch pattern. Review worktrees, on review/<N> branches in
+// review-<N> directories, belong to pull requests and give 0, as does a
+// worktree matching neither.
+func InferIssueNumber(cfg project.BranchConfig, path, branch string) int {
+	if strings.HasPrefix(branch, "review/") || reviewDirPattern.MatchString(filepath.Base(path)) {
+		return 0
+	}
+	if m := issueDirPattern.FindStringSubmatch(filepath.Base(path)); m != nil {
+		n, _ := strconv.Atoi(m[1])
+		return n
+	}
+	re, err := regexp.Compile(branchPatternRegexp(cfg, `([0-9]+)`))
+	if err != nil {
+		return 0
+	}
+	if m := re.FindStringSubmatch(branch); m != nil && m[1] != "" {
+		n, _ := strconv.Atoi(m[1])
+		return n
+	}
+	return 0
+}
+
+// InferAttempt returns the attempt name of an "issue-<N>-<attempt>"
+// worktree directory, or "" for any other directory. Branch names are not
+// used: an attempt suffix there cannot be told apart from the slug.
+func InferAttempt(path string) string {
+	if m := issueDirPattern.FindStringSubmatch(filepath.Base(path)); m != nil {
+		return m[2]
+	}
+	return ""
+}
@@ -0,0 +1,122 @@
+package worktree
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/paolorechia/issue-flow/internal/project"
+	"github.com/paolorechia/issue-flow/internal/storage"
+	"github.com/paolorechia/issue-flow/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestManager_Reconcile(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	sp := testutil.CreateGitProject(t, db, "app")
+	_, manager, _ := startTestWorktree(t, db, 1)
+	ctx := context.Background()
+
+	_, _, missing := startTestWorktree(t, db, 2)
+	require.NoError(t, os.RemoveAll(missing.Path))
+
+	_, _, mismatch := startTestWorktree(t, db, 3)
+	testutil.RunGit(t, mismatch.Path, "checkout", "--quiet", "-b", "feature/3-renamed")
+
+	untrackedPath := filepath.Join(sp.WorktreeDir, "issue-4")
+	testutil.RunGit(t, sp.LocalPath, "worktree", "add", "--quiet", "-b", "feature/4-manual", untrackedPath)
+
+	_, _, moved := startTestWorktree(t, db, 5)
+	movedPath := filepath.Join(sp.WorktreeDir, "moved-5")
+	require.NoError(t, os.Rename(moved.Path, movedPath))
+	moved.Path = movedPath
+	require.NoError(t, db.UpdateWorktree(moved))
+
+	drifts, err := manager.Reconcile(ctx, "")
+	require.NoError(t, err)
+
+	byKind := make(map[string]Drift)
+	for _, d := range drifts {
+		byKind[d.Kind] = d
+	}
+	require.Len(t, drifts, 4)
+	assert.Equal(t, missing.ID, byKind[DriftMissing].WorktreeID)
+	assert.Equal(t, "feature/3-renamed", byKind[DriftBranchMismatch].GitBranch)
+	assert.Equal(t, 4, byKind[DriftUntracked].IssueNumber)
+	assert.Equal(t, moved.ID, byKind[DriftUnregistered].WorktreeID)
+
+	applied := manager.ApplyReconcile(ctx, drifts, ReconcileActions{
+		Adopt: true, Repair: true, MarkStale: true, FixBranches: true,
+	})
+	for _, d := range applied {
+		assert.NotEmpty(t, d.Action, d.Kind)
+		assert.Empty(t, d.Error, d.Kind)
+	}
+
+	stale := testutil.AssertWorktreeExists(t, db, missing.ID)
+	assert.Equal(t, storage.WorktreeStatusStale, stale.Status)
+	assert.Equal(t, "feature/3-renamed", testutil.AssertWorktreeExists(t, db, mismatch.ID).Branch)
+	adopted := testutil.AssertWorktreeExists(t, db, ID("app", 4))
+	assert.Equal(t, "feature/4-manual", adopted.Branch)
+
+	drifts, err = manager.Reconcile(ctx, "app")
+	require.NoError(t, err)
+	require.Len(t, drifts, 1, "only the stale row should remain")
+	assert.Equal(t, DriftMissing, drifts[0].Kind)
+
+	applied = manager.ApplyReconcile(ctx, drifts, ReconcileActions{Drop: true})
+	assert.Equal(t, ActionDrop, applied[0].Action)
+	testutil.AssertWorktreeCount(t, db, 4)
+}
+
+func TestManager_ReconcileAdoptsBesidePrimaryWorktree(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	sp := testutil.CreateGitProject(t, db, "app")
+	_, manager, primary := startTestWorktree(t, db, 7)
+	ctx := context.Background()
+
+	attemptPath := filepath.Join(sp.WorktreeDir, "issue-7-b2")
+	testutil.RunGit(t, sp.LocalPath, "worktree", "add", "--quiet", "-b", "feature/7-test-issue-b2", attemptPath)
+	otherPath := filepath.Join(sp.WorktreeDir, "scratch")
+	testutil.RunGit(t, sp.LocalPath, "worktree", "add", "--quiet", "-b", "fix/7-other", otherPath)
+
+	drifts, err := manager.Reconcile(ctx, "app")
+	require.NoError(t, err)
+	require.Len(t, drifts, 2)
+
+	applied := manager.ApplyReconcile(ctx, drifts, ReconcileActions{Adopt: true})
+	byPath := make(map[string]Drift)
+	for _, d := range applied {
+		byPath[d.Path] = d
+	}
+
+	assert.Equal(t, "b2", byPath[attemptPath].Attempt)
+	assert.Empty(t, byPath[attemptPath].Error)
+	attempt := testutil.AssertWorktreeExists(t, db, AttemptID("app", 7, "b2"))
+	assert.Equal(t, "b2", attempt.Attempt)
+	assert.Equal(t, attemptPath, attempt.Path)
+
+	assert.Contains(t, byPath[otherPath].Error, "already tracked")
+	kept := testutil.AssertWorktreeExists(t, db, primary.ID)
+	assert.Equal(t, primary.Path, kept.Path)
+	assert.Equal(t, primary.Branch, kept.Branch)
+	testutil.AssertWorktreeCount(t, db, 2)
+}
+
+func TestInferIssueNumber(t *testing.T) {
+	cfg := project.BranchConfig{Pattern: DefaultBranchPattern}
+	assert.Equal(t, 12, InferIssueNumber(cfg, "/w/issue-12", "whatever"))
+	assert.Equal(t, 12, InferIssueNumber(cfg, "/w/issue-12-a1", "whatever"))
+	assert.Equal(t, "a1", InferAttempt("/w/issue-12-a1"))
+	assert.Equal(t, "", InferAttempt("/w/issue-12"))
+	assert.Equal(t, "", InferAttempt("/w/issue-12-1"))
+	assert.Equal(t, 34, InferIssueNumber(cfg, "/w/custom", "fix/34-crash"))
+	assert.Equal(t, 0, InferIssueNumber(cfg, "/w/custom", "56-thing"), "not the project's pattern")
+	assert.Equal(t, 56, InferIssueNumber(project.BranchConfig{Pattern: "{issue-number}-{slug}"}, "/w/custom", "56-thing"))
+	assert.Equal(t, 78, InferIssueNumber(project.BranchConfig{Pattern: "{slug}-gh{issue-number}"}, "/w/custom", "login-gh78"))
+	assert.Equal(t, 0, InferIssueNumber(cfg, "/w/custom", "feature/v2-parser"))
+	assert.Equal(t, 0, InferIssueNumber(cfg, "/w/custom", "review/45"))
+	assert.Equal(t, 0, InferIssueNumber(cfg, "/w/review-45", "feature/45-x"))
+}
@@ -0,0 +1,183 @@
+package worktree
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/paolorechia/issue-flow/internal/config"
+	"github.com/paolorechia/issue-flow/internal/git"
+	"github.com/paolorechia/issue-flow/internal/project"
+	"github.com/paolorechia/issue-flow/internal/storage"
+)
+
+// ErrWouldLoseWork is returned by Remove when the worktree holds work that
+// only exists locally and Force was not set.
+var ErrWouldLoseWork = errors.New("removing worktree would lose work")
+
+// LossReport lists the work that removing a worktree would destroy.
+type LossReport struct {
+	Uncommitted []string
+	Untracked   []string
+	Stashes     []git.Stash
+	// Unpushed holds commits on the branch that are on no remote and not in
+	// the default branch.
+	Unpushed []git.Commit
+	// Archive is the archive directory of an archived worktree, which
+	// removing the worktree deletes.
+	Archive string
+	// Snapshot is the WIPRef of a parked worktree, which removing the
+	// worktree deletes along with its copy on ParkedRemote.
+	Snapshot string
+}
+
+// Empty reports whether nothing would be lost.
+func (r *LossReport) Empty() bool {
+	return len(r.Uncommitted) == 0 && len(r.Untracked) == 0 && len(r.Stashes) == 0 && len(r.Unpushed) == 0 && r.Archive == "" && r.Snapshot == ""
+}
+
+type RemoveOptions struct {
+	Force        bool
+	DeleteBranch bool
+}
+
+type RemoveResult struct {
+	Report        *LossReport
+	BranchDeleted bool
+	Warnings      []string
+}
+
+// Assess inspects a worktree for uncommitted changes, untracked files,
+// stashes, unpushed commits, an archive and a parked snapshot.
+func (m *Manager) Assess(ctx context.Context, p *project.Project, w *storage.Worktree) (*LossReport, error) {
+	repo := config.ExpandPath(p.LocalPath)
+	report := &LossReport{}
+	if w.Status == storage.WorktreeStatusArchived {
+		report.Archive = w.ArchivePath
+	}
+	// A parked worktree is clean, its changes live only under WIPRef, and
+	// once its row is gone nothing leads back to them.
+	if w.Status == storage.WorktreeStatusParked || w.ParkedRemote != "" {
+		report.Snapshot = WIPRef(w)
+	}
+
+	if _, err := os.Stat(w.Path); err == nil {
+		status, err := m.git.Status(ctx, w.Path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get status: %w", err)
+		}
+		report.Uncommitted = append(report.Uncommitted, status.Conflicted...)
+		report.Uncommitted = append(report.Uncommitted, uniqueStrings(status.Staged, status.Unstaged)...)
+		report.Untracked = status.Untracked
+	}
+
+	stashes, err := m.git.StashList(ctx, repo)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list stashes: %w", err)
+	}
+	for _, st := range stashes {
+		if st.Branch == w.Branch {
+			report.Stashes = append(report.Stashes, st)
+		}
+	}
+
+	exists, err := m.git.BranchExists(ctx, repo, w.Branch)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check branch %s: %w", w.Branch, err)
+	}
+	if exists {
+		revs := []string{"refs/heads/" + w.Branch, "--not", "--remotes"}
+		if base, err := m.BranchBaseRef(ctx, repo, p.UpstreamRemote(), w.BaseBranch); err == nil {
+			revs = append(revs, base)
+		}
+		unpushed, err := m.git.Log(ctx, repo, revs...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list unpushed commits: %w", err)
+		}
+		report.Unpushed = unpushed
+	}
+
+	return report, nil
+}
+
+// Remove deletes a worktree from disk and from the database. Unless
+// opts.Force is set it refuses with ErrWouldLoseWork when Assess finds work
+// that would be lost; the report is returned either way. The database row is
+// only deleted once git has removed the worktree. Remove is safe to call
+// concurrently.
+func (m *Manager) Remove(ctx context.Context, p *project.Project, w *storage.Worktree, opts RemoveOptions) (*RemoveResult, error) {
+	report, err := m.Assess(ctx, p, w)
+	if err != nil {
+		return nil, err
+	}
+	result := &RemoveResult{Report: report}
+	if !report.Empty() && !opts.Force {
+		return result, ErrWouldLoseWork
+	}
+
+	repo := config.ExpandPath(p.LocalPath)
+	unlock := m.lockRepo(repo)
+	defer unlock()
+
+	if err := m.removeFromGit(ctx, repo, w.Path, opts.Force); err != nil {
+		return result, err
+	}
+	if err := m.withDB(func(db *storage.Database) error { return db.DeleteWorktree(w.ID) }); err != nil {
+		return result, fmt.Errorf("worktree removed but failed to delete its record: %w", err)
+	}
+	if w.ArchivePath != "" {
+		if err := os.RemoveAll(w.ArchivePath); err != nil {
+			result.Warnings = append(result.Warnings, fmt.Sprintf("failed to delete archive %s: %v", w.ArchivePath, err))
+		}
+	}
+	if report.Snapshot != "" {
+		if err := m.git.DeleteRef(ctx, repo, report.Snapshot); err != nil {
+			result.Warnings = append(result.Warnings, fmt.Sprintf("failed to delete %s: %v", report.Snapshot, err))
+		}
+		if w.ParkedRemote != "" {
+			if err := m.git.Push(ctx, repo, w.ParkedRemote, ":"+report.Snapshot); err != nil {
+				result.Warnings = append(result.Warnings, fmt.Sprintf("failed to delete %s on %s: %v", report.Snapshot, w.ParkedRemote, err))
+			}
+		}
+	}
+
+	if opts.DeleteBranch {
+		if err := m.git.DeleteBranch(ctx, repo, w.Branch, true); err != nil {
+			result.Warnings = append(result.Warnings, fmt.Sprintf("failed to delete branch %s: %v", w.Branch, err))
+		} else {
+			result.BranchDeleted = true
+		}
+	}
+
+	return result, nil
+}
+
+// removeFromGit removes the worktree at path, pruning git's bookkeeping
+// instead when the directory is already gone.
+func (m *Manager) removeFromGit(ctx context.Context, repo, path string, force bool) error {
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		if err := m.git.WorktreePrune(ctx, repo); err != nil {
+			return fmt.Errorf("failed to prune worktrees: %w", err)
+		}
+		return nil
+	}
+	if err := m.git.WorktreeRemove(ctx, repo, path, force); err != nil {
+		return fmt.Errorf("failed to remove worktree: %w", err)
+	}
+	return nil
+}
+
+func uniqueStrings(lists ...[]string) []string {
+	seen := make(map[string]bool)
+	var result []string
+	for _, list := range lists {
+		for _, s := range list {
+			if !seen[s] {
+				seen[s] = true
+				result = append(result, s)
+			}
+		}
+	}
+	return result
+}
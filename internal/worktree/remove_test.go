@@ -0,0 +1,103 @@
+package worktree
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/paolorechia/issue-flow/internal/git"
+	"github.com/paolorechia/issue-flow/internal/project"
+	"github.com/paolorechia/issue-flow/internal/storage"
+	"github.com/paolorechia/issue-flow/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func startTestWorktree(t *testing.T, db *storage.Database, issueNumber int) (*project.Project, *Manager, *storage.Worktree) {
+	t.Helper()
+
+	p, err := project.NewManager(db).Get("app")
+	require.NoError(t, err)
+
+	manager := NewManager(db, git.NewClient(nil))
+	result, err := manager.Start(context.Background(), p, StartOptions{IssueNumber: issueNumber, Title: "Test issue"})
+	require.NoError(t, err)
+	return p, manager, result.Worktree
+}
+
+func TestManager_RemoveClean(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	sp := testutil.CreateGitProject(t, db, "app")
+	p, manager, w := startTestWorktree(t, db, 1)
+
+	result, err := manager.Remove(context.Background(), p, w, RemoveOptions{DeleteBranch: true})
+	require.NoError(t, err)
+	assert.True(t, result.Report.Empty())
+	assert.True(t, result.BranchDeleted)
+
+	testutil.AssertWorktreeCount(t, db, 0)
+	assert.NoDirExists(t, w.Path)
+	assert.Empty(t, testutil.RunGit(t, sp.LocalPath, "branch", "--list", w.Branch))
+}
+
+func TestManager_RemoveRefusesToLoseWork(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	sp := testutil.CreateGitProject(t, db, "app")
+	p, manager, w := startTestWorktree(t, db, 2)
+	ctx := context.Background()
+
+	testutil.GitCommitFile(t, w.Path, "feature.go", "package x\n", "Local only commit")
+	require.NoError(t, os.WriteFile(filepath.Join(w.Path, "stashed.txt"), []byte("s\n"), 0644))
+	testutil.RunGit(t, w.Path, "stash", "push", "--include-untracked", "-m", "parked idea")
+	require.NoError(t, os.WriteFile(filepath.Join(w.Path, "feature.go"), []byte("package y\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(w.Path, "notes.txt"), []byte("n\n"), 0644))
+
+	result, err := manager.Remove(ctx, p, w, RemoveOptions{DeleteBranch: true})
+	require.ErrorIs(t, err, ErrWouldLoseWork)
+
+	report := result.Report
+	assert.Equal(t, []string{"feature.go"}, report.Uncommitted)
+	assert.Equal(t, []string{"notes.txt"}, report.Untracked)
+	require.Len(t, report.Stashes, 1)
+	assert.Contains(t, report.Stashes[0].Message, "parked idea")
+	require.Len(t, report.Unpushed, 1)
+	assert.Equal(t, "Local only commit", report.Unpushed[0].Subject)
+
+	testutil.AssertWorktreeExists(t, db, w.ID)
+	assert.DirExists(t, w.Path)
+
+	result, err = manager.Remove(ctx, p, w, RemoveOptions{Force: true, DeleteBranch: true})
+	require.NoError(t, err)
+	assert.True(t, result.BranchDeleted)
+	testutil.AssertWorktreeCount(t, db, 0)
+	assert.NoDirExists(t, w.Path)
+	assert.Empty(t, testutil.RunGit(t, sp.LocalPath, "branch", "--list", w.Branch))
+}
+
+func TestManager_RemoveKeepsRowWhenGitFails(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	testutil.CreateTestProject(t, db)
+	p, err := project.NewManager(db).Get("test-project")
+	require.NoError(t, err)
+
+	w := &storage.Worktree{
+		ID:          "wt-test-project-5",
+		ProjectID:   p.ID,
+		IssueNumber: 5,
+		Path:        t.TempDir(),
+		Branch:      "feature/5-x",
+		Status:      storage.WorktreeStatusActive,
+	}
+	require.NoError(t, db.CreateWorktree(w))
+
+	runner := git.NewRecordingRunner().
+		On("worktree remove "+w.Path, "", errors.New("locked"))
+	manager := NewManager(db, git.NewClient(runner))
+
+	_, err = manager.Remove(context.Background(), p, w, RemoveOptions{})
+	require.Error(t, err)
+	testutil.AssertWorktreeExists(t, db, w.ID)
+	assert.Contains(t, runner.Commands(), "git worktree remove "+w.Path)
+}
@@ -0,0 +1,241 @@
+package worktree
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/paolorechia/issue-flow/internal/config"
+	"github.com/paolorechia/issue-flow/internal/git"
+	"github.com/paolorechia/issue-flow/internal/project"
+	"github.com/paolorechia/issue-flow/internal/storage"
+)
+
+type ReviewOptions struct {
+	PRNumber int
+	// Remote is fetched from; defaults to the project's upstream remote,
+	// which has the pull refs also for projects worked on from a fork.
+	Remote string
+	// Branch is fetched instead of refs/pull/<n>/head, for a pull request
+	// from a fork added as Remote or a host without pull refs.
+	Branch string
+	// WorktreeBase is used when the project has no WorktreeDir.
+	WorktreeBase string
+}
+
+type ReviewResult struct {
+	Worktree *storage.Worktree
+	// Created is false when an existing review worktree was reused.
+	Created bool
+	// Head is the fetched head of the pull request.
+	Head string
+	// Updated is true when a reused worktree was fast-forwarded to Head.
+	Updated  bool
+	Ports    []Port
+	Warnings []string
+}
+
+// ReviewID returns the worktrees table key for a pull request's review
+// worktree.
+func ReviewID(projectID string, prNumber int) string {
+	return fmt.Sprintf("wt-%s-pr-%d", projectID, prNumber)
+}
+
+// ReviewBranch returns the local branch a review worktree checks out.
+func ReviewBranch(prNumber int) string {
+	return fmt.Sprintf("review/%d", prNumber)
+}
+
+// reviewRef is the remote-tracking ref a pull request's head is fetched
+// into, so its commits count as pushed.
+func reviewRef(remote string, prNumber int) string {
+	return fmt.Sprintf("refs/remotes/%s/pr/%d", remote, prNumber)
+}
+
+// Review fetches a pull request's head and checks it out in a review
+// worktree on branch review/<n>, recorded with status review. Running it
+// again fetches the head again and fast-forwards the worktree when it is
+// clean and the pull request was not rewritten; otherwise it only warns. A
+// review branch left without a worktree is fast-forwarded the same way, and
+// refused when it has diverged.
+func (m *Manager) Review(ctx context.Context, p *project.Project, opts ReviewOptions) (*ReviewResult, error) {
+	if opts.PRNumber <= 0 {
+		return nil, fmt.Errorf("pull request number must be positive")
+	}
+	if p.LocalPath == "" {
+		return nil, fmt.Errorf("project %s has no local path", p.ID)
+	}
+	repo := config.ExpandPath(p.LocalPath)
+	remote := opts.Remote
+	if remote == "" {
+		remote = p.UpstreamRemote()
+	}
+	if ok, err := m.hasRemote(ctx, repo, remote); err != nil {
+		return nil, err
+	} else if !ok {
+		return nil, fmt.Errorf("project %s has no remote %s", p.ID, remote)
+	}
+
+	source := fmt.Sprintf("refs/pull/%d/head", opts.PRNumber)
+	if opts.Branch != "" {
+		source = "refs/heads/" + opts.Branch
+	}
+	ref := reviewRef(remote, opts.PRNumber)
+	if err := m.git.Fetch(ctx, repo, remote, "+"+source+":"+ref); err != nil {
+		return nil, fmt.Errorf("failed to fetch %s from %s: %w", source, remote, err)
+	}
+	result := &ReviewResult{}
+	head, err := m.git.RevParse(ctx, repo, ref)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve %s: %w", ref, err)
+	}
+	result.Head = head
+
+	existing, err := m.db.GetReviewWorktree(p.ID, opts.PRNumber)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("failed to look up review worktree: %w", err)
+	}
+	if existing != nil {
+		return m.refreshReview(ctx, p, repo, existing, ref, result)
+	}
+
+	dir, err := projectWorktreeDir(p, opts.WorktreeBase)
+	if err != nil {
+		return nil, err
+	}
+	path := filepath.Join(dir, fmt.Sprintf("review-%d", opts.PRNumber))
+	branch := ReviewBranch(opts.PRNumber)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create worktree directory: %w", err)
+	}
+	exists, err := m.git.BranchExists(ctx, repo, branch)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check branch %s: %w", branch, err)
+	}
+	add := git.WorktreeAddOptions{Path: path, Branch: branch}
+	if !exists {
+		add.NewBranch, add.StartPoint, add.NoTrack = true, ref, true
+	} else if err := m.adoptReviewBranch(ctx, repo, branch, ref, head); err != nil {
+		return nil, err
+	}
+	if err := m.git.WorktreeAdd(ctx, repo, add); err != nil {
+		return nil, fmt.Errorf("failed to create worktree: %w", err)
+	}
+	result.Created = true
+
+	w := &storage.Worktree{
+		ID:        ReviewID(p.ID, opts.PRNumber),
+		ProjectID: p.ID,
+		Path:      path,
+		Branch:    branch,
+		Status:    storage.WorktreeStatusReview,
+		PRNumber:  opts.PRNumber,
+	}
+	if err := m.db.CreateWorktree(w); err != nil {
+		return nil, fmt.Errorf("failed to record worktree: %w", err)
+	}
+	result.Worktree = w
+	result.Warnings = append(result.Warnings, m.applyIdentity(ctx, p, w)...)
+	if result.Ports, err = m.AllocatePorts(p, w); err != nil {
+		return result, fmt.Errorf("failed to allocate ports: %w", err)
+	}
+	return result, nil
+}
+
+// adoptReviewBranch brings a review branch left without a worktree up to
+// the fetched head, refusing when that would drop commits of its own.
+func (m *Manager) adoptReviewBranch(ctx context.Context, repo, branch, ref, head string) error {
+	tip, err := m.git.RevParse(ctx, repo, "refs/heads/"+branch)
+	if err != nil {
+		return fmt.Errorf("failed to resolve branch %s: %w", branch, err)
+	}
+	if tip == head {
+		return nil
+	}
+	ancestor, err := m.git.IsAncestor(ctx, repo, tip, head)
+	if err != nil {
+		return fmt.Errorf("failed to compare %s with %s: %w", branch, ref, err)
+	}
+	if !ancestor {
+		return fmt.Errorf("branch %s has diverged from the pull request; delete it or compare it with %s", branch, ref)
+	}
+	if err := m.git.UpdateRef(ctx, repo, "refs/heads/"+branch, head); err != nil {
+		return fmt.Errorf("failed to fast-forward %s: %w", branch, err)
+	}
+	return nil
+}
+
+// refreshReview fast-forwards an existing review worktree to the fetched
+// head when that loses nothing.
+func (m *Manager) refreshReview(ctx context.Context, p *project.Project, repo string, w *storage.Worktree, ref string, result *ReviewResult) (*ReviewResult, error) {
+	result.Worktree = w
+	if w.Status != storage.WorktreeStatusReview {
+		return nil, fmt.Errorf("review worktree %s is %s", w.ID, w.Status)
+	}
+	var err error
+	if result.Ports, err = m.AllocatePorts(p, w); err != nil {
+		return result, fmt.Errorf("failed to allocate ports: %w", err)
+	}
+
+	tip, err := m.git.RevParse(ctx, repo, "refs/heads/"+w.Branch)
+	if err != nil {
+		return result, fmt.Errorf("failed to resolve branch %s: %w", w.Branch, err)
+	}
+	if tip == result.Head {
+		return result, nil
+	}
+	ancestor, err := m.git.IsAncestor(ctx, repo, tip, result.Head)
+	if err != nil {
+		return result, fmt.Errorf("failed to compare %s with %s: %w", w.Branch, ref, err)
+	}
+	if !ancestor {
+		result.Warnings = append(result.Warnings, fmt.Sprintf("%s has diverged from the pull request; compare it with %s", w.Branch, ref))
+		return result, nil
+	}
+	status, err := m.git.Status(ctx, w.Path)
+	if err != nil {
+		return result, fmt.Errorf("failed to get status: %w", err)
+	}
+	if status.Dirty() {
+		result.Warnings = append(result.Warnings, fmt.Sprintf("the pull request has new commits but %s has uncommitted changes; merge %s yourself", w.Path, ref))
+		return result, nil
+	}
+	if err := m.git.Merge(ctx, w.Path, ref, false); err != nil {
+		return result, fmt.Errorf("failed to fast-forward %s: %w", w.Branch, err)
+	}
+	result.Updated = true
+	return result, nil
+}
+
+// ReviewDone removes a review worktree with its review branch and the
+// fetched pull request refs. Like Remove, it refuses with ErrWouldLoseWork
+// when the worktree holds local work, unless opts.Force is set.
+func (m *Manager) ReviewDone(ctx context.Context, p *project.Project, w *storage.Worktree, force bool) (*RemoveResult, error) {
+	if w.PRNumber <= 0 {
+		return nil, fmt.Errorf("worktree %s is not a review worktree", w.ID)
+	}
+	result, err := m.Remove(ctx, p, w, RemoveOptions{Force: force, DeleteBranch: true})
+	if err != nil {
+		return result, err
+	}
+
+	repo := config.ExpandPath(p.LocalPath)
+	remotes, err := m.git.Remotes(ctx, repo)
+	if err != nil {
+		result.Warnings = append(result.Warnings, fmt.Sprintf("failed to list remotes: %v", err))
+		return result, nil
+	}
+	for _, r := range remotes {
+		ref := reviewRef(r.Name, w.PRNumber)
+		if ok, err := m.git.RefExists(ctx, repo, ref); err != nil || !ok {
+			continue
+		}
+		if err := m.git.DeleteRef(ctx, repo, ref); err != nil {
+			result.Warnings = append(result.Warnings, fmt.Sprintf("failed to delete %s: %v", ref, err))
+		}
+	}
+	return result, nil
+}
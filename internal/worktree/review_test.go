@@ -0,0 +1,147 @@
+package worktree
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/paolorechia/issue-flow/internal/git"
+	"github.com/paolorechia/issue-flow/internal/project"
+	"github.com/paolorechia/issue-flow/internal/storage"
+	"github.com/paolorechia/issue-flow/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// pushPullRequest commits name on top of base in repo and points
+// refs/pull/<n>/head at the commit, as a hosting service would.
+func pushPullRequest(t *testing.T, repo string, n int, base, name string) string {
+	t.Helper()
+	testutil.RunGit(t, repo, "checkout", "-q", "--detach", base)
+	testutil.GitCommitFile(t, repo, name, name+"\n", "Add "+name)
+	head := testutil.RunGit(t, repo, "rev-parse", "HEAD")
+	testutil.RunGit(t, repo, "update-ref", "refs/pull/"+strconv.Itoa(n)+"/head", head)
+	testutil.RunGit(t, repo, "checkout", "-q", "main")
+	return head
+}
+
+func TestManager_Review(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	sp := testutil.CreateGitProject(t, db, "app")
+	repo := sp.LocalPath
+	testutil.RunGit(t, repo, "remote", "add", "origin", repo)
+	head := pushPullRequest(t, repo, 7, "main", "pr.txt")
+
+	p, err := project.NewManager(db).Get("app")
+	require.NoError(t, err)
+	manager := NewManager(db, git.NewClient(nil))
+	ctx := context.Background()
+
+	result, err := manager.Review(ctx, p, ReviewOptions{PRNumber: 7})
+	require.NoError(t, err)
+	assert.True(t, result.Created)
+	assert.Equal(t, head, result.Head)
+	w := result.Worktree
+	assert.Equal(t, "wt-app-pr-7", w.ID)
+	assert.Equal(t, "review/7", w.Branch)
+	assert.Equal(t, filepath.Join(sp.WorktreeDir, "review-7"), w.Path)
+	assert.FileExists(t, filepath.Join(w.Path, "pr.txt"))
+
+	row := testutil.AssertWorktreeExists(t, db, w.ID)
+	assert.Equal(t, storage.WorktreeStatusReview, row.Status)
+	assert.Equal(t, 7, row.PRNumber)
+	assert.Zero(t, row.IssueNumber)
+
+	sync, _, err := manager.Sync(ctx, SyncOptions{NoFetch: true}, 1)
+	require.NoError(t, err)
+	assert.Empty(t, sync, "review worktrees are not synced")
+
+	newHead := pushPullRequest(t, repo, 7, head, "more.txt")
+	result, err = manager.Review(ctx, p, ReviewOptions{PRNumber: 7})
+	require.NoError(t, err)
+	assert.False(t, result.Created)
+	assert.True(t, result.Updated)
+	assert.Equal(t, newHead, testutil.RunGit(t, w.Path, "rev-parse", "HEAD"))
+
+	rewritten := pushPullRequest(t, repo, 7, "main", "redo.txt")
+	result, err = manager.Review(ctx, p, ReviewOptions{PRNumber: 7})
+	require.NoError(t, err)
+	assert.False(t, result.Updated)
+	assert.Equal(t, rewritten, result.Head)
+	require.Len(t, result.Warnings, 1)
+	assert.Contains(t, result.Warnings[0], "has diverged from the pull request")
+	testutil.RunGit(t, w.Path, "reset", "-q", "--hard", "refs/remotes/origin/pr/7")
+
+	_, err = manager.Archive(ctx, p, row, ArchiveOptions{})
+	assert.ErrorContains(t, err, "reviews a pull request")
+
+	require.NoError(t, os.WriteFile(filepath.Join(w.Path, "notes.txt"), []byte("nit\n"), 0644))
+	done, err := manager.ReviewDone(ctx, p, row, false)
+	assert.ErrorIs(t, err, ErrWouldLoseWork)
+	assert.Equal(t, []string{"notes.txt"}, done.Report.Untracked)
+
+	require.NoError(t, os.Remove(filepath.Join(w.Path, "notes.txt")))
+	_, err = manager.ReviewDone(ctx, p, row, false)
+	require.NoError(t, err, "the pull request's commits are on the remote")
+	testutil.AssertWorktreeCount(t, db, 0)
+	assert.NoDirExists(t, w.Path)
+	assert.Empty(t, testutil.RunGit(t, repo, "branch", "--list", "review/7"))
+	assert.Empty(t, testutil.RunGit(t, repo, "for-each-ref", "refs/remotes/origin/pr/"))
+}
+
+func TestManager_ReviewFastForwardsLeftoverBranch(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	sp := testutil.CreateGitProject(t, db, "app")
+	repo := sp.LocalPath
+	testutil.RunGit(t, repo, "remote", "add", "origin", repo)
+	head := pushPullRequest(t, repo, 7, "main", "pr.txt")
+
+	p, err := project.NewManager(db).Get("app")
+	require.NoError(t, err)
+	manager := NewManager(db, git.NewClient(nil))
+	ctx := context.Background()
+
+	result, err := manager.Review(ctx, p, ReviewOptions{PRNumber: 7})
+	require.NoError(t, err)
+	w := result.Worktree
+	testutil.RunGit(t, repo, "worktree", "remove", w.Path)
+	require.NoError(t, db.DeleteWorktree(w.ID))
+
+	newHead := pushPullRequest(t, repo, 7, head, "more.txt")
+	result, err = manager.Review(ctx, p, ReviewOptions{PRNumber: 7})
+	require.NoError(t, err)
+	assert.True(t, result.Created)
+	assert.Equal(t, newHead, testutil.RunGit(t, w.Path, "rev-parse", "HEAD"))
+
+	testutil.RunGit(t, repo, "worktree", "remove", w.Path)
+	require.NoError(t, db.DeleteWorktree(w.ID))
+	pushPullRequest(t, repo, 7, "main", "redo.txt")
+	_, err = manager.Review(ctx, p, ReviewOptions{PRNumber: 7})
+	assert.ErrorContains(t, err, "has diverged from the pull request")
+	assert.Equal(t, newHead, testutil.RunGit(t, repo, "rev-parse", "review/7"))
+	testutil.AssertWorktreeCount(t, db, 0)
+}
+
+func TestManager_ReviewFromForkBranch(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	sp := testutil.CreateGitProject(t, db, "app")
+	fork := testutil.NewGitRepo(t)
+	testutil.RunGit(t, fork, "fetch", "-q", sp.LocalPath, "main")
+	testutil.RunGit(t, fork, "checkout", "-q", "-b", "fix-typo", "FETCH_HEAD")
+	testutil.GitCommitFile(t, fork, "typo.txt", "fixed\n", "Fix typo")
+	testutil.RunGit(t, sp.LocalPath, "remote", "add", "contributor", fork)
+
+	p, err := project.NewManager(db).Get("app")
+	require.NoError(t, err)
+	manager := NewManager(db, git.NewClient(nil))
+
+	_, err = manager.Review(context.Background(), p, ReviewOptions{PRNumber: 12})
+	assert.ErrorContains(t, err, "has no remote origin")
+
+	result, err := manager.Review(context.Background(), p, ReviewOptions{PRNumber: 12, Remote: "contributor", Branch: "fix-typo"})
+	require.NoError(t, err)
+	assert.FileExists(t, filepath.Join(result.Worktree.Path, "typo.txt"))
+	assert.Equal(t, testutil.RunGit(t, fork, "rev-parse", "HEAD"), result.Head)
+}
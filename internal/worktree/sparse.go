@@ -0,0 +1,129 @@
+package worktree
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/paolorechia/issue-flow/internal/project"
+	"github.com/paolorechia/issue-flow/internal/storage"
+)
+
+// SparsePatterns returns the sparse-checkout directories for a new worktree
+// of the given issue type. An IssueType's patterns replace the project's.
+func SparsePatterns(p *project.Project, issueType string) []string {
+	for _, it := range p.Config.IssueTypes {
+		if it.Name == issueType && len(it.SparsePatterns) > 0 {
+			return NormalizeSparsePatterns(it.SparsePatterns)
+		}
+	}
+	return NormalizeSparsePatterns(p.Config.SparsePatterns)
+}
+
+// NormalizeSparsePatterns cleans cone patterns into slash-separated
+// directories relative to the repository root, dropping duplicates and
+// entries that name the root itself.
+func NormalizeSparsePatterns(patterns []string) []string {
+	var result []string
+	seen := make(map[string]bool)
+	for _, p := range patterns {
+		p = strings.Trim(path.Clean(strings.ReplaceAll(p, "\\", "/")), "/")
+		if p == "" || p == "." || seen[p] {
+			continue
+		}
+		seen[p] = true
+		result = append(result, p)
+	}
+	return result
+}
+
+// populateSparse configures the sparse-checkout of a worktree created with
+// NoCheckout and then checks out its files. It does nothing without patterns.
+func (m *Manager) populateSparse(ctx context.Context, path string, sparse []string) error {
+	if len(sparse) == 0 {
+		return nil
+	}
+	if err := m.git.SparseCheckoutSet(ctx, path, sparse); err != nil {
+		return fmt.Errorf("failed to configure sparse-checkout: %w", err)
+	}
+	if err := m.git.Checkout(ctx, path, ""); err != nil {
+		return fmt.Errorf("failed to check out worktree: %w", err)
+	}
+	return nil
+}
+
+// SparseAdd widens a worktree's sparse-checkout with more directories. A
+// worktree with a full checkout becomes sparse, limited to patterns.
+func (m *Manager) SparseAdd(ctx context.Context, w *storage.Worktree, patterns []string) error {
+	patterns = NormalizeSparsePatterns(patterns)
+	if len(patterns) == 0 {
+		return fmt.Errorf("no sparse-checkout paths given")
+	}
+
+	var err error
+	if len(w.SparsePatterns) == 0 {
+		err = m.git.SparseCheckoutSet(ctx, w.Path, patterns)
+	} else {
+		err = m.git.SparseCheckoutAdd(ctx, w.Path, patterns)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update sparse-checkout: %w", err)
+	}
+	return m.saveSparse(ctx, w)
+}
+
+// SparseRemove drops directories from a worktree's sparse-checkout. Removing
+// the last directory disables sparse-checkout and restores a full checkout.
+func (m *Manager) SparseRemove(ctx context.Context, w *storage.Worktree, patterns []string) error {
+	patterns = NormalizeSparsePatterns(patterns)
+	if len(patterns) == 0 {
+		return fmt.Errorf("no sparse-checkout paths given")
+	}
+	if len(w.SparsePatterns) == 0 {
+		return fmt.Errorf("worktree %s does not use sparse-checkout", w.ID)
+	}
+
+	drop := make(map[string]bool)
+	for _, p := range patterns {
+		drop[p] = true
+	}
+	var remaining []string
+	for _, p := range w.SparsePatterns {
+		if drop[p] {
+			delete(drop, p)
+			continue
+		}
+		remaining = append(remaining, p)
+	}
+	for _, p := range patterns {
+		if drop[p] {
+			return fmt.Errorf("%s is not in the sparse-checkout of %s", p, w.ID)
+		}
+	}
+
+	var err error
+	if len(remaining) == 0 {
+		err = m.git.SparseCheckoutDisable(ctx, w.Path)
+	} else {
+		err = m.git.SparseCheckoutSet(ctx, w.Path, remaining)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update sparse-checkout: %w", err)
+	}
+	if len(remaining) == 0 {
+		w.SparsePatterns = nil
+		return m.withDB(func(db *storage.Database) error { return db.UpdateWorktree(w) })
+	}
+	return m.saveSparse(ctx, w)
+}
+
+// saveSparse records the sparse patterns git reports for w.
+func (m *Manager) saveSparse(ctx context.Context, w *storage.Worktree) error {
+	patterns, err := m.git.SparseCheckoutList(ctx, w.Path)
+	if err != nil {
+		return fmt.Errorf("failed to read sparse-checkout: %w", err)
+	}
+	w.SparsePatterns = NormalizeSparsePatterns(patterns)
+	return m.withDB(func(db *storage.Database) error { return db.UpdateWorktree(w) })
+}
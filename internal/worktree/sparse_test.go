@@ -0,0 +1,66 @@
+package worktree
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/paolorechia/issue-flow/internal/git"
+	"github.com/paolorechia/issue-flow/internal/project"
+	"github.com/paolorechia/issue-flow/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSparsePatterns(t *testing.T) {
+	p := &project.Project{Config: project.ProjectConfig{
+		SparsePatterns: []string{"services/api/", "/libs", "libs", "."},
+		IssueTypes: []project.IssueType{
+			{Name: "docs", SparsePatterns: []string{"docs"}},
+			{Name: "bug"},
+		},
+	}}
+
+	assert.Equal(t, []string{"services/api", "libs"}, SparsePatterns(p, "feature"))
+	assert.Equal(t, []string{"services/api", "libs"}, SparsePatterns(p, "bug"))
+	assert.Equal(t, []string{"docs"}, SparsePatterns(p, "docs"))
+	assert.Nil(t, SparsePatterns(&project.Project{}, "docs"))
+}
+
+func TestManager_StartSparse(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	sp := testutil.CreateGitProjectWithConfig(t, db, "app", `{"sparse_patterns":["services/api"]}`)
+	testutil.GitCommitFile(t, sp.LocalPath, "services/api/main.go", "package main\n", "Add api")
+	testutil.GitCommitFile(t, sp.LocalPath, "services/web/index.js", "//\n", "Add web")
+	testutil.GitCommitFile(t, sp.LocalPath, "libs/util.go", "package libs\n", "Add libs")
+	ctx := context.Background()
+
+	p, err := project.NewManager(db).Get("app")
+	require.NoError(t, err)
+	manager := NewManager(db, git.NewClient(nil))
+	result, err := manager.Start(ctx, p, StartOptions{IssueNumber: 1, Title: "Sparse"})
+	require.NoError(t, err)
+	w := result.Worktree
+
+	assert.FileExists(t, filepath.Join(w.Path, "README.md"))
+	assert.FileExists(t, filepath.Join(w.Path, "services", "api", "main.go"))
+	assert.NoDirExists(t, filepath.Join(w.Path, "services", "web"))
+	assert.NoDirExists(t, filepath.Join(w.Path, "libs"))
+	assert.Empty(t, testutil.RunGit(t, w.Path, "status", "--porcelain"))
+	assert.NoFileExists(t, filepath.Join(sp.LocalPath, ".git", "info", "sparse-checkout"), "main checkout stays full")
+	assert.Equal(t, []string{"services/api"}, testutil.AssertWorktreeExists(t, db, w.ID).SparsePatterns)
+
+	require.NoError(t, manager.SparseAdd(ctx, w, []string{"libs/"}))
+	assert.FileExists(t, filepath.Join(w.Path, "libs", "util.go"))
+	assert.Equal(t, []string{"libs", "services/api"}, testutil.AssertWorktreeExists(t, db, w.ID).SparsePatterns)
+
+	assert.Error(t, manager.SparseRemove(ctx, w, []string{"services/web"}))
+
+	require.NoError(t, manager.SparseRemove(ctx, w, []string{"services/api"}))
+	assert.NoDirExists(t, filepath.Join(w.Path, "services"))
+	assert.Equal(t, []string{"libs"}, testutil.AssertWorktreeExists(t, db, w.ID).SparsePatterns)
+
+	require.NoError(t, manager.SparseRemove(ctx, w, []string{"libs"}))
+	assert.FileExists(t, filepath.Join(w.Path, "services", "web", "index.js"), "removing the last path restores a full checkout")
+	assert.Empty(t, testutil.AssertWorktreeExists(t, db, w.ID).SparsePatterns)
+}
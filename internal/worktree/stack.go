@@ -0,0 +1,315 @@
+package worktree
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/paolorechia/issue-flow/internal/config"
+	"github.com/paolorechia/issue-flow/internal/project"
+	"github.com/paolorechia/issue-flow/internal/storage"
+)
+
+// RestackBlocked is the restack result of a worktree whose parent could not
+// be restacked, so it was left alone.
+const RestackBlocked = "skipped-parent"
+
+// StackNode is a worktree in a project's stack tree.
+type StackNode struct {
+	Worktree storage.Worktree
+	Children []*StackNode
+	// NeedsRestack reports that the parent's branch has moved since the
+	// worktree was last based on it.
+	NeedsRestack bool
+	// MissingParent is set for a worktree whose parent is not a checked-out
+	// worktree: it holds the parent's status, or "removed" when its row is
+	// gone.
+	MissingParent string
+
+	parent *StackNode
+}
+
+type RestackOptions struct {
+	Autostash bool
+	NoFetch   bool
+}
+
+// RestackOutcome is the result of restacking one worktree.
+type RestackOutcome struct {
+	Worktree storage.Worktree
+	// Onto is the branch or ref the worktree was rebased onto.
+	Onto string
+	// Depth is how far the worktree is from the root of its stack.
+	Depth     int
+	Result    string
+	Conflicts []string
+	Err       error
+}
+
+// checkParent validates stacking the worktree id on parent. An existing
+// worktree cannot be moved onto another parent by starting it again.
+func checkParent(p *project.Project, id string, existing, parent *storage.Worktree) error {
+	if parent == nil {
+		return nil
+	}
+	if parent.ProjectID != p.ID {
+		return fmt.Errorf("cannot stack on %s: it belongs to project %s", parent.ID, parent.ProjectID)
+	}
+	if parent.ID == id {
+		return fmt.Errorf("cannot stack %s on itself", id)
+	}
+	if parent.Status == storage.WorktreeStatusArchived || parent.Status == storage.WorktreeStatusStale || parent.Status == storage.WorktreeStatusReview {
+		return fmt.Errorf("cannot stack on %s: it is %s", parent.ID, parent.Status)
+	}
+	if existing != nil && existing.ParentID != parent.ID {
+		return fmt.Errorf("%s already exists and is not stacked on %s", id, parent.ID)
+	}
+	return nil
+}
+
+// buildStacks arranges worktrees into trees by their parent, keeping the
+// given order among siblings. Worktrees whose parent is not in the list are
+// roots.
+func buildStacks(worktrees []storage.Worktree) []*StackNode {
+	nodes := make(map[string]*StackNode, len(worktrees))
+	for _, w := range worktrees {
+		nodes[w.ID] = &StackNode{Worktree: w}
+	}
+	var roots []*StackNode
+	for _, w := range worktrees {
+		node := nodes[w.ID]
+		if parent, ok := nodes[w.ParentID]; ok {
+			node.parent = parent
+			parent.Children = append(parent.Children, node)
+		} else {
+			roots = append(roots, node)
+		}
+	}
+	return roots
+}
+
+// Stacks returns the project's worktrees as trees of stacked worktrees,
+// marking those whose parent has moved on.
+func (m *Manager) Stacks(ctx context.Context, p *project.Project) ([]*StackNode, error) {
+	var worktrees []storage.Worktree
+	err := m.withDB(func(db *storage.Database) error {
+		var err error
+		worktrees, err = db.ListWorktreesByProject(p.ID)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list worktrees: %w", err)
+	}
+	roots := buildStacks(worktrees)
+
+	repo := config.ExpandPath(p.LocalPath)
+	var mark func(node *StackNode) error
+	mark = func(node *StackNode) error {
+		for _, child := range node.Children {
+			tip, err := m.git.RevParse(ctx, repo, "refs/heads/"+node.Worktree.Branch)
+			if err != nil {
+				return fmt.Errorf("failed to resolve branch %s: %w", node.Worktree.Branch, err)
+			}
+			child.NeedsRestack = tip != child.Worktree.ParentBase
+			if err := mark(child); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	for _, root := range roots {
+		if root.Worktree.ParentID != "" {
+			if root.MissingParent, err = m.parentStatus(root.Worktree.ParentID); err != nil {
+				return nil, err
+			}
+			root.NeedsRestack = true
+		}
+		if err := mark(root); err != nil {
+			return nil, err
+		}
+	}
+	return roots, nil
+}
+
+// parentStatus returns the status of a parent that is not in the worktree
+// list, or "removed" when it has no row any more.
+func (m *Manager) parentStatus(id string) (string, error) {
+	var parent *storage.Worktree
+	err := m.withDB(func(db *storage.Database) error {
+		var err error
+		parent, err = db.GetWorktree(id)
+		return err
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return "removed", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to look up parent %s: %w", id, err)
+	}
+	return parent.Status, nil
+}
+
+// Restack rebases stacked worktrees in order, parents before children: the
+// root of each stack onto its base branch after an optional fetch, and
+// every other worktree onto its parent's branch, replaying only its own
+// commits (those after ParentBase). A worktree whose parent was removed,
+// e.g. after being merged, is moved onto its base branch and becomes a
+// root. When a worktree cannot be rebased its descendants are skipped.
+//
+// With from set only the stack containing it is restacked; otherwise every
+// stack of the project is, leaving worktrees without parent or children to
+// 'worktree sync'.
+func (m *Manager) Restack(ctx context.Context, p *project.Project, from *storage.Worktree, opts RestackOptions) ([]RestackOutcome, []string, error) {
+	stacks, err := m.Stacks(ctx, p)
+	if err != nil {
+		return nil, nil, err
+	}
+	var selected []*StackNode
+	for _, root := range stacks {
+		if from != nil && !root.contains(from.ID) {
+			continue
+		}
+		if len(root.Children) > 0 || root.Worktree.ParentID != "" || from != nil {
+			selected = append(selected, root)
+		}
+	}
+	if len(selected) == 0 {
+		return nil, nil, nil
+	}
+
+	t, warning := m.prepareSync(ctx, p, SyncOptions{Strategy: project.SyncRebase, NoFetch: opts.NoFetch})
+	if t.err != nil {
+		return nil, nil, t.err
+	}
+	var warnings []string
+	if warning != "" {
+		warnings = append(warnings, warning)
+	}
+
+	var outcomes []RestackOutcome
+	var walk func(node *StackNode, depth int, blocked bool)
+	walk = func(node *StackNode, depth int, blocked bool) {
+		out := RestackOutcome{Worktree: node.Worktree, Depth: depth}
+		if blocked {
+			out.Result = RestackBlocked
+		} else {
+			m.restackOne(ctx, t, node, &out, opts.Autostash)
+		}
+		outcomes = append(outcomes, out)
+
+		ok := out.Result == SyncUpToDate || out.Result == SyncRebased
+		for _, child := range node.Children {
+			walk(child, depth+1, !ok)
+		}
+	}
+	for _, root := range selected {
+		walk(root, 0, false)
+	}
+	return outcomes, warnings, nil
+}
+
+func (n *StackNode) contains(id string) bool {
+	if n.Worktree.ID == id {
+		return true
+	}
+	for _, child := range n.Children {
+		if child.contains(id) {
+			return true
+		}
+	}
+	return false
+}
+
+// restackOne rebases one worktree and records its new parent base.
+func (m *Manager) restackOne(ctx context.Context, t *syncTarget, node *StackNode, out *RestackOutcome, autostash bool) {
+	w := node.Worktree
+	if w.Status != storage.WorktreeStatusActive {
+		out.Result = "skipped-" + w.Status
+		return
+	}
+
+	switch {
+	case w.ParentID == "":
+		synced := m.syncOne(ctx, w, t, autostash)
+		out.Onto, out.Result, out.Conflicts, out.Err = synced.Base, synced.Result, synced.Conflicts, synced.Err
+		return
+	case node.MissingParent == "removed":
+		base, err := m.BranchBaseRef(ctx, t.repo, t.upstream, w.BaseBranch)
+		if err != nil {
+			out.Result, out.Err = SyncFailed, err
+			return
+		}
+		out.Onto = base
+	case node.MissingParent != "":
+		out.Result = RestackBlocked
+		out.Err = fmt.Errorf("parent %s is %s", w.ParentID, node.MissingParent)
+		return
+	default:
+		out.Onto = node.parent.Worktree.Branch
+	}
+	if w.ParentBase == "" {
+		out.Result, out.Err = SyncFailed, fmt.Errorf("no recorded base on %s", w.ParentID)
+		return
+	}
+
+	tip, err := m.git.RevParse(ctx, t.repo, out.Onto)
+	if err != nil {
+		out.Result, out.Err = SyncFailed, fmt.Errorf("failed to resolve %s: %w", out.Onto, err)
+		return
+	}
+	if node.MissingParent == "" && tip == w.ParentBase {
+		out.Result = SyncUpToDate
+		return
+	}
+
+	out.Result, out.Conflicts, out.Err = m.rebaseOnto(ctx, t.repo, w, out.Onto, w.ParentBase, autostash)
+	if out.Result != SyncRebased {
+		return
+	}
+	if node.MissingParent != "" {
+		w.ParentID, w.ParentBase = "", ""
+	} else {
+		w.ParentBase = tip
+	}
+	if err := m.withDB(func(db *storage.Database) error { return db.UpdateWorktree(&w) }); err != nil {
+		out.Result, out.Err = SyncFailed, fmt.Errorf("rebased but failed to update worktree: %w", err)
+		return
+	}
+	out.Worktree = w
+}
+
+// rebaseOnto replays the worktree's commits after upstream onto onto,
+// aborting the rebase when it fails so the worktree is left as it was.
+// With autostash, a dirty worktree holds repo's lock for the shared
+// refs/stash until the rebase or its abort has popped the stash.
+func (m *Manager) rebaseOnto(ctx context.Context, repo string, w storage.Worktree, onto, upstream string, autostash bool) (string, []string, error) {
+	if _, err := os.Stat(w.Path); err != nil {
+		return SyncMissing, nil, nil
+	}
+	status, err := m.git.Status(ctx, w.Path)
+	if err != nil {
+		return SyncFailed, nil, fmt.Errorf("failed to get status: %w", err)
+	}
+	if len(status.Conflicted) > 0 || (status.Dirty() && !autostash) {
+		return SyncDirty, nil, nil
+	}
+
+	if autostash && status.Dirty() {
+		unlock := m.lockRepo(repo)
+		defer unlock()
+	}
+	err = m.git.RebaseOnto(ctx, w.Path, onto, upstream, autostash)
+	if err == nil {
+		return SyncRebased, nil, nil
+	}
+	result, conflicts := SyncFailed, []string(nil)
+	if after, statusErr := m.git.Status(ctx, w.Path); statusErr == nil && len(after.Conflicted) > 0 {
+		result, conflicts, err = SyncConflict, after.Conflicted, nil
+	}
+	if abortErr := m.git.RebaseAbort(ctx, w.Path); abortErr != nil {
+		return SyncFailed, conflicts, fmt.Errorf("rebase failed and could not be aborted: %w", abortErr)
+	}
+	return result, conflicts, err
+}
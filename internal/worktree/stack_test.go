@@ -0,0 +1,163 @@
+package worktree
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/paolorechia/issue-flow/internal/project"
+	"github.com/paolorechia/issue-flow/internal/storage"
+	"github.com/paolorechia/issue-flow/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// startStacked starts a worktree for issueNumber stacked on parent.
+func startStacked(t *testing.T, manager *Manager, p *project.Project, issueNumber int, parent *storage.Worktree) *storage.Worktree {
+	t.Helper()
+	result, err := manager.Start(context.Background(), p, StartOptions{IssueNumber: issueNumber, Parent: parent})
+	require.NoError(t, err)
+	return result.Worktree
+}
+
+// subjects lists the subjects of the commits in rev-range, newest first.
+func subjects(t *testing.T, dir, revRange string) []string {
+	t.Helper()
+	out := testutil.RunGit(t, dir, "log", "--format=%s", revRange)
+	if out == "" {
+		return nil
+	}
+	return strings.Split(out, "\n")
+}
+
+func TestManager_StartStacked(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	testutil.CreateGitProject(t, db, "app")
+	p, manager, parent := startTestWorktree(t, db, 1)
+	testutil.GitCommitFile(t, parent.Path, "one.txt", "1\n", "Work for 1")
+	tip := testutil.RunGit(t, parent.Path, "rev-parse", "HEAD")
+
+	child := startStacked(t, manager, p, 2, parent)
+	assert.Equal(t, parent.ID, child.ParentID)
+	assert.Equal(t, tip, child.ParentBase)
+	assert.FileExists(t, filepath.Join(child.Path, "one.txt"))
+	row := testutil.AssertWorktreeExists(t, db, child.ID)
+	assert.Equal(t, parent.ID, row.ParentID)
+
+	_, err := manager.Start(context.Background(), p, StartOptions{IssueNumber: 2, Parent: row})
+	assert.ErrorContains(t, err, "cannot stack wt-app-2 on itself")
+
+	_, other, _ := startTestWorktree(t, db, 3)
+	third := testutil.AssertWorktreeExists(t, db, "wt-app-3")
+	_, err = other.Start(context.Background(), p, StartOptions{IssueNumber: 2, Parent: third})
+	assert.ErrorContains(t, err, "already exists and is not stacked on wt-app-3")
+}
+
+func TestManager_RestackChain(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	sp := testutil.CreateGitProject(t, db, "app")
+	p, manager, first := startTestWorktree(t, db, 1)
+	ctx := context.Background()
+
+	testutil.GitCommitFile(t, first.Path, "one.txt", "1\n", "Work for 1")
+	second := startStacked(t, manager, p, 2, first)
+	testutil.GitCommitFile(t, second.Path, "two.txt", "2\n", "Work for 2")
+	third := startStacked(t, manager, p, 3, second)
+	testutil.GitCommitFile(t, third.Path, "three.txt", "3\n", "Work for 3")
+	_, _, alone := startTestWorktree(t, db, 4)
+
+	testutil.GitCommitFile(t, sp.LocalPath, "upstream.txt", "u\n", "Upstream change")
+	testutil.RunGit(t, first.Path, "commit", "--amend", "-m", "Work for 1, reworded")
+
+	stacks, err := manager.Stacks(ctx, p)
+	require.NoError(t, err)
+	require.Len(t, stacks, 2)
+	assert.True(t, stacks[0].Children[0].NeedsRestack)
+	assert.False(t, stacks[0].Children[0].Children[0].NeedsRestack)
+
+	outcomes, _, err := manager.Restack(ctx, p, nil, RestackOptions{NoFetch: true})
+	require.NoError(t, err)
+	require.Len(t, outcomes, 3, "worktrees outside a stack are left to sync")
+	for i, w := range []*storage.Worktree{first, second, third} {
+		assert.Equal(t, w.ID, outcomes[i].Worktree.ID)
+		assert.Equal(t, i, outcomes[i].Depth)
+		assert.Equal(t, SyncRebased, outcomes[i].Result, w.ID)
+	}
+	assert.Equal(t, first.Branch, outcomes[1].Onto)
+
+	assert.Equal(t, []string{"Work for 3", "Work for 2", "Work for 1, reworded", "Upstream change"},
+		subjects(t, third.Path, "HEAD~4..HEAD"), "each commit is replayed once, onto the rewritten parent")
+	row := testutil.AssertWorktreeExists(t, db, third.ID)
+	assert.Equal(t, testutil.RunGit(t, second.Path, "rev-parse", "HEAD"), row.ParentBase)
+
+	outcomes, _, err = manager.Restack(ctx, p, alone, RestackOptions{NoFetch: true})
+	require.NoError(t, err)
+	require.Len(t, outcomes, 1)
+	assert.Equal(t, SyncRebased, outcomes[0].Result)
+
+	outcomes, _, err = manager.Restack(ctx, p, third, RestackOptions{NoFetch: true})
+	require.NoError(t, err)
+	for _, o := range outcomes {
+		assert.Equal(t, SyncUpToDate, o.Result, o.Worktree.ID)
+	}
+}
+
+func TestManager_RestackSkipsAboveFailure(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	sp := testutil.CreateGitProject(t, db, "app")
+	p, manager, first := startTestWorktree(t, db, 1)
+	ctx := context.Background()
+
+	second := startStacked(t, manager, p, 2, first)
+	third := startStacked(t, manager, p, 3, second)
+	testutil.GitCommitFile(t, sp.LocalPath, "upstream.txt", "u\n", "Upstream change")
+	require.NoError(t, os.WriteFile(filepath.Join(second.Path, "README.md"), []byte("local edit\n"), 0644))
+
+	outcomes, _, err := manager.Restack(ctx, p, third, RestackOptions{NoFetch: true})
+	require.NoError(t, err)
+	require.Len(t, outcomes, 3)
+	assert.Equal(t, SyncRebased, outcomes[0].Result)
+	assert.Equal(t, SyncDirty, outcomes[1].Result)
+	assert.Equal(t, RestackBlocked, outcomes[2].Result)
+
+	sync, _, err := manager.Sync(ctx, SyncOptions{NoFetch: true}, 1)
+	require.NoError(t, err)
+	for _, o := range sync[1:] {
+		assert.Equal(t, SyncStacked, o.Result, o.Worktree.ID)
+	}
+}
+
+func TestManager_RestackAfterParentMerged(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	sp := testutil.CreateGitProject(t, db, "app")
+	p, manager, first := startTestWorktree(t, db, 1)
+	ctx := context.Background()
+
+	testutil.GitCommitFile(t, first.Path, "one.txt", "1\n", "Work for 1")
+	testutil.GitCommitFile(t, first.Path, "one.txt", "1\n1\n", "More work for 1")
+	second := startStacked(t, manager, p, 2, first)
+	testutil.GitCommitFile(t, second.Path, "two.txt", "2\n", "Work for 2")
+
+	testutil.RunGit(t, sp.LocalPath, "merge", "--squash", first.Branch)
+	testutil.RunGit(t, sp.LocalPath, "commit", "-m", "Squashed 1")
+	_, err := manager.Remove(ctx, p, first, RemoveOptions{Force: true, DeleteBranch: true})
+	require.NoError(t, err)
+
+	stacks, err := manager.Stacks(ctx, p)
+	require.NoError(t, err)
+	require.Len(t, stacks, 1)
+	assert.Equal(t, "removed", stacks[0].MissingParent)
+
+	outcomes, _, err := manager.Restack(ctx, p, nil, RestackOptions{NoFetch: true})
+	require.NoError(t, err)
+	require.Len(t, outcomes, 1)
+	assert.Equal(t, SyncRebased, outcomes[0].Result)
+	assert.Equal(t, "main", outcomes[0].Onto)
+
+	assert.Equal(t, []string{"Work for 2"}, subjects(t, second.Path, "main..HEAD"))
+	row := testutil.AssertWorktreeExists(t, db, second.ID)
+	assert.Empty(t, row.ParentID)
+	assert.Empty(t, row.ParentBase)
+}
@@ -0,0 +1,236 @@
+package worktree
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/paolorechia/issue-flow/internal/config"
+	"github.com/paolorechia/issue-flow/internal/project"
+	"github.com/paolorechia/issue-flow/internal/storage"
+)
+
+// Sync results.
+const (
+	SyncUpToDate = "up-to-date"
+	SyncRebased  = "rebased"
+	SyncMerged   = "merged"
+	SyncDirty    = "skipped-dirty"
+	SyncMissing  = "missing"
+	SyncStacked  = "skipped-stacked"
+	SyncConflict = "conflict"
+	SyncFailed   = "failed"
+)
+
+type SyncOptions struct {
+	ProjectID string
+	// Strategy overrides the project's sync strategy when set.
+	Strategy  string
+	Autostash bool
+	NoFetch   bool
+}
+
+// SyncOutcome is the result of syncing one worktree.
+type SyncOutcome struct {
+	Worktree storage.Worktree
+	Base     string
+	Strategy string
+	Result   string
+	// Behind is how many base commits the worktree was missing before syncing.
+	Behind    int
+	Conflicts []string
+	Err       error
+}
+
+type syncTarget struct {
+	repo     string
+	upstream string
+	base     string
+	strategy string
+	err      error
+}
+
+// Sync fetches each project once and then rebases or merges every active
+// worktree onto its base branch, running at most workers syncs
+// concurrently. Dirty worktrees are skipped unless opts.Autostash is set;
+// conflicting syncs are aborted so the worktree is left as it was. Stacked
+// worktrees are skipped too; Restack moves them with their parent.
+func (m *Manager) Sync(ctx context.Context, opts SyncOptions, workers int) ([]SyncOutcome, []string, error) {
+	if workers <= 0 {
+		workers = DefaultWorkers
+	}
+
+	var worktrees []storage.Worktree
+	var err error
+	if opts.ProjectID != "" {
+		worktrees, err = m.db.ListWorktreesByProject(opts.ProjectID)
+	} else {
+		worktrees, err = m.db.ListWorktrees()
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to list worktrees: %w", err)
+	}
+
+	var active []storage.Worktree
+	for _, w := range worktrees {
+		if w.Status == storage.WorktreeStatusActive {
+			active = append(active, w)
+		}
+	}
+
+	var warnings []string
+	targets := make(map[string]*syncTarget)
+	pm := project.NewManager(m.db)
+	for _, w := range active {
+		if _, ok := targets[w.ProjectID]; ok {
+			continue
+		}
+		p, err := pm.Get(w.ProjectID)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to load project %s: %w", w.ProjectID, err)
+		}
+		t, warning := m.prepareSync(ctx, p, opts)
+		if warning != "" {
+			warnings = append(warnings, warning)
+		}
+		targets[p.ID] = t
+	}
+
+	outcomes := make([]SyncOutcome, len(active))
+	sem := make(chan struct{}, workers)
+	var wg sync.WaitGroup
+
+	for i, w := range active {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, w storage.Worktree) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			outcomes[i] = m.syncOne(ctx, w, targets[w.ProjectID], opts.Autostash)
+		}(i, w)
+	}
+	wg.Wait()
+
+	return outcomes, warnings, nil
+}
+
+// prepareSync fetches upstream for a project and resolves its base ref and
+// strategy. A failed fetch is returned as a warning, not an error.
+func (m *Manager) prepareSync(ctx context.Context, p *project.Project, opts SyncOptions) (*syncTarget, string) {
+	t := &syncTarget{repo: config.ExpandPath(p.LocalPath), upstream: p.UpstreamRemote()}
+
+	t.strategy = opts.Strategy
+	if t.strategy == "" {
+		t.strategy = p.Config.Sync.Strategy
+	}
+	if t.strategy == "" {
+		t.strategy = project.SyncRebase
+	}
+	if t.strategy != project.SyncRebase && t.strategy != project.SyncMerge {
+		t.err = fmt.Errorf("unknown sync strategy %q", t.strategy)
+		return t, ""
+	}
+
+	var warning string
+	if !opts.NoFetch {
+		hasUpstream, err := m.hasRemote(ctx, t.repo, t.upstream)
+		if err != nil {
+			t.err = err
+			return t, ""
+		}
+		if hasUpstream {
+			if err := m.git.Fetch(ctx, t.repo, t.upstream); err != nil {
+				warning = fmt.Sprintf("%s: fetch from %s failed: %v", p.ID, t.upstream, err)
+			}
+		}
+	}
+
+	t.base, t.err = m.BaseRef(ctx, t.repo, t.upstream)
+	return t, warning
+}
+
+func (m *Manager) syncOne(ctx context.Context, w storage.Worktree, t *syncTarget, autostash bool) SyncOutcome {
+	out := SyncOutcome{Worktree: w, Base: t.base, Strategy: t.strategy}
+	if t.err != nil {
+		out.Result, out.Err = SyncFailed, t.err
+		return out
+	}
+	if w.ParentID != "" {
+		out.Result = SyncStacked
+		return out
+	}
+	if w.BaseBranch != "" {
+		base, err := m.BranchBaseRef(ctx, t.repo, t.upstream, w.BaseBranch)
+		if err != nil {
+			out.Result, out.Err = SyncFailed, err
+			return out
+		}
+		out.Base = base
+	}
+
+	if _, err := os.Stat(w.Path); err != nil {
+		out.Result = SyncMissing
+		return out
+	}
+
+	status, err := m.git.Status(ctx, w.Path)
+	if err != nil {
+		out.Result, out.Err = SyncFailed, fmt.Errorf("failed to get status: %w", err)
+		return out
+	}
+	if len(status.Conflicted) > 0 || (status.Dirty() && !autostash) {
+		out.Result = SyncDirty
+		return out
+	}
+
+	_, behind, err := m.git.AheadBehind(ctx, w.Path, "HEAD", out.Base)
+	if err != nil {
+		out.Result, out.Err = SyncFailed, err
+		return out
+	}
+	out.Behind = behind
+	if behind == 0 {
+		out.Result = SyncUpToDate
+		return out
+	}
+
+	// Each worktree has its own index and HEAD, so worktrees of one repo
+	// rebase in parallel; only --autostash touches the shared refs/stash,
+	// so a dirty worktree holds the repo lock until its stash is popped.
+	if autostash && status.Dirty() {
+		unlock := m.lockRepo(t.repo)
+		defer unlock()
+	}
+	if t.strategy == project.SyncMerge {
+		err = m.git.Merge(ctx, w.Path, out.Base, autostash)
+	} else {
+		err = m.git.Rebase(ctx, w.Path, out.Base, autostash)
+	}
+	if err == nil {
+		out.Result = SyncRebased
+		if t.strategy == project.SyncMerge {
+			out.Result = SyncMerged
+		}
+		return out
+	}
+
+	if after, statusErr := m.git.Status(ctx, w.Path); statusErr == nil && len(after.Conflicted) > 0 {
+		out.Result = SyncConflict
+		out.Conflicts = after.Conflicted
+	} else {
+		out.Result, out.Err = SyncFailed, err
+	}
+
+	var abortErr error
+	if t.strategy == project.SyncMerge {
+		abortErr = m.git.MergeAbort(ctx, w.Path)
+	} else {
+		abortErr = m.git.RebaseAbort(ctx, w.Path)
+	}
+	if abortErr != nil {
+		out.Result = SyncFailed
+		out.Err = fmt.Errorf("%s failed and could not be aborted: %w", t.strategy, abortErr)
+	}
+	return out
+}
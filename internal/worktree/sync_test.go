@@ -0,0 +1,128 @@
+package worktree
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/paolorechia/issue-flow/internal/git"
+	"github.com/paolorechia/issue-flow/internal/project"
+	"github.com/paolorechia/issue-flow/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestManager_Sync(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	sp := testutil.CreateGitProject(t, db, "app")
+	_, manager, clean := startTestWorktree(t, db, 1)
+	_, _, dirty := startTestWorktree(t, db, 2)
+	_, _, conflict := startTestWorktree(t, db, 3)
+	ctx := context.Background()
+
+	testutil.GitCommitFile(t, clean.Path, "one.txt", "1\n", "Work for 1")
+	require.NoError(t, os.WriteFile(filepath.Join(dirty.Path, "README.md"), []byte("local edit\n"), 0644))
+	testutil.GitCommitFile(t, conflict.Path, "README.md", "# from issue 3\n", "Conflicting edit")
+	conflictTip := testutil.RunGit(t, conflict.Path, "rev-parse", "HEAD")
+
+	testutil.GitCommitFile(t, sp.LocalPath, "README.md", "# upstream\n", "Upstream change")
+	_, _, current := startTestWorktree(t, db, 4)
+
+	outcomes, warnings, err := manager.Sync(ctx, SyncOptions{}, 4)
+	require.NoError(t, err)
+	assert.Empty(t, warnings)
+	require.Len(t, outcomes, 4)
+
+	results := make(map[int]SyncOutcome)
+	for _, o := range outcomes {
+		results[o.Worktree.IssueNumber] = o
+	}
+
+	assert.Equal(t, SyncRebased, results[1].Result)
+	assert.Equal(t, 1, results[1].Behind)
+	assert.Equal(t, "Upstream change", testutil.RunGit(t, clean.Path, "log", "-1", "--format=%s", "HEAD~1"))
+
+	assert.Equal(t, SyncDirty, results[2].Result)
+
+	assert.Equal(t, SyncConflict, results[3].Result)
+	assert.Equal(t, []string{"README.md"}, results[3].Conflicts)
+	assert.Equal(t, conflictTip, testutil.RunGit(t, conflict.Path, "rev-parse", "HEAD"))
+	assert.Empty(t, testutil.RunGit(t, conflict.Path, "status", "--porcelain"))
+
+	assert.Equal(t, SyncUpToDate, results[4].Result)
+	assert.Equal(t, current.ID, results[4].Worktree.ID)
+}
+
+func TestManager_SyncMergeWithAutostash(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	sp := testutil.CreateGitProject(t, db, "app")
+	_, manager, w := startTestWorktree(t, db, 1)
+	ctx := context.Background()
+
+	testutil.GitCommitFile(t, w.Path, "one.txt", "1\n", "Work for 1")
+	require.NoError(t, os.WriteFile(filepath.Join(w.Path, "one.txt"), []byte("uncommitted\n"), 0644))
+	testutil.GitCommitFile(t, sp.LocalPath, "other.txt", "x\n", "Upstream change")
+
+	outcomes, _, err := manager.Sync(ctx, SyncOptions{Strategy: project.SyncMerge, Autostash: true}, 1)
+	require.NoError(t, err)
+	require.Len(t, outcomes, 1)
+	assert.Equal(t, SyncMerged, outcomes[0].Result)
+
+	assert.FileExists(t, filepath.Join(w.Path, "other.txt"))
+	content, err := os.ReadFile(filepath.Join(w.Path, "one.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, "uncommitted\n", string(content))
+}
+
+// barrierRunner holds every rebase until n rebases are running at once, or
+// until a timeout, and records whether they all met.
+type barrierRunner struct {
+	git.Runner
+	n       int
+	mu      sync.Mutex
+	running int
+	met     chan struct{}
+}
+
+func (r *barrierRunner) Run(ctx context.Context, dir string, args ...string) (string, error) {
+	if len(args) > 0 && args[0] == "rebase" {
+		r.mu.Lock()
+		r.running++
+		if r.running == r.n {
+			close(r.met)
+		}
+		r.mu.Unlock()
+		select {
+		case <-r.met:
+		case <-time.After(5 * time.Second):
+		}
+	}
+	return r.Runner.Run(ctx, dir, args...)
+}
+
+func TestManager_SyncRebasesWorktreesOfOneRepoConcurrently(t *testing.T) {
+	db := testutil.NewTestDB(t)
+	sp := testutil.CreateGitProject(t, db, "app")
+	_, _, one := startTestWorktree(t, db, 1)
+	_, _, two := startTestWorktree(t, db, 2)
+	testutil.GitCommitFile(t, one.Path, "one.txt", "1\n", "Work for 1")
+	testutil.GitCommitFile(t, two.Path, "two.txt", "2\n", "Work for 2")
+	testutil.GitCommitFile(t, sp.LocalPath, "README.md", "# upstream\n", "Upstream change")
+
+	runner := &barrierRunner{Runner: git.ExecRunner{}, n: 2, met: make(chan struct{})}
+	manager := NewManager(db, git.NewClient(runner))
+	outcomes, _, err := manager.Sync(context.Background(), SyncOptions{NoFetch: true}, 2)
+	require.NoError(t, err)
+	require.Len(t, outcomes, 2)
+	for _, o := range outcomes {
+		assert.Equal(t, SyncRebased, o.Result, o.Worktree.ID)
+	}
+	select {
+	case <-runner.met:
+	default:
+		t.Fatal("rebases of one repo ran one at a time")
+	}
+}
@@ -0,0 +1,38 @@
+package worktree
+
+import (
+	"github.com/paolorechia/issue-flow/internal/github"
+	"github.com/paolorechia/issue-flow/internal/storage"
+)
+
+type StartOptions struct {
+	IssueNumber int
+	// Title and Type describe the issue when it is not in the issue cache.
+	Title string
+	Type  string
+	// GitHub is asked for an issue that is neither cached nor given a Title.
+	GitHub github.Client
+	// WorktreeBase is used when the project has no WorktreeDir.
+	WorktreeBase string
+	// Fetch refreshes the upstream remote before branching from the default branch.
+	Fetch bool
+	// Attempt starts a named competing worktree for the issue instead of
+	// its primary one. AutoAttempt picks the next free name.
+	Attempt string
+	// Parent stacks the new worktree on another one: its branch is created
+	// from the parent's branch instead of the default branch.
+	Parent *storage.Worktree
+	// BaseBranch is the branch to start from instead of the one the issue
+	// type's base branch rule picks.
+	BaseBranch string
+}
+
+type StartResult struct {
+	Worktree *storage.Worktree
+	Issue    *storage.IssueCache
+	// Created is false when an existing worktree was reused.
+	Created bool
+	// Ports are the ports allocated to the worktree.
+	Ports    []Port
+	Warnings []string
+}
@@ -0,0 +1,144 @@
+package testutil
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// FakeGitHub is an httptest server answering GitHub API requests with
+// canned responses, so GitHub-touching code can be tested offline. Point a
+// client at URL, e.g. github.RESTClient{BaseURL: fake.URL}.
+type FakeGitHub struct {
+	URL string
+
+	t        *testing.T
+	server   *httptest.Server
+	mu       sync.Mutex
+	routes   map[string]GitHubFixture
+	requests []GitHubRequest
+}
+
+// GitHubFixture is one canned response. Path may include a query, which
+// then has to match exactly; without one, any query matches. "{server}" in
+// header values is replaced with the server URL, for pagination Link
+// headers.
+type GitHubFixture struct {
+	Method  string            `json:"method"`
+	Path    string            `json:"path"`
+	Status  int               `json:"status"`
+	Body    json.RawMessage   `json:"body"`
+	Headers map[string]string `json:"headers"`
+}
+
+// GitHubRequest is a request the fake server received.
+type GitHubRequest struct {
+	Method        string
+	Path          string
+	Query         string
+	Authorization string
+	Body          string
+}
+
+// NewFakeGitHub starts a fake GitHub server that is closed when the test
+// ends. Unknown requests get a 404 like GitHub's.
+func NewFakeGitHub(t *testing.T) *FakeGitHub {
+	t.Helper()
+
+	f := &FakeGitHub{t: t, routes: make(map[string]GitHubFixture)}
+	f.server = httptest.NewServer(http.HandlerFunc(f.serve))
+	f.URL = f.server.URL
+	t.Cleanup(f.server.Close)
+	return f
+}
+
+// On answers method and path with status and body, a JSON document.
+func (f *FakeGitHub) On(method, path string, status int, body string) *FakeGitHub {
+	return f.Add(GitHubFixture{Method: method, Path: path, Status: status, Body: json.RawMessage(body)})
+}
+
+// Add registers a fixture, replacing an earlier one for the same request.
+func (f *FakeGitHub) Add(fixture GitHubFixture) *FakeGitHub {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.routes[fixture.Method+" "+fixture.Path] = fixture
+	return f
+}
+
+// LoadFixtures registers the fixtures recorded in
+// testutil/testdata/github/<name>.json, a JSON array of GitHubFixture.
+func (f *FakeGitHub) LoadFixtures(name string) *FakeGitHub {
+	f.t.Helper()
+
+	_, file, _, _ := runtime.Caller(0)
+	data, err := os.ReadFile(filepath.Join(filepath.Dir(file), "testdata", "github", name+".json"))
+	require.NoError(f.t, err, "Failed to read GitHub fixtures %s", name)
+
+	var fixtures []GitHubFixture
+	require.NoError(f.t, json.Unmarshal(data, &fixtures), "Failed to parse GitHub fixtures %s", name)
+	for _, fixture := range fixtures {
+		f.Add(fixture)
+	}
+	return f
+}
+
+// Requests returns the requests received so far, in order.
+func (f *FakeGitHub) Requests() []GitHubRequest {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]GitHubRequest(nil), f.requests...)
+}
+
+// LastRequest returns the most recent request and fails the test if there
+// was none.
+func (f *FakeGitHub) LastRequest() GitHubRequest {
+	f.t.Helper()
+
+	requests := f.Requests()
+	require.NotEmpty(f.t, requests, "Expected a request to the fake GitHub server")
+	return requests[len(requests)-1]
+}
+
+func (f *FakeGitHub) serve(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+
+	f.mu.Lock()
+	f.requests = append(f.requests, GitHubRequest{
+		Method:        r.Method,
+		Path:          r.URL.Path,
+		Query:         r.URL.RawQuery,
+		Authorization: r.Header.Get("Authorization"),
+		Body:          string(body),
+	})
+	fixture, ok := f.routes[r.Method+" "+r.URL.RequestURI()]
+	if !ok {
+		fixture, ok = f.routes[r.Method+" "+r.URL.Path]
+	}
+	f.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprint(w, `{"message":"Not Found"}`)
+		return
+	}
+	for key, value := range fixture.Headers {
+		w.Header().Set(key, strings.ReplaceAll(value, "{server}", f.URL))
+	}
+	status := fixture.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	w.WriteHeader(status)
+	w.Write(fixture.Body)
+}
@@ -2,6 +2,9 @@ package testutil
 
 import (
 	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
 	"strings"
 	"testing"
 
@@ -132,6 +135,33 @@ func CreateTestProject(t *testing.T, db *storage.Database) *storage.Project {
 	return project
 }
 
+// DefaultGitProjectConfig is the project config CreateGitProject uses.
+const DefaultGitProjectConfig = `{"issue_types":[{"name":"bug","branch_prefix":"fix"}],"branch_config":{"pattern":"{prefix}/{issue-number}-{slug}","max_slug_length":20},"opencode":{"enabled":false}}`
+
+// CreateGitProject creates a project backed by a throwaway git repository with
+// worktrees placed in a separate temporary directory.
+func CreateGitProject(t *testing.T, db *storage.Database, id string) *storage.Project {
+	return CreateGitProjectWithConfig(t, db, id, DefaultGitProjectConfig)
+}
+
+// CreateGitProjectWithConfig is CreateGitProject with a custom project config
+// given as JSON.
+func CreateGitProjectWithConfig(t *testing.T, db *storage.Database, id, config string) *storage.Project {
+	project := &storage.Project{
+		ID:          id,
+		Name:        "Git Project " + id,
+		GitHubOwner: "testowner",
+		GitHubRepo:  id,
+		LocalPath:   NewGitRepo(t),
+		WorktreeDir: t.TempDir(),
+		Config:      config,
+	}
+
+	err := db.CreateProject(project)
+	require.NoError(t, err, "Failed to create git project")
+	return project
+}
+
 func CreateTestWorktree(t *testing.T, db *storage.Database, projectID string, issueNumber int) *storage.Worktree {
 	worktree := &storage.Worktree{
 		ID:          fmt.Sprintf("wt-%s-%d", projectID, issueNumber),
@@ -147,6 +177,41 @@ func CreateTestWorktree(t *testing.T, db *storage.Database, projectID string, is
 	return worktree
 }
 
+// NewGitRepo creates a throwaway git repository on branch main with one commit.
+func NewGitRepo(t *testing.T) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	RunGit(t, dir, "init", "--quiet", "-b", "main")
+	RunGit(t, dir, "config", "user.name", "Issue Flow Test")
+	RunGit(t, dir, "config", "user.email", "test@issue-flow.local")
+	RunGit(t, dir, "config", "commit.gpgsign", "false")
+	GitCommitFile(t, dir, "README.md", "# test\n", "Initial commit")
+	return dir
+}
+
+// GitCommitFile writes a file into dir and commits it.
+func GitCommitFile(t *testing.T, dir, name, content, message string) {
+	t.Helper()
+
+	path := filepath.Join(dir, name)
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	RunGit(t, dir, "add", name)
+	RunGit(t, dir, "commit", "--quiet", "-m", message)
+}
+
+// RunGit runs git in dir and returns trimmed stdout, failing the test on error.
+func RunGit(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	require.NoError(t, err, "git %s failed: %s", strings.Join(args, " "), out)
+	return strings.TrimSpace(string(out))
+}
+
 func TableOutput() string {
 	var buf strings.Builder
 	return buf.String()